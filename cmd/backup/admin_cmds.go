@@ -0,0 +1,320 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/backup-cronjob/internal/audit"
+	"github.com/backup-cronjob/internal/config"
+	"github.com/backup-cronjob/internal/database"
+	"github.com/backup-cronjob/internal/drive"
+	"github.com/backup-cronjob/internal/i18n"
+	"github.com/backup-cronjob/internal/models"
+	"golang.org/x/oauth2"
+	"golang.org/x/term"
+)
+
+// authGoogleCommand liên kết Google Drive từ dòng lệnh: mặc định qua redirect về listener tạm trên
+// 127.0.0.1, hoặc luồng xác thực thiết bị với --device. Hướng dẫn được in ra stderr để
+// stdout chỉ chứa kết quả.
+func authGoogleCommand(fs *flag.FlagSet) action {
+	device := fs.Bool("device", false, i18n.T("", "flag.device"))
+	port := fs.Int("port", 0, i18n.T("", "flag.callback_port"))
+	return func(a *app, args []string) int {
+		if a.cfg.UseServiceAccount() {
+			return a.out.fail(exitFailure, i18n.Errorf(i18n.ErrDriveServiceAccountMode), i18n.ErrDriveServiceAccountMode)
+		}
+
+		var (
+			token  *oauth2.Token
+			err    error
+			method = "loopback"
+		)
+		if *device {
+			method = "device"
+			token, err = a.uploader.DeviceAuth(a.ctx, func(verificationURL, userCode string, expiry time.Time) {
+				fmt.Fprintln(os.Stderr, i18n.T("", "cli.auth_device_code", verificationURL, userCode, expiry.Local().Format("15:04:05")))
+			})
+		} else {
+			token, err = a.uploader.LoopbackAuth(a.ctx, *port, os.Stdin, func(authURL, redirectURL string) {
+				fmt.Fprintln(os.Stderr, i18n.T("", "cli.auth_open_url", authURL, redirectURL))
+			})
+		}
+
+		details := "cli " + method
+		if err != nil {
+			details = audit.ErrorDetails(err)
+		}
+		audit.RecordCLI(models.AuditActionGoogleLink, a.cfg.FolderDrive, audit.ResultOf(err), details)
+		if err != nil {
+			return a.out.fail(exitFailure, err, i18n.ErrOAuthExchangeFailed)
+		}
+
+		a.out.result(map[string]any{"linked": true, "method": method, "expiry": token.Expiry}, func(w io.Writer) {
+			fmt.Fprintln(w, i18n.T("", "cli.auth_linked"))
+		})
+		return exitOK
+	}
+}
+
+// authStatusCommand báo cáo tình trạng token Google Drive; thoát với mã lỗi nếu chưa liên kết
+// hoặc token không làm mới được
+func authStatusCommand(fs *flag.FlagSet) action {
+	return func(a *app, args []string) int {
+		status := a.uploader.TokenStatus(a.ctx)
+
+		a.out.result(status, func(w io.Writer) {
+			if !status.Authorized {
+				fmt.Fprintln(w, i18n.T("", "cli.auth_status_not_authorized"))
+				return
+			}
+			if status.Method == drive.AuthServiceAccount {
+				fmt.Fprintln(w, i18n.T("", "cli.auth_status_service_account", status.ServiceAccount))
+				if status.Impersonate != "" {
+					fmt.Fprintln(w, i18n.T("", "cli.auth_status_impersonate", status.Impersonate))
+				}
+			}
+			if len(status.Scopes) > 0 {
+				fmt.Fprintln(w, i18n.T("", "cli.auth_status_scopes", strings.Join(status.Scopes, " ")))
+			}
+			if status.Expiry != nil {
+				fmt.Fprintln(w, i18n.T("", "cli.auth_status_expiry", status.Expiry.Local().Format("2006-01-02 15:04:05")))
+			}
+			switch status.Refresh {
+			case drive.RefreshOK:
+				fmt.Fprintln(w, i18n.T("", "cli.auth_status_refresh_ok"))
+			case drive.RefreshFailed:
+				fmt.Fprintln(w, i18n.T("", "cli.auth_status_refresh_failed", status.RefreshError))
+			default:
+				fmt.Fprintln(w, i18n.T("", "cli.auth_status_refresh_missing"))
+			}
+		})
+		if !status.Healthy() {
+			return exitFailure
+		}
+		return exitOK
+	}
+}
+
+// authRevokeCommand thu hồi token Google Drive và xóa token đã lưu
+func authRevokeCommand(fs *flag.FlagSet) action {
+	return func(a *app, args []string) int {
+		err := a.uploader.RevokeToken(a.ctx)
+		audit.RecordCLI(models.AuditActionGoogleUnlink, "google", audit.ResultOf(err), audit.ErrorDetails(err))
+		if err != nil {
+			return a.out.fail(exitFailure, err, i18n.ErrInternal)
+		}
+
+		a.out.result(map[string]any{"revoked": true}, func(w io.Writer) {
+			fmt.Fprintln(w, i18n.T("", "cli.auth_revoked"))
+		})
+		return exitOK
+	}
+}
+
+// usersListCommand liệt kê người dùng của giao diện web
+func usersListCommand(fs *flag.FlagSet) action {
+	return func(a *app, args []string) int {
+		users, err := database.ListUsers()
+		if err != nil {
+			return a.out.fail(exitFailure, err, i18n.ErrInternal)
+		}
+		if users == nil {
+			users = []*models.User{}
+		}
+
+		a.out.result(map[string]any{"users": users}, func(w io.Writer) {
+			tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, i18n.T("", "users.header"))
+			for _, user := range users {
+				locale := user.Locale
+				if locale == "" {
+					locale = "-"
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", user.Username, user.Role, locale, user.CreatedAt.Format("2006-01-02 15:04"))
+			}
+			tw.Flush()
+		})
+		return exitOK
+	}
+}
+
+// usersAddCommand tạo người dùng mới
+func usersAddCommand(fs *flag.FlagSet) action {
+	role := fs.String("role", models.RoleUser, i18n.T("", "flag.role"))
+	passwordStdin := fs.Bool("password-stdin", false, i18n.T("", "flag.password_stdin"))
+	return func(a *app, args []string) int {
+		username, code := usernameArg(args)
+		if code != exitOK {
+			return code
+		}
+		if *role != models.RoleUser && *role != models.RoleAdmin {
+			return a.out.fail(exitUsage, i18n.Errorf(i18n.ErrInvalidRole, *role), i18n.ErrInvalidRole)
+		}
+		if _, err := database.GetUserByUsername(username); err == nil {
+			return a.out.fail(exitFailure, i18n.Errorf(i18n.ErrUserExists, username), i18n.ErrUserExists)
+		}
+
+		hash, code := a.readPassword(*passwordStdin)
+		if code != exitOK {
+			return code
+		}
+		err := database.CreateUser(username, hash, *role)
+		audit.RecordCLI(models.AuditActionUserCreate, username, audit.ResultOf(err), audit.ErrorDetails(err))
+		if err != nil {
+			return a.out.fail(exitFailure, err, i18n.ErrInternal)
+		}
+
+		a.out.result(map[string]any{"username": username, "role": *role}, func(w io.Writer) {
+			fmt.Fprintln(w, i18n.T("", "cli.user_created", username, *role))
+		})
+		return exitOK
+	}
+}
+
+// usersPasswdCommand đổi mật khẩu người dùng
+func usersPasswdCommand(fs *flag.FlagSet) action {
+	passwordStdin := fs.Bool("password-stdin", false, i18n.T("", "flag.password_stdin"))
+	return func(a *app, args []string) int {
+		username, code := usernameArg(args)
+		if code != exitOK {
+			return code
+		}
+		if _, err := database.GetUserByUsername(username); err != nil {
+			return a.out.fail(exitFailure, i18n.Errorf(i18n.ErrUserNotFound, username), i18n.ErrUserNotFound)
+		}
+
+		hash, code := a.readPassword(*passwordStdin)
+		if code != exitOK {
+			return code
+		}
+		err := database.SetUserPassword(username, hash)
+		audit.RecordCLI(models.AuditActionUserUpdate, username, audit.ResultOf(err), "password")
+		if err != nil {
+			return a.out.fail(exitFailure, err, i18n.ErrInternal)
+		}
+
+		a.out.result(map[string]any{"username": username}, func(w io.Writer) {
+			fmt.Fprintln(w, i18n.T("", "cli.user_password_changed", username))
+		})
+		return exitOK
+	}
+}
+
+// usersDeleteCommand xóa người dùng; không xóa được tài khoản admin cấu hình qua ADMIN_USERNAME
+// (được tạo lại mỗi lần khởi động)
+func usersDeleteCommand(fs *flag.FlagSet) action {
+	return func(a *app, args []string) int {
+		username, code := usernameArg(args)
+		if code != exitOK {
+			return code
+		}
+		if username == a.cfg.AdminUsername {
+			return a.out.fail(exitFailure, i18n.Errorf(i18n.ErrUserProtected, username), i18n.ErrUserProtected)
+		}
+
+		err := database.DeleteUser(username)
+		if errors.Is(err, sql.ErrNoRows) {
+			return a.out.fail(exitFailure, i18n.Errorf(i18n.ErrUserNotFound, username), i18n.ErrUserNotFound)
+		}
+		audit.RecordCLI(models.AuditActionUserDelete, username, audit.ResultOf(err), audit.ErrorDetails(err))
+		if err != nil {
+			return a.out.fail(exitFailure, err, i18n.ErrInternal)
+		}
+
+		a.out.result(map[string]any{"username": username, "deleted": true}, func(w io.Writer) {
+			fmt.Fprintln(w, i18n.T("", "cli.user_deleted", username))
+		})
+		return exitOK
+	}
+}
+
+// usernameArg lấy tên người dùng từ tham số vị trí duy nhất
+func usernameArg(args []string) (string, int) {
+	if len(args) != 1 || strings.TrimSpace(args[0]) == "" {
+		fmt.Fprintln(os.Stderr, i18n.T("", "cli.username_required"))
+		return "", exitUsage
+	}
+	return strings.TrimSpace(args[0]), exitOK
+}
+
+// readPassword đọc mật khẩu (một dòng) từ stdin và trả về mật khẩu đã mã hóa.
+// Không có --password-stdin thì in lời nhắc trước khi đọc; khi stdin là terminal, mật khẩu
+// được đọc mà không hiện ra màn hình.
+func (a *app) readPassword(fromStdin bool) (string, int) {
+	var line string
+	if fd := int(os.Stdin.Fd()); !fromStdin && term.IsTerminal(fd) {
+		fmt.Fprint(os.Stderr, i18n.T("", "cli.password_prompt"))
+		input, err := term.ReadPassword(fd)
+		// ReadPassword không in dòng mới khi người dùng nhấn Enter
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", a.out.fail(exitFailure, err, i18n.ErrInternal)
+		}
+		line = string(input)
+	} else {
+		if !fromStdin {
+			fmt.Fprint(os.Stderr, i18n.T("", "cli.password_prompt"))
+		}
+		input, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return "", a.out.fail(exitFailure, err, i18n.ErrInternal)
+		}
+		line = input
+	}
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", a.out.fail(exitUsage, i18n.Errorf(i18n.ErrPasswordRequired), i18n.ErrPasswordRequired)
+	}
+
+	hash, err := models.HashPassword(password)
+	if err != nil {
+		return "", a.out.fail(exitFailure, err, i18n.ErrInternal)
+	}
+	return hash, exitOK
+}
+
+// jobSummary là thông tin một job trong kết quả lệnh config validate
+type jobSummary struct {
+	Name         string   `json:"name"`
+	Mode         string   `json:"mode,omitempty"`
+	Schedule     string   `json:"schedule,omitempty"`
+	Destinations []string `json:"destinations,omitempty"`
+}
+
+// configValidateCommand nạp và kiểm tra cấu hình (biến môi trường, file job, thông báo)
+// mà không kết nối database hay Docker
+func configValidateCommand(fs *flag.FlagSet) action {
+	return func(a *app, args []string) int {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return a.out.fail(exitConfig, err, i18n.ErrConfigInvalid)
+		}
+		i18n.SetDefault(cfg.Locale)
+
+		jobs := make([]jobSummary, 0, len(cfg.Jobs()))
+		for _, job := range cfg.Jobs() {
+			jobs = append(jobs, jobSummary{Name: job.Name, Mode: job.Mode, Schedule: job.Schedule, Destinations: job.Destinations})
+		}
+
+		a.out.result(map[string]any{"valid": true, "jobs": jobs}, func(w io.Writer) {
+			fmt.Fprintln(w, i18n.T("", "cli.config_valid", len(jobs)))
+			for _, job := range jobs {
+				schedule := job.Schedule
+				if schedule == "" {
+					schedule = "-"
+				}
+				fmt.Fprintf(w, "  %s (%s, %s)\n", job.Name, job.Mode, schedule)
+			}
+		})
+		return exitOK
+	}
+}
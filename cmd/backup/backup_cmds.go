@@ -0,0 +1,475 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/backup-cronjob/internal/audit"
+	"github.com/backup-cronjob/internal/backup"
+	"github.com/backup-cronjob/internal/config"
+	"github.com/backup-cronjob/internal/database"
+	"github.com/backup-cronjob/internal/i18n"
+	"github.com/backup-cronjob/internal/logging"
+	"github.com/backup-cronjob/internal/models"
+	"github.com/backup-cronjob/internal/notify"
+	"github.com/backup-cronjob/internal/retention"
+	"github.com/backup-cronjob/internal/verify"
+	"github.com/backup-cronjob/internal/wal"
+)
+
+// dumpOutcome là kết quả dump một job
+type dumpOutcome struct {
+	Job      string `json:"job"`
+	RunID    string `json:"run_id,omitempty"`
+	File     string `json:"file,omitempty"`
+	Size     int64  `json:"size,omitempty"`
+	Anomaly  string `json:"anomaly,omitempty"`
+	Uploaded bool   `json:"uploaded"`
+	Pruned   int    `json:"pruned"`
+	outcome
+}
+
+// dumpCommand dump database của các job đã chọn, tùy chọn upload và dọn dẹp bản backup hết hạn
+func dumpCommand(fs *flag.FlagSet) action {
+	jobName := jobFlag(fs)
+	upload := fs.Bool("upload", false, i18n.T("", "flag.upload"))
+	prune := fs.Bool("prune", false, i18n.T("", "flag.prune"))
+	return func(a *app, args []string) int {
+		jobs, code := a.selectJobs(*jobName)
+		if code != exitOK {
+			return code
+		}
+
+		results := make([]dumpOutcome, 0, len(jobs))
+		failed := 0
+		for _, job := range jobs {
+			a.out.progress("cli.dumping", job.Name)
+			result, err := a.runner.RunJob(a.ctx, job, backup.RunOptions{Upload: *upload, Prune: *prune, Audit: audit.ForCLI()})
+			item := dumpOutcome{Job: job.Name, outcome: newOutcome(err)}
+			if result != nil {
+				item.RunID = result.RunID
+				item.Anomaly = result.Anomaly
+				item.Uploaded = result.Uploaded
+				item.Pruned = len(result.Pruned)
+				if result.Dump != nil {
+					item.File = result.Dump.FilePath
+					item.Size = result.Dump.FileSize
+				}
+			}
+			results = append(results, item)
+
+			if err != nil {
+				// Lỗi đã được ghi log trong RunJob
+				failed++
+				continue
+			}
+			a.out.progress("cli.dump_success", item.File)
+			if item.Anomaly != "" {
+				a.out.progress("cli.anomaly_warning", item.Anomaly)
+			}
+		}
+		return a.out.report(results, failed, len(jobs), "cli.dump_failed")
+	}
+}
+
+// uploadOutcome là kết quả upload một bản backup (hoặc tất cả bản backup với --all)
+type uploadOutcome struct {
+	Job  string `json:"job,omitempty"`
+	File string `json:"file,omitempty"`
+	outcome
+}
+
+// uploadCommand upload bản backup mới nhất của các job đã chọn, một bản backup theo --id
+// hoặc tất cả bản backup với --all
+func uploadCommand(fs *flag.FlagSet) action {
+	jobName := jobFlag(fs)
+	all := fs.Bool("all", false, i18n.T("", "flag.upload_all"))
+	id := fs.String("id", "", i18n.T("", "flag.id"))
+	return func(a *app, args []string) int {
+		if *all {
+			return a.uploadAll(*jobName)
+		}
+
+		var backups []*models.BackupFile
+		if *id != "" {
+			found, err := models.FindBackupByID(a.cfg.BackupDir, *id)
+			if err != nil {
+				return a.out.fail(exitFailure, err, i18n.ErrBackupNotFound)
+			}
+			backups = append(backups, found)
+		} else {
+			jobs, code := a.selectJobs(*jobName)
+			if code != exitOK {
+				return code
+			}
+			a.out.progress("cli.finding_latest")
+			for _, job := range jobs {
+				latest, err := models.FindLatestBackup(a.cfg.BackupDir, job.Name)
+				if err != nil {
+					slog.Warn("No backup found to upload", logging.KeyJob, job.Name, logging.Err(err))
+					continue
+				}
+				backups = append(backups, latest)
+			}
+			if len(backups) == 0 {
+				return a.out.fail(exitFailure, i18n.Errorf(i18n.ErrNoBackups), i18n.ErrNoBackups)
+			}
+		}
+
+		results := make([]uploadOutcome, 0, len(backups))
+		failed := 0
+		for _, latest := range backups {
+			a.out.progress("cli.uploading", latest.Name)
+			err := a.uploader.UploadFile(a.ctx, latest.Path)
+			audit.RecordCLI(models.AuditActionUpload, latest.Name, audit.ResultOf(err), audit.ErrorDetails(err))
+			results = append(results, uploadOutcome{Job: latest.Job, File: latest.Path, outcome: newOutcome(err)})
+			if err != nil {
+				slog.Error("Upload failed", logging.KeyFile, latest.Path, logging.Err(err))
+				a.runner.Notifier.Notify(a.ctx, notify.Failure(latest.Job, latest.Path, err))
+				failed++
+				continue
+			}
+			a.out.progress("cli.upload_success", latest.Name)
+		}
+		return a.out.report(results, failed, len(backups), "cli.upload_failed")
+	}
+}
+
+// uploadAll upload tất cả bản backup (chỉ của job nếu jobName khác rỗng)
+func (a *app) uploadAll(jobName string) int {
+	if jobName != "" {
+		if _, err := a.cfg.FindJob(jobName); err != nil {
+			return a.out.fail(exitUsage, err, i18n.ErrJobNotFound)
+		}
+	}
+
+	a.out.progress("cli.uploading_all")
+	err := a.uploader.UploadAllBackups(a.ctx, jobName)
+	target := jobName
+	if target == "" {
+		target = "*"
+	}
+	audit.RecordCLI(models.AuditActionUpload, target, audit.ResultOf(err), audit.ErrorDetails(err))
+	if err != nil {
+		a.runner.Notifier.Notify(a.ctx, notify.Failure(jobName, "", err))
+		code := exitFailure
+		if i18n.Code(err) == i18n.ErrDriveUploadsPartial {
+			code = exitPartial
+		}
+		return a.out.fail(code, err, i18n.ErrUploadFailed)
+	}
+
+	a.out.result(uploadOutcome{Job: jobName, outcome: newOutcome(nil)}, func(w io.Writer) {
+		fmt.Fprintln(w, i18n.T("", "cli.upload_all_success"))
+	})
+	return exitOK
+}
+
+// backupItem là thông tin một bản backup trong kết quả lệnh list
+type backupItem struct {
+	ID            string     `json:"id"`
+	Job           string     `json:"job"`
+	Name          string     `json:"name"`
+	Path          string     `json:"path"`
+	Size          int64      `json:"size"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UploadedAt    *time.Time `json:"uploaded_at,omitempty"`
+	VerifyStatus  string     `json:"verify_status,omitempty"`
+	VerifyMessage string     `json:"verify_message,omitempty"`
+	Anomaly       string     `json:"anomaly,omitempty"`
+}
+
+// listCommand liệt kê các bản backup local kèm trạng thái upload, kiểm tra khôi phục từ catalog
+func listCommand(fs *flag.FlagSet) action {
+	jobName := jobFlag(fs)
+	return func(a *app, args []string) int {
+		var (
+			backups []*models.BackupFile
+			err     error
+		)
+		if *jobName != "" {
+			backups, err = models.GetJobBackups(a.cfg.BackupDir, *jobName)
+		} else {
+			backups, err = models.GetAllBackups(a.cfg.BackupDir)
+		}
+		if err != nil {
+			return a.out.fail(exitFailure, i18n.Errorf(i18n.ErrBackupListFailed, err), i18n.ErrBackupListFailed)
+		}
+
+		records, err := database.ListBackupRecords()
+		if err != nil {
+			return a.out.fail(exitFailure, i18n.Errorf(i18n.ErrCatalogUnavailable), i18n.ErrCatalogUnavailable)
+		}
+
+		items := make([]backupItem, 0, len(backups))
+		for _, b := range backups {
+			item := backupItem{ID: b.ID, Job: b.Job, Name: b.Name, Path: b.Path, Size: b.Size, CreatedAt: b.CreatedAt}
+			if record, ok := records[b.Path]; ok {
+				item.UploadedAt = record.UploadedAt
+				item.VerifyStatus = record.VerifyStatus
+				item.VerifyMessage = record.VerifyMessage
+				item.Anomaly = record.Anomaly
+			}
+			items = append(items, item)
+		}
+
+		a.out.result(map[string]any{"backups": items}, func(w io.Writer) {
+			if len(items) == 0 {
+				fmt.Fprintln(w, i18n.T("", "index.no_backups"))
+				return
+			}
+			tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, i18n.T("", "list.header"))
+			for _, item := range items {
+				uploaded := "-"
+				if item.UploadedAt != nil {
+					uploaded = item.UploadedAt.Format("2006-01-02 15:04")
+				}
+				status := item.VerifyStatus
+				if item.Anomaly != "" {
+					status = i18n.T("", "index.anomaly")
+				}
+				if status == "" {
+					status = "-"
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", item.ID, item.Job, item.Name,
+					models.FormatBytes(item.Size), item.CreatedAt.Format("2006-01-02 15:04"), uploaded, status)
+			}
+			tw.Flush()
+		})
+		return exitOK
+	}
+}
+
+// verifyOutcome là kết quả kiểm tra khôi phục một bản backup
+type verifyOutcome struct {
+	Job    string         `json:"job"`
+	File   string         `json:"file,omitempty"`
+	Result *verify.Result `json:"result,omitempty"`
+	outcome
+}
+
+// verifyCommand khôi phục bản backup mới nhất của từng job (hoặc bản backup --id) vào container tạm và kiểm tra
+func verifyCommand(fs *flag.FlagSet) action {
+	jobName := jobFlag(fs)
+	id := fs.String("id", "", i18n.T("", "flag.id"))
+	return func(a *app, args []string) int {
+		var targets []*models.BackupFile
+		var results []verifyOutcome
+		if *id != "" {
+			found, err := models.FindBackupByID(a.cfg.BackupDir, *id)
+			if err != nil {
+				return a.out.fail(exitFailure, err, i18n.ErrBackupNotFound)
+			}
+			targets = append(targets, found)
+		} else {
+			jobs, code := a.selectJobs(*jobName)
+			if code != exitOK {
+				return code
+			}
+			for _, job := range jobs {
+				latest, err := models.FindLatestBackup(a.cfg.BackupDir, job.Name)
+				if err != nil {
+					slog.Error("No backup found to verify", logging.KeyJob, job.Name, logging.Err(err))
+					results = append(results, verifyOutcome{Job: job.Name, outcome: newOutcome(err)})
+					continue
+				}
+				targets = append(targets, latest)
+			}
+		}
+
+		failed := len(results)
+		for _, target := range targets {
+			a.out.progress("cli.verifying", target.Name)
+			result, err := a.runner.VerifyBackup(a.ctx, target, audit.ForCLI())
+			results = append(results, verifyOutcome{Job: target.Job, File: target.Path, Result: result, outcome: newOutcome(err)})
+			if result != nil && !a.out.json {
+				for _, check := range result.Checks {
+					status := "OK"
+					if !check.Passed {
+						status = "FAIL"
+					}
+					fmt.Fprintf(a.out.w, "  [%s] %s %s\n", status, check.Name, check.Detail)
+				}
+			}
+			if err != nil {
+				slog.Error("Verification failed", logging.KeyJob, target.Job, logging.KeyFile, target.Path, logging.Err(err))
+				failed++
+				continue
+			}
+			a.out.progress("verify.success", target.Name, result.Message)
+		}
+		return a.out.report(results, failed, len(results), "cli.verify_failed")
+	}
+}
+
+// restoreCommand khôi phục job physical tới một thời điểm vào container mới (PITR)
+func restoreCommand(fs *flag.FlagSet) action {
+	jobName := jobFlag(fs)
+	targetTime := fs.String("target-time", "", i18n.T("", "flag.target_time"))
+	name := fs.String("name", "", i18n.T("", "flag.restore_name"))
+	port := fs.String("port", "", i18n.T("", "flag.restore_port"))
+	return func(a *app, args []string) int {
+		if *jobName == "" {
+			return a.out.fail(exitUsage, i18n.Errorf(i18n.ErrJobRequired), i18n.ErrJobRequired)
+		}
+		jobs, code := a.selectJobs(*jobName)
+		if code != exitOK {
+			return code
+		}
+		job := jobs[0]
+
+		opts := wal.RestoreOptions{Name: *name, Port: *port}
+		if *targetTime != "" {
+			target, err := time.Parse(time.RFC3339, *targetTime)
+			if err != nil {
+				return a.out.fail(exitUsage, i18n.Errorf(i18n.ErrInvalidTargetTime, err), i18n.ErrInvalidTargetTime)
+			}
+			opts.Target = target
+		}
+
+		a.out.progress("cli.restoring", job.Name)
+		result, err := wal.Restore(a.ctx, a.dumper.Docker, a.cfg.BackupDir, job, opts)
+		target := job.Name
+		if result != nil {
+			target = job.Name + " -> " + result.Container
+		}
+		audit.RecordCLI(models.AuditActionRestore, target, audit.ResultOf(err), audit.ErrorDetails(err))
+		if err != nil {
+			return a.out.fail(exitFailure, i18n.Errorf(i18n.ErrRestoreFailed, err), i18n.ErrRestoreFailed)
+		}
+
+		a.out.result(result, func(w io.Writer) {
+			fmt.Fprintln(w, i18n.T("", "cli.restore_success",
+				result.Container, result.Image, result.BaseBackup, result.WAL.Start, result.WAL.End))
+		})
+		return exitOK
+	}
+}
+
+// pruneOutcome là kết quả dọn dẹp bản backup hết hạn của một job
+type pruneOutcome struct {
+	Job     string   `json:"job"`
+	Removed []string `json:"removed"`
+	DryRun  bool     `json:"dry_run,omitempty"`
+	outcome
+}
+
+// pruneCommand xóa các bản backup local hết hạn theo chính sách lưu giữ của job;
+// --dry-run chỉ liệt kê các bản backup sẽ bị xóa
+func pruneCommand(fs *flag.FlagSet) action {
+	jobName := jobFlag(fs)
+	dryRun := fs.Bool("dry-run", false, i18n.T("", "flag.dry_run"))
+	return func(a *app, args []string) int {
+		jobs, code := a.selectJobs(*jobName)
+		if code != exitOK {
+			return code
+		}
+
+		results := make([]pruneOutcome, 0, len(jobs))
+		failed := 0
+		for _, job := range jobs {
+			var (
+				backups []*models.BackupFile
+				err     error
+			)
+			if *dryRun {
+				backups, err = retention.ExpiredLocal(a.cfg.BackupDir, job)
+			} else {
+				backups, err = retention.PruneLocal(a.cfg.BackupDir, job)
+				for _, b := range backups {
+					audit.RecordCLI(models.AuditActionDelete, job.Name+"/"+b.Name, models.AuditResultSuccess, "retention")
+				}
+				if err != nil {
+					audit.RecordCLI(models.AuditActionDelete, job.Name, models.AuditResultFailure, err.Error())
+				}
+			}
+
+			item := pruneOutcome{Job: job.Name, Removed: make([]string, 0, len(backups)), DryRun: *dryRun, outcome: newOutcome(err)}
+			for _, b := range backups {
+				item.Removed = append(item.Removed, b.Path)
+			}
+			results = append(results, item)
+			if err != nil {
+				slog.Error("Pruning failed", logging.KeyJob, job.Name, logging.Err(err))
+				failed++
+			}
+
+			key := "cli.pruned"
+			if *dryRun {
+				key = "cli.prune_dry_run"
+			}
+			a.out.progress(key, job.Name, len(backups))
+			for _, path := range item.Removed {
+				a.out.progress("cli.list_item", path)
+			}
+		}
+		return a.out.report(results, failed, len(jobs), "cli.prune_failed")
+	}
+}
+
+// walOutcome là kết quả lưu trữ WAL một lượt của một job physical
+type walOutcome struct {
+	Job      string `json:"job"`
+	Archived int    `json:"archived"`
+	outcome
+}
+
+// archiveWALCommand lấy các segment WAL mới của các job physical một lượt
+func archiveWALCommand(fs *flag.FlagSet) action {
+	jobName := jobFlag(fs)
+	return func(a *app, args []string) int {
+		jobs, code := a.selectJobs(*jobName)
+		if code != exitOK {
+			return code
+		}
+
+		archiver := wal.New(a.cfg, a.dumper.Docker, a.uploader)
+		var results []walOutcome
+		failed := 0
+		for _, job := range jobs {
+			if job.Mode != config.ModePhysical {
+				continue
+			}
+			n, err := archiver.ArchiveJob(a.ctx, job)
+			results = append(results, walOutcome{Job: job.Name, Archived: n, outcome: newOutcome(err)})
+			if err != nil {
+				slog.Error("WAL archiving failed", logging.KeyJob, job.Name, logging.Err(err))
+				failed++
+				continue
+			}
+			a.out.progress("cli.wal_archived", job.Name, n)
+		}
+		if len(results) == 0 {
+			return a.out.fail(exitUsage, i18n.Errorf(i18n.ErrNoPhysicalJobs), i18n.ErrNoPhysicalJobs)
+		}
+		return a.out.report(results, failed, len(results), "cli.wal_failed")
+	}
+}
+
+// notifyTestCommand gửi thông báo thử tới kênh thông báo để kiểm tra cấu hình
+func notifyTestCommand(fs *flag.FlagSet) action {
+	return func(a *app, args []string) int {
+		if len(args) != 1 {
+			fmt.Fprintln(os.Stderr, i18n.T("", "cli.channel_required"))
+			return exitUsage
+		}
+		channel := args[0]
+
+		err := a.runner.Notifier.Test(a.ctx, channel)
+		audit.RecordCLI(models.AuditActionNotifyTest, channel, audit.ResultOf(err), audit.ErrorDetails(err))
+		if err != nil {
+			return a.out.fail(exitFailure, err, i18n.ErrNotificationFailed)
+		}
+
+		a.out.result(map[string]any{"channel": channel, "ok": true}, func(w io.Writer) {
+			fmt.Fprintln(w, i18n.T("", "cli.notify_test_sent", channel))
+		})
+		return exitOK
+	}
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/backup-cronjob/internal/i18n"
+)
+
+// Mã thoát của CLI
+const (
+	exitOK      = 0 // thành công
+	exitFailure = 1 // thất bại
+	exitUsage   = 2 // sai cú pháp lệnh hoặc tham số
+	exitPartial = 3 // một phần thất bại (vd: một số job lỗi, các job còn lại thành công)
+	exitConfig  = 4 // cấu hình không hợp lệ
+)
+
+// Định dạng output của lệnh (--output)
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// progName là tên chương trình hiển thị trong hướng dẫn và script completion
+var progName = filepath.Base(os.Args[0])
+
+// action thực thi một lệnh với các tham số vị trí còn lại, trả về mã thoát
+type action func(app *app, args []string) int
+
+// command là một lệnh của CLI. Lệnh nhóm (auth, users, config...) chỉ có các lệnh con.
+type command struct {
+	name string
+	// summary là khóa thông báo mô tả ngắn của lệnh
+	summary string
+	// args là cú pháp các tham số vị trí, vd: "<username>"
+	args string
+	// sub là các lệnh con
+	sub []*command
+	// flags khai báo flag riêng của lệnh trên fs và trả về hàm thực thi
+	flags func(fs *flag.FlagSet) action
+	// noConfig: lệnh tự xử lý (hoặc không cần) việc nạp cấu hình, database
+	noConfig bool
+}
+
+// commands là cây lệnh của CLI
+var commands []*command
+
+// findCommand tìm lệnh theo các tham số đầu tiên, trả về lệnh, đường dẫn tên lệnh và phần tham số còn lại
+func findCommand(args []string) (*command, []string, []string) {
+	var (
+		cmd  *command
+		path []string
+	)
+	list := commands
+	for len(args) > 0 {
+		var next *command
+		for _, c := range list {
+			if c.name == args[0] {
+				next = c
+				break
+			}
+		}
+		if next == nil {
+			break
+		}
+		cmd, path, args, list = next, append(path, next.name), args[1:], next.sub
+		if next.flags != nil {
+			break
+		}
+	}
+	return cmd, path, args
+}
+
+// newFlagSet tạo FlagSet của lệnh cùng flag --output dùng chung
+func newFlagSet(cmd *command, path []string) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet(progName+" "+strings.Join(path, " "), flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	output := fs.String("output", outputText, i18n.T("", "flag.output"))
+	fs.Usage = func() {
+		line := strings.TrimSpace(fs.Name() + " [flags] " + cmd.args)
+		fmt.Fprintf(fs.Output(), "%s\n\n%s: %s\n\n", i18n.T("", cmd.summary), i18n.T("", "cli.usage"), line)
+		fs.PrintDefaults()
+	}
+	return fs, output
+}
+
+// jobFlag khai báo flag --job chọn job cần thực hiện
+func jobFlag(fs *flag.FlagSet) *string {
+	return fs.String("job", "", i18n.T("", "flag.job"))
+}
+
+// usage in danh sách lệnh (hoặc lệnh con của nhóm path) ra w
+func usage(w io.Writer, list []*command, path []string) {
+	name := strings.Join(append([]string{progName}, path...), " ")
+	fmt.Fprintf(w, "%s: %s <command> [flags]\n\n%s:\n", i18n.T("", "cli.usage"), name, i18n.T("", "cli.commands"))
+	for _, cmd := range list {
+		fmt.Fprintf(w, "  %-16s %s\n", cmd.name, i18n.T("", cmd.summary))
+	}
+	fmt.Fprintf(w, "\n%s\n", i18n.T("", "cli.help_hint", progName))
+}
+
+// run phân tích dòng lệnh, khởi tạo ứng dụng và thực thi lệnh, trả về mã thoát
+func run(ctx context.Context, args []string) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		usage(os.Stderr, commands, nil)
+		if len(args) == 0 {
+			return exitUsage
+		}
+		return exitOK
+	}
+
+	cmd, path, rest := findCommand(args)
+	if cmd == nil {
+		fmt.Fprintln(os.Stderr, i18n.T("", "cli.unknown_command", args[0]))
+		usage(os.Stderr, commands, nil)
+		return exitUsage
+	}
+	if cmd.flags == nil {
+		// Lệnh nhóm: thiếu hoặc sai lệnh con
+		if len(rest) > 0 && rest[0] != "help" && rest[0] != "-h" && rest[0] != "--help" {
+			fmt.Fprintln(os.Stderr, i18n.T("", "cli.unknown_command", strings.Join(append(path, rest[0]), " ")))
+		}
+		usage(os.Stderr, cmd.sub, path)
+		return exitUsage
+	}
+
+	fs, output := newFlagSet(cmd, path)
+	act := cmd.flags(fs)
+	positional, err := parseFlags(fs, rest)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if *output != outputText && *output != outputJSON {
+		fmt.Fprintln(os.Stderr, i18n.T("", "cli.invalid_output", *output))
+		return exitUsage
+	}
+
+	a := &app{ctx: ctx, out: &printer{json: *output == outputJSON, w: os.Stdout}}
+	if !cmd.noConfig {
+		if code := a.init(); code != exitOK {
+			return code
+		}
+		defer a.close()
+	}
+	return act(a, positional)
+}
+
+// parseFlags phân tích flag của lệnh, cho phép đặt flag sau tham số vị trí
+// (vd: users add bob --role admin). Trả về các tham số vị trí.
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		// "--" kết thúc các flag: mọi tham số còn lại là tham số vị trí
+		if n := len(args) - len(rest); n > 0 && args[n-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		positional, args = append(positional, rest[0]), rest[1:]
+	}
+}
+
+// exitFor trả về mã thoát theo số lượng thất bại trên tổng số
+func exitFor(failed, total int) int {
+	switch {
+	case failed == 0:
+		return exitOK
+	case failed < total:
+		return exitPartial
+	default:
+		return exitFailure
+	}
+}
+
+// printer ghi kết quả của lệnh dạng văn bản hoặc JSON (--output json).
+// Ở chế độ JSON, các thông báo tiến trình không được in để stdout chỉ chứa kết quả.
+type printer struct {
+	json bool
+	w    io.Writer
+}
+
+// progress in thông báo tiến trình key (chỉ ở dạng văn bản)
+func (p *printer) progress(key string, args ...any) {
+	if !p.json {
+		fmt.Fprintln(p.w, i18n.T("", key, args...))
+	}
+}
+
+// result in kết quả của lệnh: v dạng JSON, hoặc gọi text để in dạng văn bản
+func (p *printer) result(v any, text func(w io.Writer)) {
+	if p.json {
+		enc := json.NewEncoder(p.w)
+		enc.SetIndent("", "  ")
+		enc.Encode(v)
+		return
+	}
+	if text != nil {
+		text(p.w)
+	}
+}
+
+// fail in lỗi err (mã fallback nếu err không có mã) và trả về mã thoát code.
+// Dạng JSON in {"code": ..., "error": ...} ra stdout, dạng văn bản in thông báo ra stderr.
+func (p *printer) fail(code int, err error, fallback string) int {
+	body := i18n.ErrorBodyFor(context.Background(), err, fallback)
+	if p.json {
+		p.result(body, nil)
+	} else {
+		fmt.Fprintln(os.Stderr, body["error"])
+	}
+	return code
+}
+
+// report in kết quả của lệnh trên nhiều job (hoặc bản backup) và trả về mã thoát theo số thất bại.
+// Dạng văn bản in thông báo failedKey (số thất bại, tổng số) ra stderr nếu có thất bại.
+func (p *printer) report(results any, failed, total int, failedKey string) int {
+	p.result(map[string]any{"results": results, "failed": failed, "total": total}, nil)
+	if failed > 0 && !p.json {
+		fmt.Fprintln(os.Stderr, i18n.T("", failedKey, failed, total))
+	}
+	return exitFor(failed, total)
+}
+
+// outcome là trạng thái chung của một mục trong kết quả JSON: ok, hoặc mã và thông báo lỗi
+type outcome struct {
+	OK    bool   `json:"ok"`
+	Code  string `json:"code,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// newOutcome tạo trạng thái theo lỗi err (nil là thành công)
+func newOutcome(err error) outcome {
+	if err == nil {
+		return outcome{OK: true}
+	}
+	return outcome{Code: i18n.Code(err), Error: err.Error()}
+}
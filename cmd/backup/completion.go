@@ -0,0 +1,186 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/backup-cronjob/internal/i18n"
+)
+
+func init() {
+	commands = []*command{
+		{name: "dump", summary: "cmd.dump", flags: dumpCommand},
+		{name: "upload", summary: "cmd.upload", flags: uploadCommand},
+		{name: "list", summary: "cmd.list", flags: listCommand},
+		{name: "verify", summary: "cmd.verify", flags: verifyCommand},
+		{name: "restore", summary: "cmd.restore", flags: restoreCommand},
+		{name: "prune", summary: "cmd.prune", flags: pruneCommand},
+		{name: "archive-wal", summary: "cmd.archive_wal", flags: archiveWALCommand},
+		{name: "notify", summary: "cmd.notify", sub: []*command{
+			{name: "test", summary: "cmd.notify_test", args: "<channel>", flags: notifyTestCommand},
+		}},
+		{name: "serve", summary: "cmd.serve", flags: serveCommand},
+		{name: "auth", summary: "cmd.auth", sub: []*command{
+			{name: "google", summary: "cmd.auth_google", flags: authGoogleCommand},
+			{name: "status", summary: "cmd.auth_status", flags: authStatusCommand},
+			{name: "revoke", summary: "cmd.auth_revoke", flags: authRevokeCommand},
+		}},
+		{name: "users", summary: "cmd.users", sub: []*command{
+			{name: "list", summary: "cmd.users_list", flags: usersListCommand},
+			{name: "add", summary: "cmd.users_add", args: "<username>", flags: usersAddCommand},
+			{name: "passwd", summary: "cmd.users_passwd", args: "<username>", flags: usersPasswdCommand},
+			{name: "delete", summary: "cmd.users_delete", args: "<username>", flags: usersDeleteCommand},
+		}},
+		{name: "config", summary: "cmd.config", sub: []*command{
+			{name: "validate", summary: "cmd.config_validate", flags: configValidateCommand, noConfig: true},
+		}},
+		{name: "completion", summary: "cmd.completion", args: "bash|zsh|fish", flags: completionCommand, noConfig: true},
+	}
+}
+
+// completionCommand in script completion cho shell bash, zsh hoặc fish
+func completionCommand(fs *flag.FlagSet) action {
+	return func(a *app, args []string) int {
+		if len(args) != 1 {
+			fmt.Fprintln(os.Stderr, i18n.T("", "cli.shell_required"))
+			return exitUsage
+		}
+		switch args[0] {
+		case "bash":
+			writeBashCompletion(os.Stdout)
+		case "zsh":
+			fmt.Fprintln(os.Stdout, "autoload -U +X bashcompinit && bashcompinit")
+			writeBashCompletion(os.Stdout)
+		case "fish":
+			writeFishCompletion(os.Stdout)
+		default:
+			fmt.Fprintln(os.Stderr, i18n.T("", "cli.shell_required"))
+			return exitUsage
+		}
+		return exitOK
+	}
+}
+
+// completionFlags trả về tên các flag của lệnh (kèm --output dùng chung) và các flag cần giá trị
+func completionFlags(cmd *command, path []string) (names []string, valued []string) {
+	fs, _ := newFlagSet(cmd, path)
+	cmd.flags(fs)
+	fs.VisitAll(func(f *flag.Flag) {
+		names = append(names, "--"+f.Name)
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); !ok || !b.IsBoolFlag() {
+			valued = append(valued, "--"+f.Name)
+		}
+	})
+	return names, valued
+}
+
+// commandNames trả về tên các lệnh trong list
+func commandNames(list []*command) []string {
+	names := make([]string, 0, len(list))
+	for _, cmd := range list {
+		names = append(names, cmd.name)
+	}
+	return names
+}
+
+// walkCommands gọi fn với mọi lệnh trong cây cùng đường dẫn tên lệnh
+func walkCommands(list []*command, path []string, fn func(cmd *command, path []string)) {
+	for _, cmd := range list {
+		p := append(append([]string{}, path...), cmd.name)
+		fn(cmd, p)
+		walkCommands(cmd.sub, p, fn)
+	}
+}
+
+// writeBashCompletion in script completion cho bash: đi theo các từ đã gõ để xác định lệnh
+// (bỏ qua flag và giá trị của flag), rồi gợi ý lệnh con, flag hoặc tham số của lệnh
+func writeBashCompletion(w io.Writer) {
+	fn := "_" + strings.NewReplacer("-", "_", ".", "_").Replace(progName)
+
+	// Các flag cần giá trị: từ ngay sau chúng không phải là tên lệnh
+	seen := map[string]bool{}
+	var valued []string
+	walkCommands(commands, nil, func(cmd *command, path []string) {
+		if cmd.flags == nil {
+			return
+		}
+		_, names := completionFlags(cmd, path)
+		for _, name := range names {
+			if !seen[name] {
+				seen[name] = true
+				valued = append(valued, name)
+			}
+		}
+	})
+
+	fmt.Fprintf(w, "# Completion của %s cho bash: source <(%s completion bash)\n", progName, progName)
+	fmt.Fprintf(w, "%s() {\n", fn)
+	fmt.Fprintln(w, `    local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}" path="" i word`)
+	fmt.Fprintln(w, `    for ((i = 1; i < COMP_CWORD; i++)); do`)
+	fmt.Fprintln(w, `        word="${COMP_WORDS[i]}"`)
+	fmt.Fprintln(w, `        case "$word" in -*) continue ;; esac`)
+	fmt.Fprintf(w, "        case \"${COMP_WORDS[i-1]}\" in %s) continue ;; esac\n", strings.Join(valued, "|"))
+	fmt.Fprintln(w, `        path="${path:+$path }$word"`)
+	fmt.Fprintln(w, `    done`)
+	fmt.Fprintln(w, `    case "$prev" in`)
+	fmt.Fprintln(w, `        --output) COMPREPLY=($(compgen -W "text json" -- "$cur")); return ;;`)
+	fmt.Fprintln(w, `        --role) COMPREPLY=($(compgen -W "user admin" -- "$cur")); return ;;`)
+	fmt.Fprintln(w, `    esac`)
+	fmt.Fprintln(w, `    case "$path" in`)
+	fmt.Fprintf(w, "        \"\") COMPREPLY=($(compgen -W %q -- \"$cur\")) ;;\n", strings.Join(commandNames(commands), " "))
+	walkCommands(commands, nil, func(cmd *command, path []string) {
+		name := strings.Join(path, " ")
+		if cmd.flags == nil {
+			fmt.Fprintf(w, "        %q) COMPREPLY=($(compgen -W %q -- \"$cur\")) ;;\n", name, strings.Join(commandNames(cmd.sub), " "))
+			return
+		}
+		words, _ := completionFlags(cmd, path)
+		if cmd.name == "completion" {
+			words = append(words, "bash", "zsh", "fish")
+		}
+		// Lệnh có thể đã có tham số vị trí phía sau
+		fmt.Fprintf(w, "        %q|%q*) COMPREPLY=($(compgen -W %q -- \"$cur\")) ;;\n", name, name+" ", strings.Join(words, " "))
+	})
+	fmt.Fprintln(w, `    esac`)
+	fmt.Fprintln(w, `}`)
+	fmt.Fprintf(w, "complete -F %s %s\n", fn, progName)
+}
+
+// writeFishCompletion in script completion cho fish
+func writeFishCompletion(w io.Writer) {
+	fmt.Fprintf(w, "# Completion của %s cho fish: %s completion fish | source\n", progName, progName)
+	fmt.Fprintf(w, "complete -c %s -f\n", progName)
+	walkCommands(commands, nil, func(cmd *command, path []string) {
+		summary := i18n.T("", cmd.summary)
+		condition := "__fish_use_subcommand"
+		if len(path) > 1 {
+			condition = fmt.Sprintf("__fish_seen_subcommand_from %s; and not __fish_seen_subcommand_from %s",
+				path[len(path)-2], strings.Join(commandNames(parentOf(path).sub), " "))
+		}
+		fmt.Fprintf(w, "complete -c %s -n %q -a %s -d %q\n", progName, condition, cmd.name, summary)
+
+		if cmd.flags == nil {
+			return
+		}
+		names, valued := completionFlags(cmd, path)
+		sort.Strings(valued)
+		for _, name := range names {
+			flagName := strings.TrimPrefix(name, "--")
+			requires := ""
+			if i := sort.SearchStrings(valued, name); i < len(valued) && valued[i] == name {
+				requires = " -r"
+			}
+			fmt.Fprintf(w, "complete -c %s -n %q -l %s%s\n", progName, "__fish_seen_subcommand_from "+cmd.name, flagName, requires)
+		}
+	})
+}
+
+// parentOf trả về lệnh nhóm chứa lệnh có đường dẫn path
+func parentOf(path []string) *command {
+	cmd, _, _ := findCommand(path[:len(path)-1])
+	return cmd
+}
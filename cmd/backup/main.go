@@ -1,151 +1,271 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
-	"log"
+	"log/slog"
+	"net/http"
 	"os"
-	"path/filepath"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/backup-cronjob/internal/audit"
 	"github.com/backup-cronjob/internal/auth"
+	"github.com/backup-cronjob/internal/backup"
 	"github.com/backup-cronjob/internal/config"
+	"github.com/backup-cronjob/internal/database"
 	"github.com/backup-cronjob/internal/dbdump"
+	"github.com/backup-cronjob/internal/discovery"
 	"github.com/backup-cronjob/internal/drive"
 	"github.com/backup-cronjob/internal/handlers"
+	"github.com/backup-cronjob/internal/health"
+	"github.com/backup-cronjob/internal/i18n"
+	"github.com/backup-cronjob/internal/logging"
+	"github.com/backup-cronjob/internal/metrics"
+	"github.com/backup-cronjob/internal/ratelimit"
+	"github.com/backup-cronjob/internal/scheduler"
+	"github.com/backup-cronjob/internal/secure"
+	"github.com/backup-cronjob/internal/wal"
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
-	// Thiết lập các flag dòng lệnh
-	var (
-		dumpOnly   = flag.Bool("dump", false, "Chỉ thực hiện dump database")
-		uploadLast = flag.Bool("upload-last", false, "Upload file backup mới nhất")
-		uploadAll  = flag.Bool("upload-all", false, "Upload tất cả các file backup")
-		webMode    = flag.Bool("web", false, "Khởi động ứng dụng web")
-		port       = flag.String("port", "8080", "Port cho ứng dụng web")
-	)
-	flag.Parse()
-
-	// Nạp cấu hình
+	// Ngôn ngữ của CLI lấy từ LOCALE; được đặt lại sau khi nạp cấu hình (có thể từ file .env)
+	i18n.SetDefault(os.Getenv("LOCALE"))
+
+	// Ctrl+C/SIGTERM hủy context: các lệnh đang chạy dừng lại, server web/daemon tắt
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := run(ctx, os.Args[1:])
+	stop()
+	os.Exit(code)
+}
+
+// app chứa cấu hình và các đối tượng dùng chung của một lệnh
+type app struct {
+	ctx context.Context
+	out *printer
+
+	cfg        *config.Config
+	dumper     *dbdump.DatabaseDumper
+	uploader   *drive.DriveUploader
+	runner     *backup.Runner
+	discoverer *discovery.Discoverer
+	// discovered đánh dấu đã phát hiện container một lượt (xem selectJobs)
+	discovered bool
+}
+
+// init nạp cấu hình, cấu hình logging, master key, database và tạo các đối tượng dùng chung
+func (a *app) init() int {
 	cfg, err := config.LoadConfig()
 	if err != nil {
-		log.Fatalf("Không thể nạp cấu hình: %v", err)
+		return a.out.fail(exitConfig, err, i18n.ErrConfigInvalid)
 	}
+	a.cfg = cfg
+	i18n.SetDefault(cfg.Locale)
 
-	// Khởi tạo các đối tượng
-	dumper := dbdump.NewDatabaseDumper(cfg)
-	uploader := drive.NewDriveUploader(cfg)
+	// Logger có cấu trúc; che các giá trị secret trong mọi output log
+	if err := logging.Setup(config.NewRedactingWriter(os.Stderr), cfg.LogFormat, cfg.LogLevel); err != nil {
+		return a.out.fail(exitConfig, err, i18n.ErrConfigInvalid)
+	}
+	gin.DefaultWriter = config.NewRedactingWriter(os.Stdout)
+	gin.DefaultErrorWriter = config.NewRedactingWriter(os.Stderr)
 
-	// Thực hiện theo flag
-	if *dumpOnly || (!*uploadLast && !*uploadAll && !*webMode) {
-		// Nếu chỉ có flag dump hoặc không có flag nào, thực hiện dump
-		fmt.Println("Đang thực hiện dump database...")
-		result, err := dumper.DumpDatabase()
-		if err != nil {
-			log.Fatalf("Lỗi khi dump database: %v", err)
-		}
-		fmt.Printf("Dump thành công: %s\n", result.FilePath)
+	// Nạp master key dùng để mã hóa token lưu trong database
+	if err := secure.Init(cfg); err != nil {
+		return a.out.fail(exitConfig, fmt.Errorf("master key: %w", err), i18n.ErrStartupFailed)
 	}
 
-	if *uploadLast {
-		// Upload file mới nhất
-		fmt.Println("Đang tìm file backup mới nhất...")
-		latestFile := findLatestBackup(cfg.BackupDir)
-		if latestFile == "" {
-			log.Fatalf("Không tìm thấy file backup nào")
-		}
+	// Khởi tạo database (dùng cho audit log và dữ liệu ứng dụng)
+	if err := database.InitDB(cfg); err != nil {
+		return a.out.fail(exitFailure, fmt.Errorf("database: %w", err), i18n.ErrStartupFailed)
+	}
 
-		fmt.Printf("Đang upload file %s lên Google Drive...\n", filepath.Base(latestFile))
-		err := uploader.UploadFile(latestFile)
-		if err != nil {
-			log.Fatalf("Lỗi khi upload file: %v", err)
-		}
-		fmt.Println("Upload thành công!")
+	a.dumper = dbdump.NewDatabaseDumper(cfg)
+	a.uploader = drive.NewDriveUploader(cfg)
+	a.runner = backup.NewRunner(cfg, a.dumper, a.uploader)
+	if cfg.DiscoveryEnabled {
+		a.discoverer = discovery.New(cfg, a.dumper.Docker)
 	}
+	return exitOK
+}
+
+// close giải phóng tài nguyên đã mở trong init
+func (a *app) close() {
+	database.Close()
+}
 
-	if *uploadAll {
-		// Upload tất cả file
-		fmt.Println("Đang upload tất cả file backup lên Google Drive...")
-		err := uploader.UploadAllBackups()
-		if err != nil {
-			log.Fatalf("Lỗi khi upload tất cả file: %v", err)
+// selectJobs chọn job theo --job (mặc định: tất cả các job), trả về mã thoát khác exitOK nếu lỗi.
+// Các container có label backup.enable=true được phát hiện một lượt trước khi chọn.
+func (a *app) selectJobs(name string) ([]*config.Job, int) {
+	if a.discoverer != nil && !a.discovered {
+		a.discovered = true
+		if _, err := a.discoverer.Refresh(a.ctx); err != nil {
+			slog.Error("Container discovery failed", logging.Err(err))
 		}
-		fmt.Println("Upload thành công!")
 	}
 
-	if *webMode {
-		// Khởi động ứng dụng web
-		fmt.Printf("Đang khởi động ứng dụng web trên port %s...\n", *port)
-		startWebApp(cfg, *port)
+	jobs, err := a.cfg.SelectJobs(name)
+	if err != nil {
+		return nil, a.out.fail(exitUsage, err, i18n.ErrJobNotFound)
 	}
+	return jobs, exitOK
 }
 
-// findLatestBackup tìm file backup mới nhất
-func findLatestBackup(backupDir string) string {
-	var (
-		latestFile  string
-		latestMTime int64
-	)
+// serveCommand khởi động ứng dụng web, hoặc chỉ chạy scheduler với --daemon
+func serveCommand(fs *flag.FlagSet) action {
+	port := fs.String("port", "8080", i18n.T("", "flag.port"))
+	daemon := fs.Bool("daemon", false, i18n.T("", "flag.daemon"))
+	schedule := fs.Bool("schedule", false, i18n.T("", "flag.schedule"))
+	return func(a *app, args []string) int {
+		if *daemon {
+			a.out.progress("cli.daemon_running")
+			runDaemon(a.ctx, a.cfg, a.runner, a.discoverer, wal.New(a.cfg, a.dumper.Docker, a.uploader))
+			return exitOK
+		}
+
+		a.out.progress("cli.starting_web", *port)
+		if err := startWebApp(a.ctx, a.cfg, *port, *schedule || a.cfg.ServeSchedule); err != nil {
+			return a.out.fail(exitFailure, err, i18n.ErrServerFailed)
+		}
+		return exitOK
+	}
+}
 
-	// Duyệt qua tất cả thư mục ngày
-	dateDirs, err := os.ReadDir(backupDir)
-	if err != nil {
-		return ""
-	}
-
-	for _, dateDir := range dateDirs {
-		if dateDir.IsDir() {
-			datePath := filepath.Join(backupDir, dateDir.Name())
-			files, err := filepath.Glob(filepath.Join(datePath, "*.sql"))
-			if err != nil {
-				continue
-			}
-
-			for _, file := range files {
-				info, err := os.Stat(file)
-				if err != nil {
-					continue
-				}
-
-				modTime := info.ModTime().Unix()
-				if modTime > latestMTime {
-					latestMTime = modTime
-					latestFile = file
-				}
-			}
+// startScheduler khởi động scheduler chạy các job theo lịch cấu hình
+func startScheduler(ctx context.Context, cfg *config.Config, runner *backup.Runner) *scheduler.Scheduler {
+	s := scheduler.New(cfg, func(ctx context.Context, job *config.Job) {
+		// Kết quả và lỗi của lần chạy được ghi log (kèm trigger) và lưu trong RunJob; riêng lần
+		// chạy bị từ chối vì job đang chạy từ web/CLI thì không có bản ghi nên chỉ ghi log ở đây
+		_, err := runner.RunJob(logging.With(ctx, "trigger", "scheduler"), job, backup.RunOptions{
+			Upload: true,
+			Prune:  true,
+			Audit:  audit.ForActor("scheduler"),
+		})
+		if i18n.Code(err) == i18n.ErrJobRunning {
+			slog.Warn("Job is still running, scheduled run skipped", logging.KeyJob, job.Name)
 		}
+	})
+	s.Start(ctx)
+	return s
+}
+
+// runDaemon chạy scheduler cho tới khi nhận tín hiệu dừng
+func runDaemon(ctx context.Context, cfg *config.Config, runner *backup.Runner, discoverer *discovery.Discoverer, archiver *wal.Archiver) {
+	if discoverer != nil {
+		discoverer.Start(ctx)
 	}
+	archiver.Start(ctx)
+	runner.Notifier.StartDigest(ctx, cfg)
+	startMetricsServer(ctx, cfg, health.New(cfg, runner.DatabaseDumper.Docker, runner.DriveUploader))
+
+	s := startScheduler(ctx, cfg, runner)
+	<-ctx.Done()
 
-	return latestFile
+	slog.Info("Stopping scheduler, waiting for running jobs")
+	s.Wait()
 }
 
-// startWebApp khởi động ứng dụng web
-func startWebApp(cfg *config.Config, port string) {
+// startMetricsServer phục vụ /metrics, /healthz và /readyz trên METRICS_ADDR ở chế độ daemon
+// (không có server web)
+func startMetricsServer(ctx context.Context, cfg *config.Config, checker *health.Checker) {
+	if cfg.MetricsAddr == "" || cfg.MetricsAddr == "off" {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler(cfg, cfg.MetricsToken))
+	mux.Handle("/healthz", health.LivenessHandler())
+	mux.Handle("/readyz", checker.ReadinessHandler(func(r *http.Request) bool {
+		return metrics.Authorized(r, cfg.MetricsToken)
+	}))
+	server := &http.Server{Addr: cfg.MetricsAddr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		slog.Info("Serving metrics", "addr", cfg.MetricsAddr)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			slog.Error("Metrics server stopped", logging.Err(err))
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+}
+
+// startWebApp khởi động ứng dụng web, chạy cho tới khi ctx bị hủy
+func startWebApp(ctx context.Context, cfg *config.Config, port string, schedule bool) error {
 	// Thiết lập Gin
-	router := gin.Default()
+	router := gin.New()
+	// Chỉ lấy IP client từ header X-Forwarded-For của các proxy được cấu hình; giới hạn tần suất và
+	// khóa đăng nhập theo IP dựa vào đây nên không thể tin header từ client bất kỳ
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		return fmt.Errorf("invalid TRUSTED_PROXIES: %w", err)
+	}
+	// ID bản backup là đường dẫn tương đối (job/ngày/tên) được mã hóa thành %2F trong URL,
+	// định tuyến theo đường dẫn gốc để ID vẫn nằm trong một tham số :id
+	router.UseRawPath = true
+	router.Use(gin.Recovery(), handlers.RequestLogger(), handlers.LocaleMiddleware())
 
 	// Tạo handler
 	h := handlers.NewHandler(cfg)
 
+	// Phát hiện container theo label và chạy các job có lịch trong nền
+	if h.Discoverer != nil {
+		h.Discoverer.Start(ctx)
+	}
+	h.Archiver.Start(ctx)
+	h.Runner.Notifier.StartDigest(ctx, cfg)
+	// Server web chỉ chạy job theo lịch khi được bật rõ ràng (--schedule hoặc SERVE_SCHEDULE)
+	var s *scheduler.Scheduler
+	if schedule {
+		s = startScheduler(ctx, cfg, h.Runner)
+	} else {
+		slog.Info("Scheduler disabled in web mode, use --schedule or SERVE_SCHEDULE=true to enable")
+	}
+
 	// Cấu hình static files
 	router.Static("/static", "./ui/static")
+	router.SetFuncMap(handlers.TemplateFuncs())
 	router.LoadHTMLGlob("./ui/templates/*")
 
+	// Giới hạn tần suất cho các thao tác tốn tài nguyên (dump, upload, download)
+	actionLimit := ratelimit.Middleware(h.ActionLimiter, ratelimit.ByIP)
+
 	// Thiết lập các route
 	router.GET("/", h.IndexHandler)
-	router.POST("/dump", h.DumpHandler)
-	router.POST("/upload-last", h.UploadLastHandler)
-	router.POST("/upload-all", h.UploadAllHandler)
-	router.POST("/upload/:id", h.UploadSingleHandler)
-	router.GET("/download/:id", h.DownloadHandler)
+	router.POST("/dump", actionLimit, h.DumpHandler)
+	router.POST("/upload-last", actionLimit, h.UploadLastHandler)
+	router.POST("/upload-all", actionLimit, h.UploadAllHandler)
+	router.POST("/upload/:id", actionLimit, h.UploadSingleHandler)
+	router.GET("/download/:id", actionLimit, h.DownloadHandler)
+	router.POST("/verify/:id", actionLimit, h.VerifyHandler)
+	router.GET("/backups/:id", h.BackupPageHandler)
+	// /metrics trên server web công khai luôn cần xác thực: METRICS_TOKEN nếu được đặt,
+	// ngược lại là JWT của người dùng đã đăng nhập
+	if cfg.MetricsToken != "" {
+		router.GET("/metrics", gin.WrapH(metrics.Handler(cfg, cfg.MetricsToken)))
+	} else {
+		router.GET("/metrics", auth.AuthMiddleware(), gin.WrapH(metrics.Handler(cfg, "")))
+	}
+
+	// Kiểm tra sống/sẵn sàng cho Docker/Kubernetes (không cần đăng nhập). /readyz chỉ trả về chi
+	// tiết từng kiểm tra cho người dùng đã đăng nhập.
+	checker := health.New(cfg, h.DatabaseDumper.Docker, h.DriveUploader)
+	router.GET("/healthz", gin.WrapH(health.LivenessHandler()))
+	router.GET("/readyz", func(c *gin.Context) {
+		_, err := auth.ClaimsFromRequest(c)
+		checker.ReadinessHandler(func(*http.Request) bool { return err == nil }).ServeHTTP(c.Writer, c.Request)
+	})
 
-	// Thêm các route xác thực Google
-	router.GET("/auth", h.AuthHandler)
-	router.GET("/callback", h.OAuthCallbackHandler)
+	// Thêm các route xác thực Google (chỉ admin mới được liên kết tài khoản Drive)
+	router.GET("/auth", auth.AdminMiddleware(), h.AuthHandler)
+	router.GET("/callback", auth.AdminMiddleware(), h.OAuthCallbackHandler)
 
 	// Thêm các route xác thực JWT
 	router.GET("/login", h.LoginPageHandler)
-	router.POST("/login", h.LoginHandler)
+	router.POST("/login", ratelimit.Middleware(h.LoginIPLimiter, ratelimit.ByIP), h.LoginHandler)
 	router.POST("/logout", h.LogoutHandler)
 
 	// Nhóm các route yêu cầu xác thực
@@ -153,11 +273,46 @@ func startWebApp(cfg *config.Config, port string) {
 	authorized.Use(auth.AuthMiddleware())
 	{
 		authorized.GET("/me", h.MeHandler)
+		authorized.PUT("/me/preferences", h.PreferencesHandler)
+		authorized.GET("/jobs", h.JobsListHandler)
+		authorized.GET("/backups", h.BackupsListHandler)
+		authorized.GET("/backups/:id", h.BackupDetailHandler)
+		authorized.GET("/backups/:id/diff", h.BackupDiffHandler)
+		authorized.GET("/jobs/:name/wal", h.JobWALHandler)
+		authorized.GET("/runs", h.RunsListHandler)
+		authorized.GET("/runs/:id", h.RunDetailHandler)
+		authorized.GET("/drive/quota", h.DriveQuotaHandler)
 		// Thêm các API route khác cần xác thực ở đây
 	}
 
-	// Khởi động server
-	if err := router.Run(":" + port); err != nil {
-		log.Fatalf("Không thể khởi động server web: %v", err)
+	// Nhóm các route chỉ dành cho admin
+	admin := router.Group("/api/admin")
+	admin.Use(auth.AdminMiddleware())
+	{
+		admin.GET("/audit", h.AuditListHandler)
+		admin.GET("/audit/verify", h.AuditVerifyHandler)
+		admin.POST("/jobs/discover", h.JobsDiscoverHandler)
+		admin.DELETE("/backups/:id/anomaly", h.BackupClearAnomalyHandler)
+		admin.POST("/jobs/:name/pitr", h.JobPITRHandler)
+		admin.GET("/notifications", h.NotificationsListHandler)
+		admin.POST("/notifications/:name/test", h.NotificationTestHandler)
+	}
+
+	// Khởi động server; dừng nhận request mới khi ctx bị hủy rồi chờ các job đang chạy
+	server := &http.Server{Addr: ":" + port, Handler: router, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+
+	if s != nil {
+		slog.Info("Stopping scheduler, waiting for running jobs")
+		s.Wait()
 	}
+	return nil
 }
@@ -8,7 +8,9 @@ require (
 	github.com/joho/godotenv v1.5.1
 	golang.org/x/crypto v0.18.0
 	golang.org/x/oauth2 v0.16.0
+	golang.org/x/term v0.16.0
 	google.golang.org/api v0.159.0
+	gopkg.in/yaml.v3 v3.0.1
 	modernc.org/sqlite v1.28.0
 )
 
@@ -59,7 +61,6 @@ require (
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20240116215550-a9fa1716bcac // indirect
 	google.golang.org/grpc v1.60.1 // indirect
 	google.golang.org/protobuf v1.32.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 	lukechampine.com/uint128 v1.2.0 // indirect
 	modernc.org/cc/v3 v3.40.0 // indirect
 	modernc.org/ccgo/v3 v3.16.13 // indirect
@@ -0,0 +1,76 @@
+package anomaly
+
+import (
+	"sort"
+	"time"
+
+	"github.com/backup-cronjob/internal/config"
+	"github.com/backup-cronjob/internal/i18n"
+	"github.com/backup-cronjob/internal/models"
+)
+
+// minDuration là thời gian dump tối thiểu để xét bất thường về thời gian;
+// các lần dump ngắn hơn dao động nhiều nên không đáng tin cậy
+const minDuration = time.Minute
+
+// Detect so sánh kích thước và thời gian dump của bản backup mới với trung vị của baseline
+// (các bản backup trước đó của cùng job). Trả về danh sách lý do, rỗng nếu bình thường
+// hoặc baseline chưa đủ số bản backup.
+func Detect(cfg config.AnomalyConfig, current *models.BackupRecord, baseline []*models.BackupRecord) []string {
+	if cfg.Disabled || len(baseline) < cfg.MinSamples || len(baseline) == 0 {
+		return nil
+	}
+
+	var (
+		reasons   []string
+		sizes     []int64
+		durations []int64
+	)
+	for _, record := range baseline {
+		sizes = append(sizes, record.Size)
+		if record.DurationMs > 0 {
+			durations = append(durations, record.DurationMs)
+		}
+	}
+
+	if size := median(sizes); size > 0 {
+		change := float64(current.Size-size) * 100 / float64(size)
+		if cfg.SizeDropPercent > 0 && change <= -cfg.SizeDropPercent {
+			reasons = append(reasons, i18n.T("", "anomaly.size_drop",
+				models.FormatBytes(current.Size), -change, models.FormatBytes(size), len(sizes)))
+		}
+		if cfg.SizeGrowthPercent > 0 && change > cfg.SizeGrowthPercent {
+			reasons = append(reasons, i18n.T("", "anomaly.size_growth",
+				models.FormatBytes(current.Size), change, models.FormatBytes(size), len(sizes)))
+		}
+	}
+
+	duration := time.Duration(current.DurationMs) * time.Millisecond
+	if cfg.DurationPercent > 0 && duration >= minDuration && len(durations) >= cfg.MinSamples {
+		if base := median(durations); base > 0 {
+			change := float64(current.DurationMs-base) * 100 / float64(base)
+			if change > cfg.DurationPercent {
+				reasons = append(reasons, i18n.T("", "anomaly.slow_dump",
+					duration.Round(time.Second), change, (time.Duration(base)*time.Millisecond).Round(time.Second)))
+			}
+		}
+	}
+
+	return reasons
+}
+
+// median trả về trung vị của các giá trị
+func median(values []int64) int64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := append([]int64(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
@@ -0,0 +1,81 @@
+package anomaly
+
+import (
+	"testing"
+
+	"github.com/backup-cronjob/internal/config"
+	"github.com/backup-cronjob/internal/models"
+)
+
+func TestMedian(t *testing.T) {
+	tests := []struct {
+		values []int64
+		want   int64
+	}{
+		{nil, 0},
+		{[]int64{5}, 5},
+		{[]int64{3, 1, 2}, 2},
+		{[]int64{10, 20}, 15},
+		{[]int64{4, 1, 3, 2}, 2},
+		{[]int64{100, 1, 100, 100, 1000}, 100},
+	}
+
+	for _, tt := range tests {
+		if got := median(tt.values); got != tt.want {
+			t.Errorf("median(%v) = %d, want %d", tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestMedianDoesNotModifyInput(t *testing.T) {
+	values := []int64{3, 1, 2}
+	median(values)
+	if values[0] != 3 || values[1] != 1 || values[2] != 2 {
+		t.Errorf("median sorted its input: %v", values)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	cfg := config.AnomalyConfig{MinSamples: 3, SizeDropPercent: 50, SizeGrowthPercent: 100, DurationPercent: 100}
+
+	// baseline tạo n bản backup cùng kích thước và thời gian dump
+	baseline := func(n int, size, durationMs int64) []*models.BackupRecord {
+		var records []*models.BackupRecord
+		for i := 0; i < n; i++ {
+			records = append(records, &models.BackupRecord{Size: size, DurationMs: durationMs})
+		}
+		return records
+	}
+	const twoMinutes = 120000
+
+	tests := []struct {
+		name     string
+		cfg      config.AnomalyConfig
+		current  models.BackupRecord
+		baseline []*models.BackupRecord
+		want     int
+	}{
+		{"normal", cfg, models.BackupRecord{Size: 100, DurationMs: twoMinutes}, baseline(3, 100, twoMinutes), 0},
+		{"size drop at threshold", cfg, models.BackupRecord{Size: 50, DurationMs: twoMinutes}, baseline(3, 100, twoMinutes), 1},
+		{"size drop below threshold", cfg, models.BackupRecord{Size: 51, DurationMs: twoMinutes}, baseline(3, 100, twoMinutes), 0},
+		{"size growth above threshold", cfg, models.BackupRecord{Size: 201, DurationMs: twoMinutes}, baseline(3, 100, twoMinutes), 1},
+		{"size growth at threshold", cfg, models.BackupRecord{Size: 200, DurationMs: twoMinutes}, baseline(3, 100, twoMinutes), 0},
+		{"slow dump", cfg, models.BackupRecord{Size: 100, DurationMs: 5 * 60000}, baseline(3, 100, twoMinutes), 1},
+		// Lần dump dưới một phút không được xét về thời gian dù chậm hơn nhiều lần
+		{"short dump ignored", cfg, models.BackupRecord{Size: 100, DurationMs: 50000}, baseline(3, 100, 10000), 0},
+		// Baseline không có thời gian dump thì không xét thời gian
+		{"no durations in baseline", cfg, models.BackupRecord{Size: 100, DurationMs: 5 * 60000}, baseline(3, 100, 0), 0},
+		{"size and duration", cfg, models.BackupRecord{Size: 10, DurationMs: 5 * 60000}, baseline(3, 100, twoMinutes), 2},
+		{"too few samples", cfg, models.BackupRecord{Size: 10}, baseline(2, 100, twoMinutes), 0},
+		{"empty baseline", config.AnomalyConfig{SizeDropPercent: 50}, models.BackupRecord{Size: 10}, nil, 0},
+		{"disabled", config.AnomalyConfig{Disabled: true, SizeDropPercent: 50}, models.BackupRecord{Size: 10}, baseline(3, 100, twoMinutes), 0},
+		{"threshold off", config.AnomalyConfig{MinSamples: 3}, models.BackupRecord{Size: 10, DurationMs: 5 * 60000}, baseline(3, 100, twoMinutes), 0},
+	}
+
+	for _, tt := range tests {
+		current := tt.current
+		if got := Detect(tt.cfg, &current, tt.baseline); len(got) != tt.want {
+			t.Errorf("%s: Detect = %q, want %d reasons", tt.name, got, tt.want)
+		}
+	}
+}
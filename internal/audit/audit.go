@@ -0,0 +1,96 @@
+package audit
+
+import (
+	"log/slog"
+	"os"
+	"os/user"
+
+	"github.com/backup-cronjob/internal/auth"
+	"github.com/backup-cronjob/internal/database"
+	"github.com/backup-cronjob/internal/logging"
+	"github.com/backup-cronjob/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// Record ghi một sự kiện phát sinh từ HTTP request vào audit log.
+// Người thực hiện được xác định từ JWT trong request (nếu có).
+func Record(c *gin.Context, action, target, result, details string) {
+	actor := "anonymous"
+	if username, ok := c.Get("username"); ok {
+		actor, _ = username.(string)
+	} else if claims, err := auth.ClaimsFromRequest(c); err == nil {
+		actor = claims.Username
+	}
+
+	RecordActor(actor, action, target, result, details, c.ClientIP(), c.Request.UserAgent())
+}
+
+// RecordCLI ghi một sự kiện phát sinh từ dòng lệnh, người thực hiện là user hệ điều hành
+func RecordCLI(action, target, result, details string) {
+	actor := "cli"
+	if u, err := user.Current(); err == nil {
+		actor = "cli:" + u.Username
+	}
+
+	hostname, _ := os.Hostname()
+	RecordActor(actor, action, target, result, details, hostname, "cli")
+}
+
+// RecordActor ghi một sự kiện với đầy đủ thông tin. Lỗi khi ghi chỉ được log lại,
+// không làm gián đoạn thao tác chính.
+func RecordActor(actor, action, target, result, details, sourceIP, userAgent string) {
+	if database.DB == nil {
+		slog.Error("Audit log unavailable, dropping event", "action", action, "actor", actor)
+		return
+	}
+
+	event := &models.AuditEvent{
+		Actor:     actor,
+		Action:    action,
+		Target:    target,
+		SourceIP:  sourceIP,
+		UserAgent: userAgent,
+		Result:    result,
+		Details:   details,
+	}
+
+	if err := database.InsertAuditEvent(event); err != nil {
+		slog.Error("Failed to write audit event", "action", action, "actor", actor, logging.Err(err))
+	}
+}
+
+// ResultOf trả về kết quả audit tương ứng với lỗi của thao tác
+func ResultOf(err error) string {
+	if err != nil {
+		return models.AuditResultFailure
+	}
+	return models.AuditResultSuccess
+}
+
+// ErrorDetails trả về chuỗi mô tả lỗi để lưu vào audit log
+func ErrorDetails(err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// ForRequest trả về hàm ghi audit gắn với request hiện tại (dùng cho backup.RunOptions)
+func ForRequest(c *gin.Context) func(action, target, result, details string) {
+	return func(action, target, result, details string) {
+		Record(c, action, target, result, details)
+	}
+}
+
+// ForCLI trả về hàm ghi audit cho các thao tác từ dòng lệnh
+func ForCLI() func(action, target, result, details string) {
+	return RecordCLI
+}
+
+// ForActor trả về hàm ghi audit với người thực hiện cố định (vd: "scheduler")
+func ForActor(actor string) func(action, target, result, details string) {
+	hostname, _ := os.Hostname()
+	return func(action, target, result, details string) {
+		RecordActor(actor, action, target, result, details, hostname, actor)
+	}
+}
@@ -3,12 +3,15 @@ package auth
 import (
 	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/backup-cronjob/internal/config"
 	"github.com/backup-cronjob/internal/database"
+	"github.com/backup-cronjob/internal/i18n"
+	"github.com/backup-cronjob/internal/logging"
 	"github.com/backup-cronjob/internal/models"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
@@ -30,6 +33,8 @@ func GenerateJWT(user *models.User) (string, error) {
 	claims := jwt.MapClaims{
 		"username": user.Username,
 		"user_id":  user.ID,
+		"role":     user.Role,
+		"locale":   user.Locale,
 		"exp":      expirationTime.Unix(),
 	}
 
@@ -56,8 +61,11 @@ func ValidateJWT(tokenString string) (*models.JWTClaims, error) {
 		return []byte(cfg.JWTSecret), nil
 	})
 
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return nil, i18n.Errorf(i18n.ErrTokenExpired)
+	}
 	if err != nil {
-		return nil, err
+		return nil, i18n.Errorf(i18n.ErrInvalidToken)
 	}
 
 	// Xác thực token và lấy claims
@@ -65,21 +73,25 @@ func ValidateJWT(tokenString string) (*models.JWTClaims, error) {
 		// Kiểm tra thời gian hết hạn
 		if exp, ok := claims["exp"].(float64); ok {
 			if time.Now().Unix() > int64(exp) {
-				return nil, errors.New("token has expired")
+				return nil, i18n.Errorf(i18n.ErrTokenExpired)
 			}
 		}
 
 		// Lấy thông tin từ claims
 		userID, _ := claims["user_id"].(float64)
 		username, _ := claims["username"].(string)
+		role, _ := claims["role"].(string)
+		locale, _ := claims["locale"].(string)
 
 		return &models.JWTClaims{
 			Username: username,
 			UserID:   int64(userID),
+			Role:     role,
+			Locale:   locale,
 		}, nil
 	}
 
-	return nil, errors.New("invalid token")
+	return nil, i18n.Errorf(i18n.ErrInvalidToken)
 }
 
 // Middleware xác thực JWT
@@ -88,44 +100,170 @@ func AuthMiddleware() gin.HandlerFunc {
 		// Kiểm tra header có Authorization
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authorization header is required"})
+			c.AbortWithStatusJSON(http.StatusUnauthorized, i18n.ErrorBody(c.Request.Context(), i18n.ErrAuthHeaderMissing))
 			return
 		}
 
 		// Kiểm tra định dạng Bearer token
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authorization header format must be Bearer {token}"})
+			c.AbortWithStatusJSON(http.StatusUnauthorized, i18n.ErrorBody(c.Request.Context(), i18n.ErrAuthHeaderFormat))
 			return
 		}
 
 		// Xác thực token
 		claims, err := ValidateJWT(parts[1])
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			logging.From(c.Request.Context()).Warn("Rejected invalid token", logging.Err(err))
+			c.AbortWithStatusJSON(http.StatusUnauthorized, i18n.ErrorBodyFor(c.Request.Context(), err, i18n.ErrInvalidToken))
 			return
 		}
 
-		// Lưu thông tin người dùng vào context
-		c.Set("user_id", claims.UserID)
-		c.Set("username", claims.Username)
+		setUser(c, claims)
+		c.Next()
+	}
+}
 
+// AdminMiddleware yêu cầu người dùng đã đăng nhập với quyền admin.
+// Token được lấy từ header, form hoặc cookie (xem ClaimsFromRequest)
+// để dùng được cả với API lẫn các trang mở trực tiếp trên trình duyệt.
+func AdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, err := ClaimsFromRequest(c)
+		if err != nil {
+			logging.From(c.Request.Context()).Warn("Rejected unauthenticated admin request", logging.Err(err))
+			c.AbortWithStatusJSON(http.StatusUnauthorized, i18n.ErrorBodyFor(c.Request.Context(), err, i18n.ErrAuthRequired))
+			return
+		}
+
+		if !claims.IsAdmin() {
+			logging.From(c.Request.Context()).Warn("Rejected admin request from non-admin user", "user", claims.Username)
+			c.AbortWithStatusJSON(http.StatusForbidden, i18n.ErrorBody(c.Request.Context(), i18n.ErrAdminRequired))
+			return
+		}
+
+		setUser(c, claims)
 		c.Next()
 	}
 }
 
+// setUser lưu thông tin người dùng vào context của gin và thêm trường user vào logger của request
+func setUser(c *gin.Context, claims *models.JWTClaims) {
+	c.Set("user_id", claims.UserID)
+	c.Set("username", claims.Username)
+	c.Set("role", claims.Role)
+	c.Request = c.Request.WithContext(logging.With(c.Request.Context(), "user", claims.Username))
+}
+
+// ClaimsFromRequest lấy và xác thực JWT từ request, lần lượt thử header Authorization,
+// field Authorization trong form và cookie auth_token
+func ClaimsFromRequest(c *gin.Context) (*models.JWTClaims, error) {
+	return claimsFrom(requestTokens(c))
+}
+
+// ClaimsFromRequestOrQuery giống ClaimsFromRequest nhưng chấp nhận thêm query parameter token.
+// Token trong URL bị ghi vào access log, lịch sử trình duyệt và header Referer, nên chỉ dùng cho
+// endpoint tải file, nơi client như curl/wget không tiện gửi header
+func ClaimsFromRequestOrQuery(c *gin.Context) (*models.JWTClaims, error) {
+	return claimsFrom(append(requestTokens(c), c.Query("token")))
+}
+
+// requestTokens trả về các token ứng viên từ header, form và cookie
+func requestTokens(c *gin.Context) []string {
+	candidates := []string{c.GetHeader("Authorization"), c.PostForm("Authorization")}
+	if cookie, err := c.Cookie("auth_token"); err == nil {
+		candidates = append(candidates, cookie)
+	}
+	return candidates
+}
+
+// claimsFrom trả về claims của token hợp lệ đầu tiên trong danh sách
+func claimsFrom(candidates []string) (*models.JWTClaims, error) {
+	for _, candidate := range candidates {
+		token := strings.TrimPrefix(candidate, "Bearer ")
+		if token == "" {
+			continue
+		}
+
+		if claims, err := ValidateJWT(token); err == nil {
+			return claims, nil
+		}
+	}
+
+	return nil, i18n.Errorf(i18n.ErrAuthRequired)
+}
+
 // AuthenticateUser xác thực người dùng với username và password
 func AuthenticateUser(auth *models.Auth) (*models.User, error) {
 	// Tìm người dùng theo username
 	user, err := database.GetUserByUsername(auth.Username)
 	if err != nil {
-		return nil, errors.New("invalid username or password")
+		return nil, i18n.Errorf(i18n.ErrInvalidCredentials)
 	}
 
 	// Kiểm tra mật khẩu
 	if !models.CheckPasswordHash(auth.Password, user.Password) {
-		return nil, errors.New("invalid username or password")
+		return nil, i18n.Errorf(i18n.ErrInvalidCredentials)
 	}
 
 	return user, nil
 }
+
+// CheckLoginLockout kiểm tra username hoặc IP có đang bị khóa đăng nhập không.
+// Sau LoginMaxFailures lần thất bại liên tiếp, thời gian khóa tăng gấp đôi sau mỗi lần
+// thất bại tiếp theo, bắt đầu từ LoginLockoutBase và tối đa LoginLockoutMax.
+// Trả về thời gian còn lại phải chờ (0 nếu không bị khóa).
+func CheckLoginLockout(username, ip string) (time.Duration, error) {
+	if cfg.LoginMaxFailures <= 0 {
+		return 0, nil
+	}
+
+	// Chỉ xét các lần thất bại trong khoảng thời gian khóa tối đa gần nhất
+	since := time.Now().Add(-cfg.LoginLockoutMax)
+
+	var retryAfter time.Duration
+	for field, value := range map[string]string{"username": username, "ip": ip} {
+		if value == "" {
+			continue
+		}
+
+		failures, lastFailure, err := database.CountRecentFailures(field, value, since)
+		if err != nil {
+			return 0, err
+		}
+
+		if wait := lockoutRemaining(failures, lastFailure); wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+
+	return retryAfter, nil
+}
+
+// lockoutRemaining tính thời gian khóa còn lại theo số lần thất bại liên tiếp
+func lockoutRemaining(failures int, lastFailure time.Time) time.Duration {
+	if failures < cfg.LoginMaxFailures {
+		return 0
+	}
+
+	lockout := cfg.LoginLockoutBase
+	for i := cfg.LoginMaxFailures; i < failures && lockout < cfg.LoginLockoutMax; i++ {
+		lockout *= 2
+	}
+	if lockout > cfg.LoginLockoutMax {
+		lockout = cfg.LoginLockoutMax
+	}
+
+	remaining := time.Until(lastFailure.Add(lockout))
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// RecordLoginAttempt ghi nhận kết quả một lần đăng nhập
+func RecordLoginAttempt(username, ip string, success bool) {
+	if err := database.RecordLoginAttempt(username, ip, success); err != nil {
+		slog.Error("Failed to record login attempt", "user", username, "ip", ip, logging.Err(err))
+	}
+}
@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/backup-cronjob/internal/config"
+	"github.com/backup-cronjob/internal/database"
+)
+
+// setupLockout cấu hình khóa đăng nhập sau 3 lần thất bại, bắt đầu 1 phút, tối đa 10 phút
+func setupLockout(t *testing.T) {
+	t.Helper()
+
+	prev := cfg
+	Init(&config.Config{LoginMaxFailures: 3, LoginLockoutBase: time.Minute, LoginLockoutMax: 10 * time.Minute})
+	t.Cleanup(func() { cfg = prev })
+}
+
+// openTestDB mở database SQLite tạm cho bảng login_attempts
+func openTestDB(t *testing.T) {
+	t.Helper()
+
+	prev := database.DB
+	database.DB = nil
+	if err := database.InitDB(&config.Config{SQLiteDBPath: filepath.Join(t.TempDir(), "test.db")}); err != nil {
+		t.Fatalf("InitDB: %v", err)
+	}
+	t.Cleanup(func() {
+		database.DB.Close()
+		database.DB = prev
+	})
+}
+
+func TestLockoutRemainingGrowth(t *testing.T) {
+	setupLockout(t)
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{2, 0},
+		{3, time.Minute},
+		{4, 2 * time.Minute},
+		{5, 4 * time.Minute},
+		{6, 8 * time.Minute},
+		// Không vượt quá LoginLockoutMax
+		{7, 10 * time.Minute},
+		{50, 10 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		got := lockoutRemaining(tt.failures, time.Now())
+		if got > tt.want || got < tt.want-time.Second {
+			t.Errorf("lockoutRemaining(%d, now) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestLockoutRemainingElapsed(t *testing.T) {
+	setupLockout(t)
+
+	if got := lockoutRemaining(3, time.Now().Add(-2*time.Minute)); got != 0 {
+		t.Errorf("expired lockout: got %v, want 0", got)
+	}
+	got := lockoutRemaining(4, time.Now().Add(-90*time.Second))
+	if want := 30 * time.Second; got > want || got < want-time.Second {
+		t.Errorf("partially elapsed lockout: got %v, want %v", got, want)
+	}
+}
+
+func TestCheckLoginLockout(t *testing.T) {
+	setupLockout(t)
+	openTestDB(t)
+
+	check := func(username, ip string) time.Duration {
+		t.Helper()
+		wait, err := CheckLoginLockout(username, ip)
+		if err != nil {
+			t.Fatalf("CheckLoginLockout: %v", err)
+		}
+		return wait
+	}
+
+	for i := 0; i < 2; i++ {
+		RecordLoginAttempt("bob", "192.0.2.1", false)
+	}
+	if wait := check("bob", "192.0.2.1"); wait != 0 {
+		t.Errorf("locked out before LoginMaxFailures: %v", wait)
+	}
+
+	RecordLoginAttempt("bob", "192.0.2.1", false)
+	if wait := check("bob", "192.0.2.9"); wait <= 0 || wait > time.Minute {
+		t.Errorf("username lockout = %v, want (0, 1m]", wait)
+	}
+	// Cùng IP với tên người dùng khác vẫn bị khóa theo IP
+	if wait := check("alice", "192.0.2.1"); wait <= 0 || wait > time.Minute {
+		t.Errorf("IP lockout = %v, want (0, 1m]", wait)
+	}
+	if wait := check("alice", "192.0.2.9"); wait != 0 {
+		t.Errorf("unrelated user and IP locked out: %v", wait)
+	}
+
+	// Thêm một lần thất bại: thời gian khóa tăng gấp đôi
+	RecordLoginAttempt("bob", "192.0.2.1", false)
+	if wait := check("bob", ""); wait <= time.Minute || wait > 2*time.Minute {
+		t.Errorf("lockout after 4 failures = %v, want (1m, 2m]", wait)
+	}
+
+	// Đăng nhập thành công xóa chuỗi thất bại
+	RecordLoginAttempt("bob", "192.0.2.1", true)
+	if wait := check("bob", "192.0.2.1"); wait != 0 {
+		t.Errorf("lockout after successful login = %v, want 0", wait)
+	}
+}
+
+func TestCheckLoginLockoutDisabled(t *testing.T) {
+	prev := cfg
+	Init(&config.Config{})
+	t.Cleanup(func() { cfg = prev })
+
+	// Không chạm tới database khi tắt khóa đăng nhập
+	if wait, err := CheckLoginLockout("bob", "192.0.2.1"); err != nil || wait != 0 {
+		t.Errorf("CheckLoginLockout = %v, %v; want 0, nil", wait, err)
+	}
+}
@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// oauthStateTTL là thời gian sống của một state OAuth chưa được sử dụng
+const oauthStateTTL = 10 * time.Minute
+
+// oauthState lưu thông tin của một lần bắt đầu liên kết Google
+type oauthState struct {
+	userID    int64
+	verifier  string
+	expiresAt time.Time
+}
+
+var (
+	oauthStatesMu sync.Mutex
+	oauthStates   = make(map[string]oauthState)
+)
+
+// NewOAuthState tạo state ngẫu nhiên gắn với người dùng đang đăng nhập cùng PKCE verifier.
+// State chỉ dùng được một lần và hết hạn sau oauthStateTTL.
+func NewOAuthState(userID int64) (state string, verifier string, err error) {
+	state, err = randomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	verifier = oauth2.GenerateVerifier()
+
+	oauthStatesMu.Lock()
+	defer oauthStatesMu.Unlock()
+
+	// Dọn các state đã hết hạn
+	now := time.Now()
+	for key, s := range oauthStates {
+		if now.After(s.expiresAt) {
+			delete(oauthStates, key)
+		}
+	}
+
+	oauthStates[state] = oauthState{
+		userID:    userID,
+		verifier:  verifier,
+		expiresAt: now.Add(oauthStateTTL),
+	}
+
+	return state, verifier, nil
+}
+
+// ConsumeOAuthState kiểm tra state nhận được từ callback thuộc về đúng người dùng
+// và trả về PKCE verifier tương ứng. State bị xóa ngay sau khi kiểm tra.
+func ConsumeOAuthState(state string, userID int64) (string, error) {
+	oauthStatesMu.Lock()
+	defer oauthStatesMu.Unlock()
+
+	s, ok := oauthStates[state]
+	if !ok || state == "" {
+		return "", errors.New("invalid or unknown OAuth state")
+	}
+	delete(oauthStates, state)
+
+	if time.Now().After(s.expiresAt) {
+		return "", errors.New("OAuth state has expired")
+	}
+
+	if s.userID != userID {
+		return "", errors.New("OAuth state does not belong to the current session")
+	}
+
+	return s.verifier, nil
+}
+
+// randomToken tạo chuỗi ngẫu nhiên an toàn dạng base64 URL
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,467 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/backup-cronjob/internal/anomaly"
+	"github.com/backup-cronjob/internal/audit"
+	"github.com/backup-cronjob/internal/config"
+	"github.com/backup-cronjob/internal/database"
+	"github.com/backup-cronjob/internal/dbdump"
+	"github.com/backup-cronjob/internal/drive"
+	"github.com/backup-cronjob/internal/health"
+	"github.com/backup-cronjob/internal/heartbeat"
+	"github.com/backup-cronjob/internal/hooks"
+	"github.com/backup-cronjob/internal/i18n"
+	"github.com/backup-cronjob/internal/logging"
+	"github.com/backup-cronjob/internal/metrics"
+	"github.com/backup-cronjob/internal/models"
+	"github.com/backup-cronjob/internal/notify"
+	"github.com/backup-cronjob/internal/retention"
+	"github.com/backup-cronjob/internal/verify"
+)
+
+// Ước lượng dung lượng của bản backup sắp dump: bản lớn nhất trong diskEstimateSamples bản gần
+// nhất, nhân thêm diskEstimateHeadroom vì database thường lớn dần
+const (
+	diskEstimateSamples  = 5
+	diskEstimateHeadroom = 1.2
+)
+
+// AuditFunc ghi một sự kiện audit (action, target, result, details) với người thực hiện do caller xác định
+type AuditFunc func(action, target, result, details string)
+
+// RunOptions điều khiển các bước của một lần chạy job
+type RunOptions struct {
+	// Upload lên các đích từ xa của job (Drive) sau khi dump thành công
+	Upload bool
+	// Prune xóa các bản backup local hết hạn theo chính sách lưu giữ của job
+	Prune bool
+	// Audit ghi audit log cho từng bước; nil nghĩa là không ghi
+	Audit AuditFunc
+}
+
+// RunResult chứa kết quả một lần chạy job
+type RunResult struct {
+	Job string
+	// RunID định danh lần chạy (gửi kèm ping heartbeat, ghi vào log và bản ghi lần chạy)
+	RunID    string
+	Dump     *dbdump.DumpResult
+	Uploaded bool
+	Verify   *verify.Result
+	Pruned   []*models.BackupFile
+	// Anomaly là lý do bản backup bị đánh dấu bất thường, rỗng nếu bình thường
+	Anomaly string
+	// HookErrors chứa lỗi của các hook không làm job thất bại
+	HookErrors []string
+}
+
+// Runner điều phối các bước dump, upload và dọn dẹp của một job backup
+type Runner struct {
+	Config         *config.Config
+	DatabaseDumper *dbdump.DatabaseDumper
+	DriveUploader  *drive.DriveUploader
+	Verifier       *verify.Verifier
+	Notifier       *notify.Dispatcher
+
+	// running chứa tên các job đang chạy; hai lần chạy cùng job trong cùng một giây sẽ ghi
+	// đè file dump của nhau nên lần chạy thứ hai bị từ chối
+	mu      sync.Mutex
+	running map[string]bool
+}
+
+// NewRunner tạo instance mới của Runner
+func NewRunner(cfg *config.Config, dumper *dbdump.DatabaseDumper, uploader *drive.DriveUploader) *Runner {
+	return &Runner{
+		Config:         cfg,
+		DatabaseDumper: dumper,
+		DriveUploader:  uploader,
+		Verifier:       verify.New(cfg, dumper),
+		Notifier:       notify.New(cfg.Notifications),
+		running:        make(map[string]bool),
+	}
+}
+
+// RunJob chạy một job: hook pre_dump, dump database, upload (nếu được yêu cầu và job có đích Drive)
+// rồi dọn dẹp backup hết hạn. Hook post_dump/post_upload chạy sau mỗi bước thành công và
+// on_failure khi job thất bại. Lỗi khi dọn dẹp hoặc của hook sau không làm job thất bại.
+// Thông báo failure/anomaly/success được gửi theo quy tắc trong cấu hình thông báo và
+// heartbeat của job được ping khi bắt đầu, khi thành công và khi thất bại.
+// Mọi dòng log của lần chạy (kể cả stderr của pg_dump) được lưu cùng bản ghi lần chạy.
+// Nếu job đang có một lần chạy khác (từ scheduler, web hay CLI cùng process), RunJob trả về
+// lỗi ErrJobRunning và kết quả nil mà không ghi bản ghi lần chạy.
+func (r *Runner) RunJob(ctx context.Context, job *config.Job, opts RunOptions) (*RunResult, error) {
+	if !r.acquire(job.Name) {
+		return nil, i18n.Errorf(i18n.ErrJobRunning, job.Name)
+	}
+	defer r.release(job.Name)
+
+	result := &RunResult{Job: job.Name, RunID: logging.NewID()}
+	ctx, capture := logging.StartCapture(logging.With(ctx, logging.KeyJob, job.Name, logging.KeyRunID, result.RunID))
+	logger := logging.From(ctx)
+
+	done := metrics.JobQueued()
+	defer done()
+
+	started := time.Now()
+	logger.Info("Job run started")
+	err := r.runJob(ctx, job, opts, result)
+	if err != nil {
+		logger.Error("Job run failed", logging.Err(err), "duration", time.Since(started))
+	} else {
+		logger.Info("Job run finished", "duration", time.Since(started), "uploaded", result.Uploaded, "pruned", len(result.Pruned))
+	}
+
+	r.saveRun(ctx, result, started, err, capture)
+	return result, err
+}
+
+// acquire đánh dấu job đang chạy, trả về false nếu job đã có lần chạy khác
+func (r *Runner) acquire(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.running[name] {
+		return false
+	}
+	r.running[name] = true
+	return true
+}
+
+// release bỏ đánh dấu job đang chạy
+func (r *Runner) release(name string) {
+	r.mu.Lock()
+	delete(r.running, name)
+	r.mu.Unlock()
+}
+
+// saveRun lưu bản ghi lần chạy kèm log đã thu; lỗi chỉ được ghi log
+func (r *Runner) saveRun(ctx context.Context, result *RunResult, started time.Time, cause error, capture *logging.Capture) {
+	run := &models.RunRecord{
+		ID:         result.RunID,
+		Job:        result.Job,
+		Status:     models.RunStatusSuccess,
+		StartedAt:  started,
+		FinishedAt: time.Now(),
+		Log:        capture.String(),
+	}
+	if result.Dump != nil {
+		run.BackupPath = result.Dump.FilePath
+	}
+	if cause != nil {
+		run.Status = models.RunStatusFailure
+		run.Error = cause.Error()
+	}
+
+	if err := database.SaveRun(run, r.Config.RunHistory); err != nil {
+		logging.From(ctx).Error("Failed to save run log", logging.Err(err))
+	}
+}
+
+// runJob thực hiện các bước của một lần chạy job, ghi kết quả vào result
+func (r *Runner) runJob(ctx context.Context, job *config.Job, opts RunOptions, result *RunResult) error {
+	record := opts.Audit
+	if record == nil {
+		record = func(action, target, result, details string) {}
+	}
+	logger := logging.From(ctx)
+
+	heartbeat.Ping(ctx, job, heartbeat.SignalStart, result.RunID, "")
+
+	// Hook trước khi dump, có thể dừng backup
+	if err := hooks.Run(ctx, job, hooks.Event{Stage: config.HookPreDump, Job: job.Name, Status: hooks.StatusRunning}); err != nil {
+		if errors.Is(err, hooks.ErrAborted) {
+			record(models.AuditActionDump, job.Name, models.AuditResultFailure, err.Error())
+			r.handleFailure(ctx, job, result, err)
+			return i18n.Errorf(i18n.ErrJobDumpFailed, job.Name, err)
+		}
+		result.HookErrors = append(result.HookErrors, err.Error())
+	}
+
+	// Kiểm tra dung lượng trống trước khi dump bắt đầu ghi file
+	if err := r.checkDiskSpace(ctx, job); err != nil {
+		metrics.DumpFailed(job.Name)
+		metrics.PreflightFailed("disk", job.Name)
+		record(models.AuditActionDump, job.Name, models.AuditResultFailure, audit.ErrorDetails(err))
+		r.handleFailure(ctx, job, result, err)
+		return i18n.Errorf(i18n.ErrJobDumpFailed, job.Name, err)
+	}
+
+	// Dump database
+	started := time.Now()
+	dumpResult, err := r.DatabaseDumper.DumpDatabase(ctx, job)
+	duration := time.Since(started)
+	result.Dump = dumpResult
+	target := job.Name
+	if dumpResult != nil && dumpResult.FilePath != "" {
+		target = job.Name + "/" + filepath.Base(dumpResult.FilePath)
+	}
+	if err != nil {
+		metrics.DumpFailed(job.Name)
+		record(models.AuditActionDump, target, models.AuditResultFailure, audit.ErrorDetails(err))
+		r.handleFailure(ctx, job, result, err)
+		return i18n.Errorf(i18n.ErrJobDumpFailed, job.Name, err)
+	}
+
+	metrics.ObserveDump(job.Name, duration, dumpResult.FileSize)
+
+	// Ghi bản backup vào catalog cùng thông tin thu thập lúc dump
+	catalogRecord := &models.BackupRecord{
+		Job:           job.Name,
+		Path:          dumpResult.FilePath,
+		Size:          dumpResult.FileSize,
+		ServerVersion: dumpResult.ServerVersion,
+		Kind:          models.BackupKindLogical,
+		WALStart:      dumpResult.WALStart,
+		DurationMs:    duration.Milliseconds(),
+	}
+	if job.Mode == config.ModePhysical {
+		catalogRecord.Kind = models.BackupKindBase
+	}
+	if err := database.SaveBackupRecord(catalogRecord, dumpResult.Databases); err != nil {
+		logger.Error("Failed to save backup to catalog", logging.KeyFile, dumpResult.FilePath, logging.Err(err))
+	} else {
+		result.Anomaly = r.detectAnomaly(ctx, job, catalogRecord)
+	}
+
+	var details []string
+	if len(dumpResult.Warnings) > 0 {
+		details = append(details, "partial: "+strings.Join(dumpResult.Warnings, "; "))
+	}
+	dumpAuditResult := models.AuditResultSuccess
+	if result.Anomaly != "" {
+		dumpAuditResult = models.AuditResultWarning
+		details = append(details, "anomaly: "+result.Anomaly)
+	}
+	record(models.AuditActionDump, target, dumpAuditResult, strings.Join(details, "; "))
+
+	postDump := newHookEvent(config.HookPostDump, job, dumpResult, nil)
+	postDump.Anomaly = result.Anomaly
+	r.runHooks(ctx, job, result, postDump)
+	if result.Anomaly != "" {
+		r.Notifier.Notify(ctx, notify.Anomaly(job.Name, dumpResult.FilePath, dumpResult.FileSize, result.Anomaly))
+	}
+
+	// Upload lên Google Drive
+	if opts.Upload && job.HasDestination(config.DestinationDrive) {
+		err := r.DriveUploader.UploadFile(ctx, dumpResult.FilePath)
+		record(models.AuditActionUpload, target, audit.ResultOf(err), audit.ErrorDetails(err))
+		if err != nil {
+			r.handleFailure(ctx, job, result, err)
+			return i18n.Errorf(i18n.ErrJobUploadFailed, job.Name, err)
+		}
+		result.Uploaded = true
+
+		r.runHooks(ctx, job, result, newHookEvent(config.HookPostUpload, job, dumpResult, nil))
+	}
+
+	// Kiểm tra khả năng khôi phục; bản backup không đạt được đánh dấu trong catalog
+	// nhưng không làm job thất bại
+	if job.Verify.AfterDump {
+		backup := &models.BackupFile{Job: job.Name, Name: filepath.Base(dumpResult.FilePath), Path: dumpResult.FilePath, Size: dumpResult.FileSize}
+		verifyResult, err := r.Verifier.Verify(ctx, job, backup)
+		record(models.AuditActionVerify, target, audit.ResultOf(err), audit.ErrorDetails(err))
+		if err != nil {
+			logger.Warn("Verification failed", logging.KeyFile, dumpResult.FilePath, logging.Err(err))
+		}
+		result.Verify = verifyResult
+	}
+
+	// Dọn dẹp các bản backup hết hạn
+	if opts.Prune {
+		removed, err := retention.PruneLocal(r.Config.BackupDir, job)
+		for _, backup := range removed {
+			record(models.AuditActionDelete, job.Name+"/"+backup.Name, models.AuditResultSuccess, "retention")
+		}
+		if err != nil {
+			record(models.AuditActionDelete, job.Name, models.AuditResultFailure, err.Error())
+		}
+		result.Pruned = removed
+	}
+
+	summary := successDetails(result)
+	r.Notifier.Notify(ctx, notify.Success(job.Name, dumpResult.FilePath, dumpResult.FileSize, summary...))
+	heartbeat.Ping(ctx, job, heartbeat.SignalSuccess, result.RunID, logExcerpt(result, nil, summary))
+
+	return nil
+}
+
+// successDetails mô tả các bước đã thực hiện của lần chạy thành công cho thông báo
+func successDetails(result *RunResult) []string {
+	var details []string
+	if result.Uploaded {
+		details = append(details, i18n.T("", "run.uploaded"))
+	}
+	if result.Verify != nil {
+		details = append(details, i18n.T("", "run.verify", result.Verify.Message))
+	}
+	if len(result.Pruned) > 0 {
+		details = append(details, i18n.T("", "run.pruned", len(result.Pruned)))
+	}
+	if result.Anomaly != "" {
+		details = append(details, i18n.T("", "run.anomaly", result.Anomaly))
+	}
+	return details
+}
+
+// logExcerpt tạo đoạn log tóm tắt lần chạy gửi kèm ping heartbeat
+func logExcerpt(result *RunResult, cause error, details []string) string {
+	lines := []string{fmt.Sprintf("job=%s run=%s", result.Job, result.RunID)}
+	if result.Dump != nil && result.Dump.FilePath != "" {
+		lines = append(lines, fmt.Sprintf("file=%s size=%s", result.Dump.FilePath, models.FormatBytes(result.Dump.FileSize)))
+		for _, warning := range result.Dump.Warnings {
+			lines = append(lines, "warning: "+warning)
+		}
+	}
+	lines = append(lines, details...)
+	for _, hookErr := range result.HookErrors {
+		lines = append(lines, "hook: "+hookErr)
+	}
+	if cause != nil {
+		lines = append(lines, "error: "+cause.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// detectAnomaly so sánh bản backup vừa tạo với baseline trong catalog và đánh dấu nếu bất thường.
+// Trả về lý do bất thường, rỗng nếu bình thường; lỗi chỉ được ghi log.
+func (r *Runner) detectAnomaly(ctx context.Context, job *config.Job, record *models.BackupRecord) string {
+	if job.Anomaly.Disabled {
+		return ""
+	}
+
+	baseline, err := database.ListBaselineRecords(job.Name, record.Path, job.Anomaly.Window)
+	if err != nil {
+		logging.From(ctx).Error("Failed to load anomaly baseline", logging.Err(err))
+		return ""
+	}
+
+	reasons := anomaly.Detect(job.Anomaly, record, baseline)
+	if len(reasons) == 0 {
+		return ""
+	}
+
+	message := strings.Join(reasons, "; ")
+	logging.From(ctx).Warn("Backup flagged as anomalous", logging.KeyFile, record.Path, "reason", message)
+	if err := database.SetBackupAnomaly(record.Path, message); err != nil {
+		logging.From(ctx).Error("Failed to flag backup as anomalous", logging.KeyFile, record.Path, logging.Err(err))
+	}
+	return message
+}
+
+// checkDiskSpace kiểm tra thư mục backup còn đủ chỗ cho bản backup sắp dump của job, ước lượng từ
+// các bản backup trước trong catalog. Job chưa có bản backup nào thì không kiểm tra; không đọc được
+// catalog hoặc dung lượng trống thì chỉ ghi log.
+func (r *Runner) checkDiskSpace(ctx context.Context, job *config.Job) error {
+	if !r.Config.PreflightDiskCheck {
+		return nil
+	}
+	logger := logging.From(ctx)
+
+	records, err := database.ListBaselineRecords(job.Name, "", diskEstimateSamples)
+	if err != nil {
+		logger.Warn("Failed to load previous backups for disk space estimate", logging.Err(err))
+		return nil
+	}
+	var largest int64
+	for _, record := range records {
+		if record.Size > largest {
+			largest = record.Size
+		}
+	}
+	if largest == 0 {
+		return nil
+	}
+
+	free, err := health.FreeSpace(r.Config.BackupDir)
+	if err != nil {
+		logger.Warn("Failed to check free disk space before dump", logging.Err(err))
+		return nil
+	}
+
+	need := int64(float64(largest) * diskEstimateHeadroom)
+	if free < need {
+		return i18n.Errorf(i18n.ErrDumpDiskSpace, job.Name, models.FormatBytes(need), models.FormatBytes(free))
+	}
+	logger.Debug("Disk space pre-flight passed", "needed", need, "free", free)
+	return nil
+}
+
+// VerifyBackup kiểm tra khả năng khôi phục của một bản backup có sẵn theo cấu hình verify của job
+func (r *Runner) VerifyBackup(ctx context.Context, backup *models.BackupFile, record AuditFunc) (*verify.Result, error) {
+	if record == nil {
+		record = func(action, target, result, details string) {}
+	}
+
+	target := backup.Name
+	if backup.Job != "" {
+		target = backup.Job + "/" + backup.Name
+	}
+
+	job, err := r.JobForBackup(backup)
+	if err != nil {
+		record(models.AuditActionVerify, target, models.AuditResultFailure, err.Error())
+		return nil, err
+	}
+
+	result, err := r.Verifier.Verify(ctx, job, backup)
+	record(models.AuditActionVerify, target, audit.ResultOf(err), audit.ErrorDetails(err))
+	return result, err
+}
+
+// JobForBackup tìm job đã tạo ra bản backup. Bản backup theo cấu trúc cũ (không có job)
+// chỉ xác định được khi chỉ có một job.
+func (r *Runner) JobForBackup(backup *models.BackupFile) (*config.Job, error) {
+	if backup.Job != "" {
+		return r.Config.FindJob(backup.Job)
+	}
+
+	jobs := r.Config.Jobs()
+	if len(jobs) != 1 {
+		return nil, i18n.Errorf(i18n.ErrBackupJobUnknown, backup.Name)
+	}
+	return jobs[0], nil
+}
+
+// runHooks chạy các hook sau dump/upload; lỗi được lưu vào kết quả, không làm job thất bại
+func (r *Runner) runHooks(ctx context.Context, job *config.Job, result *RunResult, event hooks.Event) {
+	if err := hooks.Run(ctx, job, event); err != nil {
+		result.HookErrors = append(result.HookErrors, err.Error())
+	}
+}
+
+// handleFailure chạy các hook on_failure của job, gửi thông báo thất bại và ping heartbeat fail
+func (r *Runner) handleFailure(ctx context.Context, job *config.Job, result *RunResult, cause error) {
+	hooks.Run(ctx, job, newHookEvent(config.HookOnFailure, job, result.Dump, cause))
+
+	filePath := ""
+	if result.Dump != nil {
+		filePath = result.Dump.FilePath
+	}
+	r.Notifier.Notify(ctx, notify.Failure(job.Name, filePath, cause))
+	heartbeat.Ping(ctx, job, heartbeat.SignalFail, result.RunID, logExcerpt(result, cause, nil))
+}
+
+// newHookEvent tạo event cho hook từ kết quả dump
+func newHookEvent(stage string, job *config.Job, dumpResult *dbdump.DumpResult, cause error) hooks.Event {
+	event := hooks.Event{
+		Stage:  stage,
+		Job:    job.Name,
+		Status: hooks.StatusSuccess,
+	}
+	if dumpResult != nil {
+		event.FilePath = dumpResult.FilePath
+		event.FileSize = dumpResult.FileSize
+	}
+	if cause != nil {
+		event.Status = hooks.StatusFailure
+		event.Error = cause.Error()
+	}
+	return event
+}
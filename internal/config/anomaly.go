@@ -0,0 +1,61 @@
+package config
+
+import "fmt"
+
+// Giá trị mặc định của phát hiện bất thường
+const (
+	DefaultAnomalyWindow            = 7
+	DefaultAnomalyMinSamples        = 3
+	DefaultAnomalySizeDropPercent   = 50
+	DefaultAnomalySizeGrowthPercent = 300
+	DefaultAnomalyDurationPercent   = 200
+)
+
+// AnomalyConfig cấu hình việc so sánh kích thước và thời gian dump của bản backup mới với
+// baseline (trung vị) của các bản backup trước đó trong catalog. Ngưỡng âm là tắt kiểm tra đó.
+type AnomalyConfig struct {
+	Disabled bool `yaml:"disabled"`
+	// Window là số bản backup gần nhất (không bị đánh dấu) dùng làm baseline
+	Window int `yaml:"window"`
+	// MinSamples là số bản backup tối thiểu trong baseline trước khi bắt đầu so sánh
+	MinSamples int `yaml:"min_samples"`
+	// SizeDropPercent đánh dấu bản backup nhỏ hơn baseline từ ngần này phần trăm trở lên
+	SizeDropPercent float64 `yaml:"size_drop_percent"`
+	// SizeGrowthPercent đánh dấu bản backup lớn hơn baseline quá ngần này phần trăm
+	SizeGrowthPercent float64 `yaml:"size_growth_percent"`
+	// DurationPercent đánh dấu lần dump chậm hơn baseline quá ngần này phần trăm
+	DurationPercent float64 `yaml:"duration_percent"`
+}
+
+// prepareAnomaly áp dụng giá trị mặc định và kiểm tra cấu hình phát hiện bất thường của job
+func prepareAnomaly(job *Job) error {
+	a := &job.Anomaly
+
+	if a.Window < 0 || a.MinSamples < 0 {
+		return fmt.Errorf("anomaly.window and anomaly.min_samples must not be negative")
+	}
+	if a.SizeDropPercent > 100 {
+		return fmt.Errorf("anomaly.size_drop_percent must not exceed 100")
+	}
+
+	if a.Window == 0 {
+		a.Window = DefaultAnomalyWindow
+	}
+	if a.MinSamples == 0 {
+		a.MinSamples = DefaultAnomalyMinSamples
+	}
+	if a.MinSamples > a.Window {
+		return fmt.Errorf("anomaly.min_samples must not exceed anomaly.window")
+	}
+	if a.SizeDropPercent == 0 {
+		a.SizeDropPercent = DefaultAnomalySizeDropPercent
+	}
+	if a.SizeGrowthPercent == 0 {
+		a.SizeGrowthPercent = DefaultAnomalySizeGrowthPercent
+	}
+	if a.DurationPercent == 0 {
+		a.DurationPercent = DefaultAnomalyDurationPercent
+	}
+
+	return nil
+}
@@ -1,13 +1,28 @@
 package config
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/backup-cronjob/internal/i18n"
 	"github.com/joho/godotenv"
 )
 
+// Cách xử lý khi Google Drive không đủ dung lượng cho các file sắp upload (DRIVE_QUOTA_ACTION)
+const (
+	QuotaActionRefuse = "refuse" // từ chối upload với lỗi rõ ràng
+	QuotaActionPrune  = "prune"  // xóa các bản backup hết hạn trên Drive theo chính sách lưu giữ rồi kiểm tra lại
+	QuotaActionOff    = "off"    // không kiểm tra
+)
+
 // Config chứa các thông tin cấu hình từ file .env
 type Config struct {
 	DBUser             string
@@ -21,18 +36,89 @@ type Config struct {
 	BackupDir          string
 	TokenDir           string
 	WebAppPort         string
+	PublicBaseURL      string
 	AdminUsername      string
 	AdminPassword      string
 	JWTSecret          string
 	SQLiteDBPath       string
+	MasterKey          string
+	MasterKeyFile      string
+	JobsFile           string
+	NotificationsFile  string
+	DockerHost         string
+
+	// Giới hạn tần suất và khóa đăng nhập
+	LoginMaxFailures    int
+	LoginLockoutBase    time.Duration
+	LoginLockoutMax     time.Duration
+	LoginRatePerMinute  int
+	ActionRatePerMinute int
+
+	// Các reverse proxy (IP hoặc CIDR) được tin cậy để lấy IP client từ X-Forwarded-For/X-Real-IP.
+	// Rỗng = không tin proxy nào, IP client là địa chỉ kết nối trực tiếp.
+	TrustedProxies []string
+
+	// Chạy các job theo lịch trong server web (serve không có --daemon). Mặc định tắt để
+	// mở giao diện web không tự khởi động backup; chế độ daemon luôn chạy theo lịch.
+	ServeSchedule bool
+
+	// Tự động phát hiện container cần backup qua label
+	DiscoveryEnabled  bool
+	DiscoveryInterval time.Duration
+
+	// Endpoint /metrics (Prometheus): token bearer và địa chỉ lắng nghe ở chế độ daemon (mặc định chỉ
+	// loopback, "off" = tắt). Ở chế độ web, /metrics dùng chung port của ứng dụng và yêu cầu token
+	// này, hoặc JWT đăng nhập khi token rỗng.
+	MetricsToken string
+	MetricsAddr  string
+
+	// Logging: định dạng (text, json), mức log và số lần chạy (kèm log) giữ lại cho mỗi job
+	LogFormat  string
+	LogLevel   string
+	RunHistory int
+
+	// Ngôn ngữ mặc định của CLI, thông báo lỗi API và giao diện web (vi, en)
+	Locale string
+
+	// /readyz: dung lượng trống tối thiểu (MB) của BackupDir, thời gian chờ tối đa của mỗi kiểm tra
+	// và thời gian dùng lại kết quả kiểm tra gần nhất (0 = luôn kiểm tra lại)
+	HealthMinFreeMB int
+	HealthTimeout   time.Duration
+	HealthCacheTTL  time.Duration
+
+	// Google Drive qua service account (nội dung file key JSON), thay cho token OAuth của một người
+	// dùng; GoogleImpersonate là email người dùng được ủy quyền toàn miền (domain-wide delegation)
+	GoogleServiceAccountKey string
+	GoogleImpersonate       string
+
+	// Folder gốc trên Drive theo ID (thay cho tìm theo tên FolderDrive) và Shared Drive chứa backup
+	FolderDriveID string
+	SharedDriveID string
+
+	// Kiểm tra trước khi chạy: dung lượng trống local trước khi dump (ước lượng từ các bản backup
+	// trước) và hạn mức Google Drive trước khi upload (QuotaActionRefuse, QuotaActionPrune, QuotaActionOff)
+	PreflightDiskCheck bool
+	DriveQuotaAction   string
+
+	// Kênh và quy tắc gửi thông báo (từ NotificationsFile)
+	Notifications *NotificationsConfig
+
+	// Danh sách job backup (từ JobsFile hoặc job mặc định dựng từ biến môi trường)
+	jobs []*Job
+	// Các job được phát hiện tự động, thay đổi trong lúc chạy
+	jobsMu         sync.RWMutex
+	discoveredJobs []*Job
 }
 
-// LoadConfig nạp cấu hình từ file .env
+// LoadConfig nạp cấu hình từ file .env (nếu có) và biến môi trường.
+// Các giá trị nhạy cảm có thể là tham chiếu secret (xem getSecretEnv).
 func LoadConfig() (*Config, error) {
-	// Nạp biến môi trường từ file .env
-	err := godotenv.Load()
-	if err != nil {
-		return nil, fmt.Errorf("error loading .env file: %w", err)
+	// Nạp biến môi trường từ file .env (hoặc ENV_FILE). Cho phép thiếu file
+	// khi môi trường (Docker, Kubernetes...) đã cung cấp đủ biến.
+	envFile := getEnv("ENV_FILE", ".env")
+	err := godotenv.Load(envFile)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("error loading %s file: %w", envFile, err)
 	}
 
 	// Xác định đường dẫn thư mục gốc
@@ -46,10 +132,10 @@ func LoadConfig() (*Config, error) {
 	tokenDir := filepath.Join(rootDir, "token")
 	dataDir := filepath.Join(rootDir, "data")
 
-	// Đảm bảo các thư mục tồn tại
-	os.MkdirAll(backupDir, 0755)
-	os.MkdirAll(tokenDir, 0755)
-	os.MkdirAll(dataDir, 0755)
+	// Đảm bảo các thư mục tồn tại (chỉ chủ sở hữu được truy cập)
+	os.MkdirAll(backupDir, 0700)
+	os.MkdirAll(tokenDir, 0700)
+	os.MkdirAll(dataDir, 0700)
 
 	// Đường dẫn đến SQLite database
 	sqliteDBPath := filepath.Join(dataDir, "app.db")
@@ -60,38 +146,145 @@ func LoadConfig() (*Config, error) {
 		webAppPort = "8080"
 	}
 
+	// URL công khai của ứng dụng, dùng để tạo redirect URL cho OAuth
+	publicBaseURL := strings.TrimRight(os.Getenv("PUBLIC_BASE_URL"), "/")
+	if publicBaseURL == "" {
+		publicBaseURL = fmt.Sprintf("http://localhost:%s", webAppPort)
+	}
+
+	// Phân giải các giá trị có thể là tham chiếu secret
+	values := make(map[string]string)
+	for _, key := range []string{"DB_USER", "CONTAINER_NAME", "DB_NAME", "GOOGLE_CLIENT_ID", "ADMIN_USERNAME"} {
+		if values[key], err = resolveEnv(key); err != nil {
+			return nil, err
+		}
+	}
+	for _, key := range []string{"DB_PASSWORD", "GOOGLE_CLIENT_SECRET", "JWT_SECRET", "ADMIN_PASSWORD", "MASTER_KEY", "METRICS_TOKEN", "GOOGLE_SERVICE_ACCOUNT_KEY"} {
+		if values[key], err = getSecretEnv(key); err != nil {
+			return nil, err
+		}
+	}
+
 	// JWT Secret mặc định nếu không được cấu hình
-	jwtSecret := os.Getenv("JWT_SECRET")
+	jwtSecret := values["JWT_SECRET"]
 	if jwtSecret == "" {
 		jwtSecret = "default_jwt_secret_please_change_in_production"
 	}
 
 	// Lấy giá trị từ các biến môi trường
 	config := &Config{
-		DBUser:             os.Getenv("DB_USER"),
-		DBPassword:         os.Getenv("DB_PASSWORD"),
-		ContainerName:      os.Getenv("CONTAINER_NAME"),
-		DBName:             os.Getenv("DB_NAME"),
-		GoogleClientID:     os.Getenv("GOOGLE_CLIENT_ID"),
-		GoogleClientSecret: os.Getenv("GOOGLE_CLIENT_SECRET"),
+		DBUser:             values["DB_USER"],
+		DBPassword:         values["DB_PASSWORD"],
+		ContainerName:      values["CONTAINER_NAME"],
+		DBName:             values["DB_NAME"],
+		GoogleClientID:     values["GOOGLE_CLIENT_ID"],
+		GoogleClientSecret: values["GOOGLE_CLIENT_SECRET"],
 		FolderDrive:        os.Getenv("FOLDER_DRIVE"),
 		CronSchedule:       os.Getenv("CRON_SCHEDULE"),
 		BackupDir:          backupDir,
 		TokenDir:           tokenDir,
 		WebAppPort:         webAppPort,
-		AdminUsername:      os.Getenv("ADMIN_USERNAME"),
-		AdminPassword:      os.Getenv("ADMIN_PASSWORD"),
+		PublicBaseURL:      publicBaseURL,
+		AdminUsername:      values["ADMIN_USERNAME"],
+		AdminPassword:      values["ADMIN_PASSWORD"],
 		JWTSecret:          jwtSecret,
 		SQLiteDBPath:       sqliteDBPath,
+		MasterKey:          values["MASTER_KEY"],
+		MasterKeyFile:      getEnv("MASTER_KEY_FILE", filepath.Join(dataDir, "master.key")),
+		JobsFile:           getEnv("JOBS_FILE", filepath.Join(rootDir, "jobs.yaml")),
+		NotificationsFile:  getEnv("NOTIFICATIONS_FILE", filepath.Join(rootDir, "notifications.yaml")),
+		DockerHost:         getEnv("DOCKER_HOST", "unix:///var/run/docker.sock"),
+
+		LoginMaxFailures:    getEnvInt("LOGIN_MAX_FAILURES", 5),
+		LoginLockoutBase:    getEnvDuration("LOGIN_LOCKOUT_BASE", time.Minute),
+		LoginLockoutMax:     getEnvDuration("LOGIN_LOCKOUT_MAX", time.Hour),
+		LoginRatePerMinute:  getEnvInt("LOGIN_RATE_PER_MINUTE", 10),
+		ActionRatePerMinute: getEnvInt("ACTION_RATE_PER_MINUTE", 6),
+		TrustedProxies:      getEnvList("TRUSTED_PROXIES"),
+
+		ServeSchedule: getEnvBool("SERVE_SCHEDULE", false),
+
+		DiscoveryEnabled:  getEnvBool("DISCOVERY_ENABLED", false),
+		DiscoveryInterval: getEnvDuration("DISCOVERY_INTERVAL", 5*time.Minute),
+
+		MetricsToken: values["METRICS_TOKEN"],
+		MetricsAddr:  getEnv("METRICS_ADDR", "127.0.0.1:9090"),
+
+		LogFormat:  getEnv("LOG_FORMAT", "text"),
+		LogLevel:   getEnv("LOG_LEVEL", "info"),
+		RunHistory: getEnvInt("RUN_HISTORY", 100),
+
+		Locale: getEnv("LOCALE", i18n.VI),
+
+		HealthMinFreeMB: getEnvInt("HEALTH_MIN_FREE_MB", 1024),
+		HealthTimeout:   getEnvDuration("HEALTH_TIMEOUT", 5*time.Second),
+		HealthCacheTTL:  getEnvDuration("HEALTH_CACHE_TTL", 10*time.Second),
+
+		GoogleServiceAccountKey: values["GOOGLE_SERVICE_ACCOUNT_KEY"],
+		GoogleImpersonate:       os.Getenv("GOOGLE_IMPERSONATE_USER"),
+
+		FolderDriveID: os.Getenv("FOLDER_DRIVE_ID"),
+		SharedDriveID: os.Getenv("SHARED_DRIVE_ID"),
+
+		PreflightDiskCheck: getEnvBool("PREFLIGHT_DISK_CHECK", true),
+		DriveQuotaAction:   getEnv("DRIVE_QUOTA_ACTION", QuotaActionRefuse),
 	}
 
-	// Kiểm tra các biến bắt buộc
-	if config.DBUser == "" || config.DBPassword == "" || config.ContainerName == "" || config.DBName == "" {
-		return nil, fmt.Errorf("missing required environment variables: DB_USER, DB_PASSWORD, CONTAINER_NAME, DB_NAME")
+	if err := config.checkServiceAccount(); err != nil {
+		return nil, err
+	}
+
+	locale := i18n.Normalize(config.Locale)
+	if locale == "" {
+		return nil, fmt.Errorf("invalid LOCALE %q (supported: %s)", config.Locale, strings.Join(i18n.Supported(), ", "))
+	}
+	config.Locale = locale
+
+	switch config.DriveQuotaAction {
+	case QuotaActionRefuse, QuotaActionPrune, QuotaActionOff:
+	default:
+		return nil, fmt.Errorf("invalid DRIVE_QUOTA_ACTION %q (supported: %s, %s, %s)",
+			config.DriveQuotaAction, QuotaActionRefuse, QuotaActionPrune, QuotaActionOff)
+	}
+
+	// Nạp danh sách job từ file cấu hình; nếu không có file, dựng một job mặc định
+	// từ các biến DB_* để giữ tương thích với cấu hình cũ
+	jobs, err := loadJobsFile(config.JobsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if jobs == nil && !(config.DiscoveryEnabled && config.ContainerName == "") {
+		if config.DBUser == "" || config.DBPassword == "" || config.ContainerName == "" || config.DBName == "" {
+			return nil, fmt.Errorf("missing required environment variables: DB_USER, DB_PASSWORD, CONTAINER_NAME, DB_NAME (or provide a jobs file via JOBS_FILE)")
+		}
+
+		jobs = []*Job{{
+			Name:          getEnv("JOB_NAME", config.DBName),
+			ContainerName: config.ContainerName,
+			DBName:        config.DBName,
+			DBUser:        config.DBUser,
+			DBPassword:    config.DBPassword,
+			Schedule:      config.CronSchedule,
+			Destinations:  []string{DestinationLocal, DestinationDrive},
+		}}
 	}
 
-	if config.GoogleClientID == "" || config.GoogleClientSecret == "" || config.FolderDrive == "" {
-		return nil, fmt.Errorf("missing required Google Drive environment variables")
+	if err := prepareJobs(jobs); err != nil {
+		return nil, err
+	}
+	config.jobs = jobs
+
+	// Thông tin Google Drive chỉ bắt buộc khi có job upload lên Drive
+	for _, job := range jobs {
+		if err := config.checkDestinations(job); err != nil {
+			return nil, err
+		}
+	}
+
+	// Nạp cấu hình thông báo (không bắt buộc)
+	if config.Notifications, err = loadNotificationsFile(config.NotificationsFile); err != nil {
+		return nil, err
 	}
 
 	// Kiểm tra tài khoản admin
@@ -101,3 +294,95 @@ func LoadConfig() (*Config, error) {
 
 	return config, nil
 }
+
+// UseServiceAccount cho biết Google Drive được truy cập bằng service account thay cho token OAuth
+func (c *Config) UseServiceAccount() bool {
+	return c.GoogleServiceAccountKey != ""
+}
+
+// checkServiceAccount kiểm tra key JSON của service account (nếu có) đúng định dạng Google cấp
+func (c *Config) checkServiceAccount() error {
+	if !c.UseServiceAccount() {
+		if c.GoogleImpersonate != "" {
+			return fmt.Errorf("GOOGLE_IMPERSONATE_USER requires GOOGLE_SERVICE_ACCOUNT_KEY")
+		}
+		return nil
+	}
+
+	var key struct {
+		Type        string `json:"type"`
+		ClientEmail string `json:"client_email"`
+		PrivateKey  string `json:"private_key"`
+	}
+	if err := json.Unmarshal([]byte(c.GoogleServiceAccountKey), &key); err != nil {
+		return fmt.Errorf("invalid GOOGLE_SERVICE_ACCOUNT_KEY: %w", err)
+	}
+	if key.Type != "service_account" || key.ClientEmail == "" || key.PrivateKey == "" {
+		return fmt.Errorf("invalid GOOGLE_SERVICE_ACCOUNT_KEY: not a service account JSON key")
+	}
+	return nil
+}
+
+// getEnv đọc biến môi trường dạng chuỗi, trả về giá trị mặc định nếu rỗng
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvInt đọc biến môi trường kiểu số nguyên, trả về giá trị mặc định nếu không hợp lệ
+func getEnvInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return defaultValue
+	}
+
+	return n
+}
+
+// getEnvList đọc biến môi trường dạng danh sách phân tách bằng dấu phẩy, bỏ các phần tử rỗng
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getEnvBool đọc biến môi trường kiểu bool (true/false, 1/0...), trả về giá trị mặc định nếu không hợp lệ
+func getEnvBool(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return defaultValue
+	}
+
+	return b
+}
+
+// getEnvDuration đọc biến môi trường kiểu thời lượng (vd: 30s, 5m, 1h)
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return defaultValue
+	}
+
+	return d
+}
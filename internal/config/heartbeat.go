@@ -0,0 +1,66 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Giá trị mặc định của heartbeat
+const (
+	DefaultHeartbeatTimeout    = 10 * time.Second
+	DefaultHeartbeatRetries    = 3
+	DefaultHeartbeatRetryDelay = 2 * time.Second
+)
+
+// HeartbeatConfig cấu hình ping heartbeat kiểu Healthchecks.io quanh mỗi lần chạy job:
+// <url>/start khi bắt đầu, <url> khi thành công và <url>/fail khi thất bại. Dịch vụ giám sát
+// cảnh báo khi không nhận được ping đúng hạn (kể cả khi scheduler không chạy).
+type HeartbeatConfig struct {
+	// URL là địa chỉ ping của check (vd: https://hc-ping.com/<uuid>), hỗ trợ tham chiếu secret
+	URL        string        `yaml:"url"`
+	Timeout    time.Duration `yaml:"timeout"`
+	Retries    int           `yaml:"retries"`
+	RetryDelay time.Duration `yaml:"retry_delay"`
+}
+
+// Enabled cho biết job có cấu hình heartbeat không
+func (h *HeartbeatConfig) Enabled() bool {
+	return h.URL != ""
+}
+
+// prepareHeartbeat áp dụng giá trị mặc định, phân giải secret và kiểm tra cấu hình heartbeat của job
+func prepareHeartbeat(job *Job) error {
+	h := &job.Heartbeat
+	if h.URL == "" {
+		return nil
+	}
+
+	// URL ping của check là bí mật (ai có URL đều gửi được ping)
+	resolved, err := ResolveSecret(h.URL)
+	if err != nil {
+		return fmt.Errorf("heartbeat.url: %w", err)
+	}
+	h.URL = strings.TrimRight(resolved, "/")
+	RegisterSecret(h.URL)
+
+	u, err := url.Parse(h.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("heartbeat.url must be an http(s) URL")
+	}
+
+	if h.Timeout < 0 || h.Retries < 0 || h.RetryDelay < 0 {
+		return fmt.Errorf("heartbeat timeout, retries and retry_delay must not be negative")
+	}
+	if h.Timeout == 0 {
+		h.Timeout = DefaultHeartbeatTimeout
+	}
+	if h.Retries == 0 {
+		h.Retries = DefaultHeartbeatRetries
+	}
+	if h.RetryDelay == 0 {
+		h.RetryDelay = DefaultHeartbeatRetryDelay
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Các thời điểm chạy hook của một job
+const (
+	HookPreDump    = "pre_dump"
+	HookPostDump   = "post_dump"
+	HookPostUpload = "post_upload"
+	HookOnFailure  = "on_failure"
+)
+
+// DefaultHookTimeout là thời gian chạy tối đa của hook khi không cấu hình timeout
+const DefaultHookTimeout = time.Minute
+
+// Hook là một lệnh shell hoặc HTTP request chạy quanh quá trình backup.
+// Chỉ một trong hai trường Command hoặc URL được đặt.
+type Hook struct {
+	Name    string            `yaml:"name"`
+	Command string            `yaml:"command"`
+	URL     string            `yaml:"url"`
+	Method  string            `yaml:"method"`
+	Headers map[string]string `yaml:"headers"`
+	Timeout time.Duration     `yaml:"timeout"`
+	// ContinueOnFailure cho phép backup tiếp tục khi hook pre_dump thất bại (mặc định: dừng backup)
+	ContinueOnFailure bool `yaml:"continue_on_failure"`
+}
+
+// Hooks là các hook của job theo từng thời điểm
+type Hooks struct {
+	PreDump    []*Hook `yaml:"pre_dump"`
+	PostDump   []*Hook `yaml:"post_dump"`
+	PostUpload []*Hook `yaml:"post_upload"`
+	OnFailure  []*Hook `yaml:"on_failure"`
+}
+
+// Stage trả về các hook của thời điểm stage
+func (h *Hooks) Stage(stage string) []*Hook {
+	switch stage {
+	case HookPreDump:
+		return h.PreDump
+	case HookPostDump:
+		return h.PostDump
+	case HookPostUpload:
+		return h.PostUpload
+	case HookOnFailure:
+		return h.OnFailure
+	}
+	return nil
+}
+
+// Label trả về tên dùng để hiển thị hook trong log
+func (h *Hook) Label() string {
+	if h.Name != "" {
+		return h.Name
+	}
+	if h.URL != "" {
+		return h.Method + " " + h.URL
+	}
+	return h.Command
+}
+
+// prepareHooks áp dụng giá trị mặc định và kiểm tra các hook của job
+func prepareHooks(job *Job) error {
+	for _, stage := range []string{HookPreDump, HookPostDump, HookPostUpload, HookOnFailure} {
+		for i, hook := range job.Hooks.Stage(stage) {
+			if hook == nil {
+				return fmt.Errorf("hooks.%s[%d] is empty", stage, i)
+			}
+			if (hook.Command == "") == (hook.URL == "") {
+				return fmt.Errorf("hooks.%s[%d]: exactly one of command or url is required", stage, i)
+			}
+
+			if hook.URL != "" {
+				u, err := url.Parse(hook.URL)
+				if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+					return fmt.Errorf("hooks.%s[%d]: invalid url %q", stage, i, hook.URL)
+				}
+				if hook.Method == "" {
+					hook.Method = "POST"
+				}
+				hook.Method = strings.ToUpper(hook.Method)
+
+				// Header (vd: Authorization) có thể là tham chiếu secret
+				for key, value := range hook.Headers {
+					resolved, err := ResolveSecret(value)
+					if err != nil {
+						return fmt.Errorf("hooks.%s[%d]: header %s: %w", stage, i, key, err)
+					}
+					hook.Headers[key] = resolved
+					RegisterSecret(resolved)
+				}
+			}
+
+			if hook.Timeout < 0 {
+				return fmt.Errorf("hooks.%s[%d]: timeout must not be negative", stage, i)
+			}
+			if hook.Timeout == 0 {
+				hook.Timeout = DefaultHookTimeout
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,444 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"time"
+
+	"github.com/backup-cronjob/internal/cron"
+	"github.com/backup-cronjob/internal/i18n"
+	"gopkg.in/yaml.v3"
+)
+
+// Các engine database được hỗ trợ
+const (
+	EnginePostgres = "postgres"
+)
+
+// Các chế độ dump
+const (
+	// ModeDatabase dump một database (mặc định)
+	ModeDatabase = "database"
+	// ModeCluster dump tất cả database trong container cùng các đối tượng toàn cục (roles, tablespaces)
+	ModeCluster = "cluster"
+	// ModePhysical sao lưu vật lý bằng pg_basebackup kèm lưu trữ WAL liên tục (khôi phục theo thời điểm)
+	ModePhysical = "physical"
+)
+
+// Các cách kết nối tới database
+const (
+	// ConnectionDocker chạy pg_dump bên trong container qua docker exec (mặc định)
+	ConnectionDocker = "docker"
+	// ConnectionNetwork kết nối tới database qua mạng (host/port), dùng cho managed Postgres hoặc host từ xa
+	ConnectionNetwork = "network"
+)
+
+// Các client chạy pg_dump ở chế độ kết nối network
+const (
+	// ClientLocal dùng pg_dump/psql được cài trên máy chạy ứng dụng
+	ClientLocal = "local"
+	// ClientDocker chạy pg_dump trong một container tạm (docker run --rm) từ image client postgres
+	ClientDocker = "docker"
+)
+
+// DefaultClientImage là image postgres dùng làm client khi không cấu hình client_image
+const DefaultClientImage = "postgres:17-alpine"
+
+// sslModes là các giá trị sslmode hợp lệ của libpq
+var sslModes = map[string]bool{
+	"disable": true, "allow": true, "prefer": true,
+	"require": true, "verify-ca": true, "verify-full": true,
+}
+
+// Các đích lưu trữ của một job
+const (
+	DestinationLocal = "local"
+	DestinationDrive = "drive"
+)
+
+// DefaultDumpOptions là các tham số pg_dump mặc định (giữ nguyên hành vi cũ)
+var DefaultDumpOptions = []string{"--data-only", "--column-inserts", "--disable-triggers"}
+
+// jobNamePattern giới hạn ký tự của tên job vì tên được dùng làm tên thư mục
+var jobNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// legacyDateLayout là định dạng tên thư mục ngày của cấu trúc backup cũ; tên job dạng này bị từ chối
+const legacyDateLayout = "2006-01-02"
+
+// RetentionPolicy quy định số lượng/thời gian giữ lại các bản backup
+type RetentionPolicy struct {
+	// KeepLast là số bản backup mới nhất luôn được giữ lại (0 = không giới hạn)
+	KeepLast int `yaml:"keep_last"`
+	// MaxAgeDays là số ngày tối đa giữ các bản backup ngoài KeepLast bản mới nhất (0 = không giới hạn)
+	MaxAgeDays int `yaml:"max_age_days"`
+}
+
+// Connection mô tả cách kết nối tới database của job
+type Connection struct {
+	Type string `yaml:"type"`
+
+	// Chỉ dùng với type network
+	Host        string `yaml:"host"`
+	Port        int    `yaml:"port"`
+	SSLMode     string `yaml:"sslmode"`
+	SSLRootCert string `yaml:"ssl_root_cert"`
+	Client      string `yaml:"client"`
+	// ClientImage là image postgres dùng khi client = docker; nên chọn cùng major version với server
+	ClientImage string `yaml:"client_image"`
+	// DockerNetwork là network của container client (vd: host), rỗng = mặc định của Docker
+	DockerNetwork string `yaml:"docker_network"`
+}
+
+// Job mô tả một job backup: database nào, chạy khi nào, lưu ở đâu
+type Job struct {
+	Name          string          `yaml:"name"`
+	Engine        string          `yaml:"engine"`
+	Mode          string          `yaml:"mode"`
+	Connection    Connection      `yaml:"connection"`
+	ContainerName string          `yaml:"container"`
+	DBName        string          `yaml:"database"`
+	DBUser        string          `yaml:"user"`
+	DBPassword    string          `yaml:"password"`
+	Schedule      string          `yaml:"schedule"`
+	DumpOptions   []string        `yaml:"dump_options"`
+	Destinations  []string        `yaml:"destinations"`
+	Retention     RetentionPolicy `yaml:"retention"`
+	Hooks         Hooks           `yaml:"hooks"`
+	Verify        VerifyConfig    `yaml:"verify"`
+	Anomaly       AnomalyConfig   `yaml:"anomaly"`
+	WAL           WALConfig       `yaml:"wal"`
+	Heartbeat     HeartbeatConfig `yaml:"heartbeat"`
+
+	// Chỉ dùng ở chế độ cluster: mẫu glob tên database cần/không cần dump
+	IncludeDatabases []string `yaml:"include_databases"`
+	ExcludeDatabases []string `yaml:"exclude_databases"`
+
+	// Discovered đánh dấu job được dựng từ label của container (không có trong file cấu hình)
+	Discovered bool `yaml:"-"`
+}
+
+// MatchDatabase kiểm tra database có được chọn theo các mẫu include/exclude không.
+// Không có mẫu include nghĩa là chọn tất cả.
+func (j *Job) MatchDatabase(name string) bool {
+	included := len(j.IncludeDatabases) == 0
+	for _, pattern := range j.IncludeDatabases {
+		if ok, _ := path.Match(pattern, name); ok {
+			included = true
+			break
+		}
+	}
+	if !included {
+		return false
+	}
+
+	for _, pattern := range j.ExcludeDatabases {
+		if ok, _ := path.Match(pattern, name); ok {
+			return false
+		}
+	}
+	return true
+}
+
+// HasDestination kiểm tra job có đích lưu trữ dest không
+func (j *Job) HasDestination(dest string) bool {
+	for _, d := range j.Destinations {
+		if d == dest {
+			return true
+		}
+	}
+	return false
+}
+
+// jobsFile là cấu trúc của file cấu hình jobs (YAML)
+type jobsFile struct {
+	Jobs []*Job `yaml:"jobs"`
+}
+
+// loadJobsFile đọc danh sách job từ file YAML. Trả về nil nếu file không tồn tại.
+func loadJobsFile(path string) ([]*Job, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var file jobsFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("error parsing jobs file %s: %w", path, err)
+	}
+
+	if len(file.Jobs) == 0 {
+		return nil, fmt.Errorf("jobs file %s does not declare any job", path)
+	}
+
+	return file.Jobs, nil
+}
+
+// prepareJobs áp dụng giá trị mặc định, phân giải secret và kiểm tra tính hợp lệ của các job
+func prepareJobs(jobs []*Job) error {
+	seen := make(map[string]bool)
+
+	for i, job := range jobs {
+		if job == nil {
+			return fmt.Errorf("job #%d is empty", i+1)
+		}
+
+		if seen[job.Name] {
+			return fmt.Errorf("duplicate job name %q", job.Name)
+		}
+		seen[job.Name] = true
+
+		if err := prepareJob(job); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// prepareJob áp dụng giá trị mặc định, phân giải secret và kiểm tra một job (trừ tính duy nhất của tên)
+func prepareJob(job *Job) error {
+	if !jobNamePattern.MatchString(job.Name) {
+		return fmt.Errorf("job has invalid name %q (allowed: letters, digits, '_', '-', '.')", job.Name)
+	}
+	// Thư mục backup đặt tên theo ngày (cấu trúc cũ backups/<ngày>/) không được trùng với thư mục job
+	if _, err := time.Parse(legacyDateLayout, job.Name); err == nil {
+		return fmt.Errorf("job has invalid name %q (names in the form YYYY-MM-DD are reserved for legacy backup directories)", job.Name)
+	}
+
+	if job.Engine == "" {
+		job.Engine = EnginePostgres
+	}
+	if job.Engine != EnginePostgres {
+		return fmt.Errorf("job %q: unsupported engine %q", job.Name, job.Engine)
+	}
+
+	switch job.Mode {
+	case "":
+		job.Mode = ModeDatabase
+	case ModeDatabase:
+	case ModeCluster, ModePhysical:
+		// Database dùng để kết nối khi liệt kê các database trong cluster / lấy thông tin server
+		if job.DBName == "" {
+			job.DBName = "postgres"
+		}
+	default:
+		return fmt.Errorf("job %q: unknown mode %q", job.Name, job.Mode)
+	}
+
+	if job.Schedule != "" {
+		if _, err := cron.Parse(job.Schedule); err != nil {
+			return fmt.Errorf("job %q: %w", job.Name, err)
+		}
+	}
+
+	for _, pattern := range append(append([]string(nil), job.IncludeDatabases...), job.ExcludeDatabases...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("job %q: invalid database pattern %q", job.Name, pattern)
+		}
+	}
+
+	if job.DumpOptions == nil {
+		job.DumpOptions = append([]string(nil), DefaultDumpOptions...)
+	}
+	if len(job.Destinations) == 0 {
+		job.Destinations = []string{DestinationLocal}
+	}
+	for _, dest := range job.Destinations {
+		if dest != DestinationLocal && dest != DestinationDrive {
+			return fmt.Errorf("job %q: unknown destination %q", job.Name, dest)
+		}
+	}
+
+	// Mật khẩu có thể là tham chiếu secret (file:, env:, exec:...). Job phát hiện từ label do bất kỳ
+	// ai tạo được container đặt ra nên mật khẩu luôn được dùng nguyên văn, không qua provider
+	if !job.Discovered {
+		password, err := ResolveSecret(job.DBPassword)
+		if err != nil {
+			return fmt.Errorf("job %q: password: %w", job.Name, err)
+		}
+		job.DBPassword = password
+	}
+	RegisterSecret(job.DBPassword)
+
+	if job.DBName == "" || job.DBUser == "" || job.DBPassword == "" {
+		return fmt.Errorf("job %q: database, user and password are required", job.Name)
+	}
+
+	if err := prepareConnection(job); err != nil {
+		return fmt.Errorf("job %q: %w", job.Name, err)
+	}
+
+	if job.Retention.KeepLast < 0 || job.Retention.MaxAgeDays < 0 {
+		return fmt.Errorf("job %q: retention values must not be negative", job.Name)
+	}
+
+	if err := prepareHooks(job); err != nil {
+		return fmt.Errorf("job %q: %w", job.Name, err)
+	}
+
+	if err := prepareVerify(job); err != nil {
+		return fmt.Errorf("job %q: %w", job.Name, err)
+	}
+
+	if err := prepareAnomaly(job); err != nil {
+		return fmt.Errorf("job %q: %w", job.Name, err)
+	}
+
+	if err := prepareWAL(job); err != nil {
+		return fmt.Errorf("job %q: %w", job.Name, err)
+	}
+
+	if err := prepareHeartbeat(job); err != nil {
+		return fmt.Errorf("job %q: %w", job.Name, err)
+	}
+
+	return nil
+}
+
+// prepareConnection áp dụng giá trị mặc định và kiểm tra cấu hình kết nối của job
+func prepareConnection(job *Job) error {
+	conn := &job.Connection
+
+	switch conn.Type {
+	case "", ConnectionDocker:
+		conn.Type = ConnectionDocker
+		if job.ContainerName == "" {
+			return fmt.Errorf("container is required for docker connection")
+		}
+		return nil
+	case ConnectionNetwork:
+	default:
+		return fmt.Errorf("unknown connection type %q", conn.Type)
+	}
+
+	if conn.Host == "" {
+		return fmt.Errorf("connection.host is required for network connection")
+	}
+	if conn.Port == 0 {
+		conn.Port = 5432
+	}
+	if conn.Port < 0 || conn.Port > 65535 {
+		return fmt.Errorf("invalid connection.port %d", conn.Port)
+	}
+	if conn.SSLMode == "" {
+		conn.SSLMode = "prefer"
+	}
+	if !sslModes[conn.SSLMode] {
+		return fmt.Errorf("invalid connection.sslmode %q", conn.SSLMode)
+	}
+	if conn.SSLRootCert != "" {
+		if _, err := os.Stat(conn.SSLRootCert); err != nil {
+			return fmt.Errorf("connection.ssl_root_cert: %w", err)
+		}
+	}
+
+	switch conn.Client {
+	case "":
+		conn.Client = ClientLocal
+	case ClientLocal:
+	case ClientDocker:
+		if conn.ClientImage == "" {
+			conn.ClientImage = DefaultClientImage
+		}
+	default:
+		return fmt.Errorf("unknown connection.client %q", conn.Client)
+	}
+
+	return nil
+}
+
+// Jobs trả về danh sách tất cả các job: job đã cấu hình và job được phát hiện tự động
+func (c *Config) Jobs() []*Job {
+	c.jobsMu.RLock()
+	defer c.jobsMu.RUnlock()
+
+	if len(c.discoveredJobs) == 0 {
+		return c.jobs
+	}
+
+	jobs := make([]*Job, 0, len(c.jobs)+len(c.discoveredJobs))
+	jobs = append(jobs, c.jobs...)
+	return append(jobs, c.discoveredJobs...)
+}
+
+// SetDiscoveredJobs thay thế danh sách job được phát hiện tự động (từ label của container).
+// Job không hợp lệ hoặc trùng tên với job khác bị bỏ qua, lỗi tương ứng được trả về.
+// Mật khẩu của các job này không được phân giải qua secret provider (file:, env:, exec:...).
+func (c *Config) SetDiscoveredJobs(jobs []*Job) []error {
+	var errs []error
+
+	seen := make(map[string]bool)
+	for _, job := range c.jobs {
+		seen[job.Name] = true
+	}
+
+	valid := make([]*Job, 0, len(jobs))
+	for _, job := range jobs {
+		job.Discovered = true
+		if err := prepareJob(job); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if seen[job.Name] {
+			errs = append(errs, fmt.Errorf("discovered job %q conflicts with an existing job", job.Name))
+			continue
+		}
+		if err := c.checkDestinations(job); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		seen[job.Name] = true
+		valid = append(valid, job)
+	}
+
+	c.jobsMu.Lock()
+	c.discoveredJobs = valid
+	c.jobsMu.Unlock()
+
+	return errs
+}
+
+// checkDestinations kiểm tra cấu hình cần thiết cho các đích lưu trữ của job
+func (c *Config) checkDestinations(job *Job) error {
+	if !job.HasDestination(DestinationDrive) {
+		return nil
+	}
+	// Xác thực bằng service account hoặc OAuth client; folder gốc theo tên, theo ID hoặc gốc Shared Drive
+	if !c.UseServiceAccount() && (c.GoogleClientID == "" || c.GoogleClientSecret == "") {
+		return fmt.Errorf("job %q: missing required Google Drive environment variables: GOOGLE_CLIENT_ID, GOOGLE_CLIENT_SECRET (or GOOGLE_SERVICE_ACCOUNT_KEY)", job.Name)
+	}
+	if c.FolderDrive == "" && c.FolderDriveID == "" && c.SharedDriveID == "" {
+		return fmt.Errorf("job %q: missing required Google Drive environment variables: FOLDER_DRIVE (or FOLDER_DRIVE_ID, SHARED_DRIVE_ID)", job.Name)
+	}
+	return nil
+}
+
+// FindJob tìm job theo tên
+func (c *Config) FindJob(name string) (*Job, error) {
+	for _, job := range c.Jobs() {
+		if job.Name == name {
+			return job, nil
+		}
+	}
+	return nil, i18n.Errorf(i18n.ErrJobNotFound, name)
+}
+
+// SelectJobs trả về job theo tên, hoặc tất cả các job nếu name rỗng
+func (c *Config) SelectJobs(name string) ([]*Job, error) {
+	if name == "" {
+		return c.Jobs(), nil
+	}
+
+	job, err := c.FindJob(name)
+	if err != nil {
+		return nil, err
+	}
+	return []*Job{job}, nil
+}
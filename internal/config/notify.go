@@ -0,0 +1,257 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"time"
+
+	"github.com/backup-cronjob/internal/cron"
+	"gopkg.in/yaml.v3"
+)
+
+// Các loại sự kiện gửi thông báo
+const (
+	NotifyFailure = "failure"
+	NotifySuccess = "success"
+	NotifyAnomaly = "anomaly"
+	NotifyDigest  = "digest"
+)
+
+// Các loại kênh thông báo
+const (
+	ChannelWebhook  = "webhook"
+	ChannelSlack    = "slack"
+	ChannelDiscord  = "discord"
+	ChannelTelegram = "telegram"
+	ChannelEmail    = "email"
+)
+
+// Giá trị mặc định của kênh thông báo
+const (
+	DefaultNotifyTimeout    = 10 * time.Second
+	DefaultNotifyRetries    = 3
+	DefaultNotifyRetryDelay = 5 * time.Second
+	// DefaultDigestSchedule là lịch gửi báo cáo tổng hợp hằng ngày (8 giờ sáng)
+	DefaultDigestSchedule = "0 8 * * *"
+)
+
+// NotifyChannel là một kênh nhận thông báo. Các trường dùng tùy theo Type:
+// webhook (URL, Secret để ký HMAC), slack/discord (URL incoming webhook),
+// telegram (BotToken, ChatID), email (SMTP*, From, To).
+type NotifyChannel struct {
+	Name string `yaml:"name"`
+	Type string `yaml:"type"`
+
+	URL    string `yaml:"url"`
+	Secret string `yaml:"secret"`
+
+	BotToken string `yaml:"bot_token"`
+	ChatID   string `yaml:"chat_id"`
+	// APIURL là địa chỉ Telegram Bot API (mặc định https://api.telegram.org)
+	APIURL string `yaml:"api_url"`
+
+	SMTPHost     string `yaml:"smtp_host"`
+	SMTPPort     int    `yaml:"smtp_port"`
+	SMTPUsername string `yaml:"smtp_username"`
+	SMTPPassword string `yaml:"smtp_password"`
+	// SMTPTLS: starttls (mặc định), tls (kết nối TLS trực tiếp, cổng 465) hoặc none
+	SMTPTLS string   `yaml:"smtp_tls"`
+	From    string   `yaml:"from"`
+	To      []string `yaml:"to"`
+
+	Timeout    time.Duration `yaml:"timeout"`
+	Retries    int           `yaml:"retries"`
+	RetryDelay time.Duration `yaml:"retry_delay"`
+}
+
+// NotifyRule chọn các sự kiện (của các job khớp mẫu glob Jobs, rỗng = mọi job) gửi tới các kênh
+type NotifyRule struct {
+	Events   []string `yaml:"events"`
+	Jobs     []string `yaml:"jobs"`
+	Channels []string `yaml:"channels"`
+}
+
+// NotificationsConfig là cấu hình thông báo (file NOTIFICATIONS_FILE)
+type NotificationsConfig struct {
+	Channels []*NotifyChannel `yaml:"channels"`
+	Rules    []*NotifyRule    `yaml:"rules"`
+	// DigestSchedule là lịch (cron) gửi báo cáo tổng hợp cho các quy tắc có sự kiện digest
+	DigestSchedule string `yaml:"digest_schedule"`
+}
+
+// Channel tìm kênh thông báo theo tên
+func (n *NotificationsConfig) Channel(name string) (*NotifyChannel, error) {
+	for _, channel := range n.Channels {
+		if channel.Name == name {
+			return channel, nil
+		}
+	}
+	return nil, fmt.Errorf("notification channel %q not found", name)
+}
+
+// Matches kiểm tra quy tắc có áp dụng cho sự kiện event của job không.
+// Với digest (không gắn với job), job rỗng luôn khớp.
+func (r *NotifyRule) Matches(event, job string) bool {
+	matched := false
+	for _, e := range r.Events {
+		if e == event {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return false
+	}
+
+	if len(r.Jobs) == 0 || job == "" {
+		return true
+	}
+	for _, pattern := range r.Jobs {
+		if ok, _ := path.Match(pattern, job); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// loadNotificationsFile đọc cấu hình thông báo từ file YAML. Trả về cấu hình rỗng nếu file không tồn tại.
+func loadNotificationsFile(file string) (*NotificationsConfig, error) {
+	n := &NotificationsConfig{}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return n, nil
+		}
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(data, n); err != nil {
+		return nil, fmt.Errorf("error parsing notifications file %s: %w", file, err)
+	}
+	if err := prepareNotifications(n); err != nil {
+		return nil, fmt.Errorf("notifications file %s: %w", file, err)
+	}
+	return n, nil
+}
+
+// prepareNotifications áp dụng giá trị mặc định, phân giải secret và kiểm tra cấu hình thông báo
+func prepareNotifications(n *NotificationsConfig) error {
+	seen := make(map[string]bool)
+	for i, channel := range n.Channels {
+		if channel == nil {
+			return fmt.Errorf("channels[%d] is empty", i)
+		}
+		if channel.Name == "" {
+			return fmt.Errorf("channels[%d]: name is required", i)
+		}
+		if seen[channel.Name] {
+			return fmt.Errorf("duplicate channel name %q", channel.Name)
+		}
+		seen[channel.Name] = true
+
+		if err := prepareChannel(channel); err != nil {
+			return fmt.Errorf("channel %q: %w", channel.Name, err)
+		}
+	}
+
+	for i, rule := range n.Rules {
+		if rule == nil {
+			return fmt.Errorf("rules[%d] is empty", i)
+		}
+		if len(rule.Events) == 0 || len(rule.Channels) == 0 {
+			return fmt.Errorf("rules[%d]: events and channels are required", i)
+		}
+		for _, event := range rule.Events {
+			switch event {
+			case NotifyFailure, NotifySuccess, NotifyAnomaly, NotifyDigest:
+			default:
+				return fmt.Errorf("rules[%d]: unknown event %q", i, event)
+			}
+		}
+		for _, name := range rule.Channels {
+			if !seen[name] {
+				return fmt.Errorf("rules[%d]: unknown channel %q", i, name)
+			}
+		}
+		for _, pattern := range rule.Jobs {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("rules[%d]: invalid job pattern %q", i, pattern)
+			}
+		}
+	}
+
+	if n.DigestSchedule == "" {
+		n.DigestSchedule = DefaultDigestSchedule
+	}
+	if _, err := cron.Parse(n.DigestSchedule); err != nil {
+		return fmt.Errorf("digest_schedule: %w", err)
+	}
+	return nil
+}
+
+// prepareChannel áp dụng giá trị mặc định, phân giải secret và kiểm tra một kênh thông báo
+func prepareChannel(channel *NotifyChannel) error {
+	// URL webhook (chứa token của Slack/Discord), secret, bot token và mật khẩu SMTP
+	// có thể là tham chiếu secret (file:, env:, exec:...)
+	for _, field := range []*string{&channel.URL, &channel.Secret, &channel.BotToken, &channel.SMTPPassword} {
+		resolved, err := ResolveSecret(*field)
+		if err != nil {
+			return err
+		}
+		*field = resolved
+		RegisterSecret(resolved)
+	}
+
+	switch channel.Type {
+	case ChannelWebhook, ChannelSlack, ChannelDiscord:
+		u, err := url.Parse(channel.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("invalid url")
+		}
+	case ChannelTelegram:
+		if channel.BotToken == "" || channel.ChatID == "" {
+			return fmt.Errorf("bot_token and chat_id are required")
+		}
+		if channel.APIURL == "" {
+			channel.APIURL = "https://api.telegram.org"
+		}
+	case ChannelEmail:
+		if channel.SMTPHost == "" || channel.From == "" || len(channel.To) == 0 {
+			return fmt.Errorf("smtp_host, from and to are required")
+		}
+		switch channel.SMTPTLS {
+		case "":
+			channel.SMTPTLS = "starttls"
+		case "starttls", "tls", "none":
+		default:
+			return fmt.Errorf("unknown smtp_tls %q (allowed: starttls, tls, none)", channel.SMTPTLS)
+		}
+		if channel.SMTPPort == 0 {
+			channel.SMTPPort = 587
+			if channel.SMTPTLS == "tls" {
+				channel.SMTPPort = 465
+			}
+		}
+	default:
+		return fmt.Errorf("unknown type %q", channel.Type)
+	}
+
+	if channel.Timeout < 0 || channel.Retries < 0 || channel.RetryDelay < 0 {
+		return fmt.Errorf("timeout, retries and retry_delay must not be negative")
+	}
+	if channel.Timeout == 0 {
+		channel.Timeout = DefaultNotifyTimeout
+	}
+	if channel.Retries == 0 {
+		channel.Retries = DefaultNotifyRetries
+	}
+	if channel.RetryDelay == 0 {
+		channel.RetryDelay = DefaultNotifyRetryDelay
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SecretProvider phân giải một tham chiếu secret (phần sau "scheme:") thành giá trị thật
+type SecretProvider func(ref string) (string, error)
+
+// dockerSecretsDir là thư mục Docker/Kubernetes mount secrets mặc định
+var dockerSecretsDir = "/run/secrets"
+
+var (
+	providersMu sync.RWMutex
+	providers   = map[string]SecretProvider{
+		"file":    fileSecretProvider,
+		"env":     envSecretProvider,
+		"exec":    execSecretProvider,
+		"literal": func(ref string) (string, error) { return ref, nil },
+	}
+)
+
+// RegisterSecretProvider đăng ký provider cho một scheme mới (vd: "vault").
+// Giá trị cấu hình dạng "<scheme>:<ref>" sẽ được phân giải bởi provider này.
+func RegisterSecretProvider(scheme string, provider SecretProvider) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers[scheme] = provider
+}
+
+// getSecretEnv đọc một giá trị cấu hình nhạy cảm theo thứ tự ưu tiên:
+//  1. <KEY>_FILE: đường dẫn file chứa giá trị (quy ước của Docker/Kubernetes)
+//  2. <KEY>: giá trị trực tiếp hoặc tham chiếu dạng file:/env:/exec:/literal:
+//  3. /run/secrets/<key>: Docker secret cùng tên (chữ thường)
+//
+// Giá trị tìm được sẽ được đăng ký để che đi trong log.
+func getSecretEnv(key string) (string, error) {
+	value, err := resolveEnv(key)
+	if err != nil {
+		return "", err
+	}
+
+	if value == "" {
+		path := filepath.Join(dockerSecretsDir, strings.ToLower(key))
+		if data, err := os.ReadFile(path); err == nil {
+			value = strings.TrimRight(string(data), "\r\n")
+		}
+	}
+
+	RegisterSecret(value)
+	return value, nil
+}
+
+// resolveEnv đọc biến môi trường và phân giải tham chiếu secret nếu có
+func resolveEnv(key string) (string, error) {
+	if path := os.Getenv(key + "_FILE"); path != "" {
+		value, err := fileSecretProvider(path)
+		if err != nil {
+			return "", fmt.Errorf("%s_FILE: %w", key, err)
+		}
+		return value, nil
+	}
+
+	value, err := ResolveSecret(os.Getenv(key))
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", key, err)
+	}
+	return value, nil
+}
+
+// ResolveSecret phân giải giá trị dạng "<scheme>:<ref>" qua provider đã đăng ký.
+// Giá trị không có scheme hợp lệ được trả về nguyên vẹn.
+func ResolveSecret(value string) (string, error) {
+	scheme, ref, ok := strings.Cut(value, ":")
+	if !ok {
+		return value, nil
+	}
+
+	providersMu.RLock()
+	provider, found := providers[scheme]
+	providersMu.RUnlock()
+	if !found {
+		return value, nil
+	}
+
+	resolved, err := provider(ref)
+	if err != nil {
+		return "", fmt.Errorf("cannot resolve %s secret: %w", scheme, err)
+	}
+	return resolved, nil
+}
+
+// fileSecretProvider đọc secret từ file, bỏ ký tự xuống dòng ở cuối
+func fileSecretProvider(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// envSecretProvider đọc secret từ một biến môi trường khác
+func envSecretProvider(name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return value, nil
+}
+
+// execSecretProvider chạy lệnh helper và dùng stdout làm giá trị secret
+func execSecretProvider(command string) (string, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return "", fmt.Errorf("empty exec command")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stderr = os.Stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("helper %s failed: %w", args[0], err)
+	}
+
+	return strings.TrimRight(string(output), "\r\n"), nil
+}
+
+// redactMask là chuỗi thay thế cho giá trị secret trong log
+const redactMask = "******"
+
+var (
+	secretsMu sync.RWMutex
+	secrets   []string
+)
+
+// RegisterSecret đăng ký một giá trị cần được che trong mọi output log.
+// Các giá trị quá ngắn được bỏ qua để tránh che nhầm văn bản thông thường.
+func RegisterSecret(value string) {
+	if len(value) < 4 {
+		return
+	}
+
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+
+	for _, s := range secrets {
+		if s == value {
+			return
+		}
+	}
+	secrets = append(secrets, value)
+
+	// Che chuỗi dài trước để không bị lộ một phần khi secret này chứa secret khác
+	sort.Slice(secrets, func(i, j int) bool { return len(secrets[i]) > len(secrets[j]) })
+}
+
+// Redact thay thế mọi secret đã đăng ký trong chuỗi bằng redactMask
+func Redact(s string) string {
+	secretsMu.RLock()
+	defer secretsMu.RUnlock()
+
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, redactMask)
+	}
+	return s
+}
+
+// redactingWriter là io.Writer che secret trước khi ghi ra writer gốc
+type redactingWriter struct {
+	w io.Writer
+}
+
+// NewRedactingWriter bọc writer để che các secret đã đăng ký
+func NewRedactingWriter(w io.Writer) io.Writer {
+	return &redactingWriter{w: w}
+}
+
+// Write che secret rồi ghi ra writer gốc. Luôn báo đã ghi đủ len(p) byte
+// vì độ dài sau khi che có thể khác độ dài ban đầu.
+func (r *redactingWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(r.w, Redact(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultVerifyTimeout là thời gian tối đa của một lần kiểm tra khôi phục
+const DefaultVerifyTimeout = 30 * time.Minute
+
+// DefaultRowCountTolerance là chênh lệch số dòng mặc định (phần trăm). Số dòng được đếm trong một
+// phiên riêng ngay sau pg_dump, không cùng snapshot với bản dump, nên các thay đổi ghi vào database
+// trong khoảng đó làm số dòng lệch một chút so với dữ liệu đã dump.
+const DefaultRowCountTolerance = 1.0
+
+// VerifyConfig cấu hình việc kiểm tra khả năng khôi phục bản backup của job
+type VerifyConfig struct {
+	// Enabled bật thu thập số dòng của từng bảng lúc dump để so sánh khi kiểm tra
+	Enabled bool `yaml:"enabled"`
+	// AfterDump tự động kiểm tra ngay sau mỗi lần dump thành công
+	AfterDump bool `yaml:"after_dump"`
+	// Image là image postgres dùng để khôi phục; rỗng = theo major version của server nguồn
+	Image string `yaml:"image"`
+	// RowCountTolerance là chênh lệch số dòng cho phép (phần trăm) giữa lúc dump và sau khi khôi phục;
+	// bỏ trống = DefaultRowCountTolerance, đặt 0 để yêu cầu khớp tuyệt đối (chỉ phù hợp khi không
+	// có ghi vào database trong lúc dump)
+	RowCountTolerance *float64      `yaml:"row_count_tolerance"`
+	Timeout           time.Duration `yaml:"timeout"`
+	Assertions        []*Assertion  `yaml:"assertions"`
+}
+
+// Assertion là câu SQL do người dùng cung cấp, phải trả về true trên database đã khôi phục
+type Assertion struct {
+	Name string `yaml:"name"`
+	SQL  string `yaml:"sql"`
+	// Database chỉ cần với bản backup cluster; mặc định là database của job
+	Database string `yaml:"database"`
+}
+
+// prepareVerify áp dụng giá trị mặc định và kiểm tra cấu hình verify của job
+func prepareVerify(job *Job) error {
+	v := &job.Verify
+
+	if v.AfterDump {
+		v.Enabled = true
+	}
+	if v.RowCountTolerance == nil {
+		tolerance := DefaultRowCountTolerance
+		v.RowCountTolerance = &tolerance
+	}
+	if *v.RowCountTolerance < 0 || *v.RowCountTolerance > 100 {
+		return fmt.Errorf("verify.row_count_tolerance must be between 0 and 100")
+	}
+	if v.Timeout < 0 {
+		return fmt.Errorf("verify.timeout must not be negative")
+	}
+	if v.Timeout == 0 {
+		v.Timeout = DefaultVerifyTimeout
+	}
+
+	for i, assertion := range v.Assertions {
+		if assertion == nil || assertion.SQL == "" {
+			return fmt.Errorf("verify.assertions[%d]: sql is required", i)
+		}
+		if assertion.Name == "" {
+			assertion.Name = fmt.Sprintf("assertion #%d", i+1)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"time"
+)
+
+// Giá trị mặc định của lưu trữ WAL
+const (
+	DefaultWALArchiveDir   = "/wal-archive"
+	DefaultWALPollInterval = time.Minute
+)
+
+// WALConfig cấu hình lưu trữ WAL liên tục của job ở chế độ physical. Server nguồn phải bật
+// archive_mode và archive_command chép segment vào ArchiveDir (bên trong container), ứng dụng
+// định kỳ lấy các segment mới về thư mục backup rồi xóa khỏi ArchiveDir.
+type WALConfig struct {
+	ArchiveDir   string        `yaml:"archive_dir"`
+	PollInterval time.Duration `yaml:"poll_interval"`
+}
+
+// prepareWAL áp dụng giá trị mặc định và kiểm tra cấu hình WAL của job
+func prepareWAL(job *Job) error {
+	if job.Mode != ModePhysical {
+		return nil
+	}
+
+	// Segment WAL và pg_basebackup được lấy qua docker exec
+	if job.Connection.Type != ConnectionDocker {
+		return fmt.Errorf("mode physical requires connection type %q", ConnectionDocker)
+	}
+
+	w := &job.WAL
+	if w.ArchiveDir == "" {
+		w.ArchiveDir = DefaultWALArchiveDir
+	}
+	if !path.IsAbs(w.ArchiveDir) {
+		return fmt.Errorf("wal.archive_dir must be an absolute path inside the container")
+	}
+	if w.PollInterval < 0 {
+		return fmt.Errorf("wal.poll_interval must not be negative")
+	}
+	if w.PollInterval == 0 {
+		w.PollInterval = DefaultWALPollInterval
+	}
+
+	return nil
+}
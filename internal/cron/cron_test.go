@@ -0,0 +1,68 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"61 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"1-x * * * *",
+		"@every 5m",
+	} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q): expected error", expr)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	// 2025-01-01 là thứ Tư; 2025-01-13 là thứ Hai
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2025, time.January, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		expr     string
+		from     time.Time
+		want     time.Time
+		describe string
+	}{
+		{"*/15 * * * *", at(1, 10, 7), at(1, 10, 15), "step"},
+		{"*/15 * * * *", at(1, 10, 15), at(1, 10, 30), "strictly after from"},
+		{"0 9 * * 1-5", at(3, 10, 0), at(6, 9, 0), "weekday range skips the weekend"},
+		{"@daily", at(1, 10, 0), at(2, 0, 0), "alias"},
+		{"0 0 1 * *", at(15, 0, 0), time.Date(2025, time.February, 1, 0, 0, 0, 0, time.UTC), "day of month only"},
+		{"0 0 * * 7", at(1, 0, 0), at(5, 0, 0), "Sunday written as 7"},
+		{"0 0 * * 0", at(1, 0, 0), at(5, 0, 0), "Sunday written as 0"},
+		{"0 0 * * 5-7", at(4, 12, 0), at(5, 0, 0), "range ending at 7 includes Sunday"},
+		// Cả ngày trong tháng và thứ bị giới hạn: khớp khi một trong hai khớp
+		{"0 0 13 * 5", at(11, 0, 0), at(13, 0, 0), "day of month matches before weekday"},
+		{"0 0 13 * 5", at(14, 0, 0), at(17, 0, 0), "weekday matches before day of month"},
+		// Chỉ thứ bị giới hạn: ngày trong tháng "*" không làm mọi ngày khớp
+		{"0 0 * * 1", at(1, 0, 0), at(6, 0, 0), "weekday only"},
+		{"0 0 30 2 *", at(1, 0, 0), time.Time{}, "never matches"},
+	}
+
+	for _, tt := range tests {
+		sched, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("%s: Parse(%q): %v", tt.describe, tt.expr, err)
+			continue
+		}
+		if got := sched.Next(tt.from); !got.Equal(tt.want) {
+			t.Errorf("%s: Next(%q, %s) = %s, want %s", tt.describe, tt.expr, tt.from, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package database
+
+import (
+	"crypto/hmac"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/backup-cronjob/internal/models"
+	"github.com/backup-cronjob/internal/secure"
+)
+
+// auditKeyPurpose là mục đích dùng để tạo khóa HMAC của chuỗi hash audit log từ master key
+const auditKeyPurpose = "audit-chain"
+
+// auditMu tuần tự hóa việc ghi audit log trong tiến trình: mỗi sự kiện phải đọc hash cuối và ghi
+// nối tiếp trước khi sự kiện khác đọc, nếu không chuỗi hash sẽ rẽ nhánh
+var auditMu sync.Mutex
+
+// InsertAuditEvent thêm một sự kiện vào audit log, tính hash nối tiếp với sự kiện cuối cùng
+func InsertAuditEvent(event *models.AuditEvent) error {
+	key, err := secure.DeriveKey(auditKeyPurpose)
+	if err != nil {
+		return err
+	}
+
+	auditMu.Lock()
+	defer auditMu.Unlock()
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var prevHash string
+	err = tx.QueryRow("SELECT hash FROM audit_events ORDER BY id DESC LIMIT 1").Scan(&prevHash)
+	if err != nil && !isNoRows(err) {
+		return err
+	}
+
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now().UTC()
+	}
+	event.PrevHash = prevHash
+	event.Hash = event.ComputeHash(key, prevHash)
+
+	res, err := tx.Exec(
+		`INSERT INTO audit_events (created_at, actor, action, target, source_ip, user_agent, result, details, prev_hash, hash)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		event.CreatedAt, event.Actor, event.Action, event.Target, event.SourceIP,
+		event.UserAgent, event.Result, event.Details, event.PrevHash, event.Hash,
+	)
+	if err != nil {
+		return err
+	}
+
+	if event.ID, err = res.LastInsertId(); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ListAuditEvents lấy danh sách sự kiện theo bộ lọc, mới nhất trước
+func ListAuditEvents(filter models.AuditFilter) ([]*models.AuditEvent, error) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+
+	if filter.Actor != "" {
+		conditions = append(conditions, "actor = ?")
+		args = append(args, filter.Actor)
+	}
+	if filter.Action != "" {
+		conditions = append(conditions, "action = ?")
+		args = append(args, filter.Action)
+	}
+	if filter.Target != "" {
+		conditions = append(conditions, "target LIKE ?")
+		args = append(args, "%"+filter.Target+"%")
+	}
+	if filter.Result != "" {
+		conditions = append(conditions, "result = ?")
+		args = append(args, filter.Result)
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, filter.From.UTC())
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "created_at <= ?")
+		args = append(args, filter.To.UTC())
+	}
+
+	query := "SELECT " + auditColumns + " FROM audit_events"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY id DESC"
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", filter.Limit)
+	}
+
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*models.AuditEvent
+	for rows.Next() {
+		event, err := scanAuditEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// VerifyAuditChain duyệt toàn bộ audit log và kiểm tra chuỗi hash. Các bản ghi hash không khóa của
+// phiên bản cũ chỉ được chấp nhận ở đầu chuỗi, trước bản ghi HMAC đầu tiên. Kết quả gồm số bản ghi,
+// ID của bản ghi đầu tiên bị sai lệch (0 nếu chuỗi còn nguyên vẹn) và hash của bản ghi cuối.
+func VerifyAuditChain() (*models.AuditChainStatus, error) {
+	key, err := secure.DeriveKey(auditKeyPurpose)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := DB.Query("SELECT " + auditColumns + " FROM audit_events ORDER BY id ASC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	status := &models.AuditChainStatus{}
+	keyed := false
+	for rows.Next() {
+		event, err := scanAuditEvent(rows)
+		if err != nil {
+			return status, err
+		}
+		status.Count++
+
+		want := event.ComputeLegacyHash(status.HeadHash)
+		if strings.HasPrefix(event.Hash, models.AuditHashPrefix) {
+			keyed = true
+			want = event.ComputeHash(key, status.HeadHash)
+		} else if keyed {
+			want = ""
+		}
+		if event.PrevHash != status.HeadHash || !hmac.Equal([]byte(want), []byte(event.Hash)) {
+			status.BrokenID = event.ID
+			return status, nil
+		}
+		status.HeadID = event.ID
+		status.HeadHash = event.Hash
+	}
+
+	return status, rows.Err()
+}
+
+const auditColumns = "id, created_at, actor, action, target, source_ip, user_agent, result, details, prev_hash, hash"
+
+// rowScanner là interface chung của *sql.Row và *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanAuditEvent đọc một sự kiện từ kết quả truy vấn
+func scanAuditEvent(row rowScanner) (*models.AuditEvent, error) {
+	event := &models.AuditEvent{}
+	err := row.Scan(
+		&event.ID, &event.CreatedAt, &event.Actor, &event.Action, &event.Target, &event.SourceIP,
+		&event.UserAgent, &event.Result, &event.Details, &event.PrevHash, &event.Hash,
+	)
+	return event, err
+}
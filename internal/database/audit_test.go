@@ -0,0 +1,194 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/backup-cronjob/internal/config"
+	"github.com/backup-cronjob/internal/models"
+	"github.com/backup-cronjob/internal/secure"
+)
+
+// openTestDB mở một database SQLite tạm với schema đầy đủ và gán vào DB, kèm master key cố định
+func openTestDB(t *testing.T) {
+	t.Helper()
+
+	if err := secure.Init(&config.Config{MasterKey: strings.Repeat("ab", 32)}); err != nil {
+		t.Fatalf("secure.Init: %v", err)
+	}
+
+	db, err := sql.Open("sqlite", dsn(filepath.Join(t.TempDir(), "test.db")))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	prev := DB
+	DB = db
+	t.Cleanup(func() {
+		db.Close()
+		DB = prev
+	})
+
+	if err := createSchema(); err != nil {
+		t.Fatalf("createSchema: %v", err)
+	}
+}
+
+func TestInsertAuditEventConcurrent(t *testing.T) {
+	openTestDB(t)
+
+	const (
+		workers = 16
+		perWork = 25
+	)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*perWork)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWork; i++ {
+				errs <- InsertAuditEvent(&models.AuditEvent{
+					Actor:  fmt.Sprintf("worker-%d", w),
+					Action: "test.insert",
+					Target: fmt.Sprintf("%d", i),
+					Result: models.AuditResultSuccess,
+				})
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("InsertAuditEvent: %v", err)
+		}
+	}
+
+	status, err := VerifyAuditChain()
+	if err != nil {
+		t.Fatalf("VerifyAuditChain: %v", err)
+	}
+	if status.BrokenID != 0 {
+		t.Fatalf("chain broken at event %d", status.BrokenID)
+	}
+	if status.Count != workers*perWork {
+		t.Fatalf("got %d events, want %d", status.Count, workers*perWork)
+	}
+}
+
+// insertTestEvents ghi n sự kiện qua InsertAuditEvent
+func insertTestEvents(t *testing.T, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if err := InsertAuditEvent(&models.AuditEvent{Actor: "admin", Action: "test.insert", Target: fmt.Sprintf("%d", i), Result: models.AuditResultSuccess}); err != nil {
+			t.Fatalf("InsertAuditEvent: %v", err)
+		}
+	}
+}
+
+// dropAuditTriggers gỡ trigger chỉ-thêm-mới để giả lập kẻ tấn công sửa trực tiếp file database
+func dropAuditTriggers(t *testing.T) {
+	t.Helper()
+	for _, trigger := range []string{"audit_events_no_update", "audit_events_no_delete"} {
+		if _, err := DB.Exec("DROP TRIGGER " + trigger); err != nil {
+			t.Fatalf("drop trigger: %v", err)
+		}
+	}
+}
+
+func TestVerifyAuditChainHead(t *testing.T) {
+	openTestDB(t)
+	insertTestEvents(t, 3)
+
+	status, err := VerifyAuditChain()
+	if err != nil {
+		t.Fatalf("VerifyAuditChain: %v", err)
+	}
+
+	var lastID int64
+	var lastHash string
+	if err := DB.QueryRow("SELECT id, hash FROM audit_events ORDER BY id DESC LIMIT 1").Scan(&lastID, &lastHash); err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if status.Count != 3 || status.BrokenID != 0 || status.HeadID != lastID || status.HeadHash != lastHash {
+		t.Errorf("status = %+v, want 3 events with head %d/%s", status, lastID, lastHash)
+	}
+	if !strings.HasPrefix(lastHash, models.AuditHashPrefix) {
+		t.Errorf("hash %q is not keyed", lastHash)
+	}
+}
+
+func TestVerifyAuditChainDetectsRewrite(t *testing.T) {
+	tests := []struct {
+		name    string
+		rewrite func(event *models.AuditEvent) string
+	}{
+		// Sửa nội dung mà không tính lại hash
+		{"stale hash", func(event *models.AuditEvent) string { return event.Hash }},
+		// Tính lại hash nhưng không có master key
+		{"wrong key", func(event *models.AuditEvent) string { return event.ComputeHash([]byte("guess"), event.PrevHash) }},
+		// Hạ cấp về hash không khóa sau khi chuỗi đã dùng HMAC
+		{"legacy hash", func(event *models.AuditEvent) string { return event.ComputeLegacyHash(event.PrevHash) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			openTestDB(t)
+			insertTestEvents(t, 3)
+			dropAuditTriggers(t)
+
+			event, err := scanAuditEvent(DB.QueryRow("SELECT " + auditColumns + " FROM audit_events WHERE id = 2"))
+			if err != nil {
+				t.Fatalf("scan: %v", err)
+			}
+			event.Details = "tampered"
+			if _, err := DB.Exec("UPDATE audit_events SET details = ?, hash = ? WHERE id = 2", event.Details, tt.rewrite(event)); err != nil {
+				t.Fatalf("update: %v", err)
+			}
+
+			status, err := VerifyAuditChain()
+			if err != nil {
+				t.Fatalf("VerifyAuditChain: %v", err)
+			}
+			if status.BrokenID != 2 {
+				t.Errorf("BrokenID = %d, want 2", status.BrokenID)
+			}
+		})
+	}
+}
+
+func TestVerifyAuditChainAcceptsLegacyPrefix(t *testing.T) {
+	openTestDB(t)
+
+	// Hai bản ghi của phiên bản cũ (hash không khóa) rồi tới các bản ghi HMAC
+	prevHash := ""
+	for i := 0; i < 2; i++ {
+		event := &models.AuditEvent{CreatedAt: time.Now().UTC(), Actor: "admin", Action: "legacy", Result: models.AuditResultSuccess}
+		event.Hash = event.ComputeLegacyHash(prevHash)
+		if _, err := DB.Exec(
+			`INSERT INTO audit_events (created_at, actor, action, target, source_ip, user_agent, result, details, prev_hash, hash)
+			VALUES (?, ?, ?, '', '', '', ?, '', ?, ?)`,
+			event.CreatedAt, event.Actor, event.Action, event.Result, prevHash, event.Hash,
+		); err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+		prevHash = event.Hash
+	}
+	insertTestEvents(t, 2)
+
+	status, err := VerifyAuditChain()
+	if err != nil {
+		t.Fatalf("VerifyAuditChain: %v", err)
+	}
+	if status.BrokenID != 0 || status.Count != 4 {
+		t.Errorf("status = %+v, want intact chain of 4 events", status)
+	}
+}
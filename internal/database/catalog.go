@@ -0,0 +1,321 @@
+package database
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/backup-cronjob/internal/models"
+)
+
+// SaveBackupRecord thêm hoặc cập nhật (theo đường dẫn) bản ghi backup trong catalog
+// cùng thông tin các database và bảng tại thời điểm dump. databases nil nghĩa là giữ nguyên thông tin cũ.
+func SaveBackupRecord(record *models.BackupRecord, databases []models.DatabaseStats) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = time.Now()
+	}
+	if record.Kind == "" {
+		record.Kind = models.BackupKindLogical
+	}
+
+	if databases != nil {
+		record.DatabaseSize = 0
+		for _, db := range databases {
+			record.DatabaseSize += db.SizeBytes
+		}
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO backups (job, path, size, created_at, server_version, database_size, duration_ms, kind, wal_start)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(path) DO UPDATE SET job = excluded.job, size = excluded.size,
+			server_version = excluded.server_version, database_size = excluded.database_size,
+			duration_ms = excluded.duration_ms, kind = excluded.kind, wal_start = excluded.wal_start`,
+		record.Job, record.Path, record.Size, record.CreatedAt, record.ServerVersion, record.DatabaseSize, record.DurationMs,
+		record.Kind, record.WALStart,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err = tx.QueryRow("SELECT id FROM backups WHERE path = ?", record.Path).Scan(&record.ID); err != nil {
+		return err
+	}
+
+	if databases != nil {
+		for _, table := range []string{"backup_tables", "backup_databases"} {
+			if _, err = tx.Exec("DELETE FROM "+table+" WHERE backup_id = ?", record.ID); err != nil {
+				return err
+			}
+		}
+		for _, db := range databases {
+			_, err = tx.Exec(
+				"INSERT INTO backup_databases (backup_id, database_name, size_bytes, extensions) VALUES (?, ?, ?, ?)",
+				record.ID, db.Name, db.SizeBytes, strings.Join(db.Extensions, ","),
+			)
+			if err != nil {
+				return err
+			}
+			for _, t := range db.Tables {
+				_, err = tx.Exec(
+					`INSERT INTO backup_tables (backup_id, database_name, schema_name, table_name, row_count, row_estimate, total_bytes)
+					VALUES (?, ?, ?, ?, ?, ?, ?)`,
+					record.ID, t.Database, t.Schema, t.Table, t.RowCount, t.RowEstimate, t.TotalBytes,
+				)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetBackupRecord lấy bản ghi backup theo đường dẫn file, trả về nil nếu không có
+func GetBackupRecord(path string) (*models.BackupRecord, error) {
+	row := DB.QueryRow("SELECT "+backupColumns+" FROM backups WHERE path = ?", path)
+	record, err := scanBackupRecord(row)
+	if isNoRows(err) {
+		return nil, nil
+	}
+	return record, err
+}
+
+// ListBackupRecords lấy tất cả bản ghi backup, trả về map theo đường dẫn file
+func ListBackupRecords() (map[string]*models.BackupRecord, error) {
+	rows, err := DB.Query("SELECT " + backupColumns + " FROM backups")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := make(map[string]*models.BackupRecord)
+	for rows.Next() {
+		record, err := scanBackupRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		records[record.Path] = record
+	}
+	return records, rows.Err()
+}
+
+// ListJobBackupRecords lấy các bản ghi backup của job theo loại (rỗng = mọi loại), mới nhất trước
+func ListJobBackupRecords(job, kind string) ([]*models.BackupRecord, error) {
+	rows, err := DB.Query(
+		"SELECT "+backupColumns+" FROM backups WHERE job = ? AND (? = '' OR kind = ?) ORDER BY created_at DESC",
+		job, kind, kind,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []*models.BackupRecord
+	for rows.Next() {
+		record, err := scanBackupRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
+// ListBaselineRecords lấy tối đa limit bản backup gần nhất của job (trừ bản backup excludePath
+// và các bản bị đánh dấu bất thường) để làm baseline phát hiện bất thường, mới nhất trước
+func ListBaselineRecords(job, excludePath string, limit int) ([]*models.BackupRecord, error) {
+	rows, err := DB.Query(
+		"SELECT "+backupColumns+" FROM backups WHERE job = ? AND path <> ? AND anomaly = '' ORDER BY created_at DESC LIMIT ?",
+		job, excludePath, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []*models.BackupRecord
+	for rows.Next() {
+		record, err := scanBackupRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
+// SetBackupAnomaly ghi lý do bản backup bị đánh dấu bất thường (rỗng để bỏ đánh dấu)
+func SetBackupAnomaly(path, anomaly string) error {
+	_, err := DB.Exec("UPDATE backups SET anomaly = ? WHERE path = ?", anomaly, path)
+	return err
+}
+
+// SetBackupUploaded ghi thời điểm bản backup được upload lên đích từ xa
+// (bỏ qua nếu bản backup chưa có trong catalog)
+func SetBackupUploaded(path string, uploadedAt time.Time) error {
+	_, err := DB.Exec("UPDATE backups SET uploaded_at = ? WHERE path = ?", uploadedAt, path)
+	return err
+}
+
+// LastBackupTimes trả về thời điểm tạo và thời điểm upload của bản backup mới nhất của từng job
+func LastBackupTimes() (created map[string]time.Time, uploaded map[string]time.Time, err error) {
+	created = make(map[string]time.Time)
+	uploaded = make(map[string]time.Time)
+
+	// Truy vấn cột gốc (không dùng MAX) để driver giữ kiểu DATETIME khi scan
+	for _, q := range []struct {
+		column string
+		result map[string]time.Time
+	}{
+		{"created_at", created},
+		{"uploaded_at", uploaded},
+	} {
+		rows, err := DB.Query(
+			"SELECT b.job, b." + q.column + " FROM backups b WHERE b." + q.column + " = (SELECT MAX(" + q.column + ") FROM backups WHERE job = b.job)",
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+		for rows.Next() {
+			var (
+				job string
+				t   time.Time
+			)
+			if err := rows.Scan(&job, &t); err != nil {
+				rows.Close()
+				return nil, nil, err
+			}
+			q.result[job] = t
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, nil, err
+		}
+	}
+	return created, uploaded, nil
+}
+
+// GetBackupRecordByID lấy bản ghi backup theo ID, trả về nil nếu không có
+func GetBackupRecordByID(id int64) (*models.BackupRecord, error) {
+	row := DB.QueryRow("SELECT "+backupColumns+" FROM backups WHERE id = ?", id)
+	record, err := scanBackupRecord(row)
+	if isNoRows(err) {
+		return nil, nil
+	}
+	return record, err
+}
+
+// GetBackupDatabases lấy thông tin các database (kèm các bảng) của bản backup
+func GetBackupDatabases(backupID int64) ([]models.DatabaseStats, error) {
+	rows, err := DB.Query(
+		"SELECT database_name, size_bytes, extensions FROM backup_databases WHERE backup_id = ? ORDER BY database_name",
+		backupID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var (
+		databases []models.DatabaseStats
+		index     = make(map[string]int)
+	)
+	for rows.Next() {
+		var (
+			db         models.DatabaseStats
+			extensions string
+		)
+		if err := rows.Scan(&db.Name, &db.SizeBytes, &extensions); err != nil {
+			return nil, err
+		}
+		if extensions != "" {
+			db.Extensions = strings.Split(extensions, ",")
+		}
+		index[db.Name] = len(databases)
+		databases = append(databases, db)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	tables, err := GetBackupTables(backupID)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tables {
+		i, ok := index[t.Database]
+		if !ok {
+			// Bản ghi cũ chỉ có số dòng của các bảng
+			i = len(databases)
+			index[t.Database] = i
+			databases = append(databases, models.DatabaseStats{Name: t.Database})
+		}
+		databases[i].Tables = append(databases[i].Tables, t)
+	}
+
+	return databases, nil
+}
+
+// GetBackupTables lấy thông tin các bảng của bản backup
+func GetBackupTables(backupID int64) ([]models.TableStat, error) {
+	rows, err := DB.Query(
+		`SELECT database_name, schema_name, table_name, row_count, row_estimate, total_bytes
+		FROM backup_tables WHERE backup_id = ? ORDER BY database_name, schema_name, table_name`,
+		backupID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []models.TableStat
+	for rows.Next() {
+		var t models.TableStat
+		if err := rows.Scan(&t.Database, &t.Schema, &t.Table, &t.RowCount, &t.RowEstimate, &t.TotalBytes); err != nil {
+			return nil, err
+		}
+		tables = append(tables, t)
+	}
+	return tables, rows.Err()
+}
+
+// UpdateVerifyStatus ghi kết quả kiểm tra khôi phục của bản backup
+func UpdateVerifyStatus(path, status, message string) error {
+	_, err := DB.Exec(
+		"UPDATE backups SET verify_status = ?, verify_message = ?, verified_at = ? WHERE path = ?",
+		status, message, time.Now(), path,
+	)
+	return err
+}
+
+// backupColumns là danh sách cột khi đọc bảng backups
+const backupColumns = "id, job, path, size, created_at, server_version, kind, wal_start, database_size, duration_ms, anomaly, verify_status, verify_message, verified_at, uploaded_at"
+
+// scanBackupRecord đọc một dòng của bảng backups
+func scanBackupRecord(row rowScanner) (*models.BackupRecord, error) {
+	var (
+		record     models.BackupRecord
+		verifiedAt sql.NullTime
+		uploadedAt sql.NullTime
+	)
+	err := row.Scan(&record.ID, &record.Job, &record.Path, &record.Size, &record.CreatedAt,
+		&record.ServerVersion, &record.Kind, &record.WALStart, &record.DatabaseSize, &record.DurationMs, &record.Anomaly, &record.VerifyStatus, &record.VerifyMessage, &verifiedAt, &uploadedAt)
+	if err != nil {
+		return nil, err
+	}
+	if verifiedAt.Valid {
+		record.VerifiedAt = &verifiedAt.Time
+	}
+	if uploadedAt.Valid {
+		record.UploadedAt = &uploadedAt.Time
+	}
+	return &record, nil
+}
@@ -2,11 +2,14 @@ package database
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"os"
 	"time"
 
 	"github.com/backup-cronjob/internal/config"
+	"github.com/backup-cronjob/internal/logging"
 	"github.com/backup-cronjob/internal/models"
 	_ "modernc.org/sqlite"
 )
@@ -16,10 +19,15 @@ var DB *sql.DB
 
 // InitDB khởi tạo kết nối database
 func InitDB(cfg *config.Config) error {
+	// Database đã được khởi tạo trước đó (CLI và web dùng chung)
+	if DB != nil {
+		return nil
+	}
+
 	var err error
 
 	// Kết nối đến database SQLite
-	DB, err = sql.Open("sqlite", cfg.SQLiteDBPath)
+	DB, err = sql.Open("sqlite", dsn(cfg.SQLiteDBPath))
 	if err != nil {
 		return fmt.Errorf("error connecting to SQLite database: %w", err)
 	}
@@ -29,32 +37,186 @@ func InitDB(cfg *config.Config) error {
 		return fmt.Errorf("error pinging SQLite database: %w", err)
 	}
 
+	// File database chứa dữ liệu nhạy cảm, chỉ chủ sở hữu được đọc/ghi
+	if err = os.Chmod(cfg.SQLiteDBPath, 0600); err != nil {
+		slog.Warn("Failed to restrict database file permissions", logging.KeyFile, cfg.SQLiteDBPath, logging.Err(err))
+	}
+
 	// Tạo schema
 	if err = createSchema(); err != nil {
 		return fmt.Errorf("error creating database schema: %w", err)
 	}
 
+	// Dọn dẹp lịch sử đăng nhập cũ hơn 30 ngày
+	if err = PruneLoginAttempts(time.Now().AddDate(0, 0, -30)); err != nil {
+		slog.Error("Failed to prune old login attempts", logging.Err(err))
+	}
+
 	// Kiểm tra và tạo tài khoản admin nếu chưa tồn tại
 	if err = ensureAdminExists(cfg); err != nil {
 		return fmt.Errorf("error ensuring admin user exists: %w", err)
 	}
 
-	log.Println("Database initialized successfully")
+	slog.Info("Database initialized", logging.KeyFile, cfg.SQLiteDBPath)
 	return nil
 }
 
+// dsn thêm các tùy chọn kết nối vào đường dẫn file SQLite. CLI và daemon có thể ghi cùng lúc nên
+// kết nối chờ khóa thay vì trả về SQLITE_BUSY ngay, và transaction lấy khóa ghi ngay từ BEGIN để
+// hai transaction không cùng đọc một trạng thái rồi tranh nhau ghi (ví dụ hash cuối của audit log).
+func dsn(path string) string {
+	return path + "?_pragma=busy_timeout(5000)&_txlock=immediate"
+}
+
 // createSchema tạo cấu trúc cơ sở dữ liệu nếu chưa tồn tại
 func createSchema() error {
-	// Tạo bảng users
-	_, err := DB.Exec(`
-		CREATE TABLE IF NOT EXISTS users (
+	statements := []string{
+		// Bảng users
+		`CREATE TABLE IF NOT EXISTS users (
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			username TEXT NOT NULL UNIQUE,
 			password TEXT NOT NULL,
 			created_at DATETIME NOT NULL,
 			updated_at DATETIME NOT NULL
-		)
-	`)
+		)`,
+		// Bảng lưu các lần đăng nhập để phát hiện brute-force
+		`CREATE TABLE IF NOT EXISTS login_attempts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			username TEXT NOT NULL,
+			ip TEXT NOT NULL,
+			success INTEGER NOT NULL,
+			created_at DATETIME NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_login_attempts_username ON login_attempts (username, created_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_login_attempts_ip ON login_attempts (ip, created_at)`,
+		// Bảng audit log chỉ cho phép thêm mới, mỗi bản ghi nối hash với bản ghi trước
+		`CREATE TABLE IF NOT EXISTS audit_events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			created_at DATETIME NOT NULL,
+			actor TEXT NOT NULL,
+			action TEXT NOT NULL,
+			target TEXT NOT NULL DEFAULT '',
+			source_ip TEXT NOT NULL DEFAULT '',
+			user_agent TEXT NOT NULL DEFAULT '',
+			result TEXT NOT NULL,
+			details TEXT NOT NULL DEFAULT '',
+			prev_hash TEXT NOT NULL,
+			hash TEXT NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_audit_events_action ON audit_events (action, created_at)`,
+		`CREATE TRIGGER IF NOT EXISTS audit_events_no_update BEFORE UPDATE ON audit_events
+		BEGIN SELECT RAISE(ABORT, 'audit_events is append-only'); END`,
+		`CREATE TRIGGER IF NOT EXISTS audit_events_no_delete BEFORE DELETE ON audit_events
+		BEGIN SELECT RAISE(ABORT, 'audit_events is append-only'); END`,
+		// Bảng lưu OAuth token đã được mã hóa bằng master key
+		`CREATE TABLE IF NOT EXISTS oauth_tokens (
+			name TEXT PRIMARY KEY,
+			ciphertext BLOB NOT NULL,
+			updated_at DATETIME NOT NULL
+		)`,
+		// Catalog các bản backup: thông tin thu thập lúc dump và kết quả kiểm tra khôi phục
+		`CREATE TABLE IF NOT EXISTS backups (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			job TEXT NOT NULL,
+			path TEXT NOT NULL UNIQUE,
+			size INTEGER NOT NULL,
+			created_at DATETIME NOT NULL,
+			server_version TEXT NOT NULL DEFAULT '',
+			verify_status TEXT NOT NULL DEFAULT '',
+			verify_message TEXT NOT NULL DEFAULT '',
+			verified_at DATETIME
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_backups_job ON backups (job, created_at)`,
+		// Số dòng của từng bảng trong database nguồn tại thời điểm dump
+		`CREATE TABLE IF NOT EXISTS backup_tables (
+			backup_id INTEGER NOT NULL REFERENCES backups (id),
+			database_name TEXT NOT NULL DEFAULT '',
+			schema_name TEXT NOT NULL,
+			table_name TEXT NOT NULL,
+			row_count INTEGER NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_backup_tables_backup ON backup_tables (backup_id)`,
+		// Dung lượng và extension của từng database nguồn tại thời điểm dump
+		`CREATE TABLE IF NOT EXISTS backup_databases (
+			backup_id INTEGER NOT NULL REFERENCES backups (id),
+			database_name TEXT NOT NULL,
+			size_bytes INTEGER NOT NULL DEFAULT 0,
+			extensions TEXT NOT NULL DEFAULT ''
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_backup_databases_backup ON backup_databases (backup_id)`,
+		// Các segment WAL đã lưu trữ của job ở chế độ physical
+		`CREATE TABLE IF NOT EXISTS wal_segments (
+			job TEXT NOT NULL,
+			name TEXT NOT NULL,
+			size INTEGER NOT NULL,
+			archived_at DATETIME NOT NULL,
+			uploaded INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (job, name)
+		)`,
+		// Các lần chạy job kèm log của từng lần chạy (gồm stderr của pg_dump)
+		`CREATE TABLE IF NOT EXISTS runs (
+			id TEXT PRIMARY KEY,
+			job TEXT NOT NULL,
+			status TEXT NOT NULL,
+			started_at DATETIME NOT NULL,
+			finished_at DATETIME NOT NULL,
+			backup_path TEXT NOT NULL DEFAULT '',
+			error TEXT NOT NULL DEFAULT '',
+			log TEXT NOT NULL DEFAULT ''
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_runs_job ON runs (job, started_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_runs_backup ON runs (backup_path)`,
+	}
+
+	for _, stmt := range statements {
+		if _, err := DB.Exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	// Các cột được bổ sung sau khi bảng đã tồn tại
+	columns := []struct{ table, column, definition string }{
+		{"users", "role", "TEXT NOT NULL DEFAULT 'admin'"},
+		{"users", "locale", "TEXT NOT NULL DEFAULT ''"},
+		{"backups", "database_size", "INTEGER NOT NULL DEFAULT 0"},
+		{"backups", "duration_ms", "INTEGER NOT NULL DEFAULT 0"},
+		{"backups", "anomaly", "TEXT NOT NULL DEFAULT ''"},
+		{"backups", "kind", "TEXT NOT NULL DEFAULT 'logical'"},
+		{"backups", "wal_start", "TEXT NOT NULL DEFAULT ''"},
+		{"backups", "uploaded_at", "DATETIME"},
+		{"backup_tables", "row_estimate", "INTEGER NOT NULL DEFAULT 0"},
+		{"backup_tables", "total_bytes", "INTEGER NOT NULL DEFAULT 0"},
+	}
+	for _, c := range columns {
+		if err := addColumnIfMissing(c.table, c.column, c.definition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addColumnIfMissing thêm cột vào bảng nếu cột chưa tồn tại (migration đơn giản)
+func addColumnIfMissing(table, column, definition string) error {
+	rows, err := DB.Query("SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	_, err = DB.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
 	return err
 }
 
@@ -78,14 +240,14 @@ func ensureAdminExists(cfg *config.Config) error {
 		// Tạo admin
 		now := time.Now()
 		_, err = DB.Exec(
-			"INSERT INTO users (username, password, created_at, updated_at) VALUES (?, ?, ?, ?)",
-			cfg.AdminUsername, hashedPassword, now, now,
+			"INSERT INTO users (username, password, role, created_at, updated_at) VALUES (?, ?, ?, ?, ?)",
+			cfg.AdminUsername, hashedPassword, models.RoleAdmin, now, now,
 		)
 		if err != nil {
 			return err
 		}
 
-		log.Printf("Admin user '%s' created successfully", cfg.AdminUsername)
+		slog.Info("Admin user created", "user", cfg.AdminUsername)
 	}
 
 	return nil
@@ -95,9 +257,9 @@ func ensureAdminExists(cfg *config.Config) error {
 func GetUserByUsername(username string) (*models.User, error) {
 	user := &models.User{}
 	err := DB.QueryRow(
-		"SELECT id, username, password, created_at, updated_at FROM users WHERE username = ?",
+		"SELECT id, username, password, role, locale, created_at, updated_at FROM users WHERE username = ?",
 		username,
-	).Scan(&user.ID, &user.Username, &user.Password, &user.CreatedAt, &user.UpdatedAt)
+	).Scan(&user.ID, &user.Username, &user.Password, &user.Role, &user.Locale, &user.CreatedAt, &user.UpdatedAt)
 
 	if err != nil {
 		return nil, err
@@ -106,9 +268,155 @@ func GetUserByUsername(username string) (*models.User, error) {
 	return user, nil
 }
 
+// SetUserLocale lưu ngôn ngữ người dùng chọn (rỗng = theo trình duyệt hoặc LOCALE)
+func SetUserLocale(userID int64, locale string) error {
+	_, err := DB.Exec("UPDATE users SET locale = ?, updated_at = ? WHERE id = ?", locale, time.Now(), userID)
+	return err
+}
+
+// ListUsers trả về tất cả người dùng theo thứ tự username
+func ListUsers() ([]*models.User, error) {
+	rows, err := DB.Query("SELECT id, username, role, locale, created_at, updated_at FROM users ORDER BY username")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []*models.User
+	for rows.Next() {
+		user := &models.User{}
+		if err := rows.Scan(&user.ID, &user.Username, &user.Role, &user.Locale, &user.CreatedAt, &user.UpdatedAt); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// CreateUser tạo người dùng mới; passwordHash là mật khẩu đã mã hóa bằng models.HashPassword
+func CreateUser(username, passwordHash, role string) error {
+	now := time.Now()
+	_, err := DB.Exec(
+		"INSERT INTO users (username, password, role, created_at, updated_at) VALUES (?, ?, ?, ?, ?)",
+		username, passwordHash, role, now, now,
+	)
+	return err
+}
+
+// SetUserPassword đổi mật khẩu người dùng, trả về sql.ErrNoRows nếu không có người dùng
+func SetUserPassword(username, passwordHash string) error {
+	return updateUser("UPDATE users SET password = ?, updated_at = ? WHERE username = ?", passwordHash, time.Now(), username)
+}
+
+// DeleteUser xóa người dùng, trả về sql.ErrNoRows nếu không có người dùng
+func DeleteUser(username string) error {
+	return updateUser("DELETE FROM users WHERE username = ?", username)
+}
+
+// updateUser thực thi câu lệnh thay đổi một người dùng, trả về sql.ErrNoRows nếu không có dòng nào bị ảnh hưởng
+func updateUser(query string, args ...interface{}) error {
+	res, err := DB.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// RecordLoginAttempt ghi lại một lần đăng nhập (thành công hoặc thất bại)
+func RecordLoginAttempt(username, ip string, success bool) error {
+	_, err := DB.Exec(
+		"INSERT INTO login_attempts (username, ip, success, created_at) VALUES (?, ?, ?, ?)",
+		username, ip, success, time.Now(),
+	)
+	return err
+}
+
+// CountRecentFailures đếm số lần đăng nhập thất bại liên tiếp (kể từ lần thành công gần nhất)
+// theo username hoặc IP, chỉ tính các lần sau mốc since. Trả về số lần và thời điểm thất bại cuối cùng.
+func CountRecentFailures(field, value string, since time.Time) (int, time.Time, error) {
+	if field != "username" && field != "ip" {
+		return 0, time.Time{}, fmt.Errorf("invalid login attempt field: %s", field)
+	}
+
+	rows, err := DB.Query(
+		"SELECT success, created_at FROM login_attempts WHERE "+field+" = ? ORDER BY id DESC LIMIT 100",
+		value,
+	)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	defer rows.Close()
+
+	var (
+		count       int
+		lastFailure time.Time
+	)
+	for rows.Next() {
+		var (
+			success   bool
+			createdAt time.Time
+		)
+		if err := rows.Scan(&success, &createdAt); err != nil {
+			return 0, time.Time{}, err
+		}
+
+		// Dừng khi gặp lần đăng nhập thành công hoặc bản ghi đã quá cũ
+		if success || createdAt.Before(since) {
+			break
+		}
+
+		if count == 0 {
+			lastFailure = createdAt
+		}
+		count++
+	}
+
+	return count, lastFailure, rows.Err()
+}
+
+// PruneLoginAttempts xóa các bản ghi đăng nhập cũ hơn mốc before
+func PruneLoginAttempts(before time.Time) error {
+	_, err := DB.Exec("DELETE FROM login_attempts WHERE created_at < ?", before)
+	return err
+}
+
+// SaveOAuthToken lưu (hoặc thay thế) OAuth token đã mã hóa theo tên
+func SaveOAuthToken(name string, ciphertext []byte) error {
+	_, err := DB.Exec(
+		`INSERT INTO oauth_tokens (name, ciphertext, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET ciphertext = excluded.ciphertext, updated_at = excluded.updated_at`,
+		name, ciphertext, time.Now(),
+	)
+	return err
+}
+
+// GetOAuthToken lấy OAuth token đã mã hóa theo tên
+func GetOAuthToken(name string) ([]byte, error) {
+	var ciphertext []byte
+	err := DB.QueryRow("SELECT ciphertext FROM oauth_tokens WHERE name = ?", name).Scan(&ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	return ciphertext, nil
+}
+
+// DeleteOAuthToken xóa OAuth token theo tên
+func DeleteOAuthToken(name string) error {
+	_, err := DB.Exec("DELETE FROM oauth_tokens WHERE name = ?", name)
+	return err
+}
+
 // Close đóng kết nối đến database
 func Close() {
 	if DB != nil {
 		DB.Close()
 	}
 }
+
+// isNoRows kiểm tra lỗi truy vấn không có kết quả
+func isNoRows(err error) bool {
+	return errors.Is(err, sql.ErrNoRows)
+}
@@ -0,0 +1,76 @@
+package database
+
+import (
+	"github.com/backup-cronjob/internal/models"
+)
+
+// runColumns là các cột của bảng runs (trừ log) theo thứ tự quét khi đọc
+const runColumns = "id, job, status, started_at, finished_at, backup_path, error"
+
+// SaveRun lưu bản ghi một lần chạy job và chỉ giữ lại keep lần chạy mới nhất của job (keep <= 0: giữ tất cả)
+func SaveRun(run *models.RunRecord, keep int) error {
+	_, err := DB.Exec(
+		"INSERT OR REPLACE INTO runs ("+runColumns+", log) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		run.ID, run.Job, run.Status, run.StartedAt, run.FinishedAt, run.BackupPath, run.Error, run.Log,
+	)
+	if err != nil || keep <= 0 {
+		return err
+	}
+
+	_, err = DB.Exec(
+		`DELETE FROM runs WHERE job = ? AND id NOT IN (
+			SELECT id FROM runs WHERE job = ? ORDER BY started_at DESC LIMIT ?
+		)`,
+		run.Job, run.Job, keep,
+	)
+	return err
+}
+
+// ListRuns lấy các lần chạy (không kèm log) mới nhất trước, lọc theo job nếu job khác rỗng
+func ListRuns(job string, limit int) ([]*models.RunRecord, error) {
+	query := "SELECT " + runColumns + " FROM runs"
+	var args []interface{}
+	if job != "" {
+		query += " WHERE job = ?"
+		args = append(args, job)
+	}
+	query += " ORDER BY started_at DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := make([]*models.RunRecord, 0)
+	for rows.Next() {
+		var run models.RunRecord
+		if err := rows.Scan(&run.ID, &run.Job, &run.Status, &run.StartedAt, &run.FinishedAt, &run.BackupPath, &run.Error); err != nil {
+			return nil, err
+		}
+		runs = append(runs, &run)
+	}
+	return runs, rows.Err()
+}
+
+// GetRun lấy một lần chạy kèm log theo run ID
+func GetRun(id string) (*models.RunRecord, error) {
+	return getRun("id = ?", id)
+}
+
+// GetRunByBackup lấy lần chạy (kèm log) đã tạo ra file backup path
+func GetRunByBackup(path string) (*models.RunRecord, error) {
+	return getRun("backup_path = ? ORDER BY started_at DESC LIMIT 1", path)
+}
+
+func getRun(where string, arg interface{}) (*models.RunRecord, error) {
+	var run models.RunRecord
+	err := DB.QueryRow("SELECT "+runColumns+", log FROM runs WHERE "+where, arg).Scan(
+		&run.ID, &run.Job, &run.Status, &run.StartedAt, &run.FinishedAt, &run.BackupPath, &run.Error, &run.Log,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
@@ -0,0 +1,48 @@
+package database
+
+import (
+	"github.com/backup-cronjob/internal/models"
+)
+
+// SaveWALSegment thêm segment WAL vừa lưu trữ vào catalog (bỏ qua nếu đã có)
+func SaveWALSegment(segment *models.WALSegment) error {
+	_, err := DB.Exec(
+		"INSERT OR IGNORE INTO wal_segments (job, name, size, archived_at, uploaded) VALUES (?, ?, ?, ?, ?)",
+		segment.Job, segment.Name, segment.Size, segment.ArchivedAt, segment.Uploaded,
+	)
+	return err
+}
+
+// ListWALSegments lấy các segment WAL của job, sắp xếp theo tên (thứ tự WAL)
+func ListWALSegments(job string) ([]*models.WALSegment, error) {
+	rows, err := DB.Query(
+		"SELECT job, name, size, archived_at, uploaded FROM wal_segments WHERE job = ? ORDER BY name",
+		job,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var segments []*models.WALSegment
+	for rows.Next() {
+		var segment models.WALSegment
+		if err := rows.Scan(&segment.Job, &segment.Name, &segment.Size, &segment.ArchivedAt, &segment.Uploaded); err != nil {
+			return nil, err
+		}
+		segments = append(segments, &segment)
+	}
+	return segments, rows.Err()
+}
+
+// MarkWALUploaded đánh dấu segment WAL đã được upload lên các đích từ xa
+func MarkWALUploaded(job, name string) error {
+	_, err := DB.Exec("UPDATE wal_segments SET uploaded = 1 WHERE job = ? AND name = ?", job, name)
+	return err
+}
+
+// DeleteWALSegment xóa segment WAL khỏi catalog
+func DeleteWALSegment(job, name string) error {
+	_, err := DB.Exec("DELETE FROM wal_segments WHERE job = ? AND name = ?", job, name)
+	return err
+}
@@ -0,0 +1,235 @@
+package dbdump
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/backup-cronjob/internal/config"
+	"github.com/backup-cronjob/internal/i18n"
+	"github.com/backup-cronjob/internal/logging"
+)
+
+// listDatabases liệt kê các database có thể kết nối trong cluster (bỏ qua template)
+func (d *DatabaseDumper) listDatabases(ctx context.Context, job *config.Job) ([]string, error) {
+	var stdout bytes.Buffer
+	stderr, err := d.run(ctx, job, &stdout,
+		"psql", "-U", job.DBUser, "-d", job.DBName, "-Atc",
+		"SELECT datname FROM pg_database WHERE datallowconn AND NOT datistemplate ORDER BY datname",
+	)
+	if err != nil {
+		return nil, i18n.Errorf(i18n.ErrDumpListDatabases, err, strings.TrimSpace(stderr))
+	}
+
+	var databases []string
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		if name := strings.TrimSpace(line); name != "" {
+			databases = append(databases, name)
+		}
+	}
+	return databases, nil
+}
+
+// dumpCluster dump tất cả database được chọn cùng các đối tượng toàn cục (roles, tablespaces)
+// và đóng gói thành một file <job>_<timestamp>_cluster.tar.gz kèm manifest.json.
+// Lỗi của từng database được ghi vào manifest và Warnings, không làm dừng các database khác.
+func (d *DatabaseDumper) dumpCluster(ctx context.Context, job *config.Job, backupDir, timestamp string, result *DumpResult) (*DumpResult, error) {
+	logger := logging.From(ctx)
+	databases, err := d.listDatabases(ctx, job)
+	if err != nil {
+		return fail(result, err)
+	}
+
+	// Thư mục tạm chứa các file dump trước khi đóng gói
+	workDir, err := os.MkdirTemp(backupDir, ".cluster-")
+	if err != nil {
+		return fail(result, i18n.Errorf(i18n.ErrDumpTempDir, err))
+	}
+	defer os.RemoveAll(workDir)
+
+	manifest := &Manifest{
+		Job:       job.Name,
+		Mode:      config.ModeCluster,
+		Container: job.ContainerName,
+		Host:      job.Connection.Host,
+		CreatedAt: time.Now(),
+	}
+
+	// Dump roles và tablespaces
+	logger.Info("Dumping cluster globals (roles, tablespaces)")
+	globals := d.dumpClusterEntry(workDir, "globals", "globals.sql", func(out io.Writer) (string, error) {
+		return d.run(ctx, job, out, "pg_dumpall", "--globals-only", "-U", job.DBUser)
+	})
+	manifest.Globals = &globals
+	if !globals.Success {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("globals: %s", manifest.Globals.Error))
+	}
+
+	// Dump từng database
+	succeeded := 0
+	for _, dbName := range databases {
+		if !job.MatchDatabase(dbName) {
+			continue
+		}
+
+		logger.Info("Running pg_dump", "database", dbName)
+		entry := d.dumpClusterEntry(workDir, dbName, filepath.Join("databases", dbName+".sql"), func(out io.Writer) (string, error) {
+			args := []string{"pg_dump"}
+			args = append(args, job.DumpOptions...)
+			args = append(args, "-U", job.DBUser, "-d", dbName)
+			return d.run(ctx, job, out, args...)
+		})
+		if entry.Success {
+			succeeded++
+			entry.Stats = d.collectStats(ctx, job, dbName, result)
+			result.Databases = append(result.Databases, *entry.Stats)
+			d.captureSchema(ctx, job, &entry, result)
+		} else {
+			logger.Warn("Dump of database failed", "database", dbName, logging.KeyError, entry.Error)
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s: %s", dbName, entry.Error))
+		}
+		manifest.Databases = append(manifest.Databases, entry)
+	}
+
+	if len(manifest.Databases) == 0 {
+		return fail(result, i18n.Errorf(i18n.ErrDumpNoDatabases))
+	}
+
+	if succeeded == 0 {
+		return fail(result, i18n.Errorf(i18n.ErrDumpAllFailed, len(manifest.Databases)))
+	}
+
+	manifest.ServerVersion = result.ServerVersion
+
+	// Đóng gói thành một file archive
+	outputFile := filepath.Join(backupDir, fmt.Sprintf("%s_%s_cluster.tar.gz", job.Name, timestamp))
+	if err := writeClusterArchive(outputFile, workDir, manifest); err != nil {
+		os.Remove(outputFile)
+		return fail(result, i18n.Errorf(i18n.ErrDumpArchive, err))
+	}
+
+	fileInfo, err := os.Stat(outputFile)
+	if err != nil {
+		return fail(result, i18n.Errorf(i18n.ErrDumpOutputMissing, outputFile, err))
+	}
+
+	logger.Info("Cluster dump finished", logging.KeyFile, outputFile, "size", fileInfo.Size(),
+		"succeeded", succeeded, "databases", len(manifest.Databases))
+
+	result.FilePath = outputFile
+	result.FileSize = fileInfo.Size()
+	result.Success = true
+	result.Message = i18n.T("", "dump.cluster_success", succeeded, len(manifest.Databases))
+
+	return result, nil
+}
+
+// dumpClusterEntry chạy một lệnh dump, ghi kết quả vào workDir/file và trả về thông tin cho manifest
+func (d *DatabaseDumper) dumpClusterEntry(workDir, name, file string, run func(out io.Writer) (string, error)) ManifestEntry {
+	entry := ManifestEntry{Name: name, File: file}
+
+	path := filepath.Join(workDir, file)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		entry.Error = err.Error()
+		return entry
+	}
+
+	out, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		entry.Error = err.Error()
+		return entry
+	}
+
+	stderr, err := run(out)
+	out.Close()
+	if err != nil {
+		os.Remove(path)
+		entry.File = ""
+		entry.Error = strings.TrimSpace(err.Error() + ": " + stderr)
+		return entry
+	}
+
+	if info, err := os.Stat(path); err == nil {
+		entry.Size = info.Size()
+	}
+	entry.Success = true
+	return entry
+}
+
+// writeClusterArchive ghi manifest.json và các file dump thành công vào file tar.gz
+func writeClusterArchive(outputFile, workDir string, manifest *Manifest) error {
+	f, err := os.OpenFile(outputFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeTarEntry(tw, ManifestFile, bytes.NewReader(manifestData), int64(len(manifestData)), manifest.CreatedAt); err != nil {
+		return err
+	}
+
+	entries := append([]ManifestEntry{*manifest.Globals}, manifest.Databases...)
+	for _, entry := range entries {
+		if !entry.Success {
+			continue
+		}
+		if err := addFileToTar(tw, filepath.Join(workDir, entry.File), entry.File); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// addFileToTar thêm file trên đĩa vào tar với tên name
+func addFileToTar(tw *tar.Writer, path, name string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	return writeTarEntry(tw, filepath.ToSlash(name), file, info.Size(), info.ModTime())
+}
+
+// writeTarEntry ghi một file thường vào tar
+func writeTarEntry(tw *tar.Writer, name string, r io.Reader, size int64, modTime time.Time) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    size,
+		ModTime: modTime,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+
+	_, err := io.Copy(tw, r)
+	return err
+}
@@ -1,134 +1,218 @@
 package dbdump
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/backup-cronjob/internal/config"
+	"github.com/backup-cronjob/internal/docker"
+	"github.com/backup-cronjob/internal/i18n"
+	"github.com/backup-cronjob/internal/logging"
+	"github.com/backup-cronjob/internal/models"
 )
 
 // DumpResult chứa thông tin kết quả dump
 type DumpResult struct {
+	Job      string
 	FilePath string
 	FileSize int64
 	Success  bool
 	Message  string
+	// Warnings chứa các lỗi không làm hỏng toàn bộ bản backup (vd: một database trong cluster)
+	Warnings []string
+
+	// Thông tin database nguồn thu thập lúc dump
+	ServerVersion string
+	Databases     []models.DatabaseStats
+	// WALStart là segment WAL đầu tiên cần để khôi phục bản sao lưu vật lý (chế độ physical)
+	WALStart string
+}
+
+// Tables trả về thông tin các bảng của tất cả database đã dump
+func (r *DumpResult) Tables() []models.TableStat {
+	var tables []models.TableStat
+	for _, db := range r.Databases {
+		tables = append(tables, db.Tables...)
+	}
+	return tables
 }
 
 // DatabaseDumper là struct quản lý việc dump database
 type DatabaseDumper struct {
 	Config *config.Config
+	Docker *docker.Client
 }
 
 // NewDatabaseDumper tạo instance mới của DatabaseDumper
 func NewDatabaseDumper(cfg *config.Config) *DatabaseDumper {
 	return &DatabaseDumper{
 		Config: cfg,
+		Docker: docker.NewClient(cfg.DockerHost),
 	}
 }
 
-// DumpDatabase thực hiện việc dump database từ container Docker
-func (d *DatabaseDumper) DumpDatabase() (*DumpResult, error) {
+// DumpDatabase thực hiện việc dump database của job (qua docker exec hoặc kết nối mạng tùy cấu hình)
+func (d *DatabaseDumper) DumpDatabase(ctx context.Context, job *config.Job) (*DumpResult, error) {
 	result := &DumpResult{
+		Job:     job.Name,
 		Success: false,
 	}
+	logger := logging.From(ctx)
 
-	// Tạo thư mục backup theo ngày
+	// Tạo thư mục backup theo job và ngày
 	now := time.Now()
-	dateFolder := now.Format("2006-01-02")
 	timestamp := now.Format("20060102_150405")
 
-	backupDir := filepath.Join(d.Config.BackupDir, dateFolder)
-	if err := os.MkdirAll(backupDir, 0755); err != nil {
-		errMsg := fmt.Sprintf("Không thể tạo thư mục backup: %v", err)
-		result.Message = errMsg
-		return result, fmt.Errorf(errMsg)
+	backupDir := models.BackupDirFor(d.Config.BackupDir, job.Name, now)
+	if err := os.MkdirAll(backupDir, 0700); err != nil {
+		return fail(result, i18n.Errorf(i18n.ErrDumpBackupDir, err))
 	}
 
-	fmt.Printf("Đã tạo thư mục backup: %s\n", backupDir)
+	logger.Debug("Backup directory ready", "dir", backupDir)
 
-	// Tạo tên file output
-	outputFile := filepath.Join(backupDir, fmt.Sprintf("%s_%s_data.sql", d.Config.DBName, timestamp))
-
-	// Tạo lệnh dump database
-	cmd := exec.Command(
-		"docker", "exec",
-		"-e", fmt.Sprintf("PGPASSWORD=%s", d.Config.DBPassword),
-		d.Config.ContainerName,
-		"pg_dump",
-		"-v",
-		"--data-only",
-		"--column-inserts",
-		"--disable-triggers",
-		"-U", d.Config.DBUser,
-		"-d", d.Config.DBName,
-	)
-
-	fmt.Println("Đang thực hiện lệnh dump...")
-
-	// Tạo file output
-	outFile, err := os.Create(outputFile)
-	if err != nil {
-		errMsg := fmt.Sprintf("Không thể tạo file output: %v", err)
-		result.Message = errMsg
-		return result, fmt.Errorf(errMsg)
+	switch job.Mode {
+	case config.ModeCluster:
+		return d.dumpCluster(ctx, job, backupDir, timestamp, result)
+	case config.ModePhysical:
+		return d.dumpBase(ctx, job, backupDir, timestamp, result)
 	}
-	defer outFile.Close()
 
-	// Thiết lập output, stderr
-	cmd.Stdout = outFile
-	stderrPipe, err := cmd.StderrPipe()
-	if err != nil {
-		errMsg := fmt.Sprintf("Không thể thiết lập stderr pipe: %v", err)
-		result.Message = errMsg
-		return result, fmt.Errorf(errMsg)
-	}
-
-	// Thực thi lệnh
-	if err := cmd.Start(); err != nil {
-		errMsg := fmt.Sprintf("Không thể khởi động lệnh: %v", err)
-		result.Message = errMsg
-		return result, fmt.Errorf(errMsg)
-	}
+	// Tạo tên file output
+	outputFile := filepath.Join(backupDir, fmt.Sprintf("%s_%s_data.sql", job.DBName, timestamp))
 
-	// Đọc stderr
-	stderrBytes, _ := io.ReadAll(stderrPipe)
-	stderrOutput := string(stderrBytes)
+	logger.Info("Running pg_dump", "database", job.DBName)
 
-	// Đợi lệnh hoàn thành
-	if err := cmd.Wait(); err != nil {
-		fmt.Printf("Lỗi trong stderr: %s\n", stderrOutput)
-		errMsg := fmt.Sprintf("Lệnh thất bại với mã lỗi: %v", err)
-		result.Message = errMsg
-		return result, fmt.Errorf(errMsg)
+	if err := d.dumpToFile(ctx, job, job.DBName, outputFile); err != nil {
+		return fail(result, err)
 	}
 
-	if stderrOutput != "" {
-		fmt.Printf("Thông báo từ stderr: %s\n", stderrOutput)
-	}
+	stats := d.collectStats(ctx, job, job.DBName, result)
+	result.Databases = append(result.Databases, *stats)
 
 	// Kiểm tra file có tồn tại không
 	fileInfo, err := os.Stat(outputFile)
 	if err != nil {
-		errMsg := fmt.Sprintf("File không được tạo tại %s: %v", outputFile, err)
-		result.Message = errMsg
-		return result, fmt.Errorf(errMsg)
+		os.Remove(outputFile)
+		return fail(result, i18n.Errorf(i18n.ErrDumpOutputMissing, outputFile, err))
 	}
 
 	fileSize := fileInfo.Size()
 
-	fmt.Println("Dump dữ liệu thành công.")
-	fmt.Printf("Vị trí file: %s\n", outputFile)
-	fmt.Printf("Kích thước file: %.2f MB\n", float64(fileSize)/(1024*1024))
+	entry := ManifestEntry{
+		Name:    job.DBName,
+		File:    filepath.Base(outputFile),
+		Size:    fileSize,
+		Success: true,
+		Stats:   stats,
+	}
+	d.captureSchema(ctx, job, &entry, result)
+
+	// Ghi manifest cạnh file dump; thiếu manifest không làm hỏng bản backup
+	manifest := &Manifest{
+		Job:           job.Name,
+		Mode:          config.ModeDatabase,
+		Container:     job.ContainerName,
+		Host:          job.Connection.Host,
+		CreatedAt:     now,
+		ServerVersion: result.ServerVersion,
+		Databases:     []ManifestEntry{entry},
+	}
+	if err := writeManifest(outputFile, manifest); err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("manifest: %v", err))
+	}
+
+	logger.Info("Dump finished", logging.KeyFile, outputFile, "size", fileSize)
 
 	result.FilePath = outputFile
 	result.FileSize = fileSize
 	result.Success = true
-	result.Message = "Dump dữ liệu thành công"
+	result.Message = i18n.T("", "dump.success")
 
 	return result, nil
 }
+
+// fail ghi lỗi vào result.Message và trả về result cùng lỗi
+func fail(result *DumpResult, err error) (*DumpResult, error) {
+	result.Message = err.Error()
+	return result, err
+}
+
+// dumpToFile chạy pg_dump cho database dbName của job và ghi kết quả vào outputFile.
+// Khi lỗi, file dở dang bị xóa để không bị coi là một bản backup.
+func (d *DatabaseDumper) dumpToFile(ctx context.Context, job *config.Job, dbName, outputFile string) error {
+	// Tạo file output, chỉ chủ sở hữu được đọc/ghi
+	outFile, err := os.OpenFile(outputFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return i18n.Errorf(i18n.ErrDumpOutputCreate, err)
+	}
+
+	args := []string{"pg_dump", "-v"}
+	args = append(args, job.DumpOptions...)
+	args = append(args, "-U", job.DBUser, "-d", dbName)
+
+	// Output -v của pg_dump đã được ghi log từng dòng trong run; lỗi chỉ kèm các dòng cuối
+	stderr, err := d.run(ctx, job, outFile, args...)
+	if err != nil {
+		err = i18n.Errorf(i18n.ErrDumpCommandOutput, err, stderrTail(stderr, 5))
+	}
+	if closeErr := outFile.Close(); err == nil && closeErr != nil {
+		err = i18n.Errorf(i18n.ErrDumpOutputWrite, closeErr)
+	}
+	if err != nil {
+		os.Remove(outputFile)
+		return err
+	}
+
+	return nil
+}
+
+// stderrTail trả về n dòng cuối của stderr
+func stderrTail(stderr string, n int) string {
+	lines := strings.Split(strings.TrimSpace(stderr), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// captureSchema lưu cấu trúc database vào entry khi job dump chỉ dữ liệu. Lỗi chỉ được ghi vào
+// Warnings vì bản dump vẫn khôi phục được vào một database đã có cấu trúc.
+func (d *DatabaseDumper) captureSchema(ctx context.Context, job *config.Job, entry *ManifestEntry, result *DumpResult) {
+	if !IsDataOnly(job.DumpOptions) {
+		return
+	}
+
+	var schema bytes.Buffer
+	if err := d.DumpSchema(ctx, job, entry.Name, &schema); err != nil {
+		logging.From(ctx).Warn("Failed to capture schema of data-only dump", "database", entry.Name, logging.Err(err))
+		result.Warnings = append(result.Warnings, fmt.Sprintf("%s schema: %v", entry.Name, err))
+		return
+	}
+	entry.Schema = schema.String()
+}
+
+// IsDataOnly kiểm tra tham số pg_dump có tạo bản dump chỉ gồm dữ liệu không
+func IsDataOnly(options []string) bool {
+	for _, opt := range options {
+		if opt == "--data-only" || opt == "-a" {
+			return true
+		}
+	}
+	return false
+}
+
+// DumpSchema ghi cấu trúc (không có dữ liệu, owner và quyền) của database dbName vào w.
+// Dùng khi cần khôi phục một bản dump chỉ có dữ liệu (--data-only).
+func (d *DatabaseDumper) DumpSchema(ctx context.Context, job *config.Job, dbName string, w io.Writer) error {
+	stderr, err := d.run(ctx, job, w, "pg_dump", "--schema-only", "--no-owner", "--no-privileges", "-U", job.DBUser, "-d", dbName)
+	if err != nil {
+		return i18n.Errorf(i18n.ErrDumpCommandOutput, err, strings.TrimSpace(stderr))
+	}
+	return nil
+}
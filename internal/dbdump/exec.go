@@ -0,0 +1,140 @@
+package dbdump
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strconv"
+
+	"github.com/backup-cronjob/internal/config"
+	"github.com/backup-cronjob/internal/docker"
+	"github.com/backup-cronjob/internal/i18n"
+	"github.com/backup-cronjob/internal/logging"
+)
+
+// clientCertPath là đường dẫn CA certificate bên trong container client
+const clientCertPath = "/certs/root.crt"
+
+// run chạy một lệnh client postgres (pg_dump, psql, pg_dumpall) theo cấu hình kết nối của job,
+// ghi stdout vào stdout và trả về nội dung stderr. Từng dòng stderr (vd: output -v của pg_dump)
+// cũng được ghi log ở mức debug để lưu cùng log của lần chạy.
+func (d *DatabaseDumper) run(ctx context.Context, job *config.Job, stdout io.Writer, command ...string) (string, error) {
+	var stderr bytes.Buffer
+	logWriter := logging.Writer(ctx, slog.LevelDebug, "Command output", "command", command[0])
+	errWriter := io.MultiWriter(&stderr, logWriter)
+
+	var exitCode int
+	var err error
+
+	switch {
+	case job.Connection.Type != config.ConnectionNetwork:
+		exitCode, err = d.runInContainer(ctx, job, command, stdout, errWriter)
+	case job.Connection.Client == config.ClientDocker:
+		exitCode, err = d.runClientContainer(ctx, job, command, stdout, errWriter)
+	default:
+		exitCode, err = runLocal(ctx, job, command, stdout, errWriter)
+	}
+	logWriter.Close()
+
+	if err != nil {
+		return stderr.String(), i18n.Errorf(i18n.ErrDumpCommandStart, command[0], err)
+	}
+	if exitCode != 0 {
+		return stderr.String(), i18n.Errorf(i18n.ErrDumpCommandExit, command[0], exitCode)
+	}
+
+	return stderr.String(), nil
+}
+
+// runInContainer chạy lệnh trong container của job qua Docker Engine API (exec).
+// Mật khẩu nằm trong cấu hình exec gửi qua socket, không xuất hiện trên dòng lệnh.
+func (d *DatabaseDumper) runInContainer(ctx context.Context, job *config.Job, command []string, stdout, stderr io.Writer) (int, error) {
+	if err := d.Docker.EnsureRunning(ctx, job.ContainerName); err != nil {
+		return -1, err
+	}
+
+	return d.Docker.Exec(ctx, job.ContainerName, docker.ExecOptions{
+		Cmd: command,
+		Env: []string{passwordEnv(job)},
+	}, stdout, stderr)
+}
+
+// runClientContainer chạy client postgres trong container tạm từ image client của job,
+// giúp dùng đúng phiên bản pg_dump với server mà không cần cài trên máy.
+// Lệnh được chạy qua exec như runInContainer: mật khẩu trong cấu hình container sẽ hiện ra
+// qua docker inspect trong suốt thời gian dump.
+func (d *DatabaseDumper) runClientContainer(ctx context.Context, job *config.Job, command []string, stdout, stderr io.Writer) (int, error) {
+	conn := job.Connection
+
+	opts := docker.RunOptions{
+		Image: conn.ClientImage,
+		// Giữ container chạy để exec; entrypoint của image postgres chạy thẳng lệnh khác "postgres"
+		Cmd:         []string{"tail", "-f", "/dev/null"},
+		NetworkMode: conn.DockerNetwork,
+	}
+	if conn.SSLRootCert != "" {
+		opts.Binds = []string{conn.SSLRootCert + ":" + clientCertPath + ":ro"}
+		opts.Env = connectionEnv(job, clientCertPath)
+	} else {
+		opts.Env = connectionEnv(job, "")
+	}
+
+	id, err := d.Docker.CreateContainer(ctx, opts)
+	if err != nil {
+		return -1, err
+	}
+	defer func() {
+		if err := d.Docker.RemoveContainer(id); err != nil {
+			logging.From(ctx).Warn("Failed to remove client container", "container", id, logging.Err(err))
+		}
+	}()
+
+	if err := d.Docker.StartContainer(ctx, id); err != nil {
+		return -1, err
+	}
+
+	return d.Docker.Exec(ctx, id, docker.ExecOptions{
+		Cmd: command,
+		Env: []string{passwordEnv(job)},
+	}, stdout, stderr)
+}
+
+// runLocal chạy client postgres cài trên máy, kết nối tới host/port của job
+func runLocal(ctx context.Context, job *config.Job, command []string, stdout, stderr io.Writer) (int, error) {
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Env = append(os.Environ(), connectionEnv(job, job.Connection.SSLRootCert)...)
+	cmd.Env = append(cmd.Env, passwordEnv(job))
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := cmd.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return exitErr.ExitCode(), nil
+		}
+		return -1, err
+	}
+	return 0, nil
+}
+
+// connectionEnv trả về các biến môi trường libpq cho kết nối mạng của job (không gồm mật khẩu,
+// xem passwordEnv)
+func connectionEnv(job *config.Job, sslRootCert string) []string {
+	conn := job.Connection
+	env := []string{
+		"PGHOST=" + conn.Host,
+		"PGPORT=" + strconv.Itoa(conn.Port),
+		"PGSSLMODE=" + conn.SSLMode,
+	}
+	if sslRootCert != "" {
+		env = append(env, "PGSSLROOTCERT="+sslRootCert)
+	}
+	return env
+}
+
+// passwordEnv trả về biến môi trường chứa mật khẩu của job, chỉ truyền cho tiến trình client
+func passwordEnv(job *config.Job) string {
+	return "PGPASSWORD=" + job.DBPassword
+}
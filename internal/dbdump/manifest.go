@@ -0,0 +1,106 @@
+package dbdump
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/backup-cronjob/internal/i18n"
+	"github.com/backup-cronjob/internal/models"
+)
+
+// ManifestFile là tên file manifest bên trong archive của bản backup cluster
+const ManifestFile = "manifest.json"
+
+// Manifest mô tả nội dung một bản backup: manifest.json trong archive với bản backup cluster,
+// file <tên file dump>.manifest.json đặt cạnh file dump với bản backup một database
+type Manifest struct {
+	Job       string    `json:"job"`
+	Mode      string    `json:"mode,omitempty"`
+	Container string    `json:"container,omitempty"`
+	Host      string    `json:"host,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	// ServerVersion là phiên bản server nguồn, dùng để chọn image khi kiểm tra khôi phục
+	ServerVersion string          `json:"server_version,omitempty"`
+	Globals       *ManifestEntry  `json:"globals,omitempty"`
+	Databases     []ManifestEntry `json:"databases"`
+}
+
+// ManifestEntry là thông tin của một file dump trong bản backup
+type ManifestEntry struct {
+	Name    string `json:"name"`
+	File    string `json:"file,omitempty"`
+	Size    int64  `json:"size"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+	// Stats là thông tin database nguồn thu thập lúc dump
+	Stats *models.DatabaseStats `json:"stats,omitempty"`
+	// Schema là cấu trúc database (pg_dump --schema-only) lấy cùng lúc với bản dump chỉ có dữ liệu,
+	// để khôi phục đúng cấu trúc tại thời điểm dump
+	Schema string `json:"schema,omitempty"`
+}
+
+// writeManifest ghi manifest của bản backup một database cạnh file dump
+func writeManifest(dumpPath string, manifest *Manifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(models.ManifestPath(dumpPath), data, 0600)
+}
+
+// ReadManifest đọc manifest của bản backup: từ archive với bản backup cluster,
+// từ file đặt cạnh với bản backup một database. Bản backup cũ không có manifest trả về os.ErrNotExist.
+func ReadManifest(path string) (*Manifest, error) {
+	var data []byte
+	if strings.HasSuffix(path, ".tar.gz") {
+		var err error
+		if data, err = readArchiveFile(path, ManifestFile); err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		if data, err = os.ReadFile(models.ManifestPath(path)); err != nil {
+			return nil, err
+		}
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, i18n.Errorf(i18n.ErrDumpManifestInvalid, err)
+	}
+	return &manifest, nil
+}
+
+// readArchiveFile đọc nội dung file name trong archive tar.gz
+func readArchiveFile(path, name string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil, os.ErrNotExist
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Name == name {
+			return io.ReadAll(tr)
+		}
+	}
+}
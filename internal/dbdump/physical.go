@@ -0,0 +1,84 @@
+package dbdump
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/backup-cronjob/internal/config"
+	"github.com/backup-cronjob/internal/i18n"
+	"github.com/backup-cronjob/internal/logging"
+)
+
+// backupLabelFile là file do pg_basebackup ghi vào bản sao lưu, chứa vị trí WAL bắt đầu
+const backupLabelFile = "backup_label"
+
+// startWALPattern lấy tên segment WAL bắt đầu trong backup_label,
+// vd: "START WAL LOCATION: 0/2000028 (file 000000010000000000000002)"
+var startWALPattern = regexp.MustCompile(`START WAL LOCATION: \S+ \(file ([0-9A-F]{24})\)`)
+
+// dumpBase tạo bản sao lưu vật lý của cả cluster bằng pg_basebackup (định dạng tar, kèm các
+// segment WAL cần để nhất quán) và nén thành file <job>_<timestamp>_base.tar.gz.
+// Segment WAL bắt đầu được ghi vào result để biết WAL nào cần giữ cho việc khôi phục theo thời điểm.
+func (d *DatabaseDumper) dumpBase(ctx context.Context, job *config.Job, backupDir, timestamp string, result *DumpResult) (*DumpResult, error) {
+	outputFile := filepath.Join(backupDir, fmt.Sprintf("%s_%s_base.tar.gz", job.Name, timestamp))
+
+	logger := logging.From(ctx)
+	logger.Info("Running pg_basebackup")
+	if err := d.writeBaseBackup(ctx, job, outputFile); err != nil {
+		os.Remove(outputFile)
+		return fail(result, err)
+	}
+
+	label, err := readArchiveFile(outputFile, backupLabelFile)
+	if err != nil {
+		os.Remove(outputFile)
+		return fail(result, i18n.Errorf(i18n.ErrDumpBackupLabel, backupLabelFile, err))
+	}
+	match := startWALPattern.FindSubmatch(label)
+	if match == nil {
+		os.Remove(outputFile)
+		return fail(result, i18n.Errorf(i18n.ErrDumpWALStart, backupLabelFile))
+	}
+	result.WALStart = string(match[1])
+
+	d.fetchServerVersion(ctx, job, job.DBName, result)
+
+	fileInfo, err := os.Stat(outputFile)
+	if err != nil {
+		return fail(result, i18n.Errorf(i18n.ErrDumpOutputMissing, outputFile, err))
+	}
+
+	logger.Info("Base backup finished", logging.KeyFile, outputFile, "size", fileInfo.Size(), "wal_start", result.WALStart)
+
+	result.FilePath = outputFile
+	result.FileSize = fileInfo.Size()
+	result.Success = true
+	result.Message = i18n.T("", "dump.physical_success")
+
+	return result, nil
+}
+
+// writeBaseBackup chạy pg_basebackup ghi tar ra stdout và nén gzip vào outputFile
+func (d *DatabaseDumper) writeBaseBackup(ctx context.Context, job *config.Job, outputFile string) error {
+	outFile, err := os.OpenFile(outputFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return i18n.Errorf(i18n.ErrDumpOutputCreate, err)
+	}
+	defer outFile.Close()
+
+	gz := gzip.NewWriter(outFile)
+	stderr, err := d.run(ctx, job, gz, "pg_basebackup", "-U", job.DBUser, "-D", "-", "-F", "t", "-X", "fetch", "-c", "fast")
+	if err != nil {
+		return i18n.Errorf(i18n.ErrDumpCommandOutput, err, strings.TrimSpace(stderr))
+	}
+
+	if err := gz.Close(); err != nil {
+		return i18n.Errorf(i18n.ErrDumpOutputWrite, err)
+	}
+	return outFile.Close()
+}
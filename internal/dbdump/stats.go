@@ -0,0 +1,157 @@
+package dbdump
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/backup-cronjob/internal/config"
+	"github.com/backup-cronjob/internal/i18n"
+	"github.com/backup-cronjob/internal/models"
+)
+
+// RowCountQuery đếm chính xác số dòng của mọi bảng người dùng, mỗi dòng kết quả: schema<TAB>bảng<TAB>số dòng
+const RowCountQuery = `SELECT n.nspname, c.relname,
+	(xpath('/row/c/text()', query_to_xml(format('SELECT count(*) AS c FROM %I.%I', n.nspname, c.relname), false, true, '')))[1]::text::bigint
+FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace
+WHERE c.relkind IN ('r', 'p')
+	AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+	AND n.nspname NOT LIKE 'pg_toast%'
+ORDER BY 1, 2`
+
+// tableStatsQuery lấy số dòng ước lượng (theo thống kê của planner) và dung lượng của từng bảng,
+// mỗi dòng kết quả: schema<TAB>bảng<TAB>số dòng ước lượng<TAB>dung lượng (byte)
+const tableStatsQuery = `SELECT n.nspname, c.relname, GREATEST(c.reltuples, 0)::bigint, pg_total_relation_size(c.oid)
+FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace
+WHERE c.relkind IN ('r', 'p')
+	AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+	AND n.nspname NOT LIKE 'pg_toast%'
+ORDER BY 1, 2`
+
+// databaseStatsQuery lấy dung lượng database và danh sách extension (tên phiên bản, phân cách bởi dấu phẩy)
+const databaseStatsQuery = `SELECT pg_database_size(current_database()),
+	COALESCE((SELECT string_agg(extname || ' ' || extversion, ',' ORDER BY extname) FROM pg_extension), '')`
+
+// query chạy câu SQL qua psql trên database dbName, trả về các dòng kết quả đã tách theo tab
+func (d *DatabaseDumper) query(ctx context.Context, job *config.Job, dbName, sql string) ([][]string, error) {
+	var stdout bytes.Buffer
+	stderr, err := d.run(ctx, job, &stdout, "psql", "-U", job.DBUser, "-d", dbName, "-At", "-F", "\t", "-c", sql)
+	if err != nil {
+		return nil, i18n.Errorf(i18n.ErrDumpCommandOutput, err, strings.TrimSpace(stderr))
+	}
+	return splitRows(stdout.String()), nil
+}
+
+// splitRows tách output dạng unaligned của psql thành các dòng và cột
+func splitRows(output string) [][]string {
+	var rows [][]string
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		rows = append(rows, strings.Split(line, "\t"))
+	}
+	return rows
+}
+
+// ParseRowCounts parse kết quả của RowCountQuery
+func ParseRowCounts(output string) ([]models.TableStat, error) {
+	var tables []models.TableStat
+	for _, row := range splitRows(output) {
+		if len(row) != 3 {
+			return nil, i18n.Errorf(i18n.ErrDumpInvalidRowData, strings.Join(row, "\t"))
+		}
+		count, err := strconv.ParseInt(strings.TrimSpace(row[2]), 10, 64)
+		if err != nil {
+			return nil, i18n.Errorf(i18n.ErrDumpInvalidRowData, strings.Join(row, "\t"))
+		}
+
+		tables = append(tables, models.TableStat{Schema: row[0], Table: row[1], RowCount: count})
+	}
+	return tables, nil
+}
+
+// fetchServerVersion lấy phiên bản server nguồn vào result (một lần cho mỗi lần dump)
+func (d *DatabaseDumper) fetchServerVersion(ctx context.Context, job *config.Job, dbName string, result *DumpResult) {
+	if result.ServerVersion != "" {
+		return
+	}
+
+	rows, err := d.query(ctx, job, dbName, "SHOW server_version")
+	if err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("%s: server version: %v", dbName, err))
+		return
+	}
+	if len(rows) > 0 {
+		// Bỏ phần mô tả bản build, vd: "16.2 (Debian 16.2-1.pgdg120+2)"
+		result.ServerVersion = strings.Fields(rows[0][0])[0]
+	}
+}
+
+// collectStats thu thập phiên bản server, dung lượng, extension và thông tin từng bảng của
+// database dbName. Số dòng chính xác chỉ được đếm khi job bật verify (tốn thời gian với database lớn);
+// việc đếm chạy trong phiên riêng sau pg_dump nên có thể lệch với dữ liệu đã dump nếu database đang
+// được ghi, phần chênh lệch này do verify.row_count_tolerance bù lại.
+// Lỗi chỉ được ghi vào Warnings vì không ảnh hưởng tới file dump.
+func (d *DatabaseDumper) collectStats(ctx context.Context, job *config.Job, dbName string, result *DumpResult) *models.DatabaseStats {
+	warn := func(what string, err error) {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("%s: %s: %v", dbName, what, err))
+	}
+
+	d.fetchServerVersion(ctx, job, dbName, result)
+
+	stats := &models.DatabaseStats{Name: dbName}
+
+	if rows, err := d.query(ctx, job, dbName, databaseStatsQuery); err != nil {
+		warn("database size", err)
+	} else if len(rows) > 0 && len(rows[0]) == 2 {
+		stats.SizeBytes, _ = strconv.ParseInt(rows[0][0], 10, 64)
+		if rows[0][1] != "" {
+			stats.Extensions = strings.Split(rows[0][1], ",")
+		}
+	}
+
+	rows, err := d.query(ctx, job, dbName, tableStatsQuery)
+	if err != nil {
+		warn("table stats", err)
+		return stats
+	}
+
+	index := make(map[string]int)
+	for _, row := range rows {
+		if len(row) != 4 {
+			continue
+		}
+		table := models.TableStat{Database: dbName, Schema: row[0], Table: row[1], RowCount: -1}
+		table.RowEstimate, _ = strconv.ParseInt(row[2], 10, 64)
+		table.TotalBytes, _ = strconv.ParseInt(row[3], 10, 64)
+
+		index[table.QualifiedName()] = len(stats.Tables)
+		stats.Tables = append(stats.Tables, table)
+	}
+
+	if !job.Verify.Enabled {
+		return stats
+	}
+
+	var stdout bytes.Buffer
+	stderr, err := d.run(ctx, job, &stdout, "psql", "-U", job.DBUser, "-d", dbName, "-At", "-F", "\t", "-c", RowCountQuery)
+	if err != nil {
+		warn("row counts", i18n.Errorf(i18n.ErrDumpCommandOutput, err, strings.TrimSpace(stderr)))
+		return stats
+	}
+	counts, err := ParseRowCounts(stdout.String())
+	if err != nil {
+		warn("row counts", err)
+		return stats
+	}
+	for _, count := range counts {
+		if i, ok := index[count.QualifiedName()]; ok {
+			stats.Tables[i].RowCount = count.RowCount
+		}
+	}
+
+	return stats
+}
@@ -0,0 +1,200 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/backup-cronjob/internal/config"
+	"github.com/backup-cronjob/internal/docker"
+	"github.com/backup-cronjob/internal/logging"
+)
+
+// Các label dùng để khai báo backup trên container
+const (
+	LabelEnable       = "backup.enable"
+	LabelEngine       = "backup.engine"
+	LabelName         = "backup.name"
+	LabelDB           = "backup.db"
+	LabelUser         = "backup.user"
+	LabelPassword     = "backup.password"
+	LabelMode         = "backup.mode"
+	LabelSchedule     = "backup.schedule"
+	LabelDestinations = "backup.destinations"
+	LabelKeepLast     = "backup.retention.keep_last"
+	LabelMaxAgeDays   = "backup.retention.max_age_days"
+	LabelHeartbeatURL = "backup.heartbeat.url"
+)
+
+// invalidNameChars là các ký tự không được phép trong tên job
+var invalidNameChars = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// Discoverer dựng job backup từ các container đang chạy có label backup.enable=true
+type Discoverer struct {
+	Config *config.Config
+	Docker *docker.Client
+}
+
+// New tạo Discoverer mới
+func New(cfg *config.Config, client *docker.Client) *Discoverer {
+	return &Discoverer{
+		Config: cfg,
+		Docker: client,
+	}
+}
+
+// Start phát hiện job ngay lập tức rồi làm mới định kỳ theo DiscoveryInterval cho tới khi ctx bị hủy
+func (d *Discoverer) Start(ctx context.Context) {
+	d.refreshAndLog(ctx)
+
+	interval := d.Config.DiscoveryInterval
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				d.refreshAndLog(ctx)
+			}
+		}
+	}()
+}
+
+// refreshAndLog làm mới danh sách job và ghi log các lỗi
+func (d *Discoverer) refreshAndLog(ctx context.Context) {
+	jobs, err := d.Refresh(ctx)
+	if err != nil {
+		slog.Error("Container discovery failed", logging.Err(err))
+		return
+	}
+	slog.Info("Container discovery finished", "jobs", len(jobs))
+}
+
+// Refresh liệt kê container có label backup.enable=true, dựng job và cập nhật vào cấu hình.
+// Container cấu hình sai bị bỏ qua (có ghi log), không làm hỏng các container khác.
+func (d *Discoverer) Refresh(ctx context.Context) ([]*config.Job, error) {
+	containers, err := d.Docker.ContainerList(ctx, LabelEnable+"=true")
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs []*config.Job
+	for i := range containers {
+		container := &containers[i]
+
+		engine := container.Labels[LabelEngine]
+		if engine != "" && engine != config.EnginePostgres {
+			slog.Warn("Container skipped: unsupported engine", "container", container.Name(), "engine", engine)
+			continue
+		}
+
+		job, err := d.buildJob(ctx, container)
+		if err != nil {
+			slog.Warn("Container skipped", "container", container.Name(), logging.Err(err))
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+
+	for _, err := range d.Config.SetDiscoveredJobs(jobs) {
+		slog.Warn("Container discovery", logging.Err(err))
+	}
+
+	discovered := make([]*config.Job, 0, len(jobs))
+	for _, job := range d.Config.Jobs() {
+		if job.Discovered {
+			discovered = append(discovered, job)
+		}
+	}
+	return discovered, nil
+}
+
+// buildJob dựng job từ label của container. Thông tin đăng nhập không có trong label
+// được lấy từ biến môi trường POSTGRES_* của container.
+// Mật khẩu được dùng nguyên văn, không phân giải tham chiếu secret (xem config.SetDiscoveredJobs).
+func (d *Discoverer) buildJob(ctx context.Context, container *docker.ContainerSummary) (*config.Job, error) {
+	labels := container.Labels
+
+	info, err := d.Docker.ContainerInspect(ctx, container.ID)
+	if err != nil {
+		return nil, fmt.Errorf("inspect: %w", err)
+	}
+	env := make(map[string]string)
+	if info.Config != nil {
+		for _, kv := range info.Config.Env {
+			if key, value, ok := strings.Cut(kv, "="); ok {
+				env[key] = value
+			}
+		}
+	}
+
+	name := labels[LabelName]
+	if name == "" {
+		name = strings.Trim(invalidNameChars.ReplaceAllString(container.Name(), "-"), "-.")
+	}
+
+	user := firstNonEmpty(labels[LabelUser], env["POSTGRES_USER"], "postgres")
+	job := &config.Job{
+		Name:          name,
+		Engine:        config.EnginePostgres,
+		Mode:          labels[LabelMode],
+		ContainerName: container.Name(),
+		DBName:        firstNonEmpty(labels[LabelDB], env["POSTGRES_DB"], user),
+		DBUser:        user,
+		DBPassword:    firstNonEmpty(labels[LabelPassword], env["POSTGRES_PASSWORD"]),
+		Schedule:      firstNonEmpty(labels[LabelSchedule], d.Config.CronSchedule),
+		Heartbeat:     config.HeartbeatConfig{URL: labels[LabelHeartbeatURL]},
+	}
+
+	if destinations := labels[LabelDestinations]; destinations != "" {
+		for _, dest := range strings.Split(destinations, ",") {
+			if dest = strings.TrimSpace(dest); dest != "" {
+				job.Destinations = append(job.Destinations, dest)
+			}
+		}
+	}
+
+	if job.Retention.KeepLast, err = intLabel(labels, LabelKeepLast); err != nil {
+		return nil, err
+	}
+	if job.Retention.MaxAgeDays, err = intLabel(labels, LabelMaxAgeDays); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// intLabel đọc label kiểu số nguyên, trả về 0 nếu không có
+func intLabel(labels map[string]string, key string) (int, error) {
+	value := labels[key]
+	if value == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q", key, value)
+	}
+	return n, nil
+}
+
+// firstNonEmpty trả về giá trị khác rỗng đầu tiên
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,162 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/backup-cronjob/internal/i18n"
+)
+
+// DefaultHost là địa chỉ Docker Engine mặc định (unix socket)
+const DefaultHost = "unix:///var/run/docker.sock"
+
+// apiVersion là phiên bản Docker Engine API được sử dụng (Docker 20.10 trở lên)
+const apiVersion = "v1.41"
+
+// Client gọi Docker Engine API qua unix socket hoặc TCP, không cần binary docker trong PATH
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	// err là lỗi cấu hình host, được trả về ở mọi request
+	err error
+}
+
+// APIError là lỗi do Docker Engine trả về
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("docker API error (%d): %s", e.StatusCode, e.Message)
+}
+
+// IsNotFound kiểm tra lỗi có phải do đối tượng (container, image, exec) không tồn tại
+func IsNotFound(err error) bool {
+	apiErr, ok := err.(*APIError)
+	return ok && apiErr.StatusCode == http.StatusNotFound
+}
+
+// NewClient tạo client cho Docker Engine tại host (dạng unix:///path hoặc tcp://host:port).
+// host rỗng nghĩa là DefaultHost. Lỗi cấu hình được trả về ở lần gọi API đầu tiên.
+func NewClient(host string) *Client {
+	if host == "" {
+		host = DefaultHost
+	}
+
+	c := &Client{}
+
+	u, err := url.Parse(host)
+	if err != nil {
+		c.err = fmt.Errorf("invalid docker host %q: %w", host, err)
+		return c
+	}
+
+	switch u.Scheme {
+	case "unix":
+		socketPath := u.Path
+		c.baseURL = "http://docker"
+		c.httpClient = &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var dialer net.Dialer
+					return dialer.DialContext(ctx, "unix", socketPath)
+				},
+			},
+		}
+	case "tcp", "http":
+		c.baseURL = "http://" + u.Host
+		c.httpClient = &http.Client{Transport: &http.Transport{}}
+	default:
+		c.err = fmt.Errorf("unsupported docker host scheme %q (use unix:// or tcp://)", u.Scheme)
+	}
+
+	return c
+}
+
+// Ping kiểm tra Docker Engine có phản hồi không
+func (c *Client) Ping(ctx context.Context) error {
+	resp, err := c.do(ctx, http.MethodGet, "/_ping", nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// do gửi request tới Docker Engine. body (nếu khác nil) được mã hóa JSON.
+// Response có mã lỗi được chuyển thành *APIError.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body interface{}) (*http.Response, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	target := c.baseURL + "/" + apiVersion + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, i18n.Errorf(i18n.ErrDockerConnect, err)
+	}
+
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		var payload struct {
+			Message string `json:"message"`
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		if json.Unmarshal(data, &payload) != nil || payload.Message == "" {
+			payload.Message = strings.TrimSpace(string(data))
+		}
+		return nil, &APIError{StatusCode: resp.StatusCode, Message: payload.Message}
+	}
+
+	return resp, nil
+}
+
+// doJSON gửi request và giải mã response JSON vào out (nếu khác nil)
+func (c *Client) doJSON(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	resp, err := c.do(ctx, method, path, query, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		_, err = io.Copy(io.Discard, resp.Body)
+		return err
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// cleanupContext trả về context dùng cho các thao tác dọn dẹp, vẫn chạy khi ctx gốc đã bị hủy
+func cleanupContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), 30*time.Second)
+}
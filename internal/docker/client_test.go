@@ -0,0 +1,325 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeExec là một exec trong Docker Engine giả
+type fakeExec struct {
+	Cmd    []string
+	Env    []string
+	output []byte
+	exit   int
+}
+
+// fakeDocker là Docker Engine API giả phục vụ qua unix socket, đủ cho các thao tác của Client
+type fakeDocker struct {
+	mu sync.Mutex
+
+	// containers ánh xạ tên container tới trạng thái running
+	containers map[string]bool
+	// output và exitCode là kết quả của lệnh chạy trong exec và container tạm
+	output   []byte
+	exitCode int
+	// execRunning giả lập exec vẫn chạy sau khi stream kết thúc
+	execRunning bool
+	// failPath trả về lỗi 500 cho request có path chứa chuỗi này
+	failPath string
+
+	execs   map[string]*fakeExec
+	created []map[string]interface{}
+	removed []string
+}
+
+// newFakeDocker khởi động Docker Engine giả trên unix socket và trả về client kết nối tới nó
+func newFakeDocker(t *testing.T) (*fakeDocker, *Client) {
+	t.Helper()
+
+	socket := filepath.Join(t.TempDir(), "docker.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	fake := &fakeDocker{containers: map[string]bool{}, execs: map[string]*fakeExec{}}
+	server := httptest.NewUnstartedServer(fake)
+	server.Listener.Close()
+	server.Listener = listener
+	server.Start()
+	t.Cleanup(server.Close)
+
+	return fake, NewClient("unix://" + socket)
+}
+
+func (f *fakeDocker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/"+apiVersion)
+	if f.failPath != "" && strings.Contains(path, f.failPath) {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"message": "boom"})
+		return
+	}
+
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case path == "/_ping":
+		w.Write([]byte("OK"))
+
+	case len(parts) == 3 && parts[0] == "containers" && parts[2] == "json":
+		running, ok := f.containers[parts[1]]
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"message": "No such container: " + parts[1]})
+			return
+		}
+		status := "exited"
+		if running {
+			status = "running"
+		}
+		writeJSON(w, http.StatusOK, ContainerInfo{ID: parts[1], Name: "/" + parts[1], State: &ContainerState{Status: status, Running: running}})
+
+	case len(parts) == 3 && parts[0] == "containers" && parts[2] == "exec":
+		if !f.containers[parts[1]] {
+			writeJSON(w, http.StatusNotFound, map[string]string{"message": "No such container: " + parts[1]})
+			return
+		}
+		var exec fakeExec
+		json.NewDecoder(r.Body).Decode(&exec)
+		exec.output, exec.exit = f.output, f.exitCode
+		id := "exec-" + parts[1]
+		f.execs[id] = &exec
+		writeJSON(w, http.StatusCreated, map[string]string{"Id": id})
+
+	case len(parts) == 3 && parts[0] == "exec" && parts[2] == "start":
+		exec, ok := f.execs[parts[1]]
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"message": "No such exec instance"})
+			return
+		}
+		w.Header().Set("Content-Type", "application/vnd.docker.raw-stream")
+		w.Write(exec.output)
+
+	case len(parts) == 3 && parts[0] == "exec" && parts[2] == "json":
+		exec := f.execs[parts[1]]
+		writeJSON(w, http.StatusOK, map[string]interface{}{"Running": f.execRunning, "ExitCode": exec.exit})
+
+	case len(parts) == 3 && parts[0] == "images" && parts[2] == "json":
+		writeJSON(w, http.StatusOK, map[string]string{"Id": parts[1]})
+
+	case path == "/containers/create":
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		f.created = append(f.created, body)
+		f.containers["tmp"] = false
+		writeJSON(w, http.StatusCreated, map[string]string{"Id": "tmp"})
+
+	case len(parts) == 3 && parts[0] == "containers" && parts[2] == "start":
+		f.containers[parts[1]] = true
+		w.WriteHeader(http.StatusNoContent)
+
+	case len(parts) == 3 && parts[0] == "containers" && parts[2] == "attach":
+		w.Header().Set("Content-Type", "application/vnd.docker.raw-stream")
+		w.Write(f.output)
+
+	case len(parts) == 3 && parts[0] == "containers" && parts[2] == "wait":
+		writeJSON(w, http.StatusOK, map[string]int{"StatusCode": f.exitCode})
+
+	case len(parts) == 2 && parts[0] == "containers" && r.Method == http.MethodDelete:
+		f.removed = append(f.removed, parts[1]+"?"+r.URL.RawQuery)
+		delete(f.containers, parts[1])
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "page not found", http.StatusNotFound)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func TestPing(t *testing.T) {
+	_, client := newFakeDocker(t)
+	if err := client.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+}
+
+func TestPingUnreachable(t *testing.T) {
+	client := NewClient("unix://" + filepath.Join(t.TempDir(), "missing.sock"))
+	if err := client.Ping(context.Background()); err == nil {
+		t.Fatal("expected error for missing socket")
+	}
+}
+
+func TestNewClientUnsupportedScheme(t *testing.T) {
+	client := NewClient("ssh://docker-host")
+	if err := client.Ping(context.Background()); err == nil {
+		t.Fatal("expected error for unsupported scheme")
+	}
+}
+
+func TestExec(t *testing.T) {
+	fake, client := newFakeDocker(t)
+	fake.containers["db"] = true
+	fake.output = append(frame(streamStdout, "dump data"), frame(streamStderr, "pg_dump: done\n")...)
+	fake.exitCode = 0
+
+	var stdout, stderr strings.Builder
+	code, err := client.Exec(context.Background(), "db", ExecOptions{
+		Cmd: []string{"pg_dump", "-U", "postgres"},
+		Env: []string{"PGPASSWORD=secret"},
+	}, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("Exec: %v", err)
+	}
+	if code != 0 {
+		t.Errorf("exit code = %d, want 0", code)
+	}
+	if stdout.String() != "dump data" || stderr.String() != "pg_dump: done\n" {
+		t.Errorf("stdout = %q, stderr = %q", stdout.String(), stderr.String())
+	}
+
+	exec := fake.execs["exec-db"]
+	if strings.Join(exec.Cmd, " ") != "pg_dump -U postgres" {
+		t.Errorf("Cmd = %v", exec.Cmd)
+	}
+	if len(exec.Env) != 1 || exec.Env[0] != "PGPASSWORD=secret" {
+		t.Errorf("Env = %v", exec.Env)
+	}
+}
+
+func TestExecExitCode(t *testing.T) {
+	fake, client := newFakeDocker(t)
+	fake.containers["db"] = true
+	fake.output = frame(streamStderr, "pg_dump: error: connection failed\n")
+	fake.exitCode = 3
+
+	var stderr strings.Builder
+	code, err := client.Exec(context.Background(), "db", ExecOptions{Cmd: []string{"pg_dump"}}, nil, &stderr)
+	if err != nil {
+		t.Fatalf("Exec: %v", err)
+	}
+	if code != 3 {
+		t.Errorf("exit code = %d, want 3", code)
+	}
+	if !strings.Contains(stderr.String(), "connection failed") {
+		t.Errorf("stderr = %q", stderr.String())
+	}
+}
+
+func TestExecStillRunning(t *testing.T) {
+	fake, client := newFakeDocker(t)
+	fake.containers["db"] = true
+	fake.execRunning = true
+
+	if _, err := client.Exec(context.Background(), "db", ExecOptions{Cmd: []string{"true"}}, nil, nil); err == nil {
+		t.Fatal("expected error when exec is still running")
+	}
+}
+
+func TestExecCorruptStream(t *testing.T) {
+	fake, client := newFakeDocker(t)
+	fake.containers["db"] = true
+	fake.output = frame(9, "garbage")
+
+	if _, err := client.Exec(context.Background(), "db", ExecOptions{Cmd: []string{"true"}}, nil, nil); err == nil {
+		t.Fatal("expected error for corrupt stream")
+	}
+}
+
+func TestExecContainerNotFound(t *testing.T) {
+	_, client := newFakeDocker(t)
+
+	_, err := client.Exec(context.Background(), "missing", ExecOptions{Cmd: []string{"true"}}, nil, nil)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("err = %v, want wrapped 404 APIError", err)
+	}
+	if !strings.Contains(apiErr.Message, "No such container") {
+		t.Errorf("Message = %q", apiErr.Message)
+	}
+}
+
+func TestAPIErrorPlainText(t *testing.T) {
+	_, client := newFakeDocker(t)
+
+	err := client.doJSON(context.Background(), http.MethodGet, "/unknown", nil, nil, nil)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("err = %v, want APIError", err)
+	}
+	if apiErr.StatusCode != http.StatusNotFound || apiErr.Message != "page not found" {
+		t.Errorf("got %d %q", apiErr.StatusCode, apiErr.Message)
+	}
+	if !IsNotFound(err) {
+		t.Error("IsNotFound = false")
+	}
+}
+
+func TestEnsureRunning(t *testing.T) {
+	fake, client := newFakeDocker(t)
+	fake.containers["up"] = true
+	fake.containers["down"] = false
+
+	if err := client.EnsureRunning(context.Background(), "up"); err != nil {
+		t.Errorf("running container: %v", err)
+	}
+	if err := client.EnsureRunning(context.Background(), "down"); err == nil {
+		t.Error("expected error for stopped container")
+	}
+	if err := client.EnsureRunning(context.Background(), "missing"); err == nil {
+		t.Error("expected error for missing container")
+	}
+}
+
+func TestRun(t *testing.T) {
+	fake, client := newFakeDocker(t)
+	fake.output = append(frame(streamStdout, "16.2\n"), frame(streamStderr, "notice\n")...)
+	fake.exitCode = 2
+
+	var stdout, stderr strings.Builder
+	code, err := client.Run(context.Background(), RunOptions{
+		Image: "postgres:16-alpine",
+		Cmd:   []string{"psql", "--version"},
+	}, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if code != 2 {
+		t.Errorf("exit code = %d, want 2", code)
+	}
+	if stdout.String() != "16.2\n" || stderr.String() != "notice\n" {
+		t.Errorf("stdout = %q, stderr = %q", stdout.String(), stderr.String())
+	}
+
+	if len(fake.created) != 1 || fake.created[0]["AttachStdout"] != true {
+		t.Errorf("created = %v", fake.created)
+	}
+	if len(fake.removed) != 1 || !strings.Contains(fake.removed[0], "force=1") {
+		t.Errorf("removed = %v, want forced removal of the temporary container", fake.removed)
+	}
+}
+
+func TestRunStartFailureRemovesContainer(t *testing.T) {
+	fake, client := newFakeDocker(t)
+	fake.failPath = "/start"
+
+	if _, err := client.Run(context.Background(), RunOptions{Image: "postgres:16-alpine"}, nil, nil); err == nil {
+		t.Fatal("expected error when start fails")
+	}
+	if len(fake.removed) != 1 {
+		t.Errorf("removed = %v, want the temporary container removed", fake.removed)
+	}
+}
@@ -0,0 +1,351 @@
+package docker
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/backup-cronjob/internal/i18n"
+)
+
+// ContainerState là trạng thái của container
+type ContainerState struct {
+	Status  string `json:"Status"`
+	Running bool   `json:"Running"`
+}
+
+// ContainerConfig là cấu hình của container (phần cần dùng)
+type ContainerConfig struct {
+	Image  string            `json:"Image"`
+	Env    []string          `json:"Env"`
+	Labels map[string]string `json:"Labels"`
+}
+
+// ContainerInfo là kết quả inspect container
+type ContainerInfo struct {
+	ID     string           `json:"Id"`
+	Name   string           `json:"Name"`
+	State  *ContainerState  `json:"State"`
+	Config *ContainerConfig `json:"Config"`
+}
+
+// ContainerInspect lấy thông tin container theo tên hoặc ID
+func (c *Client) ContainerInspect(ctx context.Context, name string) (*ContainerInfo, error) {
+	var info ContainerInfo
+	if err := c.doJSON(ctx, http.MethodGet, "/containers/"+url.PathEscape(name)+"/json", nil, nil, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// ContainerSummary là thông tin rút gọn của container trong danh sách
+type ContainerSummary struct {
+	ID     string            `json:"Id"`
+	Names  []string          `json:"Names"`
+	Image  string            `json:"Image"`
+	State  string            `json:"State"`
+	Labels map[string]string `json:"Labels"`
+}
+
+// Name trả về tên container (bỏ dấu "/" ở đầu)
+func (s *ContainerSummary) Name() string {
+	if len(s.Names) == 0 {
+		return s.ID
+	}
+	return strings.TrimPrefix(s.Names[0], "/")
+}
+
+// ContainerList liệt kê các container đang chạy có đủ các label (dạng "key" hoặc "key=value")
+func (c *Client) ContainerList(ctx context.Context, labels ...string) ([]ContainerSummary, error) {
+	query := url.Values{}
+	if len(labels) > 0 {
+		filters, err := json.Marshal(map[string][]string{"label": labels})
+		if err != nil {
+			return nil, err
+		}
+		query.Set("filters", string(filters))
+	}
+
+	var containers []ContainerSummary
+	if err := c.doJSON(ctx, http.MethodGet, "/containers/json", query, nil, &containers); err != nil {
+		return nil, err
+	}
+	return containers, nil
+}
+
+// EnsureRunning kiểm tra container tồn tại và đang chạy
+func (c *Client) EnsureRunning(ctx context.Context, name string) error {
+	info, err := c.ContainerInspect(ctx, name)
+	if err != nil {
+		if IsNotFound(err) {
+			return i18n.Errorf(i18n.ErrContainerNotFound, name)
+		}
+		return err
+	}
+	if info.State == nil || !info.State.Running {
+		status := "unknown"
+		if info.State != nil {
+			status = info.State.Status
+		}
+		return i18n.Errorf(i18n.ErrContainerNotRunning, name, status)
+	}
+	return nil
+}
+
+// ExecOptions là tham số chạy lệnh trong container
+type ExecOptions struct {
+	Cmd []string
+	Env []string
+}
+
+// Exec chạy lệnh trong container đang chạy, stream stdout/stderr ra các writer tương ứng
+// và trả về exit code thật của lệnh
+func (c *Client) Exec(ctx context.Context, container string, opts ExecOptions, stdout, stderr io.Writer) (int, error) {
+	var created struct {
+		ID string `json:"Id"`
+	}
+	createReq := map[string]interface{}{
+		"AttachStdout": true,
+		"AttachStderr": true,
+		"Tty":          false,
+		"Cmd":          opts.Cmd,
+		"Env":          opts.Env,
+	}
+	if err := c.doJSON(ctx, http.MethodPost, "/containers/"+url.PathEscape(container)+"/exec", nil, createReq, &created); err != nil {
+		return -1, i18n.Errorf(i18n.ErrExecCreate, err)
+	}
+
+	resp, err := c.do(ctx, http.MethodPost, "/exec/"+created.ID+"/start", nil, map[string]bool{"Detach": false, "Tty": false})
+	if err != nil {
+		return -1, i18n.Errorf(i18n.ErrExecStart, err)
+	}
+	streamErr := demuxStream(resp.Body, stdout, stderr)
+	resp.Body.Close()
+	if streamErr != nil {
+		return -1, streamErr
+	}
+
+	var inspect struct {
+		Running  bool `json:"Running"`
+		ExitCode int  `json:"ExitCode"`
+	}
+	if err := c.doJSON(ctx, http.MethodGet, "/exec/"+created.ID+"/json", nil, nil, &inspect); err != nil {
+		return -1, i18n.Errorf(i18n.ErrExecInspect, err)
+	}
+	if inspect.Running {
+		return -1, i18n.Errorf(i18n.ErrExecRunning)
+	}
+
+	return inspect.ExitCode, nil
+}
+
+// RunOptions là tham số tạo container
+type RunOptions struct {
+	// Name là tên container; rỗng để Docker tự đặt
+	Name        string
+	Image       string
+	Cmd         []string
+	Env         []string
+	Binds       []string
+	NetworkMode string
+	// Ports công bố cổng của container ra host, dạng "hostPort:containerPort" (tcp)
+	Ports []string
+	// Attach giữ stdout/stderr để đọc qua attach (dùng cho container chạy một lệnh rồi thoát)
+	Attach bool
+}
+
+// CreateContainer tạo container từ image (pull nếu chưa có) và trả về ID
+func (c *Client) CreateContainer(ctx context.Context, opts RunOptions) (string, error) {
+	if err := c.ensureImage(ctx, opts.Image); err != nil {
+		return "", err
+	}
+
+	hostConfig := map[string]interface{}{
+		"Binds": opts.Binds,
+	}
+	if opts.NetworkMode != "" {
+		hostConfig["NetworkMode"] = opts.NetworkMode
+	}
+
+	exposedPorts := make(map[string]struct{})
+	portBindings := make(map[string][]map[string]string)
+	for _, mapping := range opts.Ports {
+		hostPort, containerPort, ok := strings.Cut(mapping, ":")
+		if !ok {
+			return "", i18n.Errorf(i18n.ErrContainerPort, mapping)
+		}
+		key := containerPort + "/tcp"
+		exposedPorts[key] = struct{}{}
+		portBindings[key] = append(portBindings[key], map[string]string{"HostPort": hostPort})
+	}
+	if len(portBindings) > 0 {
+		hostConfig["PortBindings"] = portBindings
+	}
+
+	var created struct {
+		ID string `json:"Id"`
+	}
+	createReq := map[string]interface{}{
+		"Image":        opts.Image,
+		"Cmd":          opts.Cmd,
+		"Env":          opts.Env,
+		"AttachStdout": opts.Attach,
+		"AttachStderr": opts.Attach,
+		"Tty":          false,
+		"ExposedPorts": exposedPorts,
+		"HostConfig":   hostConfig,
+	}
+	var query url.Values
+	if opts.Name != "" {
+		query = url.Values{"name": {opts.Name}}
+	}
+	if err := c.doJSON(ctx, http.MethodPost, "/containers/create", query, createReq, &created); err != nil {
+		return "", i18n.Errorf(i18n.ErrContainerCreate, err)
+	}
+	return created.ID, nil
+}
+
+// StartContainer khởi động container đã tạo
+func (c *Client) StartContainer(ctx context.Context, id string) error {
+	if err := c.doJSON(ctx, http.MethodPost, "/containers/"+id+"/start", nil, nil, nil); err != nil {
+		return i18n.Errorf(i18n.ErrContainerStart, err)
+	}
+	return nil
+}
+
+// RemoveContainer dừng (nếu đang chạy) và xóa container cùng volume ẩn danh của nó.
+// Luôn chạy với context riêng để vẫn dọn dẹp được khi ctx của caller đã bị hủy.
+func (c *Client) RemoveContainer(id string) error {
+	ctx, cancel := cleanupContext()
+	defer cancel()
+	return c.doJSON(ctx, http.MethodDelete, "/containers/"+id, url.Values{"force": {"1"}, "v": {"1"}}, nil, nil)
+}
+
+// CopyFile ghi nội dung r (size byte) thành file name trong thư mục dir (phải tồn tại) của container
+func (c *Client) CopyFile(ctx context.Context, id, dir, name string, r io.Reader, size int64) error {
+	pr, pw := io.Pipe()
+	go func() {
+		tw := tar.NewWriter(pw)
+		err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: size, ModTime: time.Now()})
+		if err == nil {
+			_, err = io.Copy(tw, r)
+		}
+		if err == nil {
+			err = tw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	return c.CopyArchive(ctx, id, dir, pr)
+}
+
+// CopyArchive giải nén luồng tar r vào thư mục dir (phải tồn tại) của container.
+// Container không cần đang chạy.
+func (c *Client) CopyArchive(ctx context.Context, id, dir string, r io.ReadCloser) error {
+	if c.err != nil {
+		r.Close()
+		return c.err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut,
+		c.baseURL+"/"+apiVersion+"/containers/"+id+"/archive?"+url.Values{"path": {dir}}.Encode(), r)
+	if err != nil {
+		r.Close()
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-tar")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		r.Close()
+		return i18n.Errorf(i18n.ErrContainerCopy, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		return &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(data))}
+	}
+	return nil
+}
+
+// Run tạo container tạm từ image (pull nếu chưa có), chạy tới khi kết thúc, stream
+// stdout/stderr và trả về exit code. Container luôn được xóa sau khi chạy.
+func (c *Client) Run(ctx context.Context, opts RunOptions, stdout, stderr io.Writer) (int, error) {
+	opts.Attach = true
+	id, err := c.CreateContainer(ctx, opts)
+	if err != nil {
+		return -1, err
+	}
+	defer c.RemoveContainer(id)
+
+	// Attach trước khi start để không mất output
+	attachQuery := url.Values{"stream": {"1"}, "stdout": {"1"}, "stderr": {"1"}}
+	resp, err := c.do(ctx, http.MethodPost, "/containers/"+id+"/attach", attachQuery, nil)
+	if err != nil {
+		return -1, i18n.Errorf(i18n.ErrContainerAttach, err)
+	}
+	defer resp.Body.Close()
+
+	if err := c.StartContainer(ctx, id); err != nil {
+		return -1, err
+	}
+
+	if err := demuxStream(resp.Body, stdout, stderr); err != nil {
+		return -1, err
+	}
+
+	var waited struct {
+		StatusCode int `json:"StatusCode"`
+	}
+	if err := c.doJSON(ctx, http.MethodPost, "/containers/"+id+"/wait", nil, nil, &waited); err != nil {
+		return -1, i18n.Errorf(i18n.ErrContainerWait, err)
+	}
+
+	return waited.StatusCode, nil
+}
+
+// ensureImage pull image nếu chưa có trên máy
+func (c *Client) ensureImage(ctx context.Context, image string) error {
+	err := c.doJSON(ctx, http.MethodGet, "/images/"+image+"/json", nil, nil, nil)
+	if err == nil || !IsNotFound(err) {
+		return err
+	}
+
+	name, tag := splitImage(image)
+	query := url.Values{"fromImage": {name}}
+	if tag != "" {
+		query.Set("tag", tag)
+	}
+	resp, err := c.do(ctx, http.MethodPost, "/images/create", query, nil)
+	if err != nil {
+		return i18n.Errorf(i18n.ErrImagePull, image, err)
+	}
+	defer resp.Body.Close()
+
+	// Tiến trình pull được trả về dạng luồng JSON, lỗi nằm trong trường "error"
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return i18n.Errorf(i18n.ErrImagePull, image, err)
+	}
+	if i := strings.LastIndex(string(data), `"error"`); i >= 0 {
+		return i18n.Errorf(i18n.ErrImagePull, image, strings.TrimSpace(string(data[i:])))
+	}
+	return nil
+}
+
+// splitImage tách tên image và tag (mặc định latest). Image theo digest được giữ nguyên.
+func splitImage(image string) (string, string) {
+	if strings.Contains(image, "@") {
+		return image, ""
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[:i], image[i+1:]
+	}
+	return image, "latest"
+}
@@ -0,0 +1,51 @@
+package docker
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/backup-cronjob/internal/i18n"
+)
+
+// Mã luồng trong header của raw stream (khi container/exec không dùng TTY)
+const (
+	streamStdin  = 0
+	streamStdout = 1
+	streamStderr = 2
+)
+
+// demuxStream tách raw stream đa kênh của Docker thành stdout và stderr.
+// Mỗi frame gồm 8 byte header [stream, 0, 0, 0, size (big endian uint32)] và payload.
+func demuxStream(r io.Reader, stdout, stderr io.Writer) error {
+	if stdout == nil {
+		stdout = io.Discard
+	}
+	if stderr == nil {
+		stderr = io.Discard
+	}
+
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return i18n.Errorf(i18n.ErrDockerStreamRead, err)
+		}
+
+		var dst io.Writer
+		switch header[0] {
+		case streamStdin, streamStdout:
+			dst = stdout
+		case streamStderr:
+			dst = stderr
+		default:
+			return i18n.Errorf(i18n.ErrDockerStreamInvalid, header[0])
+		}
+
+		size := int64(binary.BigEndian.Uint32(header[4:]))
+		if _, err := io.CopyN(dst, r, size); err != nil {
+			return i18n.Errorf(i18n.ErrDockerStreamRead, err)
+		}
+	}
+}
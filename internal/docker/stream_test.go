@@ -0,0 +1,75 @@
+package docker
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// frame tạo một frame của raw stream Docker
+func frame(stream byte, payload string) []byte {
+	header := make([]byte, 8)
+	header[0] = stream
+	binary.BigEndian.PutUint32(header[4:], uint32(len(payload)))
+	return append(header, payload...)
+}
+
+func TestDemuxStream(t *testing.T) {
+	var input bytes.Buffer
+	input.Write(frame(streamStdout, "hello "))
+	input.Write(frame(streamStderr, "warning\n"))
+	input.Write(frame(streamStdout, ""))
+	input.Write(frame(streamStdin, "world"))
+	input.Write(frame(streamStderr, "done\n"))
+
+	var stdout, stderr bytes.Buffer
+	if err := demuxStream(&input, &stdout, &stderr); err != nil {
+		t.Fatalf("demuxStream: %v", err)
+	}
+	if got := stdout.String(); got != "hello world" {
+		t.Errorf("stdout = %q, want %q", got, "hello world")
+	}
+	if got := stderr.String(); got != "warning\ndone\n" {
+		t.Errorf("stderr = %q, want %q", got, "warning\ndone\n")
+	}
+}
+
+func TestDemuxStreamLargeFrame(t *testing.T) {
+	payload := bytes.Repeat([]byte("x"), 1<<20)
+	input := bytes.NewReader(frame(streamStdout, string(payload)))
+
+	var stdout bytes.Buffer
+	if err := demuxStream(input, &stdout, nil); err != nil {
+		t.Fatalf("demuxStream: %v", err)
+	}
+	if !bytes.Equal(stdout.Bytes(), payload) {
+		t.Errorf("stdout has %d bytes, want %d", stdout.Len(), len(payload))
+	}
+}
+
+func TestDemuxStreamNilWriters(t *testing.T) {
+	input := bytes.NewReader(append(frame(streamStdout, "out"), frame(streamStderr, "err")...))
+	if err := demuxStream(input, nil, nil); err != nil {
+		t.Fatalf("demuxStream: %v", err)
+	}
+}
+
+func TestDemuxStreamErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+	}{
+		{"truncated header", frame(streamStdout, "abc")[:5]},
+		{"truncated payload", frame(streamStdout, "abcdef")[:10]},
+		{"invalid stream", frame(7, "abc")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if err := demuxStream(bytes.NewReader(tt.input), &stdout, &stderr); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
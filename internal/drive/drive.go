@@ -2,22 +2,48 @@ package drive
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/backup-cronjob/internal/config"
+	"github.com/backup-cronjob/internal/database"
+	"github.com/backup-cronjob/internal/i18n"
+	"github.com/backup-cronjob/internal/logging"
+	"github.com/backup-cronjob/internal/metrics"
+	"github.com/backup-cronjob/internal/models"
+	"github.com/backup-cronjob/internal/secure"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
+	"golang.org/x/oauth2/jwt"
 	"google.golang.org/api/drive/v3"
 	"google.golang.org/api/option"
 )
 
+// tokenName là khóa lưu OAuth token của Google Drive trong SQLite
+const tokenName = "google_drive"
+
+// folderMimeType là MIME type của folder trên Google Drive
+const folderMimeType = "application/vnd.google-apps.folder"
+
 // DriveUploader quản lý việc upload file lên Google Drive
 type DriveUploader struct {
 	Config *config.Config
+
+	// Kết quả đọc hạn mức lưu trữ gần nhất (xem Quota)
+	quotaMu    sync.Mutex
+	quota      *Quota
+	quotaErr   error
+	quotaErrAt time.Time
 }
 
 // NewDriveUploader tạo instance mới của DriveUploader
@@ -32,323 +58,577 @@ func (d *DriveUploader) GetOAuthConfig() *oauth2.Config {
 	return &oauth2.Config{
 		ClientID:     d.Config.GoogleClientID,
 		ClientSecret: d.Config.GoogleClientSecret,
-		Scopes:       []string{drive.DriveFileScope},
-		RedirectURL:  fmt.Sprintf("http://localhost:%s/callback", d.Config.WebAppPort),
+		Scopes:       []string{d.scope()},
+		RedirectURL:  d.Config.PublicBaseURL + "/callback",
 		Endpoint:     google.Endpoint,
 	}
 }
 
-// GetAuthURL tạo URL xác thực
-func (d *DriveUploader) GetAuthURL() string {
+// GetAuthURL tạo URL xác thực với state và PKCE challenge sinh từ verifier
+func (d *DriveUploader) GetAuthURL(state, verifier string) string {
 	config := d.GetOAuthConfig()
-	return config.AuthCodeURL("state-token", oauth2.AccessTypeOffline, oauth2.ApprovalForce)
+	return config.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.ApprovalForce, oauth2.S256ChallengeOption(verifier))
 }
 
-// ExchangeAuthCode đổi mã xác thực lấy token
-func (d *DriveUploader) ExchangeAuthCode(code string) (*oauth2.Token, error) {
+// ExchangeAuthCode đổi mã xác thực lấy token, gửi kèm PKCE verifier
+func (d *DriveUploader) ExchangeAuthCode(code, verifier string) (*oauth2.Token, error) {
 	config := d.GetOAuthConfig()
-	token, err := config.Exchange(context.Background(), code)
+	token, err := config.Exchange(context.Background(), code, oauth2.VerifierOption(verifier))
 	if err != nil {
-		return nil, fmt.Errorf("không thể đổi mã xác thực: %v", err)
+		return nil, i18n.Errorf(i18n.ErrDriveExchange, err)
 	}
 
 	// Lưu token
-	tokenFile := filepath.Join(d.Config.TokenDir, "token.json")
-	err = d.saveToken(tokenFile, token)
+	err = d.saveToken(token)
 	if err != nil {
-		return nil, fmt.Errorf("không thể lưu token: %v", err)
+		return nil, i18n.Errorf(i18n.ErrDriveTokenSave, err)
 	}
 
 	return token, nil
 }
 
-// getClient lấy OAuth2 client để truy cập Google Drive API
-func (d *DriveUploader) getClient() (*drive.Service, error) {
-	// Tạo OAuth2 config từ client id và client secret
-	config := d.GetOAuthConfig()
+// scope trả về scope Drive cần cấp. Mặc định chỉ cần drive.file (file do ứng dụng tạo); khi folder
+// gốc chỉ định theo ID hoặc nằm trong Shared Drive (do người khác tạo) thì cần toàn quyền drive.
+func (d *DriveUploader) scope() string {
+	if d.Config.FolderDriveID != "" || d.Config.SharedDriveID != "" {
+		return drive.DriveScope
+	}
+	return drive.DriveFileScope
+}
 
-	// Kiểm tra token đã lưu
-	tokenFile := filepath.Join(d.Config.TokenDir, "token.json")
-	token, err := d.tokenFromFile(tokenFile)
+// serviceAccountConfig tạo cấu hình JWT từ key của service account, kèm người dùng được ủy quyền
+// toàn miền nếu có
+func (d *DriveUploader) serviceAccountConfig() (*jwt.Config, error) {
+	config, err := google.JWTConfigFromJSON([]byte(d.Config.GoogleServiceAccountKey), d.scope())
+	if err != nil {
+		return nil, i18n.Errorf(i18n.ErrDriveServiceAccount, err)
+	}
+	config.Subject = d.Config.GoogleImpersonate
+	return config, nil
+}
 
-	// Nếu không có token hoặc token không hợp lệ
+// tokenSource trả về nguồn access token: service account nếu được cấu hình, ngược lại là token
+// OAuth đã lưu
+func (d *DriveUploader) tokenSource(ctx context.Context) (oauth2.TokenSource, error) {
+	if d.Config.UseServiceAccount() {
+		config, err := d.serviceAccountConfig()
+		if err != nil {
+			return nil, err
+		}
+		return config.TokenSource(ctx), nil
+	}
+
+	token, err := d.loadToken()
 	if err != nil {
-		return nil, fmt.Errorf("không tìm thấy token xác thực. Vui lòng xác thực qua UI hoặc CLI")
+		return nil, i18n.Errorf(i18n.ErrDriveTokenMissing)
+	}
+	return d.GetOAuthConfig().TokenSource(ctx, token), nil
+}
+
+// getClient lấy client để truy cập Google Drive API
+func (d *DriveUploader) getClient(ctx context.Context) (*drive.Service, error) {
+	tokenSource, err := d.tokenSource(ctx)
+	if err != nil {
+		return nil, err
 	}
 
 	// Tạo service sử dụng token
-	ctx := context.Background()
-	service, err := drive.NewService(ctx, option.WithTokenSource(config.TokenSource(ctx, token)))
+	service, err := drive.NewService(ctx, option.WithTokenSource(tokenSource))
 	if err != nil {
-		return nil, fmt.Errorf("không thể tạo Drive service: %v", err)
+		return nil, i18n.Errorf(i18n.ErrDriveService, err)
 	}
 
 	return service, nil
 }
 
-// CheckAuth kiểm tra đã xác thực chưa
+// CheckAuth kiểm tra đã xác thực chưa. Với service account không cần liên kết tài khoản.
 func (d *DriveUploader) CheckAuth() bool {
-	tokenFile := filepath.Join(d.Config.TokenDir, "token.json")
-	_, err := d.tokenFromFile(tokenFile)
+	if d.Config.UseServiceAccount() {
+		return true
+	}
+	_, err := d.loadToken()
 	return err == nil
 }
 
-// tokenFromFile đọc token từ file
-func (d *DriveUploader) tokenFromFile(file string) (*oauth2.Token, error) {
-	f, err := os.Open(file)
+// CheckToken kiểm tra token đã lưu còn dùng được: access token còn hạn, hoặc làm mới được bằng
+// refresh token (token mới được lưu lại). Trả về lỗi nếu chưa xác thực hoặc không làm mới được.
+// Với service account, kiểm tra lấy được access token bằng key.
+func (d *DriveUploader) CheckToken(ctx context.Context) error {
+	if d.Config.UseServiceAccount() {
+		tokenSource, err := d.tokenSource(ctx)
+		if err != nil {
+			return err
+		}
+		if _, err := tokenSource.Token(); err != nil {
+			return i18n.Errorf(i18n.ErrDriveServiceAccount, metrics.DriveAPIError(err))
+		}
+		return nil
+	}
+
+	token, err := d.loadToken()
+	if err != nil {
+		return i18n.Errorf(i18n.ErrDriveNotAuthorized, err)
+	}
+	if token.Valid() {
+		return nil
+	}
+	if token.RefreshToken == "" {
+		return i18n.Errorf(i18n.ErrDriveTokenExpired)
+	}
+
+	refreshed, err := d.GetOAuthConfig().TokenSource(ctx, token).Token()
+	if err != nil {
+		return i18n.Errorf(i18n.ErrDriveTokenRefresh, metrics.DriveAPIError(err))
+	}
+	if refreshed.AccessToken != token.AccessToken {
+		if err := d.saveToken(refreshed); err != nil {
+			logging.From(ctx).Error("Failed to save refreshed Drive token", logging.Err(err))
+		}
+	}
+	return nil
+}
+
+// loadToken đọc token đã mã hóa từ SQLite. Nếu chưa có nhưng còn file token.json
+// từ phiên bản cũ, token sẽ được chuyển vào SQLite và file cũ bị xóa.
+func (d *DriveUploader) loadToken() (*oauth2.Token, error) {
+	ciphertext, err := database.GetOAuthToken(tokenName)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return d.migrateLegacyToken()
+		}
+		return nil, err
+	}
+
+	plaintext, err := secure.Decrypt(ciphertext)
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
 
 	token := &oauth2.Token{}
-	err = json.NewDecoder(f).Decode(token)
-	return token, err
+	if err := json.Unmarshal(plaintext, token); err != nil {
+		return nil, err
+	}
+	return token, nil
 }
 
-// getTokenFromWeb yêu cầu người dùng xác thực qua trình duyệt
-func (d *DriveUploader) getTokenFromWeb(config *oauth2.Config) (*oauth2.Token, error) {
-	// Tạo URL xác thực
-	authURL := config.AuthCodeURL("state-token", oauth2.AccessTypeOffline)
-	fmt.Printf("\n=== HƯỚNG DẪN XÁC THỰC GOOGLE DRIVE ===\n")
-	fmt.Printf("\nBước 1: Mở URL sau trong trình duyệt:\n")
-	fmt.Printf("%s\n", authURL)
-	fmt.Printf("\nBước 2: Đăng nhập Google và cho phép quyền truy cập\n")
-	fmt.Printf("\nBước 3: Copy mã và paste vào đây\n\n")
+// migrateLegacyToken chuyển token từ file token.json (không mã hóa) vào SQLite
+func (d *DriveUploader) migrateLegacyToken() (*oauth2.Token, error) {
+	tokenFile := filepath.Join(d.Config.TokenDir, "token.json")
+	data, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return nil, err
+	}
 
-	var code string
-	fmt.Print("Nhập mã xác thực: ")
-	if _, err := fmt.Scan(&code); err != nil {
-		return nil, fmt.Errorf("không thể đọc mã xác thực: %v", err)
+	token := &oauth2.Token{}
+	if err := json.Unmarshal(data, token); err != nil {
+		return nil, err
 	}
 
-	// Đổi mã xác thực lấy token
-	token, err := config.Exchange(context.Background(), code)
-	if err != nil {
-		return nil, fmt.Errorf("không thể đổi mã xác thực: %v", err)
+	if err := d.saveToken(token); err != nil {
+		return nil, i18n.Errorf(i18n.ErrDriveTokenMigrate, err)
+	}
+
+	if err := os.Remove(tokenFile); err != nil {
+		return nil, i18n.Errorf(i18n.ErrDriveTokenMigrate, err)
 	}
 
+	slog.Info("Migrated legacy Drive token into encrypted storage", logging.KeyFile, tokenFile)
 	return token, nil
 }
 
-// saveToken lưu token vào file
-func (d *DriveUploader) saveToken(path string, token *oauth2.Token) error {
-	// Đảm bảo thư mục tồn tại
-	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+// revokeURL là endpoint thu hồi token của Google
+const revokeURL = "https://oauth2.googleapis.com/revoke"
+
+// RevokeToken hủy liên kết Google Drive: thu hồi token đã lưu ở phía Google rồi xóa khỏi SQLite.
+// Lỗi khi thu hồi ở phía Google chỉ được ghi log, token local vẫn bị xóa.
+func (d *DriveUploader) RevokeToken(ctx context.Context) error {
+	token, err := d.loadToken()
+	if err != nil {
+		return i18n.Errorf(i18n.ErrDriveNotAuthorized, err)
+	}
+
+	value := token.RefreshToken
+	if value == "" {
+		value = token.AccessToken
+	}
+	form := url.Values{"token": {value}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, revokeURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	if resp, err := http.DefaultClient.Do(req); err != nil {
+		logging.From(ctx).Warn("Failed to revoke Drive token at Google", logging.Err(err))
+	} else {
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			logging.From(ctx).Warn("Google rejected Drive token revocation", "status", resp.StatusCode)
+		}
+	}
+
+	return database.DeleteOAuthToken(tokenName)
+}
+
+// saveToken mã hóa token bằng master key và lưu vào SQLite
+func (d *DriveUploader) saveToken(token *oauth2.Token) error {
+	plaintext, err := json.Marshal(token)
+	if err != nil {
 		return err
 	}
 
-	// Mở file để ghi
-	f, err := os.Create(path)
+	ciphertext, err := secure.Encrypt(plaintext)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
 
-	// Ghi token vào file dưới dạng JSON
-	return json.NewEncoder(f).Encode(token)
+	return database.SaveOAuthToken(tokenName, ciphertext)
 }
 
-// createOrFindFolder tạo hoặc tìm folder trên Drive
-func (d *DriveUploader) createOrFindFolder(service *drive.Service, name string, parentID string) (string, error) {
-	// Tạo query để tìm folder
-	query := fmt.Sprintf("name='%s' and mimeType='application/vnd.google-apps.folder'", name)
-	if parentID != "" {
-		query += fmt.Sprintf(" and '%s' in parents", parentID)
+// quoteQuery đặt value trong dấu nháy đơn để dùng trong query tìm kiếm của Drive,
+// escape dấu \ và ' trong value
+func quoteQuery(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+// listFiles tạo lệnh tìm file theo query, tìm cả trong Shared Drive
+func (d *DriveUploader) listFiles(service *drive.Service, query string) *drive.FilesListCall {
+	call := service.Files.List().Q(query).SupportsAllDrives(true).IncludeItemsFromAllDrives(true)
+	if d.Config.SharedDriveID != "" {
+		call = call.Corpora("drive").DriveId(d.Config.SharedDriveID)
 	}
+	return call
+}
+
+// rootFolderID trả về folder gốc chứa backup trên Drive: FolderDriveID nếu có, ngược lại là
+// gốc của Shared Drive hoặc của My Drive
+func (d *DriveUploader) rootFolderID() string {
+	switch {
+	case d.Config.FolderDriveID != "":
+		return d.Config.FolderDriveID
+	case d.Config.SharedDriveID != "":
+		return d.Config.SharedDriveID
+	default:
+		return "root"
+	}
+}
+
+// findFolder tìm folder name trong parentID, trả về ID rỗng nếu không có. Khi có nhiều folder
+// trùng tên, folder được tạo sớm nhất được dùng để kết quả luôn ổn định.
+func (d *DriveUploader) findFolder(ctx context.Context, service *drive.Service, name string, parentID string) (string, error) {
+	// Tạo query để tìm folder
+	query := fmt.Sprintf("name=%s and mimeType='%s' and %s in parents and trashed=false",
+		quoteQuery(name), folderMimeType, quoteQuery(parentID))
 
 	// Tìm folder
-	r, err := service.Files.List().Q(query).Fields("files(id, name)").Do()
+	r, err := d.listFiles(service, query).OrderBy("createdTime").Fields("files(id, name)").Context(ctx).Do()
 	if err != nil {
-		return "", fmt.Errorf("không thể tìm folder: %v", err)
+		metrics.DriveAPIError(err)
+		return "", i18n.Errorf(i18n.ErrDriveFolderFind, err)
+	}
+	if len(r.Files) == 0 {
+		return "", nil
+	}
+
+	folderID := r.Files[0].Id
+	if len(r.Files) > 1 {
+		logging.From(ctx).Warn("Multiple Drive folders share the same name, using the oldest one",
+			"folder", name, "parent_id", parentID, "count", len(r.Files), "folder_id", folderID)
+	}
+	return folderID, nil
+}
+
+// createOrFindFolder tìm folder name trong parentID (xem findFolder) hoặc tạo mới nếu chưa có
+func (d *DriveUploader) createOrFindFolder(ctx context.Context, service *drive.Service, name string, parentID string) (string, error) {
+	folderID, err := d.findFolder(ctx, service, name, parentID)
+	if err != nil {
+		return "", err
 	}
 
 	// Nếu folder đã tồn tại
-	if len(r.Files) > 0 {
-		folderID := r.Files[0].Id
-		fmt.Printf("Sử dụng folder có sẵn: %s (ID: %s)\n", name, folderID)
+	if folderID != "" {
+		logging.From(ctx).Debug("Using existing Drive folder", "folder", name, "folder_id", folderID)
 		return folderID, nil
 	}
 
 	// Nếu chưa có folder, tạo mới
 	folderMetadata := &drive.File{
 		Name:     name,
-		MimeType: "application/vnd.google-apps.folder",
-	}
-
-	// Nếu có parent folder, thiết lập parents
-	if parentID != "" {
-		folderMetadata.Parents = []string{parentID}
+		MimeType: folderMimeType,
+		Parents:  []string{parentID},
 	}
 
 	// Tạo folder
-	folder, err := service.Files.Create(folderMetadata).Fields("id").Do()
+	folder, err := service.Files.Create(folderMetadata).SupportsAllDrives(true).Fields("id").Context(ctx).Do()
 	if err != nil {
-		return "", fmt.Errorf("không thể tạo folder: %v", err)
+		metrics.DriveAPIError(err)
+		return "", i18n.Errorf(i18n.ErrDriveFolderCreate, err)
 	}
 
-	fmt.Printf("Đã tạo folder mới: %s (ID: %s)\n", name, folder.Id)
+	logging.From(ctx).Info("Created Drive folder", "folder", name, "folder_id", folder.Id)
 	return folder.Id, nil
 }
 
 // checkFileExists kiểm tra file đã tồn tại trong folder chưa
-func (d *DriveUploader) checkFileExists(service *drive.Service, fileName string, parentFolderID string) (bool, error) {
-	query := fmt.Sprintf("name='%s' and '%s' in parents and trashed=false", fileName, parentFolderID)
-	r, err := service.Files.List().Q(query).Fields("files(id, name)").Do()
+func (d *DriveUploader) checkFileExists(ctx context.Context, service *drive.Service, fileName string, parentFolderID string) (bool, error) {
+	query := fmt.Sprintf("name=%s and %s in parents and trashed=false", quoteQuery(fileName), quoteQuery(parentFolderID))
+	r, err := d.listFiles(service, query).Fields("files(id, name)").Context(ctx).Do()
 	if err != nil {
-		return false, fmt.Errorf("không thể kiểm tra file: %v", err)
+		metrics.DriveAPIError(err)
+		return false, i18n.Errorf(i18n.ErrDriveFileCheck, err)
 	}
 
 	return len(r.Files) > 0, nil
 }
 
-// UploadFile upload một file lên Google Drive
-func (d *DriveUploader) UploadFile(filePath string) error {
-	// Lấy Drive client
-	service, err := d.getClient()
-	if err != nil {
-		return fmt.Errorf("không thể kết nối Google Drive: %v", err)
+// backupFolderID tìm hoặc tạo folder đích cho file backup trên Drive theo cấu trúc
+// <FolderDrive>/<job>/<ngày>/ (hoặc <FolderDrive>/<ngày>/ với backup theo cấu trúc cũ). Folder
+// FolderDrive nằm trong gốc My Drive hoặc Shared Drive; khi có FolderDriveID, folder đó thay cho
+// FolderDrive. cache lưu các folder đã tìm được để tránh truy vấn lặp lại khi upload nhiều file.
+func (d *DriveUploader) backupFolderID(ctx context.Context, service *drive.Service, cache map[string]string, filePath string) (string, error) {
+	job, date := models.ParseBackupLocation(d.Config.BackupDir, filePath)
+	if date == "" {
+		date = time.Now().Format("2006-01-02")
 	}
 
-	// Tạo folder gốc nếu chưa có
-	rootFolderID, err := d.createOrFindFolder(service, d.Config.FolderDrive, "")
-	if err != nil {
-		return fmt.Errorf("không thể tạo folder gốc: %v", err)
+	var path []string
+	if d.Config.FolderDriveID == "" && d.Config.FolderDrive != "" {
+		path = append(path, d.Config.FolderDrive)
 	}
+	if job != "" {
+		path = append(path, job)
+	}
+	path = append(path, date)
+
+	parentID := d.rootFolderID()
+	key := ""
+	for _, name := range path {
+		key += "/" + name
+		if id, ok := cache[key]; ok {
+			parentID = id
+			continue
+		}
 
-	// Tạo folder theo ngày
-	today := time.Now().Format("2006-01-02")
-	dateFolderID, err := d.createOrFindFolder(service, today, rootFolderID)
-	if err != nil {
-		return fmt.Errorf("không thể tạo folder ngày: %v", err)
+		id, err := d.createOrFindFolder(ctx, service, name, parentID)
+		if err != nil {
+			return "", i18n.Errorf(i18n.ErrDriveFolderPath, key, err)
+		}
+		cache[key] = id
+		parentID = id
 	}
 
+	return parentID, nil
+}
+
+// uploadToFolder upload file vào folder trên Drive, bỏ qua nếu file đã tồn tại.
+// Trả về false nếu file đã có sẵn trên Drive.
+func (d *DriveUploader) uploadToFolder(ctx context.Context, service *drive.Service, filePath, folderID string) (bool, error) {
 	// Lấy tên file
 	fileName := filepath.Base(filePath)
 
 	// Kiểm tra file đã tồn tại chưa
-	exists, err := d.checkFileExists(service, fileName, dateFolderID)
+	exists, err := d.checkFileExists(ctx, service, fileName, folderID)
 	if err != nil {
-		return fmt.Errorf("không thể kiểm tra file tồn tại: %v", err)
+		return false, err
 	}
 
 	if exists {
-		fmt.Printf("File %s đã tồn tại trong thư mục, bỏ qua upload\n", fileName)
-		return nil
+		logging.From(ctx).Info("File already exists on Drive, upload skipped", logging.KeyFile, filePath)
+		return false, nil
 	}
 
+	return true, d.createFile(ctx, service, filePath, folderID)
+}
+
+// createFile upload file vào folder trên Drive (không kiểm tra file đã tồn tại)
+func (d *DriveUploader) createFile(ctx context.Context, service *drive.Service, filePath, folderID string) error {
 	// Chuẩn bị metadata
 	fileMetadata := &drive.File{
-		Name:    fileName,
-		Parents: []string{dateFolderID},
+		Name:    filepath.Base(filePath),
+		Parents: []string{folderID},
 	}
 
 	// Mở file để upload
 	content, err := os.Open(filePath)
 	if err != nil {
-		return fmt.Errorf("không thể mở file: %v", err)
+		return i18n.Errorf(i18n.ErrDriveFileOpen, err)
 	}
 	defer content.Close()
 
 	// Upload file
 	file, err := service.Files.Create(fileMetadata).
+		SupportsAllDrives(true).
 		Media(content).
 		Fields("id, webViewLink").
+		Context(ctx).
 		Do()
 	if err != nil {
-		return fmt.Errorf("không thể upload file: %v", err)
+		metrics.DriveAPIError(err)
+		return i18n.Errorf(i18n.ErrDriveUpload, err)
 	}
 
-	fmt.Printf("File %s đã được upload:\n", fileName)
-	fmt.Printf("- File ID: %s\n", file.Id)
-	fmt.Printf("- Web Link: %s\n", file.WebViewLink)
+	logging.From(ctx).Info("Uploaded file to Drive", logging.KeyFile, filePath, "file_id", file.Id, "web_link", file.WebViewLink)
 
 	return nil
 }
 
-// UploadAllBackups upload tất cả các file backup trong thư mục backups
-func (d *DriveUploader) UploadAllBackups() error {
+// UploadFile upload một file lên Google Drive. File đã có sẵn trên Drive được bỏ qua mà không
+// kiểm tra hạn mức, vì không tốn thêm dung lượng.
+func (d *DriveUploader) UploadFile(ctx context.Context, filePath string) error {
 	// Lấy Drive client
-	service, err := d.getClient()
+	service, err := d.getClient(ctx)
 	if err != nil {
-		return fmt.Errorf("không thể kết nối Google Drive: %v", err)
+		err = i18n.Errorf(i18n.ErrDriveConnect, err)
+		d.recordUpload(ctx, filePath, false, err)
+		return err
 	}
 
-	// Tạo folder gốc nếu chưa có
-	rootFolderID, err := d.createOrFindFolder(service, d.Config.FolderDrive, "")
+	// Tạo cấu trúc folder job/ngày nếu chưa có
+	folderID, err := d.backupFolderID(ctx, service, make(map[string]string), filePath)
 	if err != nil {
-		return fmt.Errorf("không thể tạo folder gốc: %v", err)
+		d.recordUpload(ctx, filePath, false, err)
+		return err
 	}
 
-	// Duyệt qua từng thư mục ngày
-	backupDir := d.Config.BackupDir
-	entries, err := os.ReadDir(backupDir)
+	exists, err := d.checkFileExists(ctx, service, filepath.Base(filePath), folderID)
 	if err != nil {
-		return fmt.Errorf("không thể đọc thư mục backup: %v", err)
+		d.recordUpload(ctx, filePath, false, err)
+		return err
 	}
 
-	for _, entry := range entries {
-		if entry.IsDir() {
-			dateFolderPath := filepath.Join(backupDir, entry.Name())
+	if exists {
+		logging.From(ctx).Info("File already exists on Drive, upload skipped", logging.KeyFile, filePath)
+	} else {
+		// Kiểm tra Drive còn đủ chỗ trước khi upload
+		job, _ := models.ParseBackupLocation(d.Config.BackupDir, filePath)
+		if err := d.checkQuota(ctx, service, job, uploadSize(filePath)); err != nil {
+			d.recordUpload(ctx, filePath, false, err)
+			return err
+		}
+		if err := d.createFile(ctx, service, filePath, folderID); err != nil {
+			d.recordUpload(ctx, filePath, false, err)
+			return err
+		}
+	}
 
-			// Tạo hoặc lấy folder ngày trên Drive
-			dateFolderID, err := d.createOrFindFolder(service, entry.Name(), rootFolderID)
-			if err != nil {
-				fmt.Printf("Không thể tạo folder ngày %s: %v\n", entry.Name(), err)
-				continue
-			}
+	d.recordUpload(ctx, filePath, !exists, nil)
+	d.uploadManifest(ctx, service, filePath, folderID)
+	return nil
+}
+
+// recordUpload ghi nhận kết quả upload một file vào metric và catalog (thời điểm upload).
+// File đã có sẵn trên Drive (uploaded = false, không lỗi) vẫn được coi là đã upload.
+func (d *DriveUploader) recordUpload(ctx context.Context, filePath string, uploaded bool, err error) {
+	job, _ := models.ParseBackupLocation(d.Config.BackupDir, filePath)
+	if err != nil {
+		metrics.UploadFailed(config.DestinationDrive, job)
+		return
+	}
+
+	if uploaded {
+		if info, statErr := os.Stat(filePath); statErr == nil {
+			metrics.Uploaded(config.DestinationDrive, job, info.Size())
+		}
+	}
+	if err := database.SetBackupUploaded(filePath, time.Now()); err != nil {
+		logging.From(ctx).Error("Failed to record upload time in catalog", logging.KeyFile, filePath, logging.Err(err))
+	}
+}
+
+// uploadManifest upload manifest đặt cạnh file dump (nếu có) vào cùng folder.
+// Lỗi chỉ được ghi log vì manifest không cần để khôi phục bản backup.
+func (d *DriveUploader) uploadManifest(ctx context.Context, service *drive.Service, filePath, folderID string) {
+	manifestPath := models.ManifestPath(filePath)
+	if _, err := os.Stat(manifestPath); err != nil {
+		return
+	}
+	if _, err := d.uploadToFolder(ctx, service, manifestPath, folderID); err != nil {
+		logging.From(ctx).Warn("Failed to upload manifest", logging.KeyFile, manifestPath, logging.Err(err))
+	}
+}
+
+// plannedUpload là một bản backup trong lần upload tất cả: folder đích trên Drive và file
+// đã có sẵn ở đó hay chưa
+type plannedUpload struct {
+	backup   *models.BackupFile
+	folderID string
+	exists   bool
+}
+
+// planUploads tìm (hoặc tạo) folder đích và kiểm tra sự tồn tại trên Drive của từng bản backup.
+// Bản backup lỗi được ghi nhận và bỏ khỏi danh sách; trả về danh sách cùng số bản lỗi.
+func (d *DriveUploader) planUploads(ctx context.Context, service *drive.Service, backups []*models.BackupFile) ([]plannedUpload, int) {
+	cache := make(map[string]string)
+	var (
+		plan   []plannedUpload
+		failed int
+	)
+	for _, backup := range backups {
+		folderID, err := d.backupFolderID(ctx, service, cache, backup.Path)
+		if err != nil {
+			logging.From(ctx).Error("Failed to create Drive folder for backup", logging.KeyFile, backup.Path, logging.Err(err))
+			d.recordUpload(ctx, backup.Path, false, err)
+			failed++
+			continue
+		}
+
+		exists, err := d.checkFileExists(ctx, service, filepath.Base(backup.Path), folderID)
+		if err != nil {
+			logging.From(ctx).Error("Failed to check backup on Drive", logging.KeyFile, backup.Path, logging.Err(err))
+			d.recordUpload(ctx, backup.Path, false, err)
+			failed++
+			continue
+		}
+		plan = append(plan, plannedUpload{backup: backup, folderID: folderID, exists: exists})
+	}
+	return plan, failed
+}
+
+// UploadAllBackups upload tất cả các file backup trong thư mục backups,
+// chỉ của job nếu job khác rỗng. Lỗi của từng file được ghi lại và upload tiếp các file khác.
+func (d *DriveUploader) UploadAllBackups(ctx context.Context, job string) error {
+	// Lấy Drive client
+	service, err := d.getClient(ctx)
+	if err != nil {
+		return i18n.Errorf(i18n.ErrDriveConnect, err)
+	}
+
+	// Lấy danh sách file backup
+	var backups []*models.BackupFile
+	if job == "" {
+		backups, err = models.GetAllBackups(d.Config.BackupDir)
+	} else {
+		backups, err = models.GetJobBackups(d.Config.BackupDir, job)
+	}
+	if err != nil {
+		return i18n.Errorf(i18n.ErrDriveReadBackupDir, err)
+	}
+
+	// Xác định folder và các file chưa có trên Drive trước, để kiểm tra dung lượng đúng với
+	// danh sách sẽ upload
+	plan, failed := d.planUploads(ctx, service, backups)
+	if err := d.checkQuota(ctx, service, job, pendingSize(plan)); err != nil {
+		return err
+	}
 
-			// Đọc tất cả file SQL trong thư mục ngày
-			files, err := filepath.Glob(filepath.Join(dateFolderPath, "*.sql"))
+	for _, item := range plan {
+		if item.exists {
+			logging.From(ctx).Info("File already exists on Drive, upload skipped", logging.KeyFile, item.backup.Path)
+			d.recordUpload(ctx, item.backup.Path, false, nil)
+		} else {
+			err := d.createFile(ctx, service, item.backup.Path, item.folderID)
+			d.recordUpload(ctx, item.backup.Path, err == nil, err)
 			if err != nil {
-				fmt.Printf("Không thể đọc file trong thư mục %s: %v\n", dateFolderPath, err)
+				logging.From(ctx).Error("Failed to upload backup", logging.KeyFile, item.backup.Path, logging.Err(err))
+				failed++
 				continue
 			}
-
-			// Upload từng file
-			for _, filePath := range files {
-				fileName := filepath.Base(filePath)
-
-				// Kiểm tra file đã tồn tại chưa
-				exists, err := d.checkFileExists(service, fileName, dateFolderID)
-				if err != nil {
-					fmt.Printf("Không thể kiểm tra file %s: %v\n", fileName, err)
-					continue
-				}
-
-				if exists {
-					fmt.Printf("File %s đã tồn tại trên Drive, bỏ qua\n", fileName)
-					continue
-				}
-
-				// Chuẩn bị metadata
-				fileMetadata := &drive.File{
-					Name:    fileName,
-					Parents: []string{dateFolderID},
-				}
-
-				// Mở file để upload
-				content, err := os.Open(filePath)
-				if err != nil {
-					fmt.Printf("Không thể mở file %s: %v\n", filePath, err)
-					continue
-				}
-
-				// Upload file
-				file, err := service.Files.Create(fileMetadata).
-					Media(content).
-					Fields("id").
-					Do()
-				content.Close()
-
-				if err != nil {
-					fmt.Printf("Không thể upload file %s: %v\n", fileName, err)
-					continue
-				}
-
-				fmt.Printf("Đã upload file %s (ID: %s)\n", fileName, file.Id)
-			}
 		}
+		d.uploadManifest(ctx, service, item.backup.Path, item.folderID)
+	}
+
+	if failed > 0 {
+		return i18n.Errorf(i18n.ErrDriveUploadsPartial, failed, len(backups))
 	}
 
 	return nil
@@ -0,0 +1,291 @@
+package drive
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/backup-cronjob/internal/i18n"
+	"github.com/backup-cronjob/internal/logging"
+	"golang.org/x/oauth2"
+)
+
+// tokenInfoURL là endpoint trả về thông tin (scope, thời hạn) của access token
+const tokenInfoURL = "https://oauth2.googleapis.com/tokeninfo"
+
+// Trạng thái làm mới token (TokenStatus.Refresh)
+const (
+	RefreshOK      = "ok"
+	RefreshFailed  = "failed"
+	RefreshMissing = "missing"
+)
+
+// Cách xác thực với Google Drive (TokenStatus.Method)
+const (
+	AuthOAuth          = "oauth"
+	AuthServiceAccount = "service_account"
+)
+
+// TokenStatus là tình trạng token Google Drive đã lưu (hoặc của service account)
+type TokenStatus struct {
+	Authorized bool   `json:"authorized"`
+	Method     string `json:"method"`
+	// ServiceAccount là email của service account, Impersonate là người dùng được ủy quyền toàn miền
+	ServiceAccount string     `json:"service_account,omitempty"`
+	Impersonate    string     `json:"impersonate,omitempty"`
+	Scopes         []string   `json:"scopes,omitempty"`
+	Expiry         *time.Time `json:"expiry,omitempty"`
+	// Refresh là kết quả làm mới access token bằng refresh token (RefreshOK, RefreshFailed, RefreshMissing)
+	Refresh      string `json:"refresh,omitempty"`
+	RefreshError string `json:"refresh_error,omitempty"`
+}
+
+// Healthy cho biết token dùng được lâu dài: đã xác thực và làm mới được
+func (s *TokenStatus) Healthy() bool {
+	return s.Authorized && s.Refresh == RefreshOK
+}
+
+// callbackResult là mã xác thực (hoặc lỗi) nhận được từ Google qua redirect hoặc do người dùng dán vào
+type callbackResult struct {
+	code string
+	err  error
+}
+
+// LoopbackAuth liên kết Google Drive từ dòng lệnh: mở listener tạm trên 127.0.0.1:port (0 = cổng
+// ngẫu nhiên) làm redirect URI, gọi prompt với URL xác thực và URL redirect, rồi chờ Google chuyển
+// hướng về. Khi trình duyệt ở máy khác, người dùng có thể dán URL bị chuyển hướng tới (hoặc mã code)
+// vào manual. Token nhận được được lưu lại.
+func (d *DriveUploader) LoopbackAuth(ctx context.Context, port int, manual io.Reader, prompt func(authURL, redirectURL string)) (*oauth2.Token, error) {
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		return nil, i18n.Errorf(i18n.ErrDriveLoopback, err)
+	}
+
+	config := d.GetOAuthConfig()
+	config.RedirectURL = fmt.Sprintf("http://127.0.0.1:%d/", listener.Addr().(*net.TCPAddr).Port)
+	state := oauth2.GenerateVerifier()
+	verifier := oauth2.GenerateVerifier()
+
+	results := make(chan callbackResult, 1)
+	deliver := func(result callbackResult) {
+		select {
+		case results <- result:
+		default:
+		}
+	}
+
+	server := &http.Server{
+		ReadHeaderTimeout: 10 * time.Second,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/" {
+				http.NotFound(w, r)
+				return
+			}
+			result := parseCallback(r.URL.Query(), state)
+			message := i18n.T("", "auth_success.heading")
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			if result.err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				message = result.err.Error()
+			}
+			fmt.Fprintf(w, "<!DOCTYPE html><html><body><p>%s</p></body></html>", html.EscapeString(message))
+			// Request không mang đúng state không phải redirect của phiên này: bỏ qua và tiếp tục chờ
+			if i18n.Code(result.err) != i18n.ErrOAuthStateInvalid {
+				deliver(result)
+			}
+		}),
+	}
+	go server.Serve(listener)
+	defer server.Close()
+
+	if manual != nil {
+		go func() {
+			scanner := bufio.NewScanner(manual)
+			for scanner.Scan() {
+				if line := strings.TrimSpace(scanner.Text()); line != "" {
+					deliver(parsePasted(line, state))
+					return
+				}
+			}
+		}()
+	}
+
+	prompt(config.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.ApprovalForce, oauth2.S256ChallengeOption(verifier)), config.RedirectURL)
+
+	var result callbackResult
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result = <-results:
+	}
+	if result.err != nil {
+		return nil, result.err
+	}
+
+	token, err := config.Exchange(ctx, result.code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, i18n.Errorf(i18n.ErrDriveExchange, err)
+	}
+	return token, d.storeToken(token)
+}
+
+// parseCallback lấy mã xác thực từ query của redirect, kiểm tra state để chống CSRF
+func parseCallback(query url.Values, state string) callbackResult {
+	if query.Get("state") != state {
+		return callbackResult{err: i18n.Errorf(i18n.ErrOAuthStateInvalid)}
+	}
+	if errParam := query.Get("error"); errParam != "" {
+		return callbackResult{err: i18n.Errorf(i18n.ErrOAuthDenied, errParam)}
+	}
+	code := query.Get("code")
+	if code == "" {
+		return callbackResult{err: i18n.Errorf(i18n.ErrOAuthCodeMissing)}
+	}
+	return callbackResult{code: code}
+}
+
+// parsePasted lấy mã xác thực từ nội dung người dùng dán vào: URL redirect đầy đủ hoặc chỉ mã code
+func parsePasted(line, state string) callbackResult {
+	if u, err := url.Parse(line); err == nil && u.RawQuery != "" {
+		return parseCallback(u.Query(), state)
+	}
+	return callbackResult{code: line}
+}
+
+// DeviceAuth liên kết Google Drive bằng luồng xác thực thiết bị (OAuth device authorization) cho máy
+// chủ không có trình duyệt: gọi prompt với URL xác minh và mã người dùng cần nhập trên thiết bị khác,
+// rồi chờ người dùng xác nhận. Client OAuth phải thuộc loại "TVs and Limited Input devices".
+func (d *DriveUploader) DeviceAuth(ctx context.Context, prompt func(verificationURL, userCode string, expiry time.Time)) (*oauth2.Token, error) {
+	config := d.GetOAuthConfig()
+	config.RedirectURL = ""
+
+	auth, err := config.DeviceAuth(ctx)
+	if err != nil {
+		return nil, i18n.Errorf(i18n.ErrDriveDeviceAuth, err)
+	}
+
+	verificationURL := auth.VerificationURIComplete
+	if verificationURL == "" {
+		verificationURL = auth.VerificationURI
+	}
+	prompt(verificationURL, auth.UserCode, auth.Expiry)
+
+	token, err := config.DeviceAccessToken(ctx, auth)
+	if err != nil {
+		return nil, i18n.Errorf(i18n.ErrDriveExchange, err)
+	}
+	return token, d.storeToken(token)
+}
+
+// storeToken lưu token vừa nhận được khi liên kết
+func (d *DriveUploader) storeToken(token *oauth2.Token) error {
+	if err := d.saveToken(token); err != nil {
+		return i18n.Errorf(i18n.ErrDriveTokenSave, err)
+	}
+	return nil
+}
+
+// TokenStatus kiểm tra token đã lưu: làm mới access token bằng refresh token (token mới được lưu
+// lại) để biết refresh token còn dùng được, rồi hỏi Google scope và thời hạn của access token.
+// Với service account, kiểm tra lấy được access token bằng key.
+func (d *DriveUploader) TokenStatus(ctx context.Context) *TokenStatus {
+	if d.Config.UseServiceAccount() {
+		return d.serviceAccountStatus(ctx)
+	}
+
+	token, err := d.loadToken()
+	if err != nil {
+		return &TokenStatus{Method: AuthOAuth}
+	}
+	status := &TokenStatus{Authorized: true, Method: AuthOAuth, Refresh: RefreshMissing}
+
+	if token.RefreshToken != "" {
+		expired := *token
+		expired.Expiry = time.Now().Add(-time.Minute)
+		refreshed, err := d.GetOAuthConfig().TokenSource(ctx, &expired).Token()
+		if err != nil {
+			status.Refresh = RefreshFailed
+			status.RefreshError = i18n.Errorf(i18n.ErrDriveTokenRefresh, err).Error()
+		} else {
+			status.Refresh = RefreshOK
+			if err := d.saveToken(refreshed); err != nil {
+				logging.From(ctx).Error("Failed to save refreshed Drive token", logging.Err(err))
+			}
+			token = refreshed
+		}
+	}
+
+	status.describe(ctx, token)
+	return status
+}
+
+// serviceAccountStatus lấy access token mới bằng key của service account để kiểm tra key
+// (và quyền ủy quyền toàn miền nếu có) còn dùng được
+func (d *DriveUploader) serviceAccountStatus(ctx context.Context) *TokenStatus {
+	status := &TokenStatus{Authorized: true, Method: AuthServiceAccount, Impersonate: d.Config.GoogleImpersonate}
+
+	config, err := d.serviceAccountConfig()
+	if err != nil {
+		status.Refresh = RefreshFailed
+		status.RefreshError = err.Error()
+		return status
+	}
+	status.ServiceAccount = config.Email
+
+	token, err := config.TokenSource(ctx).Token()
+	if err != nil {
+		status.Refresh = RefreshFailed
+		status.RefreshError = i18n.Errorf(i18n.ErrDriveServiceAccount, err).Error()
+		return status
+	}
+	status.Refresh = RefreshOK
+	status.describe(ctx, token)
+	return status
+}
+
+// describe điền thời hạn và scope (hỏi Google) của access token vào status
+func (s *TokenStatus) describe(ctx context.Context, token *oauth2.Token) {
+	if !token.Expiry.IsZero() {
+		expiry := token.Expiry
+		s.Expiry = &expiry
+	}
+	if token.Valid() {
+		scopes, err := tokenScopes(ctx, token.AccessToken)
+		if err != nil {
+			logging.From(ctx).Warn("Failed to read Drive token info", logging.Err(err))
+		}
+		s.Scopes = scopes
+	}
+}
+
+// tokenScopes hỏi Google các scope đã cấp cho access token
+func tokenScopes(ctx context.Context, accessToken string) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenInfoURL+"?access_token="+url.QueryEscape(accessToken), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("tokeninfo: status %d", resp.StatusCode)
+	}
+
+	var info struct {
+		Scope string `json:"scope"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, err
+	}
+	return strings.Fields(info.Scope), nil
+}
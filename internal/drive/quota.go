@@ -0,0 +1,381 @@
+package drive
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/backup-cronjob/internal/config"
+	"github.com/backup-cronjob/internal/database"
+	"github.com/backup-cronjob/internal/i18n"
+	"github.com/backup-cronjob/internal/logging"
+	"github.com/backup-cronjob/internal/metrics"
+	"github.com/backup-cronjob/internal/models"
+	"github.com/backup-cronjob/internal/retention"
+	"google.golang.org/api/drive/v3"
+)
+
+// quotaCacheTTL là thời gian dùng lại hạn mức đã đọc cho giao diện web và API
+const quotaCacheTTL = time.Minute
+
+// Quota là hạn mức lưu trữ Google Drive của tài khoản đang dùng (người dùng đã liên kết hoặc
+// service account)
+type Quota struct {
+	// Limit là tổng dung lượng (byte), 0 nếu không giới hạn
+	Limit        int64     `json:"limit"`
+	Usage        int64     `json:"usage"`
+	UsageInDrive int64     `json:"usage_in_drive"`
+	UsageInTrash int64     `json:"usage_in_trash"`
+	CheckedAt    time.Time `json:"checked_at"`
+}
+
+// Unlimited cho biết tài khoản không bị giới hạn dung lượng
+func (q *Quota) Unlimited() bool {
+	return q.Limit <= 0
+}
+
+// Free trả về dung lượng còn trống (byte), 0 nếu không giới hạn
+func (q *Quota) Free() int64 {
+	if q.Unlimited() || q.Usage >= q.Limit {
+		return 0
+	}
+	return q.Limit - q.Usage
+}
+
+// Fits cho biết còn đủ chỗ cho size byte
+func (q *Quota) Fits(size int64) bool {
+	return q.Unlimited() || size <= q.Free()
+}
+
+// UsedPercent trả về phần trăm dung lượng đã dùng (0 nếu không giới hạn)
+func (q *Quota) UsedPercent() int {
+	if q.Unlimited() {
+		return 0
+	}
+	if q.Usage >= q.Limit {
+		return 100
+	}
+	return int(q.Usage * 100 / q.Limit)
+}
+
+// FormatUsage trả về dung lượng đã dùng đã được format
+func (q *Quota) FormatUsage() string {
+	return models.FormatBytes(q.Usage)
+}
+
+// FormatLimit trả về hạn mức đã được format
+func (q *Quota) FormatLimit() string {
+	return models.FormatBytes(q.Limit)
+}
+
+// Quota trả về hạn mức lưu trữ Google Drive, dùng lại kết quả (kể cả lỗi) đọc trong vòng
+// quotaCacheTTL để trang chủ không phải chờ Drive mỗi lần tải
+func (d *DriveUploader) Quota(ctx context.Context) (*Quota, error) {
+	d.quotaMu.Lock()
+	cached, cachedErr, errAt := d.quota, d.quotaErr, d.quotaErrAt
+	d.quotaMu.Unlock()
+	if cachedErr != nil && time.Since(errAt) < quotaCacheTTL {
+		return nil, cachedErr
+	}
+	if cached != nil && time.Since(cached.CheckedAt) < quotaCacheTTL {
+		return cached, nil
+	}
+
+	service, err := d.getClient(ctx)
+	if err == nil {
+		var quota *Quota
+		if quota, err = d.fetchQuota(ctx, service); err == nil {
+			return quota, nil
+		}
+	} else {
+		err = i18n.Errorf(i18n.ErrDriveConnect, err)
+	}
+
+	d.quotaMu.Lock()
+	d.quotaErr, d.quotaErrAt = err, time.Now()
+	d.quotaMu.Unlock()
+	return nil, err
+}
+
+// fetchQuota đọc about.storageQuota từ Drive, cập nhật bản lưu tạm và metric
+func (d *DriveUploader) fetchQuota(ctx context.Context, service *drive.Service) (*Quota, error) {
+	about, err := service.About.Get().Fields("storageQuota").Context(ctx).Do()
+	if err != nil {
+		metrics.DriveAPIError(err)
+		return nil, i18n.Errorf(i18n.ErrDriveQuota, err)
+	}
+
+	quota := &Quota{CheckedAt: time.Now()}
+	if sq := about.StorageQuota; sq != nil {
+		quota.Limit = sq.Limit
+		quota.Usage = sq.Usage
+		quota.UsageInDrive = sq.UsageInDrive
+		quota.UsageInTrash = sq.UsageInDriveTrash
+	}
+	metrics.DriveQuota(quota.Limit, quota.Usage)
+
+	d.quotaMu.Lock()
+	d.quota, d.quotaErr = quota, nil
+	d.quotaMu.Unlock()
+	return quota, nil
+}
+
+// checkQuota kiểm tra Drive còn đủ chỗ cho need byte sắp upload của job. Khi không đủ, tùy
+// DriveQuotaAction: từ chối, hoặc xóa các bản backup hết hạn trên Drive rồi kiểm tra lại.
+// Không đọc được hạn mức thì chỉ ghi log, để việc upload tự báo lỗi nếu có. File trong Shared
+// Drive không tính vào hạn mức của tài khoản nên không được kiểm tra.
+func (d *DriveUploader) checkQuota(ctx context.Context, service *drive.Service, job string, need int64) error {
+	if d.Config.DriveQuotaAction == config.QuotaActionOff || d.Config.SharedDriveID != "" || need <= 0 {
+		return nil
+	}
+	logger := logging.From(ctx)
+
+	quota, err := d.fetchQuota(ctx, service)
+	if err != nil {
+		logger.Warn("Failed to check Drive quota before upload", logging.Err(err))
+		return nil
+	}
+	if quota.Fits(need) {
+		return nil
+	}
+
+	free := quota.Free()
+	if d.Config.DriveQuotaAction == config.QuotaActionPrune {
+		logger.Warn("Not enough Drive storage, pruning expired backups on Drive", "needed", need, "free", free)
+		freed := d.pruneAllRemote(ctx, service)
+		// Dung lượng báo về có thể chưa cập nhật ngay sau khi xóa: tính theo số byte đã xóa
+		if _, err := d.fetchQuota(ctx, service); err != nil {
+			logger.Warn("Failed to refresh Drive quota after pruning", logging.Err(err))
+		}
+		if need <= free+freed {
+			return nil
+		}
+		free += freed
+	}
+
+	metrics.PreflightFailed("drive_quota", job)
+	return i18n.Errorf(i18n.ErrDriveQuotaExceeded, models.FormatBytes(need), models.FormatBytes(free))
+}
+
+// uploadSize trả về dung lượng file cùng manifest đặt cạnh (nếu có)
+func uploadSize(filePath string) int64 {
+	var size int64
+	for _, path := range []string{filePath, models.ManifestPath(filePath)} {
+		if info, err := os.Stat(path); err == nil {
+			size += info.Size()
+		}
+	}
+	return size
+}
+
+// pendingSize trả về dung lượng sẽ upload của danh sách upload: các bản backup chưa có trên
+// Drive cùng manifest đi kèm
+func pendingSize(plan []plannedUpload) int64 {
+	var size int64
+	for _, item := range plan {
+		if !item.exists {
+			size += uploadSize(item.backup.Path)
+		}
+	}
+	return size
+}
+
+// pruneAllRemote xóa các bản backup hết hạn trên Drive của mọi job upload lên Drive.
+// Trả về số byte đã giải phóng; lỗi của từng job chỉ được ghi log.
+func (d *DriveUploader) pruneAllRemote(ctx context.Context, service *drive.Service) int64 {
+	var freed int64
+	for _, job := range d.Config.Jobs() {
+		if !job.HasDestination(config.DestinationDrive) {
+			continue
+		}
+		n, err := d.pruneRemote(ctx, service, job)
+		if err != nil {
+			logging.From(ctx).Error("Failed to prune expired backups on Drive", logging.KeyJob, job.Name, logging.Err(err))
+		}
+		freed += n
+	}
+	return freed
+}
+
+// jobFolderID tìm (không tạo) folder của job trên Drive, trả về ID rỗng nếu chưa có
+func (d *DriveUploader) jobFolderID(ctx context.Context, service *drive.Service, job string) (string, error) {
+	var path []string
+	if d.Config.FolderDriveID == "" && d.Config.FolderDrive != "" {
+		path = append(path, d.Config.FolderDrive)
+	}
+	path = append(path, job)
+
+	folderID := d.rootFolderID()
+	for _, name := range path {
+		id, err := d.findFolder(ctx, service, name, folderID)
+		if err != nil || id == "" {
+			return "", err
+		}
+		folderID = id
+	}
+	return folderID, nil
+}
+
+// listAll liệt kê mọi file khớp query (qua tất cả các trang kết quả)
+func (d *DriveUploader) listAll(ctx context.Context, service *drive.Service, query string) ([]*drive.File, error) {
+	var files []*drive.File
+	err := d.listFiles(service, query).
+		Fields("nextPageToken, files(id, name, size, createdTime)").
+		Pages(ctx, func(page *drive.FileList) error {
+			files = append(files, page.Files...)
+			return nil
+		})
+	if err != nil {
+		metrics.DriveAPIError(err)
+		return nil, i18n.Errorf(i18n.ErrDriveList, err)
+	}
+	return files, nil
+}
+
+// pruneRemote xóa các bản backup đã hết hạn của job trên Drive (<job>/<ngày>/) theo chính sách
+// lưu giữ của job như retention.PruneLocal, kèm manifest. Thời điểm tạo và đánh dấu bất thường lấy
+// từ catalog khi có. Với job physical, các file WAL trong <job>/wal/ trước điểm bắt đầu của bản sao
+// lưu vật lý cũ nhất còn giữ lại cũng bị xóa như retention.PruneWAL. Trả về số byte đã giải phóng.
+func (d *DriveUploader) pruneRemote(ctx context.Context, service *drive.Service, job *config.Job) (int64, error) {
+	folderID, err := d.jobFolderID(ctx, service, job.Name)
+	if err != nil || folderID == "" {
+		return 0, err
+	}
+
+	records, err := database.ListJobBackupRecords(job.Name, "")
+	if err != nil {
+		return 0, i18n.Errorf(i18n.ErrCatalogRead, err)
+	}
+	byName := make(map[string]*models.BackupRecord, len(records))
+	for _, record := range records {
+		byName[filepath.Base(record.Path)] = record
+	}
+
+	dates, err := d.listAll(ctx, service, fmt.Sprintf("%s in parents and mimeType='%s' and trashed=false", quoteQuery(folderID), folderMimeType))
+	if err != nil {
+		return 0, err
+	}
+
+	var backups []*models.BackupFile
+	manifests := make(map[string]*drive.File)
+	walFolderID := ""
+	for _, date := range dates {
+		if date.Name == models.WALDirName {
+			walFolderID = date.Id
+			continue
+		}
+		// Chỉ các folder ngày chứa bản backup
+		if _, err := time.Parse("2006-01-02", date.Name); err != nil {
+			continue
+		}
+		files, err := d.listAll(ctx, service, fmt.Sprintf("%s in parents and trashed=false", quoteQuery(date.Id)))
+		if err != nil {
+			return 0, err
+		}
+
+		for _, file := range files {
+			// Path là <ID folder ngày>/<tên file>, dùng để ghép bản backup với manifest
+			path := date.Id + "/" + file.Name
+			if strings.HasSuffix(file.Name, models.ManifestPath("")) {
+				manifests[path] = file
+				continue
+			}
+			if !models.IsBackupName(file.Name) {
+				continue
+			}
+
+			backup := &models.BackupFile{ID: file.Id, Job: job.Name, Name: file.Name, Path: path, Size: file.Size}
+			backup.CreatedAt, _ = time.Parse(time.RFC3339, file.CreatedTime)
+			if record, ok := byName[file.Name]; ok {
+				backup.CreatedAt = record.CreatedAt
+				backup.Anomaly = record.Anomaly
+			}
+			backups = append(backups, backup)
+		}
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+
+	var (
+		freed  int64
+		failed int
+	)
+	for _, backup := range retention.SelectExpired(backups, job.Retention, time.Now()) {
+		if err := d.deleteFile(ctx, service, backup.ID, backup.Name); err != nil {
+			logging.From(ctx).Error("Failed to delete expired backup from Drive", logging.KeyJob, job.Name, logging.KeyFile, backup.Name, logging.Err(err))
+			failed++
+			continue
+		}
+		freed += backup.Size
+		logging.From(ctx).Info("Deleted expired backup from Drive", logging.KeyJob, job.Name, logging.KeyFile, backup.Name, "size", backup.Size)
+
+		if manifest, ok := manifests[models.ManifestPath(backup.Path)]; ok {
+			if err := d.deleteFile(ctx, service, manifest.Id, manifest.Name); err != nil {
+				logging.From(ctx).Warn("Failed to delete manifest from Drive", logging.KeyJob, job.Name, logging.KeyFile, manifest.Name, logging.Err(err))
+				continue
+			}
+			freed += manifest.Size
+		}
+	}
+
+	if job.Mode == config.ModePhysical && walFolderID != "" {
+		n, walFailed, err := d.pruneRemoteWAL(ctx, service, job, walFolderID)
+		if err != nil {
+			return freed, err
+		}
+		freed += n
+		failed += walFailed
+	}
+
+	if failed > 0 {
+		return freed, i18n.Errorf(i18n.ErrDriveRetentionDelete, failed)
+	}
+	return freed, nil
+}
+
+// pruneRemoteWAL xóa các file WAL trong folder wal/ của job trên Drive nằm trước điểm bắt đầu của
+// bản sao lưu vật lý cũ nhất còn giữ lại. Trả về số byte đã giải phóng và số file xóa thất bại.
+func (d *DriveUploader) pruneRemoteWAL(ctx context.Context, service *drive.Service, job *config.Job, folderID string) (int64, int, error) {
+	cutoff, err := retention.WALCutoff(job)
+	if err != nil || cutoff == "" {
+		return 0, 0, err
+	}
+
+	files, err := d.listAll(ctx, service, fmt.Sprintf("%s in parents and trashed=false", quoteQuery(folderID)))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var (
+		freed  int64
+		failed int
+	)
+	for _, file := range files {
+		if !retention.WALExpired(file.Name, cutoff) {
+			continue
+		}
+		if err := d.deleteFile(ctx, service, file.Id, file.Name); err != nil {
+			logging.From(ctx).Error("Failed to delete expired WAL from Drive", logging.KeyJob, job.Name, logging.KeyFile, file.Name, logging.Err(err))
+			failed++
+			continue
+		}
+		freed += file.Size
+		logging.From(ctx).Info("Deleted expired WAL from Drive", logging.KeyJob, job.Name, logging.KeyFile, file.Name, "size", file.Size)
+	}
+	return freed, failed, nil
+}
+
+// deleteFile xóa vĩnh viễn file trên Drive (file trong thùng rác vẫn tính vào hạn mức)
+func (d *DriveUploader) deleteFile(ctx context.Context, service *drive.Service, id, name string) error {
+	if err := service.Files.Delete(id).SupportsAllDrives(true).Context(ctx).Do(); err != nil {
+		metrics.DriveAPIError(err)
+		return i18n.Errorf(i18n.ErrDriveDelete, name, err)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/backup-cronjob/internal/database"
+	"github.com/backup-cronjob/internal/i18n"
+	"github.com/backup-cronjob/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// AuditListHandler trả về audit log theo bộ lọc, dạng JSON (mặc định) hoặc CSV (?format=csv)
+func (h *Handler) AuditListHandler(c *gin.Context) {
+	filter := models.AuditFilter{
+		Actor:  c.Query("actor"),
+		Action: c.Query("action"),
+		Target: c.Query("target"),
+		Result: c.Query("result"),
+		Limit:  1000,
+	}
+
+	// Parse khoảng thời gian (RFC3339 hoặc YYYY-MM-DD)
+	var err error
+	if filter.From, err = parseTimeParam(c.Query("from")); err != nil {
+		respondError(c, http.StatusBadRequest, i18n.ErrInvalidParameter, "from")
+		return
+	}
+	if filter.To, err = parseTimeParam(c.Query("to")); err != nil {
+		respondError(c, http.StatusBadRequest, i18n.ErrInvalidParameter, "to")
+		return
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 0 {
+			respondError(c, http.StatusBadRequest, i18n.ErrInvalidParameter, "limit")
+			return
+		}
+		filter.Limit = n
+	}
+
+	events, err := database.ListAuditEvents(filter)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, i18n.ErrAuditUnavailable)
+		return
+	}
+
+	if c.Query("format") == "csv" {
+		writeAuditCSV(c, events)
+		return
+	}
+
+	if events == nil {
+		events = []*models.AuditEvent{}
+	}
+	c.JSON(http.StatusOK, gin.H{"events": events})
+}
+
+// AuditVerifyHandler kiểm tra tính toàn vẹn của chuỗi hash trong audit log
+func (h *Handler) AuditVerifyHandler(c *gin.Context) {
+	status, err := database.VerifyAuditChain()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, i18n.ErrAuditUnavailable)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"valid":     status.BrokenID == 0,
+		"checked":   status.Count,
+		"broken_id": status.BrokenID,
+		"head_id":   status.HeadID,
+		"head_hash": status.HeadHash,
+	})
+}
+
+// writeAuditCSV xuất danh sách sự kiện ra file CSV
+func writeAuditCSV(c *gin.Context, events []*models.AuditEvent) {
+	fileName := fmt.Sprintf("audit_%s.csv", time.Now().Format("20060102_150405"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	w.Write([]string{"id", "created_at", "actor", "action", "target", "source_ip", "user_agent", "result", "details", "prev_hash", "hash"})
+	for _, e := range events {
+		w.Write([]string{
+			strconv.FormatInt(e.ID, 10),
+			e.CreatedAt.UTC().Format(time.RFC3339Nano),
+			e.Actor, e.Action, e.Target, e.SourceIP, e.UserAgent, e.Result, e.Details, e.PrevHash, e.Hash,
+		})
+	}
+	w.Flush()
+}
+
+// parseTimeParam parse tham số thời gian dạng RFC3339 hoặc YYYY-MM-DD
+func parseTimeParam(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	return time.ParseInLocation("2006-01-02", value, time.Local)
+}
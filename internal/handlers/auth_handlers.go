@@ -3,16 +3,19 @@ package handlers
 import (
 	"net/http"
 
-	"log"
-
+	"github.com/backup-cronjob/internal/audit"
 	"github.com/backup-cronjob/internal/auth"
+	"github.com/backup-cronjob/internal/database"
+	"github.com/backup-cronjob/internal/i18n"
+	"github.com/backup-cronjob/internal/logging"
 	"github.com/backup-cronjob/internal/models"
+	"github.com/backup-cronjob/internal/ratelimit"
 	"github.com/gin-gonic/gin"
 )
 
 // LoginPageHandler hiển thị trang đăng nhập
 func (h *Handler) LoginPageHandler(c *gin.Context) {
-	c.HTML(http.StatusOK, "login.html", nil)
+	render(c, http.StatusOK, "login.html", nil)
 }
 
 // LoginHandler xử lý đăng nhập và trả về JWT token
@@ -21,21 +24,50 @@ func (h *Handler) LoginHandler(c *gin.Context) {
 
 	// Parse dữ liệu đăng nhập từ JSON
 	if err := c.ShouldBindJSON(&loginData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		respondError(c, http.StatusBadRequest, i18n.ErrInvalidRequest)
+		return
+	}
+
+	clientIP := c.ClientIP()
+
+	// Giới hạn tần suất theo username (giới hạn theo IP đã áp dụng ở middleware)
+	if allowed, retryAfter := h.LoginUserLimiter.Allow(loginData.Username); !allowed {
+		ratelimit.AbortTooManyRequests(c, retryAfter)
+		return
+	}
+
+	// Kiểm tra tài khoản hoặc IP có đang bị khóa do đăng nhập sai nhiều lần
+	retryAfter, err := auth.CheckLoginLockout(loginData.Username, clientIP)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, i18n.ErrInternal)
+		return
+	}
+	if retryAfter > 0 {
+		logging.From(c.Request.Context()).Warn("Login locked out", "user", loginData.Username, "ip", clientIP, "retry_after", retryAfter)
+		audit.RecordActor(loginData.Username, models.AuditActionLoginFailed, loginData.Username, models.AuditResultDenied,
+			"locked out", clientIP, c.Request.UserAgent())
+		ratelimit.AbortTooManyRequests(c, retryAfter)
 		return
 	}
 
 	// Xác thực người dùng
 	user, err := auth.AuthenticateUser(&loginData)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		auth.RecordLoginAttempt(loginData.Username, clientIP, false)
+		audit.RecordActor(loginData.Username, models.AuditActionLoginFailed, loginData.Username, models.AuditResultFailure,
+			err.Error(), clientIP, c.Request.UserAgent())
+		respondErr(c, http.StatusUnauthorized, err, i18n.ErrInvalidCredentials)
 		return
 	}
 
+	auth.RecordLoginAttempt(user.Username, clientIP, true)
+	audit.RecordActor(user.Username, models.AuditActionLogin, user.Username, models.AuditResultSuccess,
+		"", clientIP, c.Request.UserAgent())
+
 	// Tạo JWT token
 	token, err := auth.GenerateJWT(user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		respondError(c, http.StatusInternalServerError, i18n.ErrInternal)
 		return
 	}
 
@@ -65,16 +97,16 @@ func (h *Handler) LoginHandler(c *gin.Context) {
 	// Đảm bảo cookie có thuộc tính SameSite đúng
 	c.SetSameSite(http.SameSiteLaxMode)
 
-	// In log thông báo về cookie đã được đặt
-	log.Printf("Login successful for user %s - Set cookies with expiry 30 days, SameSite=Lax", user.Username)
+	logging.From(c.Request.Context()).Info("Login successful", "user", user.Username, "ip", clientIP)
 
 	// Trả về token để lưu trong localStorage (dự phòng)
 	c.JSON(http.StatusOK, gin.H{
 		"token":   token,
-		"message": "Login successful",
+		"message": tr(c, "auth.login_success"),
 		"user": gin.H{
 			"id":       user.ID,
 			"username": user.Username,
+			"role":     user.Role,
 		},
 	})
 }
@@ -84,13 +116,13 @@ func (h *Handler) MeHandler(c *gin.Context) {
 	// Lấy thông tin người dùng đã được lưu trong middleware
 	username, exists := c.Get("username")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		respondError(c, http.StatusUnauthorized, i18n.ErrAuthRequired)
 		return
 	}
 
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		respondError(c, http.StatusUnauthorized, i18n.ErrAuthRequired)
 		return
 	}
 
@@ -100,6 +132,54 @@ func (h *Handler) MeHandler(c *gin.Context) {
 			"id":       userID,
 			"username": username,
 		},
+		"locale": locale(c),
+	})
+}
+
+// preferencesRequest là nội dung request cập nhật tùy chọn của người dùng
+type preferencesRequest struct {
+	// Locale là ngôn ngữ hiển thị (vi, en); rỗng để dùng ngôn ngữ của trình duyệt hoặc LOCALE
+	Locale string `json:"locale"`
+}
+
+// PreferencesHandler lưu ngôn ngữ người dùng chọn và cấp lại token mang ngôn ngữ mới
+func (h *Handler) PreferencesHandler(c *gin.Context) {
+	var req preferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, i18n.ErrInvalidRequest)
+		return
+	}
+
+	lang := i18n.Normalize(req.Locale)
+	if req.Locale != "" && lang == "" {
+		respondError(c, http.StatusBadRequest, i18n.ErrInvalidParameter, "locale")
+		return
+	}
+
+	if err := database.SetUserLocale(c.GetInt64("user_id"), lang); err != nil {
+		respondError(c, http.StatusInternalServerError, i18n.ErrInternal)
+		return
+	}
+
+	user, err := database.GetUserByUsername(c.GetString("username"))
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, i18n.ErrInternal)
+		return
+	}
+	token, err := auth.GenerateJWT(user)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, i18n.ErrInternal)
+		return
+	}
+
+	// Cập nhật cookie để các trang web dùng ngay ngôn ngữ mới
+	c.SetCookie("auth_token", token, 3600*24*30, "/", "", false, true)
+	c.SetCookie(localeCookie, lang, 3600*24*365, "/", "", false, false)
+	c.SetSameSite(http.SameSiteLaxMode)
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":  token,
+		"locale": lang,
 	})
 }
 
@@ -143,10 +223,14 @@ func (h *Handler) LogoutHandler(c *gin.Context) {
 	c.SetSameSite(http.SameSiteLaxMode)
 
 	// Ghi log đăng xuất
-	log.Printf("User %s logged out, all cookies deleted", username)
+	logging.From(c.Request.Context()).Info("User logged out", "user", username)
+	if username != "" {
+		audit.RecordActor(username, models.AuditActionLogout, username, models.AuditResultSuccess,
+			"", c.ClientIP(), c.Request.UserAgent())
+	}
 
 	// Phản hồi thành công
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Logout successful",
+		"message": tr(c, "auth.logout_success"),
 	})
 }
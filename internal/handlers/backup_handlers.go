@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/backup-cronjob/internal/audit"
+	"github.com/backup-cronjob/internal/database"
+	"github.com/backup-cronjob/internal/i18n"
+	"github.com/backup-cronjob/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultDropThreshold là mức giảm số dòng (phần trăm) mặc định để đánh dấu bảng khi so sánh hai bản backup
+const defaultDropThreshold = 50.0
+
+// backupResponse là thông tin bản backup trả về qua API
+type backupResponse struct {
+	ID            string                 `json:"id"`
+	Job           string                 `json:"job"`
+	Name          string                 `json:"name"`
+	Size          int64                  `json:"size"`
+	CreatedAt     time.Time              `json:"created_at"`
+	ServerVersion string                 `json:"server_version,omitempty"`
+	DatabaseSize  int64                  `json:"database_size,omitempty"`
+	DurationMs    int64                  `json:"duration_ms,omitempty"`
+	Anomaly       string                 `json:"anomaly,omitempty"`
+	VerifyStatus  string                 `json:"verify_status,omitempty"`
+	VerifyMessage string                 `json:"verify_message,omitempty"`
+	Databases     []models.DatabaseStats `json:"databases,omitempty"`
+}
+
+// newBackupResponse chuyển bản backup (kèm bản ghi catalog nếu có) sang dạng trả về qua API
+func newBackupResponse(backup *models.BackupFile, record *models.BackupRecord) backupResponse {
+	resp := backupResponse{
+		ID:        backup.ID,
+		Job:       backup.Job,
+		Name:      backup.Name,
+		Size:      backup.Size,
+		CreatedAt: backup.CreatedAt,
+	}
+	if record != nil {
+		resp.ServerVersion = record.ServerVersion
+		resp.DatabaseSize = record.DatabaseSize
+		resp.DurationMs = record.DurationMs
+		resp.Anomaly = record.Anomaly
+		resp.VerifyStatus = record.VerifyStatus
+		resp.VerifyMessage = record.VerifyMessage
+	}
+	return resp
+}
+
+// BackupsListHandler trả về danh sách bản backup kèm thông tin từ catalog, lọc theo ?job=
+func (h *Handler) BackupsListHandler(c *gin.Context) {
+	var (
+		backups []*models.BackupFile
+		err     error
+	)
+	if job := c.Query("job"); job != "" {
+		backups, err = models.GetJobBackups(h.Config.BackupDir, job)
+	} else {
+		backups, err = models.GetAllBackups(h.Config.BackupDir)
+	}
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, i18n.ErrBackupListFailed, err)
+		return
+	}
+
+	records, err := database.ListBackupRecords()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, i18n.ErrCatalogUnavailable)
+		return
+	}
+
+	items := make([]backupResponse, 0, len(backups))
+	for _, backup := range backups {
+		items = append(items, newBackupResponse(backup, records[backup.Path]))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"backups": items})
+}
+
+// BackupDetailHandler trả về thông tin một bản backup cùng các database và bảng lúc dump
+func (h *Handler) BackupDetailHandler(c *gin.Context) {
+	backup, err := models.FindBackupByID(h.Config.BackupDir, c.Param("id"))
+	if err != nil {
+		respondErr(c, http.StatusNotFound, err, i18n.ErrBackupNotFound)
+		return
+	}
+
+	record, databases, err := loadBackupStats(backup)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, i18n.ErrCatalogUnavailable)
+		return
+	}
+
+	resp := newBackupResponse(backup, record)
+	resp.Databases = databases
+	c.JSON(http.StatusOK, resp)
+}
+
+// BackupDiffHandler so sánh số dòng các bảng của bản backup với bản backup ?base=
+// (mặc định: bản backup liền trước của cùng job). ?threshold= là mức giảm (%) để đánh dấu.
+func (h *Handler) BackupDiffHandler(c *gin.Context) {
+	backup, err := models.FindBackupByID(h.Config.BackupDir, c.Param("id"))
+	if err != nil {
+		respondErr(c, http.StatusNotFound, err, i18n.ErrBackupNotFound)
+		return
+	}
+
+	threshold, err := parseThreshold(c.Query("threshold"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, i18n.ErrInvalidParameter, "threshold")
+		return
+	}
+
+	base, err := h.findBaseBackup(backup, c.Query("base"))
+	if err != nil {
+		respondErr(c, http.StatusNotFound, err, i18n.ErrBackupNotFound)
+		return
+	}
+
+	diffs, err := diffBackups(base, backup, threshold)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, i18n.ErrCatalogUnavailable)
+		return
+	}
+
+	if diffs == nil {
+		diffs = []models.TableDiff{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"from":      base.ID,
+		"to":        backup.ID,
+		"threshold": threshold,
+		"tables":    diffs,
+	})
+}
+
+// BackupPageHandler hiển thị trang chi tiết bản backup và so sánh với bản backup khác (?compare=)
+func (h *Handler) BackupPageHandler(c *gin.Context) {
+	if !h.requireLogin(c) {
+		return
+	}
+
+	backup, err := models.FindBackupByID(h.Config.BackupDir, c.Param("id"))
+	if err != nil {
+		redirectErr(c, err, i18n.ErrBackupNotFound)
+		return
+	}
+
+	record, databases, err := loadBackupStats(backup)
+	if err != nil {
+		redirectError(c, i18n.ErrCatalogUnavailable)
+		return
+	}
+
+	// Các bản backup khác của cùng job để chọn so sánh
+	var others []*models.BackupFile
+	if siblings, err := models.GetJobBackups(h.Config.BackupDir, backup.Job); err == nil {
+		for _, sibling := range siblings {
+			if sibling.ID != backup.ID {
+				others = append(others, sibling)
+			}
+		}
+	}
+
+	data := gin.H{
+		"Backup":    backup,
+		"Record":    record,
+		"Databases": databases,
+		"Others":    others,
+		"Threshold": defaultDropThreshold,
+	}
+
+	// Log của lần chạy đã tạo ra bản backup (nếu còn trong lịch sử)
+	if run, err := database.GetRunByBackup(backup.Path); err == nil {
+		data["Run"] = run
+	}
+
+	if base, err := h.findBaseBackup(backup, c.Query("compare")); err == nil {
+		diffs, err := diffBackups(base, backup, defaultDropThreshold)
+		if err != nil {
+			data["Error"] = tr(c, i18n.ErrCompareFailed, base.Name, err)
+		}
+		data["Compare"] = base
+		data["Diff"] = diffs
+	}
+
+	render(c, http.StatusOK, "backup.html", data)
+}
+
+// findBaseBackup tìm bản backup để so sánh theo ID; nếu id rỗng thì lấy bản backup liền trước của cùng job
+func (h *Handler) findBaseBackup(backup *models.BackupFile, id string) (*models.BackupFile, error) {
+	if id != "" {
+		return models.FindBackupByID(h.Config.BackupDir, id)
+	}
+
+	backups, err := models.GetJobBackups(h.Config.BackupDir, backup.Job)
+	if err != nil {
+		return nil, err
+	}
+	for i, b := range backups {
+		if b.ID == backup.ID && i+1 < len(backups) {
+			return backups[i+1], nil
+		}
+	}
+	return nil, i18n.Errorf(i18n.ErrNoBaseBackup, backup.Name)
+}
+
+// loadBackupStats đọc bản ghi catalog và thông tin database của bản backup.
+// Bản backup chưa có trong catalog trả về nil, không lỗi.
+func loadBackupStats(backup *models.BackupFile) (*models.BackupRecord, []models.DatabaseStats, error) {
+	record, err := database.GetBackupRecord(backup.Path)
+	if err != nil || record == nil {
+		return nil, nil, err
+	}
+
+	databases, err := database.GetBackupDatabases(record.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return record, databases, nil
+}
+
+// diffBackups so sánh số dòng các bảng của bản backup to so với from
+func diffBackups(from, to *models.BackupFile, threshold float64) ([]models.TableDiff, error) {
+	var tables [2][]models.TableStat
+	for i, backup := range []*models.BackupFile{from, to} {
+		_, databases, err := loadBackupStats(backup)
+		if err != nil {
+			return nil, err
+		}
+		for _, db := range databases {
+			tables[i] = append(tables[i], db.Tables...)
+		}
+	}
+
+	return models.DiffTables(tables[0], tables[1], threshold), nil
+}
+
+// parseThreshold parse mức giảm (%) từ query, mặc định defaultDropThreshold
+func parseThreshold(value string) (float64, error) {
+	if value == "" {
+		return defaultDropThreshold, nil
+	}
+
+	threshold, err := strconv.ParseFloat(value, 64)
+	if err != nil || threshold < 0 || threshold > 100 {
+		return 0, fmt.Errorf("invalid threshold %q", value)
+	}
+	return threshold, nil
+}
+
+// BackupClearAnomalyHandler bỏ đánh dấu bất thường của bản backup (sau khi đã kiểm tra là hợp lệ),
+// để bản backup lại được áp dụng chính sách lưu giữ
+func (h *Handler) BackupClearAnomalyHandler(c *gin.Context) {
+	backup, err := models.FindBackupByID(h.Config.BackupDir, c.Param("id"))
+	if err != nil {
+		respondErr(c, http.StatusNotFound, err, i18n.ErrBackupNotFound)
+		return
+	}
+
+	err = database.SetBackupAnomaly(backup.Path, "")
+	audit.Record(c, models.AuditActionAnomalyClear, backup.Name, audit.ResultOf(err), audit.ErrorDetails(err))
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, i18n.ErrCatalogUpdate)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": backup.ID, "anomaly": ""})
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/backup-cronjob/internal/i18n"
+	"github.com/gin-gonic/gin"
+)
+
+// DriveQuotaHandler trả về hạn mức lưu trữ Google Drive (dung lượng đã dùng, hạn mức, trong thùng rác)
+func (h *Handler) DriveQuotaHandler(c *gin.Context) {
+	if !h.DriveUploader.CheckAuth() {
+		respondError(c, http.StatusConflict, i18n.ErrDriveTokenMissing)
+		return
+	}
+
+	quota, err := h.DriveUploader.Quota(c.Request.Context())
+	if err != nil {
+		respondErr(c, http.StatusBadGateway, err, i18n.ErrDriveQuota)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"quota":        quota,
+		"unlimited":    quota.Unlimited(),
+		"free":         quota.Free(),
+		"used_percent": quota.UsedPercent(),
+	})
+}
@@ -1,19 +1,27 @@
 package handlers
 
 import (
+	"context"
 	"fmt"
-	"log"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/backup-cronjob/internal/audit"
 	"github.com/backup-cronjob/internal/auth"
+	"github.com/backup-cronjob/internal/backup"
 	"github.com/backup-cronjob/internal/config"
 	"github.com/backup-cronjob/internal/database"
 	"github.com/backup-cronjob/internal/dbdump"
+	"github.com/backup-cronjob/internal/discovery"
 	"github.com/backup-cronjob/internal/drive"
+	"github.com/backup-cronjob/internal/i18n"
+	"github.com/backup-cronjob/internal/logging"
 	"github.com/backup-cronjob/internal/models"
+	"github.com/backup-cronjob/internal/ratelimit"
+	"github.com/backup-cronjob/internal/wal"
 	"github.com/gin-gonic/gin"
 )
 
@@ -22,6 +30,16 @@ type Handler struct {
 	Config         *config.Config
 	DatabaseDumper *dbdump.DatabaseDumper
 	DriveUploader  *drive.DriveUploader
+	Runner         *backup.Runner
+	// Discoverer phát hiện job từ label của container, nil nếu không bật DISCOVERY_ENABLED
+	Discoverer *discovery.Discoverer
+	// Archiver lưu trữ WAL của các job physical
+	Archiver *wal.Archiver
+
+	// Giới hạn tần suất cho đăng nhập và các thao tác tốn tài nguyên
+	LoginIPLimiter   *ratelimit.Limiter
+	LoginUserLimiter *ratelimit.Limiter
+	ActionLimiter    *ratelimit.Limiter
 }
 
 // NewHandler tạo instance mới của Handler
@@ -34,10 +52,25 @@ func NewHandler(cfg *config.Config) *Handler {
 		panic(fmt.Sprintf("Failed to initialize database: %v", err))
 	}
 
+	dumper := dbdump.NewDatabaseDumper(cfg)
+	uploader := drive.NewDriveUploader(cfg)
+
+	var discoverer *discovery.Discoverer
+	if cfg.DiscoveryEnabled {
+		discoverer = discovery.New(cfg, dumper.Docker)
+	}
+
 	return &Handler{
 		Config:         cfg,
-		DatabaseDumper: dbdump.NewDatabaseDumper(cfg),
-		DriveUploader:  drive.NewDriveUploader(cfg),
+		DatabaseDumper: dumper,
+		DriveUploader:  uploader,
+		Runner:         backup.NewRunner(cfg, dumper, uploader),
+		Discoverer:     discoverer,
+		Archiver:       wal.New(cfg, dumper.Docker, uploader),
+
+		LoginIPLimiter:   ratelimit.NewLimiter(cfg.LoginRatePerMinute, time.Minute),
+		LoginUserLimiter: ratelimit.NewLimiter(cfg.LoginRatePerMinute, time.Minute),
+		ActionLimiter:    ratelimit.NewLimiter(cfg.ActionRatePerMinute, time.Minute),
 	}
 }
 
@@ -49,23 +82,22 @@ type OperationResult struct {
 
 // IndexHandler xử lý trang chủ
 func (h *Handler) IndexHandler(c *gin.Context) {
-	log.Printf("IndexHandler - Đang xử lý request từ %s", c.Request.RemoteAddr)
+	logger := logging.From(c.Request.Context())
 
 	// Kiểm tra các phương thức xác thực khác nhau
 	cookieValue, _ := c.Cookie("logged_in")
 	authToken, _ := c.Cookie("auth_token")
 	authHeader := c.GetHeader("Authorization")
 
-	log.Printf("Auth check: logged_in cookie=[%s], auth_token cookie exists=[%v], auth header exists=[%v]",
-		cookieValue, authToken != "", authHeader != "")
+	logger.Debug("Auth check", "logged_in_cookie", cookieValue == "true", "auth_token_cookie", authToken != "", "auth_header", authHeader != "")
 
 	// Nếu có bất kỳ phương thức xác thực hợp lệ nào, cho phép truy cập
 	if cookieValue == "true" || authToken != "" || authHeader != "" {
-		log.Printf("✅ User authenticated via: %s", getAuthMethod(cookieValue, authToken, authHeader))
+		logger.Debug("User authenticated", "method", getAuthMethod(cookieValue, authToken, authHeader))
 
 		// Nếu có auth token cookie nhưng không có logged_in cookie, đặt logged_in cookie
 		if authToken != "" && cookieValue != "true" {
-			log.Printf("Setting logged_in cookie from auth_token cookie")
+			logger.Debug("Setting logged_in cookie from auth_token cookie")
 			c.SetCookie("logged_in", "true", 3600*24*30, "/", "", false, false)
 			c.SetSameSite(http.SameSiteLaxMode)
 		}
@@ -76,8 +108,9 @@ func (h *Handler) IndexHandler(c *gin.Context) {
 
 		// Hiển thị trang chủ với trạng thái xác thực Google Drive
 		if !isAuthenticated {
-			c.HTML(http.StatusOK, "index.html", gin.H{
+			render(c, http.StatusOK, "index.html", gin.H{
 				"NeedAuth": true,
+				"Jobs":     h.Config.Jobs(),
 			})
 			return
 		}
@@ -85,12 +118,14 @@ func (h *Handler) IndexHandler(c *gin.Context) {
 		// Lấy danh sách các file backup
 		backups, err := models.GetAllBackups(h.Config.BackupDir)
 		if err != nil {
-			c.HTML(http.StatusOK, "index.html", gin.H{
-				"Error": fmt.Sprintf("Không thể lấy danh sách backup: %v", err),
+			render(c, http.StatusOK, "index.html", gin.H{
+				"Error": tr(c, i18n.ErrBackupListFailed, err),
 			})
 			return
 		}
 
+		attachCatalog(backups)
+
 		// Lấy kết quả thao tác từ session nếu có
 		var lastOperation *OperationResult
 		if flashes := c.Request.URL.Query().Get("success"); flashes != "" {
@@ -100,18 +135,51 @@ func (h *Handler) IndexHandler(c *gin.Context) {
 			}
 		}
 
-		c.HTML(http.StatusOK, "index.html", gin.H{
+		render(c, http.StatusOK, "index.html", gin.H{
 			"Backups":       backups,
+			"Jobs":          h.Config.Jobs(),
 			"LastOperation": lastOperation,
+			"DriveQuota":    h.driveQuota(c),
 		})
 		return
 	}
 
 	// Chưa xác thực, chuyển hướng đến trang đăng nhập
-	log.Printf("❌ User not authenticated, redirecting to login page")
+	logger.Debug("User not authenticated, redirecting to login page")
 	c.Redirect(http.StatusFound, "/login")
 }
 
+// driveQuota trả về hạn mức Google Drive để hiển thị khi có job upload lên Drive; nil nếu không
+// có hoặc không đọc được (lỗi chỉ được ghi log để không chặn trang chủ)
+func (h *Handler) driveQuota(c *gin.Context) *drive.Quota {
+	for _, job := range h.Config.Jobs() {
+		if !job.HasDestination(config.DestinationDrive) {
+			continue
+		}
+		quota, err := h.DriveUploader.Quota(c.Request.Context())
+		if err != nil {
+			logging.From(c.Request.Context()).Warn("Failed to read Drive quota", logging.Err(err))
+			return nil
+		}
+		return quota
+	}
+	return nil
+}
+
+// requireLogin kiểm tra request có JWT hợp lệ, nếu không chuyển hướng về trang chủ kèm thông báo
+func (h *Handler) requireLogin(c *gin.Context) bool {
+	claims, err := auth.ClaimsFromRequest(c)
+	if err != nil {
+		redirectError(c, i18n.ErrAuthRequired)
+		return false
+	}
+
+	c.Set("user_id", claims.UserID)
+	c.Set("username", claims.Username)
+	c.Set("role", claims.Role)
+	return true
+}
+
 // getAuthMethod trả về phương thức xác thực được sử dụng
 func getAuthMethod(cookieValue, authToken, authHeader string) string {
 	methods := []string{}
@@ -135,64 +203,123 @@ func getAuthMethod(cookieValue, authToken, authHeader string) string {
 	return strings.Join(methods, ", ")
 }
 
-// AuthHandler xử lý trang xác thực
+// AuthHandler xử lý trang xác thực (chỉ dành cho admin)
 func (h *Handler) AuthHandler(c *gin.Context) {
+	if h.Config.UseServiceAccount() {
+		render(c, http.StatusBadRequest, "error.html", gin.H{
+			"Error": tr(c, i18n.ErrDriveServiceAccountMode),
+		})
+		return
+	}
+
+	// Tạo state ngẫu nhiên gắn với phiên đăng nhập hiện tại kèm PKCE verifier
+	state, verifier, err := auth.NewOAuthState(c.GetInt64("user_id"))
+	if err != nil {
+		render(c, http.StatusInternalServerError, "error.html", gin.H{
+			"Error": tr(c, i18n.ErrOAuthStart),
+		})
+		return
+	}
+
 	// Tạo URL xác thực và chuyển hướng người dùng trực tiếp đến trang đăng nhập Google
-	authURL := h.DriveUploader.GetAuthURL()
+	authURL := h.DriveUploader.GetAuthURL(state, verifier)
 	c.Redirect(http.StatusFound, authURL)
 }
 
-// OAuthCallbackHandler xử lý callback từ Google OAuth2
+// OAuthCallbackHandler xử lý callback từ Google OAuth2 (chỉ dành cho admin)
 func (h *Handler) OAuthCallbackHandler(c *gin.Context) {
+	// Kiểm tra state để chống CSRF, đồng thời lấy PKCE verifier
+	verifier, err := auth.ConsumeOAuthState(c.Query("state"), c.GetInt64("user_id"))
+	if err != nil {
+		audit.Record(c, models.AuditActionGoogleLink, h.Config.FolderDrive, models.AuditResultDenied, err.Error())
+		render(c, http.StatusBadRequest, "error.html", gin.H{
+			"Error": tr(c, i18n.ErrOAuthStateInvalid),
+		})
+		return
+	}
+
+	// Google trả về lỗi (vd: người dùng từ chối cấp quyền)
+	if errParam := c.Query("error"); errParam != "" {
+		audit.Record(c, models.AuditActionGoogleLink, h.Config.FolderDrive, models.AuditResultFailure, errParam)
+		render(c, http.StatusBadRequest, "error.html", gin.H{
+			"Error": tr(c, i18n.ErrOAuthDenied, errParam),
+		})
+		return
+	}
+
 	// Lấy mã xác thực từ query parameters
 	code := c.Query("code")
 	if code == "" {
-		c.HTML(http.StatusBadRequest, "error.html", gin.H{
-			"Error": "Không nhận được mã xác thực từ Google. Vui lòng thử lại.",
+		render(c, http.StatusBadRequest, "error.html", gin.H{
+			"Error": tr(c, i18n.ErrOAuthCodeMissing),
 		})
 		return
 	}
 
 	// Đổi mã xác thực lấy token
-	_, err := h.DriveUploader.ExchangeAuthCode(code)
+	_, err = h.DriveUploader.ExchangeAuthCode(code, verifier)
+	audit.Record(c, models.AuditActionGoogleLink, h.Config.FolderDrive, audit.ResultOf(err), audit.ErrorDetails(err))
 	if err != nil {
-		c.HTML(http.StatusBadRequest, "error.html", gin.H{
-			"Error": fmt.Sprintf("Lỗi xác thực: %v", err),
+		render(c, http.StatusBadRequest, "error.html", gin.H{
+			"Error": tr(c, i18n.ErrOAuthExchangeFailed, err),
 		})
 		return
 	}
 
 	// Hiển thị trang thành công, JavaScript sẽ tự động đóng cửa sổ này
-	c.HTML(http.StatusOK, "auth_success.html", gin.H{
-		"Message": "Xác thực Google Drive thành công! Cửa sổ này sẽ tự động đóng.",
+	render(c, http.StatusOK, "auth_success.html", gin.H{
+		"Message": tr(c, "auth_success.message"),
 	})
 }
 
 // DumpHandler xử lý yêu cầu dump database
 func (h *Handler) DumpHandler(c *gin.Context) {
 	// Kiểm tra xác thực JWT từ local storage
-	authHeader := c.GetHeader("Authorization")
-	if authHeader == "" {
-		c.Redirect(http.StatusSeeOther, "/?success=false&message=Vui lòng đăng nhập để thực hiện thao tác này")
+	if !h.requireLogin(c) {
 		return
 	}
 
-	// Thực hiện dump database
-	result, err := h.DatabaseDumper.DumpDatabase()
+	// Chọn job cần dump (mặc định: tất cả các job)
+	jobs, err := h.Config.SelectJobs(c.PostForm("job"))
 	if err != nil {
-		c.Redirect(http.StatusSeeOther, "/?success=false&message="+fmt.Sprintf("Lỗi khi dump database: %v", err))
+		redirectError(c, i18n.ErrDumpFailed, err)
 		return
 	}
 
-	c.Redirect(http.StatusSeeOther, "/?success=true&message="+fmt.Sprintf("Đã dump database thành công. File: %s", filepath.Base(result.FilePath)))
+	// Thực hiện dump database cho từng job. Job chạy trên context tách khỏi request để trình
+	// duyệt hoặc proxy ngắt kết nối không hủy pg_dump giữa chừng; lỗi của một job không dừng
+	// các job còn lại.
+	ctx := context.WithoutCancel(c.Request.Context())
+	var files, anomalies, failures []string
+	for _, job := range jobs {
+		result, err := h.Runner.RunJob(ctx, job, backup.RunOptions{Audit: audit.ForRequest(c)})
+		if err != nil {
+			failures = append(failures, i18n.Message(locale(c), err))
+			continue
+		}
+		files = append(files, filepath.Base(result.Dump.FilePath))
+		if result.Anomaly != "" {
+			anomalies = append(anomalies, fmt.Sprintf("%s: %s", filepath.Base(result.Dump.FilePath), result.Anomaly))
+		}
+	}
+
+	var messages []string
+	if len(files) > 0 {
+		messages = append(messages, tr(c, "flash.dump_success", strings.Join(files, ", ")))
+	}
+	if len(anomalies) > 0 {
+		messages = append(messages, tr(c, "flash.dump_anomalies", strings.Join(anomalies, "; ")))
+	}
+	if len(failures) > 0 {
+		messages = append(messages, tr(c, i18n.ErrDumpFailed, strings.Join(failures, "; ")))
+	}
+	redirectResult(c, len(failures) == 0, strings.Join(messages, " "))
 }
 
 // UploadLastHandler xử lý yêu cầu upload file mới nhất
 func (h *Handler) UploadLastHandler(c *gin.Context) {
 	// Kiểm tra xác thực JWT
-	authHeader := c.GetHeader("Authorization")
-	if authHeader == "" {
-		c.Redirect(http.StatusSeeOther, "/?success=false&message=Vui lòng đăng nhập để thực hiện thao tác này")
+	if !h.requireLogin(c) {
 		return
 	}
 
@@ -203,28 +330,27 @@ func (h *Handler) UploadLastHandler(c *gin.Context) {
 	}
 
 	// Tìm file backup mới nhất
-	latestBackup, err := models.FindLatestBackup(h.Config.BackupDir)
+	latestBackup, err := models.FindLatestBackup(h.Config.BackupDir, c.PostForm("job"))
 	if err != nil {
-		c.Redirect(http.StatusSeeOther, "/?success=false&message="+fmt.Sprintf("Không tìm thấy file backup: %v", err))
+		redirectErr(c, err, i18n.ErrBackupListFailed)
 		return
 	}
 
 	// Upload file lên Drive
-	err = h.DriveUploader.UploadFile(latestBackup.Path)
+	err = h.DriveUploader.UploadFile(c.Request.Context(), latestBackup.Path)
+	audit.Record(c, models.AuditActionUpload, latestBackup.Name, audit.ResultOf(err), audit.ErrorDetails(err))
 	if err != nil {
-		c.Redirect(http.StatusSeeOther, "/?success=false&message="+fmt.Sprintf("Lỗi khi upload file: %v", err))
+		redirectError(c, i18n.ErrUploadFailed, err)
 		return
 	}
 
-	c.Redirect(http.StatusSeeOther, "/?success=true&message="+fmt.Sprintf("Đã upload file %s lên Google Drive", latestBackup.Name))
+	redirectResult(c, true, tr(c, "flash.upload_success", latestBackup.Name))
 }
 
 // UploadAllHandler xử lý yêu cầu upload tất cả file
 func (h *Handler) UploadAllHandler(c *gin.Context) {
 	// Kiểm tra xác thực JWT
-	authHeader := c.GetHeader("Authorization")
-	if authHeader == "" {
-		c.Redirect(http.StatusSeeOther, "/?success=false&message=Vui lòng đăng nhập để thực hiện thao tác này")
+	if !h.requireLogin(c) {
 		return
 	}
 
@@ -235,21 +361,24 @@ func (h *Handler) UploadAllHandler(c *gin.Context) {
 	}
 
 	// Upload tất cả file backup
-	err := h.DriveUploader.UploadAllBackups()
+	target := c.PostForm("job")
+	err := h.DriveUploader.UploadAllBackups(c.Request.Context(), target)
+	if target == "" {
+		target = "*"
+	}
+	audit.Record(c, models.AuditActionUpload, target, audit.ResultOf(err), audit.ErrorDetails(err))
 	if err != nil {
-		c.Redirect(http.StatusSeeOther, "/?success=false&message="+fmt.Sprintf("Lỗi khi upload tất cả file: %v", err))
+		redirectError(c, i18n.ErrUploadFailed, err)
 		return
 	}
 
-	c.Redirect(http.StatusSeeOther, "/?success=true&message=Đã upload tất cả file backup lên Google Drive")
+	redirectResult(c, true, tr(c, "flash.upload_all_success"))
 }
 
 // UploadSingleHandler xử lý yêu cầu upload một file cụ thể
 func (h *Handler) UploadSingleHandler(c *gin.Context) {
 	// Kiểm tra xác thực JWT
-	authHeader := c.GetHeader("Authorization")
-	if authHeader == "" {
-		c.Redirect(http.StatusSeeOther, "/?success=false&message=Vui lòng đăng nhập để thực hiện thao tác này")
+	if !h.requireLogin(c) {
 		return
 	}
 
@@ -259,80 +388,47 @@ func (h *Handler) UploadSingleHandler(c *gin.Context) {
 		return
 	}
 
-	fileID := c.Param("id")
-	backups, err := models.GetAllBackups(h.Config.BackupDir)
-	if err != nil {
-		c.Redirect(http.StatusSeeOther, "/?success=false&message="+fmt.Sprintf("Không thể lấy danh sách backup: %v", err))
-		return
-	}
-
 	// Tìm file backup theo ID
-	var targetBackup *models.BackupFile
-	for _, backup := range backups {
-		if backup.ID == fileID {
-			targetBackup = backup
-			break
-		}
-	}
-
-	if targetBackup == nil {
-		c.Redirect(http.StatusSeeOther, "/?success=false&message="+fmt.Sprintf("Không tìm thấy file backup có ID: %s", fileID))
+	targetBackup, err := models.FindBackupByID(h.Config.BackupDir, c.Param("id"))
+	if err != nil {
+		redirectErr(c, err, i18n.ErrBackupListFailed)
 		return
 	}
 
 	// Upload file lên Drive
-	err = h.DriveUploader.UploadFile(targetBackup.Path)
+	err = h.DriveUploader.UploadFile(c.Request.Context(), targetBackup.Path)
+	audit.Record(c, models.AuditActionUpload, targetBackup.Name, audit.ResultOf(err), audit.ErrorDetails(err))
 	if err != nil {
-		c.Redirect(http.StatusSeeOther, "/?success=false&message="+fmt.Sprintf("Lỗi khi upload file: %v", err))
+		redirectError(c, i18n.ErrUploadFailed, err)
 		return
 	}
 
-	c.Redirect(http.StatusSeeOther, "/?success=true&message="+fmt.Sprintf("Đã upload file %s lên Google Drive", targetBackup.Name))
+	redirectResult(c, true, tr(c, "flash.upload_success", targetBackup.Name))
 }
 
 // DownloadHandler xử lý yêu cầu tải xuống file backup
 func (h *Handler) DownloadHandler(c *gin.Context) {
-	// Kiểm tra xác thực JWT từ query parameter (từ client javascript)
-	token := c.Query("token")
-	if token == "" {
-		c.Redirect(http.StatusSeeOther, "/?success=false&message=Vui lòng đăng nhập để thực hiện thao tác này")
-		return
-	}
-
-	// Xác thực token
-	_, err := auth.ValidateJWT(token)
-	if err != nil {
-		c.Redirect(http.StatusSeeOther, "/?success=false&message=Phiên đăng nhập không hợp lệ hoặc đã hết hạn")
-		return
-	}
-
-	fileID := c.Param("id")
-	backups, err := models.GetAllBackups(h.Config.BackupDir)
-	if err != nil {
-		c.Redirect(http.StatusSeeOther, "/?success=false&message="+fmt.Sprintf("Không thể lấy danh sách backup: %v", err))
+	// Trình duyệt gửi cookie auth_token; query parameter token chỉ được chấp nhận ở endpoint này
+	if _, err := auth.ClaimsFromRequestOrQuery(c); err != nil {
+		audit.Record(c, models.AuditActionDownload, c.Param("id"), models.AuditResultDenied, "invalid token")
+		redirectError(c, i18n.ErrAuthRequired)
 		return
 	}
 
 	// Tìm file backup theo ID
-	var targetBackup *models.BackupFile
-	for _, backup := range backups {
-		if backup.ID == fileID {
-			targetBackup = backup
-			break
-		}
-	}
-
-	if targetBackup == nil {
-		c.Redirect(http.StatusSeeOther, "/?success=false&message="+fmt.Sprintf("Không tìm thấy file backup có ID: %s", fileID))
+	targetBackup, err := models.FindBackupByID(h.Config.BackupDir, c.Param("id"))
+	if err != nil {
+		redirectErr(c, err, i18n.ErrBackupListFailed)
 		return
 	}
 
 	// Kiểm tra file có tồn tại không
 	if _, err := os.Stat(targetBackup.Path); os.IsNotExist(err) {
-		c.Redirect(http.StatusSeeOther, "/?success=false&message="+fmt.Sprintf("File %s không tồn tại", targetBackup.Name))
+		redirectError(c, i18n.ErrBackupFileMissing, targetBackup.Name)
 		return
 	}
 
 	// Trả về file để tải xuống
+	audit.Record(c, models.AuditActionDownload, targetBackup.Name, models.AuditResultSuccess, "")
 	c.FileAttachment(targetBackup.Path, targetBackup.Name)
 }
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/backup-cronjob/internal/config"
+	"github.com/backup-cronjob/internal/i18n"
+	"github.com/gin-gonic/gin"
+)
+
+// jobResponse là thông tin job trả về qua API (không bao gồm mật khẩu)
+type jobResponse struct {
+	Name         string   `json:"name"`
+	Engine       string   `json:"engine"`
+	Mode         string   `json:"mode"`
+	Connection   string   `json:"connection"`
+	Container    string   `json:"container,omitempty"`
+	Host         string   `json:"host,omitempty"`
+	Port         int      `json:"port,omitempty"`
+	Database     string   `json:"database"`
+	User         string   `json:"user"`
+	Schedule     string   `json:"schedule"`
+	Destinations []string `json:"destinations"`
+	KeepLast     int      `json:"keep_last"`
+	MaxAgeDays   int      `json:"max_age_days"`
+	Discovered   bool     `json:"discovered"`
+}
+
+// newJobResponse chuyển job sang dạng trả về qua API
+func newJobResponse(job *config.Job) jobResponse {
+	return jobResponse{
+		Name:         job.Name,
+		Engine:       job.Engine,
+		Mode:         job.Mode,
+		Connection:   job.Connection.Type,
+		Container:    job.ContainerName,
+		Host:         job.Connection.Host,
+		Port:         job.Connection.Port,
+		Database:     job.DBName,
+		User:         job.DBUser,
+		Schedule:     job.Schedule,
+		Destinations: job.Destinations,
+		KeepLast:     job.Retention.KeepLast,
+		MaxAgeDays:   job.Retention.MaxAgeDays,
+		Discovered:   job.Discovered,
+	}
+}
+
+// JobsListHandler trả về danh sách job (cấu hình và phát hiện tự động)
+func (h *Handler) JobsListHandler(c *gin.Context) {
+	jobs := h.Config.Jobs()
+
+	items := make([]jobResponse, 0, len(jobs))
+	for _, job := range jobs {
+		items = append(items, newJobResponse(job))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"jobs": items})
+}
+
+// JobsDiscoverHandler chạy ngay một lượt phát hiện container thay vì chờ lượt định kỳ
+func (h *Handler) JobsDiscoverHandler(c *gin.Context) {
+	if h.Discoverer == nil {
+		respondError(c, http.StatusConflict, i18n.ErrDiscoveryDisabled)
+		return
+	}
+
+	jobs, err := h.Discoverer.Refresh(c.Request.Context())
+	if err != nil {
+		respondError(c, http.StatusBadGateway, i18n.ErrDiscoveryFailed, err)
+		return
+	}
+
+	items := make([]jobResponse, 0, len(jobs))
+	for _, job := range jobs {
+		items = append(items, newJobResponse(job))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"discovered": items})
+}
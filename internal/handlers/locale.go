@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/backup-cronjob/internal/auth"
+	"github.com/backup-cronjob/internal/i18n"
+	"github.com/gin-gonic/gin"
+)
+
+// localeCookie là cookie lưu ngôn ngữ chọn trên trình duyệt
+const localeCookie = "lang"
+
+// LocaleMiddleware xác định ngôn ngữ của request và gắn vào context, theo thứ tự ưu tiên:
+// tham số ?lang=, ngôn ngữ người dùng đã chọn (trong JWT), cookie lang, header Accept-Language,
+// sau cùng là LOCALE
+func LocaleMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(i18n.WithLocale(c.Request.Context(), requestLocale(c)))
+		c.Next()
+	}
+}
+
+func requestLocale(c *gin.Context) string {
+	if lang := i18n.Normalize(c.Query("lang")); lang != "" {
+		return lang
+	}
+	if claims, err := auth.ClaimsFromRequest(c); err == nil {
+		if lang := i18n.Normalize(claims.Locale); lang != "" {
+			return lang
+		}
+	}
+	if cookie, err := c.Cookie(localeCookie); err == nil {
+		if lang := i18n.Normalize(cookie); lang != "" {
+			return lang
+		}
+	}
+	if lang := i18n.Match(c.GetHeader("Accept-Language")); lang != "" {
+		return lang
+	}
+	return i18n.Default()
+}
+
+// TemplateFuncs trả về các hàm dùng trong template, gồm t để dịch: {{t .Lang "khóa" tham_số...}}
+// và pathescape để đưa ID bản backup (chứa dấu /) vào đường dẫn URL
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{"t": i18n.T, "pathescape": url.PathEscape}
+}
+
+// locale trả về ngôn ngữ của request
+func locale(c *gin.Context) string {
+	return i18n.FromContext(c.Request.Context())
+}
+
+// tr dịch thông báo key theo ngôn ngữ của request
+func tr(c *gin.Context, key string, args ...any) string {
+	return i18n.T(locale(c), key, args...)
+}
+
+// render hiển thị template name, thêm ngôn ngữ của request vào data (trường Lang)
+func render(c *gin.Context, status int, name string, data gin.H) {
+	if data == nil {
+		data = gin.H{}
+	}
+	data["Lang"] = locale(c)
+	c.HTML(status, name, data)
+}
+
+// respondError trả về lỗi JSON {"code": ..., "error": ...} theo ngôn ngữ của request
+func respondError(c *gin.Context, status int, code string, args ...any) {
+	c.JSON(status, i18n.ErrorBody(c.Request.Context(), code, args...))
+}
+
+// respondErr trả về lỗi err dạng JSON; lỗi không có mã dùng mã fallback
+func respondErr(c *gin.Context, status int, err error, fallback string) {
+	c.JSON(status, i18n.ErrorBodyFor(c.Request.Context(), err, fallback))
+}
+
+// redirectResult chuyển hướng về trang chủ kèm kết quả thao tác để hiển thị
+func redirectResult(c *gin.Context, success bool, message string) {
+	c.Redirect(http.StatusSeeOther, "/?success="+strconv.FormatBool(success)+"&message="+url.QueryEscape(message))
+}
+
+// redirectError chuyển hướng về trang chủ kèm thông báo lỗi code theo ngôn ngữ của request
+func redirectError(c *gin.Context, code string, args ...any) {
+	redirectResult(c, false, tr(c, code, args...))
+}
+
+// redirectErr chuyển hướng về trang chủ kèm lỗi err theo ngôn ngữ của request;
+// lỗi không có mã được hiển thị qua thông báo fallback
+func redirectErr(c *gin.Context, err error, fallback string) {
+	if e, ok := err.(*i18n.Error); ok {
+		redirectResult(c, false, e.Localize(locale(c)))
+		return
+	}
+	redirectError(c, fallback, err)
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/backup-cronjob/internal/logging"
+	"github.com/gin-gonic/gin"
+)
+
+// quietPaths là các đường dẫn được gọi định kỳ (probe, scrape, file tĩnh), chỉ ghi log ở mức debug
+var quietPaths = []string{"/healthz", "/readyz", "/metrics", "/static/"}
+
+// RequestLogger gắn logger có request_id, method và path vào context của request
+// và ghi một dòng log cho mỗi request sau khi xử lý xong (thay cho logger mặc định của gin)
+func RequestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		requestID := c.GetHeader("X-Request-ID")
+		if requestID == "" || len(requestID) > 64 {
+			requestID = logging.NewID()
+		}
+		c.Header("X-Request-ID", requestID)
+
+		path := c.Request.URL.Path
+		ctx := logging.With(c.Request.Context(), "request_id", requestID, "method", c.Request.Method, "path", path)
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		case isQuietPath(path):
+			level = slog.LevelDebug
+		}
+
+		// Logger lấy lại từ request để có trường user do middleware xác thực thêm vào
+		logging.From(c.Request.Context()).Log(c.Request.Context(), level, "HTTP request",
+			"status", status,
+			"duration", time.Since(start),
+			"ip", c.ClientIP(),
+			"bytes", c.Writer.Size(),
+		)
+	}
+}
+
+func isQuietPath(path string) bool {
+	for _, prefix := range quietPaths {
+		if path == prefix || (strings.HasSuffix(prefix, "/") && strings.HasPrefix(path, prefix)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/backup-cronjob/internal/audit"
+	"github.com/backup-cronjob/internal/i18n"
+	"github.com/backup-cronjob/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// channelResponse là thông tin kênh thông báo trả về qua API (không bao gồm URL, token, mật khẩu)
+type channelResponse struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	Events  []string `json:"events"`
+	Retries int      `json:"retries"`
+}
+
+// NotificationsListHandler trả về các kênh thông báo cùng các sự kiện được gửi tới từng kênh
+func (h *Handler) NotificationsListHandler(c *gin.Context) {
+	notifier := h.Runner.Notifier
+
+	items := make([]channelResponse, 0)
+	for _, channel := range notifier.Channels() {
+		item := channelResponse{Name: channel.Name, Type: channel.Type, Events: []string{}, Retries: channel.Retries}
+
+		seen := make(map[string]bool)
+		for _, rule := range notifier.Config.Rules {
+			for _, name := range rule.Channels {
+				if name != channel.Name {
+					continue
+				}
+				for _, event := range rule.Events {
+					if !seen[event] {
+						seen[event] = true
+						item.Events = append(item.Events, event)
+					}
+				}
+			}
+		}
+		items = append(items, item)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"channels":        items,
+		"digest_schedule": notifier.Config.DigestSchedule,
+	})
+}
+
+// NotificationTestHandler gửi một thông báo thử tới kênh :name
+func (h *Handler) NotificationTestHandler(c *gin.Context) {
+	name := c.Param("name")
+	if _, err := h.Runner.Notifier.Config.Channel(name); err != nil {
+		respondError(c, http.StatusNotFound, i18n.ErrChannelNotFound, name)
+		return
+	}
+
+	err := h.Runner.Notifier.Test(c.Request.Context(), name)
+	audit.Record(c, models.AuditActionNotifyTest, name, audit.ResultOf(err), audit.ErrorDetails(err))
+	if err != nil {
+		respondError(c, http.StatusBadGateway, i18n.ErrNotificationFailed, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"channel": name, "sent": true})
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/backup-cronjob/internal/database"
+	"github.com/backup-cronjob/internal/i18n"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultRunsLimit là số lần chạy trả về mặc định của RunsListHandler
+const defaultRunsLimit = 50
+
+// RunsListHandler trả về các lần chạy job gần nhất (không kèm log), lọc theo ?job= và ?limit=
+func (h *Handler) RunsListHandler(c *gin.Context) {
+	limit := defaultRunsLimit
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			respondError(c, http.StatusBadRequest, i18n.ErrInvalidParameter, "limit")
+			return
+		}
+		limit = n
+	}
+
+	runs, err := database.ListRuns(c.Query("job"), limit)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, i18n.ErrRunsUnavailable)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"runs": runs})
+}
+
+// RunDetailHandler trả về một lần chạy kèm log đã ghi lại
+func (h *Handler) RunDetailHandler(c *gin.Context) {
+	run, err := database.GetRun(c.Param("id"))
+	if errors.Is(err, sql.ErrNoRows) {
+		respondError(c, http.StatusNotFound, i18n.ErrRunNotFound)
+		return
+	}
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, i18n.ErrRunsUnavailable)
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
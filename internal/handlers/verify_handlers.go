@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"log/slog"
+
+	"github.com/backup-cronjob/internal/audit"
+	"github.com/backup-cronjob/internal/database"
+	"github.com/backup-cronjob/internal/i18n"
+	"github.com/backup-cronjob/internal/logging"
+	"github.com/backup-cronjob/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// VerifyHandler khôi phục bản backup vào container tạm và kiểm tra dữ liệu
+func (h *Handler) VerifyHandler(c *gin.Context) {
+	// Kiểm tra xác thực JWT
+	if !h.requireLogin(c) {
+		return
+	}
+
+	backup, err := models.FindBackupByID(h.Config.BackupDir, c.Param("id"))
+	if err != nil {
+		redirectErr(c, err, i18n.ErrBackupNotFound)
+		return
+	}
+
+	result, err := h.Runner.VerifyBackup(c.Request.Context(), backup, audit.ForRequest(c))
+	if err != nil {
+		redirectError(c, i18n.ErrVerifyFailed, backup.Name, err)
+		return
+	}
+
+	redirectResult(c, true, tr(c, "verify.success", backup.Name, result.Message))
+}
+
+// attachCatalog bổ sung thông tin từ catalog (trạng thái kiểm tra, đánh dấu bất thường) vào danh sách backup
+func attachCatalog(backups []*models.BackupFile) {
+	records, err := database.ListBackupRecords()
+	if err != nil {
+		slog.Error("Failed to load backup catalog", logging.Err(err))
+		return
+	}
+
+	for _, backup := range backups {
+		if record, ok := records[backup.Path]; ok {
+			backup.VerifyStatus = record.VerifyStatus
+			backup.VerifyMessage = record.VerifyMessage
+			backup.Anomaly = record.Anomaly
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/backup-cronjob/internal/audit"
+	"github.com/backup-cronjob/internal/config"
+	"github.com/backup-cronjob/internal/database"
+	"github.com/backup-cronjob/internal/i18n"
+	"github.com/backup-cronjob/internal/models"
+	"github.com/backup-cronjob/internal/wal"
+	"github.com/gin-gonic/gin"
+)
+
+// baseBackupResponse là bản sao lưu vật lý cùng khoảng WAL liên tục có thể dùng để khôi phục
+type baseBackupResponse struct {
+	Name      string          `json:"name"`
+	Size      int64           `json:"size"`
+	CreatedAt time.Time       `json:"created_at"`
+	Available bool            `json:"available"`
+	WAL       models.WALRange `json:"wal"`
+}
+
+// pitrRequest là tham số khôi phục theo thời điểm qua API
+type pitrRequest struct {
+	// Target theo RFC3339; rỗng = khôi phục tới cuối WAL đã lưu trữ
+	Target string `json:"target"`
+	Name   string `json:"name"`
+	Image  string `json:"image"`
+	Port   string `json:"port"`
+}
+
+// findPhysicalJob tìm job theo tham số :name và kiểm tra job ở chế độ physical
+func (h *Handler) findPhysicalJob(c *gin.Context) (*config.Job, bool) {
+	job, err := h.Config.FindJob(c.Param("name"))
+	if err != nil {
+		respondErr(c, http.StatusNotFound, err, i18n.ErrJobNotFound)
+		return nil, false
+	}
+	if job.Mode != config.ModePhysical {
+		respondError(c, http.StatusBadRequest, i18n.ErrJobNotPhysical, job.Name)
+		return nil, false
+	}
+	return job, true
+}
+
+// JobWALHandler trả về các bản sao lưu vật lý của job cùng khoảng WAL liên tục của từng bản
+// và số segment WAL đã lưu trữ / chưa upload
+func (h *Handler) JobWALHandler(c *gin.Context) {
+	job, ok := h.findPhysicalJob(c)
+	if !ok {
+		return
+	}
+
+	records, err := database.ListJobBackupRecords(job.Name, models.BackupKindBase)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, i18n.ErrCatalogUnavailable)
+		return
+	}
+	segments, err := database.ListWALSegments(job.Name)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, i18n.ErrCatalogUnavailable)
+		return
+	}
+
+	bases := make([]baseBackupResponse, 0, len(records))
+	for _, record := range records {
+		_, statErr := os.Stat(record.Path)
+		bases = append(bases, baseBackupResponse{
+			Name:      filepath.Base(record.Path),
+			Size:      record.Size,
+			CreatedAt: record.CreatedAt,
+			Available: statErr == nil,
+			WAL:       models.ContiguousWAL(record.WALStart, segments),
+		})
+	}
+
+	pending := 0
+	for _, segment := range segments {
+		if !segment.Uploaded {
+			pending++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"job":          job.Name,
+		"base_backups": bases,
+		"segments":     len(segments),
+		"pending":      pending,
+	})
+}
+
+// JobPITRHandler khôi phục job ở chế độ physical tới một thời điểm vào container postgres mới
+func (h *Handler) JobPITRHandler(c *gin.Context) {
+	job, ok := h.findPhysicalJob(c)
+	if !ok {
+		return
+	}
+
+	var req pitrRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, i18n.ErrInvalidRequest)
+		return
+	}
+
+	opts := wal.RestoreOptions{Name: req.Name, Image: req.Image, Port: req.Port}
+	if req.Target != "" {
+		target, err := time.Parse(time.RFC3339, req.Target)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, i18n.ErrInvalidParameter, "target")
+			return
+		}
+		opts.Target = target
+	}
+
+	result, err := wal.Restore(c.Request.Context(), h.DatabaseDumper.Docker, h.Config.BackupDir, job, opts)
+	target := job.Name
+	if result != nil {
+		target = job.Name + " -> " + result.Container
+	}
+	audit.Record(c, models.AuditActionRestore, target, audit.ResultOf(err), audit.ErrorDetails(err))
+	if err != nil {
+		body := i18n.ErrorBody(c.Request.Context(), i18n.ErrRestoreFailed, err)
+		body["result"] = result
+		c.JSON(http.StatusBadGateway, body)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
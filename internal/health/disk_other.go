@@ -0,0 +1,10 @@
+//go:build !unix
+
+package health
+
+import "github.com/backup-cronjob/internal/i18n"
+
+// FreeSpace chưa được hỗ trợ trên hệ điều hành này
+func FreeSpace(path string) (int64, error) {
+	return 0, i18n.Errorf(i18n.ErrHealthDiskUnsupported)
+}
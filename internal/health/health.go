@@ -0,0 +1,232 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/backup-cronjob/internal/config"
+	"github.com/backup-cronjob/internal/database"
+	"github.com/backup-cronjob/internal/docker"
+	"github.com/backup-cronjob/internal/drive"
+	"github.com/backup-cronjob/internal/i18n"
+)
+
+// Trạng thái của một kiểm tra
+const (
+	StatusOK      = "ok"
+	StatusFail    = "fail"
+	StatusSkipped = "skipped"
+)
+
+// Check là kết quả của một kiểm tra
+type Check struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Message  string `json:"message,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Report là kết quả tổng hợp của /readyz
+type Report struct {
+	// Status là "ok" khi không có kiểm tra nào thất bại
+	Status string    `json:"status"`
+	Time   time.Time `json:"time"`
+	Checks []Check   `json:"checks"`
+}
+
+// Checker kiểm tra các phụ thuộc cần thiết để ứng dụng chạy được backup
+type Checker struct {
+	Config *config.Config
+	Docker *docker.Client
+	Drive  *drive.DriveUploader
+
+	// mu tuần tự hóa các lần chạy của Cached; last là kết quả gần nhất
+	mu   sync.Mutex
+	last *Report
+}
+
+// New tạo Checker
+func New(cfg *config.Config, client *docker.Client, uploader *drive.DriveUploader) *Checker {
+	return &Checker{Config: cfg, Docker: client, Drive: uploader}
+}
+
+// probe là một kiểm tra: trả về thông điệp mô tả, lỗi nếu thất bại hoặc skipped nếu không áp dụng
+type probe struct {
+	name string
+	run  func(ctx context.Context) (string, error)
+}
+
+// skipped đánh dấu kiểm tra không áp dụng với cấu hình hiện tại
+type skipped string
+
+func (s skipped) Error() string { return string(s) }
+
+// Run chạy song song tất cả các kiểm tra, mỗi kiểm tra bị giới hạn bởi HealthTimeout
+func (c *Checker) Run(ctx context.Context) *Report {
+	probes := c.probes()
+	checks := make([]Check, len(probes))
+
+	var wg sync.WaitGroup
+	for i, p := range probes {
+		wg.Add(1)
+		go func(i int, p probe) {
+			defer wg.Done()
+			checks[i] = c.run(ctx, p)
+		}(i, p)
+	}
+	wg.Wait()
+
+	report := &Report{Status: StatusOK, Time: time.Now(), Checks: checks}
+	for _, check := range checks {
+		if check.Status == StatusFail {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+// Cached trả về kết quả kiểm tra gần nhất nếu chưa quá HealthCacheTTL, nếu không thì chạy lại
+// (để /readyz bị gọi liên tục không làm mới token Drive hay gọi Docker ở mỗi request). Các request
+// đồng thời chờ chung một lần chạy; lần chạy không bị hủy khi request của người gọi kết thúc.
+func (c *Checker) Cached(ctx context.Context) *Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.last != nil && time.Since(c.last.Time) < c.Config.HealthCacheTTL {
+		return c.last
+	}
+	c.last = c.Run(context.WithoutCancel(ctx))
+	return c.last
+}
+
+// run chạy một kiểm tra với timeout
+func (c *Checker) run(ctx context.Context, p probe) Check {
+	timeout := c.Config.HealthTimeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	message, err := p.run(ctx)
+	check := Check{Name: p.name, Status: StatusOK, Message: message}
+	if s, ok := err.(skipped); ok {
+		check.Status = StatusSkipped
+		check.Message = string(s)
+	} else if err != nil {
+		check.Status = StatusFail
+		check.Message = err.Error()
+	}
+	check.Duration = time.Since(start).Round(time.Millisecond).String()
+	return check
+}
+
+// probes trả về danh sách kiểm tra theo cấu hình hiện tại (bao gồm job được phát hiện tự động)
+func (c *Checker) probes() []probe {
+	probes := []probe{
+		{"database", c.checkDatabase},
+		{"docker", c.checkDocker},
+	}
+	for _, container := range c.containers() {
+		container := container
+		probes = append(probes, probe{"container:" + container, func(ctx context.Context) (string, error) {
+			return "running", c.Docker.EnsureRunning(ctx, container)
+		}})
+	}
+	return append(probes,
+		probe{"drive", c.checkDrive},
+		probe{"disk", c.checkDisk},
+	)
+}
+
+// containers trả về các container (không trùng lặp) mà job kết nối qua docker exec
+func (c *Checker) containers() []string {
+	seen := make(map[string]bool)
+	var containers []string
+	for _, job := range c.Config.Jobs() {
+		if job.Connection.Type != config.ConnectionDocker || job.ContainerName == "" || seen[job.ContainerName] {
+			continue
+		}
+		seen[job.ContainerName] = true
+		containers = append(containers, job.ContainerName)
+	}
+	sort.Strings(containers)
+	return containers
+}
+
+func (c *Checker) checkDatabase(ctx context.Context) (string, error) {
+	if database.DB == nil {
+		return "", i18n.Errorf(i18n.ErrHealthDatabase)
+	}
+	return "", database.DB.PingContext(ctx)
+}
+
+func (c *Checker) checkDocker(ctx context.Context) (string, error) {
+	return "", c.Docker.Ping(ctx)
+}
+
+// checkDrive kiểm tra token Google Drive khi có job upload lên Drive
+func (c *Checker) checkDrive(ctx context.Context) (string, error) {
+	used := false
+	for _, job := range c.Config.Jobs() {
+		if job.HasDestination(config.DestinationDrive) {
+			used = true
+			break
+		}
+	}
+	if !used {
+		return "", skipped(i18n.T("", "health.no_drive_jobs"))
+	}
+	return i18n.T("", "health.token_valid"), c.Drive.CheckToken(ctx)
+}
+
+// checkDisk kiểm tra dung lượng trống của BackupDir không thấp hơn HealthMinFreeMB
+func (c *Checker) checkDisk(ctx context.Context) (string, error) {
+	free, err := FreeSpace(c.Config.BackupDir)
+	if err != nil {
+		return "", err
+	}
+
+	message := i18n.T("", "health.disk_free", free>>20)
+	if min := int64(c.Config.HealthMinFreeMB) << 20; free < min {
+		return "", i18n.Errorf(i18n.ErrHealthDiskLow, message, c.Config.HealthMinFreeMB)
+	}
+	return message, nil
+}
+
+// LivenessHandler phục vụ /healthz: chỉ xác nhận tiến trình còn phản hồi
+func LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": StatusOK})
+	})
+}
+
+// ReadinessHandler phục vụ /readyz: 200 nếu mọi kiểm tra đạt, 503 nếu không. Kết quả từng kiểm tra
+// (đường dẫn, tên container, lỗi của phụ thuộc) chỉ trả về khi detailed chấp nhận request;
+// các request khác chỉ nhận trạng thái tổng.
+func (c *Checker) ReadinessHandler(detailed func(r *http.Request) bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := c.Cached(r.Context())
+		status := http.StatusOK
+		if report.Status != StatusOK {
+			status = http.StatusServiceUnavailable
+		}
+		if !detailed(r) {
+			writeJSON(w, status, map[string]string{"status": report.Status})
+			return
+		}
+		writeJSON(w, status, report)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
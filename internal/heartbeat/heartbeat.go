@@ -0,0 +1,132 @@
+package heartbeat
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/backup-cronjob/internal/config"
+	"github.com/backup-cronjob/internal/i18n"
+	"github.com/backup-cronjob/internal/logging"
+)
+
+// Các tín hiệu gửi tới heartbeat
+const (
+	SignalStart   = "start"
+	SignalSuccess = "success"
+	SignalFail    = "fail"
+)
+
+// maxBody giới hạn kích thước đoạn log gửi kèm ping (byte)
+const maxBody = 10000
+
+// Ping gửi tín hiệu signal kèm đoạn log body tới heartbeat của job (nếu có cấu hình), thử lại
+// theo retries/retry_delay khi lỗi tạm thời. Lỗi chỉ được ghi log, không bao giờ làm backup thất bại.
+func Ping(ctx context.Context, job *config.Job, signal, runID, body string) {
+	h := &job.Heartbeat
+	if !h.Enabled() {
+		return
+	}
+
+	if err := ping(ctx, h, signal, runID, body); err != nil {
+		logging.From(ctx).Warn("Heartbeat ping failed", "signal", signal, logging.Err(err))
+	}
+}
+
+// ping gửi một tín hiệu, thử lại khi lỗi mạng hoặc server trả về 5xx/429
+func ping(ctx context.Context, h *config.HeartbeatConfig, signal, runID, body string) error {
+	target, err := pingURL(h.URL, signal, runID)
+	if err != nil {
+		return err
+	}
+	body = tail(body, maxBody)
+
+	for attempt := 0; attempt <= h.Retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(h.RetryDelay):
+			}
+		}
+
+		var retry bool
+		retry, err = send(ctx, h.Timeout, target, body)
+		if err == nil || !retry {
+			return err
+		}
+	}
+	return i18n.Errorf(i18n.ErrRetriesExhausted, h.Retries+1, err)
+}
+
+// pingURL trả về URL của tín hiệu: <url>/start, <url>/fail hoặc <url> (thành công), kèm rid=.
+// Đường dẫn tín hiệu được nối vào path, giữ nguyên query string sẵn có của URL.
+func pingURL(base, signal, runID string) (string, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		// Lỗi parse chứa URL ping (bí mật), chỉ giữ nguyên nhân
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err
+		}
+		return "", i18n.Errorf(i18n.ErrHeartbeatURL, err)
+	}
+
+	switch signal {
+	case SignalStart:
+		u = u.JoinPath("start")
+	case SignalFail:
+		u = u.JoinPath("fail")
+	}
+	if runID != "" {
+		query := u.Query()
+		query.Set("rid", runID)
+		u.RawQuery = query.Encode()
+	}
+	return u.String(), nil
+}
+
+// tail trả về tối đa n byte cuối của s, không cắt giữa một ký tự UTF-8
+func tail(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	cut := len(s) - n
+	for cut < len(s) && !utf8.RuneStart(s[cut]) {
+		cut++
+	}
+	return s[cut:]
+}
+
+// send gửi một request ping; trả về retry = true nếu nên thử lại
+func send(ctx context.Context, timeout time.Duration, target, body string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, strings.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		// Lỗi của client chứa URL ping (bí mật), chỉ giữ nguyên nhân
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err
+		}
+		return true, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1024))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	return retry, fmt.Errorf("HTTP %d", resp.StatusCode)
+}
@@ -0,0 +1,57 @@
+package heartbeat
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestPingURL(t *testing.T) {
+	tests := []struct {
+		base, signal, runID, want string
+	}{
+		{"https://hc-ping.com/uuid", SignalStart, "", "https://hc-ping.com/uuid/start"},
+		{"https://hc-ping.com/uuid/", SignalFail, "", "https://hc-ping.com/uuid/fail"},
+		{"https://hc-ping.com/uuid", SignalSuccess, "", "https://hc-ping.com/uuid"},
+		{"https://hc-ping.com/uuid", SignalStart, "run 1", "https://hc-ping.com/uuid/start?rid=run+1"},
+		{"https://kuma.example.com/api/push/abc?status=up", SignalFail, "r1", "https://kuma.example.com/api/push/abc/fail?rid=r1&status=up"},
+		{"https://kuma.example.com/api/push/abc?status=up", SignalSuccess, "", "https://kuma.example.com/api/push/abc?status=up"},
+	}
+
+	for _, tt := range tests {
+		got, err := pingURL(tt.base, tt.signal, tt.runID)
+		if err != nil {
+			t.Errorf("pingURL(%q, %q): %v", tt.base, tt.signal, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("pingURL(%q, %q, %q) = %q, want %q", tt.base, tt.signal, tt.runID, got, tt.want)
+		}
+	}
+}
+
+func TestPingURLInvalidHidesURL(t *testing.T) {
+	_, err := pingURL("https://hc-ping.com/secret-uuid\x7f", SignalStart, "")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if strings.Contains(err.Error(), "secret-uuid") {
+		t.Errorf("error leaks the ping URL: %v", err)
+	}
+}
+
+func TestTail(t *testing.T) {
+	if got := tail("short", 10); got != "short" {
+		t.Errorf("tail = %q", got)
+	}
+
+	// "ệ" có 3 byte; cắt 4 byte cuối rơi vào giữa ký tự đầu tiên của phần còn lại
+	s := strings.Repeat("ệ", 10)
+	got := tail(s, 4)
+	if !utf8.ValidString(got) {
+		t.Fatalf("tail split a rune: %q", got)
+	}
+	if got != "ệ" {
+		t.Errorf("tail = %q, want %q", got, "ệ")
+	}
+}
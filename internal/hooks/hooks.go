@@ -0,0 +1,173 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/backup-cronjob/internal/config"
+	"github.com/backup-cronjob/internal/i18n"
+	"github.com/backup-cronjob/internal/logging"
+)
+
+// Các trạng thái của bản backup truyền cho hook
+const (
+	StatusRunning = "running"
+	StatusSuccess = "success"
+	StatusFailure = "failure"
+)
+
+// maxOutput giới hạn số byte output của hook được giữ lại để ghi log
+const maxOutput = 4096
+
+// Event mô tả bản backup tại thời điểm chạy hook
+type Event struct {
+	Stage    string `json:"stage"`
+	Job      string `json:"job"`
+	Status   string `json:"status"`
+	FilePath string `json:"file_path,omitempty"`
+	FileSize int64  `json:"file_size,omitempty"`
+	Error    string `json:"error,omitempty"`
+	// Anomaly là lý do bản backup bị đánh dấu bất thường (so với các bản backup trước)
+	Anomaly string `json:"anomaly,omitempty"`
+}
+
+// Env trả về các biến môi trường mô tả event cho hook dạng lệnh shell
+func (e Event) Env() []string {
+	return []string{
+		"BACKUP_STAGE=" + e.Stage,
+		"BACKUP_JOB=" + e.Job,
+		"BACKUP_STATUS=" + e.Status,
+		"BACKUP_FILE=" + e.FilePath,
+		"BACKUP_SIZE=" + strconv.FormatInt(e.FileSize, 10),
+		"BACKUP_ERROR=" + e.Error,
+		"BACKUP_ANOMALY=" + e.Anomaly,
+	}
+}
+
+// ErrAborted được trả về khi một hook pre_dump thất bại và backup phải dừng lại
+var ErrAborted = errors.New("backup aborted by pre_dump hook")
+
+// Run chạy lần lượt các hook của job tại thời điểm event.Stage.
+// Với pre_dump, hook thất bại (không đặt continue_on_failure) dừng các hook còn lại
+// và trả về lỗi bọc ErrAborted. Ở các thời điểm khác, lỗi chỉ được ghi log và gộp lại.
+func Run(ctx context.Context, job *config.Job, event Event) error {
+	var failed []string
+
+	for _, hook := range job.Hooks.Stage(event.Stage) {
+		logger := logging.From(ctx).With("stage", event.Stage, "hook", hook.Label())
+		output, err := runHook(ctx, hook, event)
+		if output != "" {
+			logger.Info("Hook output", "output", output)
+		}
+		if err == nil {
+			continue
+		}
+
+		logger.Error("Hook failed", logging.Err(err))
+		if event.Stage == config.HookPreDump && !hook.ContinueOnFailure {
+			return fmt.Errorf("%w: %s: %v", ErrAborted, hook.Label(), err)
+		}
+		failed = append(failed, fmt.Sprintf("%s: %v", hook.Label(), err))
+	}
+
+	if len(failed) > 0 {
+		return i18n.Errorf(i18n.ErrHooksFailed, len(failed), event.Stage, strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// runHook chạy một hook với timeout của nó, trả về output (đã cắt ngắn)
+func runHook(ctx context.Context, hook *config.Hook, event Event) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, hook.Timeout)
+	defer cancel()
+
+	var output string
+	var err error
+	if hook.URL != "" {
+		output, err = runHTTP(ctx, hook, event)
+	} else {
+		output, err = runCommand(ctx, hook, event)
+	}
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return output, i18n.Errorf(i18n.ErrHookTimeout, hook.Timeout)
+	}
+	return output, err
+}
+
+// runCommand chạy hook dạng lệnh shell với các biến môi trường BACKUP_*
+func runCommand(ctx context.Context, hook *config.Hook, event Event) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", hook.Command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", hook.Command)
+	}
+	cmd.Env = append(os.Environ(), event.Env()...)
+	// Tiến trình con của shell có thể giữ output mở sau khi hết timeout, không chờ chúng
+	cmd.WaitDelay = time.Second
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	err := cmd.Run()
+	return truncate(output.String()), err
+}
+
+// runHTTP gửi event dạng JSON tới URL của hook. Mã trạng thái ngoài 2xx được coi là lỗi.
+func runHTTP(ctx context.Context, hook *config.Hook, event Event) (string, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return "", err
+	}
+
+	var reader io.Reader
+	if hook.Method != http.MethodGet {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, hook.Method, hook.URL, reader)
+	if err != nil {
+		return "", err
+	}
+	if reader != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for key, value := range hook.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, maxOutput))
+	output := truncate(string(data))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return output, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return output, nil
+}
+
+// truncate cắt output về tối đa maxOutput byte
+func truncate(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) > maxOutput {
+		return s[:maxOutput] + "..."
+	}
+	return s
+}
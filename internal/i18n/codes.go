@@ -0,0 +1,193 @@
+package i18n
+
+// Mã lỗi trả về qua API (trường code) và dùng làm khóa thông báo. Các mã này là một phần
+// của API: không đổi tên, chỉ thêm mới.
+const (
+	ErrInvalidRequest   = "invalid_request"
+	ErrInvalidParameter = "invalid_parameter"
+	ErrInternal         = "internal_error"
+	ErrTooManyRequests  = "too_many_requests"
+
+	// Xác thực và phân quyền
+	ErrAuthRequired        = "auth_required"
+	ErrAuthHeaderMissing   = "auth_header_missing"
+	ErrAuthHeaderFormat    = "auth_header_format"
+	ErrInvalidToken        = "invalid_token"
+	ErrTokenExpired        = "token_expired"
+	ErrAdminRequired       = "admin_required"
+	ErrInvalidCredentials  = "invalid_credentials"
+	ErrOAuthStart          = "oauth_start_failed"
+	ErrOAuthStateInvalid   = "oauth_state_invalid"
+	ErrOAuthDenied         = "oauth_denied"
+	ErrOAuthCodeMissing    = "oauth_code_missing"
+	ErrOAuthExchangeFailed = "oauth_exchange_failed"
+	ErrUserExists          = "user_exists"
+	ErrUserNotFound        = "user_not_found"
+	ErrUserProtected       = "user_protected"
+	ErrInvalidRole         = "invalid_role"
+	ErrPasswordRequired    = "password_required"
+
+	// Job, bản backup và lần chạy
+	ErrJobNotFound        = "job_not_found"
+	ErrJobNotPhysical     = "job_not_physical"
+	ErrBackupNotFound     = "backup_not_found"
+	ErrNoBackups          = "no_backups"
+	ErrCompareFailed      = "backup_compare_failed"
+	ErrNoBaseBackup       = "no_base_backup"
+	ErrBackupListFailed   = "backup_list_failed"
+	ErrBackupFileMissing  = "backup_file_missing"
+	ErrCatalogUnavailable = "catalog_unavailable"
+	ErrCatalogUpdate      = "catalog_update_failed"
+	ErrRunNotFound        = "run_not_found"
+	ErrRunsUnavailable    = "runs_unavailable"
+	ErrAuditUnavailable   = "audit_unavailable"
+	ErrJobRequired        = "job_required"
+	ErrNoPhysicalJobs     = "no_physical_jobs"
+	ErrInvalidTargetTime  = "invalid_target_time"
+	ErrJobRunning         = "job_running"
+
+	// Cấu hình và khởi động
+	ErrConfigInvalid = "config_invalid"
+	ErrStartupFailed = "startup_failed"
+	ErrServerFailed  = "server_failed"
+
+	// Thao tác
+	ErrDumpFailed         = "dump_failed"
+	ErrUploadFailed       = "upload_failed"
+	ErrVerifyFailed       = "verify_failed"
+	ErrRestoreFailed      = "restore_failed"
+	ErrDiscoveryDisabled  = "discovery_disabled"
+	ErrDiscoveryFailed    = "discovery_failed"
+	ErrChannelNotFound    = "channel_not_found"
+	ErrNotificationFailed = "notification_failed"
+
+	// Dump database (dbdump)
+	ErrDumpBackupDir       = "dump_backup_dir_failed"
+	ErrDumpTempDir         = "dump_temp_dir_failed"
+	ErrDumpOutputCreate    = "dump_output_create_failed"
+	ErrDumpOutputWrite     = "dump_output_write_failed"
+	ErrDumpOutputMissing   = "dump_output_missing"
+	ErrDumpCommandStart    = "dump_command_start_failed"
+	ErrDumpCommandExit     = "dump_command_exit"
+	ErrDumpCommandOutput   = "dump_command_failed"
+	ErrDumpListDatabases   = "dump_list_databases_failed"
+	ErrDumpNoDatabases     = "dump_no_databases"
+	ErrDumpAllFailed       = "dump_all_databases_failed"
+	ErrDumpArchive         = "dump_archive_failed"
+	ErrDumpBackupLabel     = "dump_backup_label_missing"
+	ErrDumpWALStart        = "dump_wal_start_unknown"
+	ErrDumpInvalidRowData  = "dump_invalid_row_counts"
+	ErrDumpManifestInvalid = "dump_manifest_invalid"
+	ErrDumpDiskSpace       = "dump_disk_space_low"
+
+	// Google Drive (drive)
+	ErrDriveNotAuthorized      = "drive_not_authorized"
+	ErrDriveTokenMissing       = "drive_token_missing"
+	ErrDriveTokenExpired       = "drive_token_expired"
+	ErrDriveTokenRefresh       = "drive_token_refresh_failed"
+	ErrDriveTokenSave          = "drive_token_save_failed"
+	ErrDriveTokenMigrate       = "drive_token_migrate_failed"
+	ErrDriveExchange           = "drive_exchange_failed"
+	ErrDriveReadCode           = "drive_read_code_failed"
+	ErrDriveLoopback           = "drive_loopback_failed"
+	ErrDriveDeviceAuth         = "drive_device_auth_failed"
+	ErrDriveServiceAccount     = "drive_service_account_failed"
+	ErrDriveServiceAccountMode = "drive_service_account_mode"
+	ErrDriveService            = "drive_service_failed"
+	ErrDriveConnect            = "drive_connect_failed"
+	ErrDriveFolderFind         = "drive_folder_find_failed"
+	ErrDriveFolderCreate       = "drive_folder_create_failed"
+	ErrDriveFolderPath         = "drive_folder_path_failed"
+	ErrDriveFileCheck          = "drive_file_check_failed"
+	ErrDriveList               = "drive_list_failed"
+	ErrDriveDelete             = "drive_delete_failed"
+	ErrDriveFileOpen           = "drive_file_open_failed"
+	ErrDriveUpload             = "drive_upload_failed"
+	ErrDriveReadBackupDir      = "drive_read_backup_dir_failed"
+	ErrDriveUploadsPartial     = "drive_uploads_partial"
+	ErrDriveQuota              = "drive_quota_failed"
+	ErrDriveQuotaExceeded      = "drive_quota_exceeded"
+
+	// Catalog và thư mục backup
+	ErrCatalogRead      = "catalog_read_failed"
+	ErrCatalogWrite     = "catalog_write_failed"
+	ErrAuditRead        = "audit_read_failed"
+	ErrBackupDirRead    = "backup_dir_read_failed"
+	ErrJobBackupDirRead = "job_backup_dir_read_failed"
+	ErrBackupJobUnknown = "backup_job_unknown"
+
+	// Chạy job (backup, hooks, anomaly)
+	ErrJobDumpFailed   = "job_dump_failed"
+	ErrJobUploadFailed = "job_upload_failed"
+	ErrHooksFailed     = "hooks_failed"
+	ErrHookTimeout     = "hook_timeout"
+
+	// Docker
+	ErrDockerConnect       = "docker_connect_failed"
+	ErrContainerNotFound   = "container_not_found"
+	ErrContainerNotRunning = "container_not_running"
+	ErrContainerCreate     = "container_create_failed"
+	ErrContainerStart      = "container_start_failed"
+	ErrContainerCopy       = "container_copy_failed"
+	ErrContainerAttach     = "container_attach_failed"
+	ErrContainerWait       = "container_wait_failed"
+	ErrContainerPort       = "container_port_invalid"
+	ErrExecCreate          = "exec_create_failed"
+	ErrExecStart           = "exec_start_failed"
+	ErrExecInspect         = "exec_inspect_failed"
+	ErrExecRunning         = "exec_still_running"
+	ErrImagePull           = "image_pull_failed"
+	ErrDockerStreamRead    = "docker_stream_read_failed"
+	ErrDockerStreamInvalid = "docker_stream_invalid"
+
+	// Kiểm tra khôi phục (verify)
+	ErrVerifyExtract         = "verify_extract_failed"
+	ErrVerifyManifestMissing = "verify_manifest_missing"
+	ErrVerifyManifestInvalid = "verify_manifest_invalid"
+	ErrVerifyNoDatabases     = "verify_no_databases"
+	ErrVerifyArchivePath     = "verify_archive_path_invalid"
+	ErrVerifyPhysical        = "verify_physical_unsupported"
+	ErrVerifyRowCounts       = "verify_row_counts_failed"
+	ErrVerifyRestoreDatabase = "verify_restore_database_failed"
+	ErrVerifyCountRows       = "verify_count_rows_failed"
+	ErrVerifySourceSchema    = "verify_source_schema_failed"
+	ErrVerifyRestoreSchema   = "verify_restore_schema_failed"
+	ErrVerifyNotReady        = "verify_postgres_not_ready"
+	ErrVerifyCommandExit     = "verify_command_exit"
+	ErrVerifyChecksFailed    = "verify_checks_failed"
+
+	// WAL và khôi phục theo thời điểm
+	ErrWALDir                 = "wal_dir_failed"
+	ErrWALFetch               = "wal_fetch_failed"
+	ErrWALListArchive         = "wal_list_archive_failed"
+	ErrWALSegmentSizeRead     = "wal_segment_size_read_failed"
+	ErrWALSegmentSizeInvalid  = "wal_segment_size_invalid"
+	ErrWALCat                 = "wal_cat_failed"
+	ErrWALSegmentIncomplete   = "wal_segment_incomplete"
+	ErrWALUploadsFailed       = "wal_uploads_failed"
+	ErrWALSegmentMissing      = "wal_segment_missing"
+	ErrWALGap                 = "wal_gap"
+	ErrPITRVersion            = "pitr_version_unsupported"
+	ErrPITRCopy               = "pitr_copy_failed"
+	ErrPITRFailed             = "pitr_failed"
+	ErrPITRContainerStopped   = "pitr_container_stopped"
+	ErrPITRTimeout            = "pitr_timeout"
+	ErrNoPhysicalBackup       = "no_physical_backup"
+	ErrNoPhysicalBackupBefore = "no_physical_backup_before"
+
+	// Xóa bản backup hết hạn (retention)
+	ErrRetentionDelete      = "retention_delete_failed"
+	ErrRetentionWALDelete   = "retention_wal_delete_failed"
+	ErrDriveRetentionDelete = "drive_retention_delete_failed"
+
+	// Thông báo và heartbeat
+	ErrNotifySend       = "notify_send_failed"
+	ErrNotifyCanceled   = "notify_canceled"
+	ErrRetriesExhausted = "retries_exhausted"
+	ErrHeartbeatURL     = "heartbeat_url_invalid"
+
+	// Kiểm tra sẵn sàng (health)
+	ErrHealthDatabase        = "health_database_uninitialized"
+	ErrHealthDiskLow         = "health_disk_low"
+	ErrHealthDiskUnsupported = "health_disk_unsupported"
+)
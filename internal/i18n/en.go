@@ -0,0 +1,421 @@
+package i18n
+
+// en là bảng thông báo tiếng Anh
+var en = map[string]string{
+	// Lỗi chung
+	ErrInvalidRequest:   "Invalid request body",
+	ErrInvalidParameter: "Invalid %s parameter",
+	ErrInternal:         "Internal error, please try again later",
+	ErrTooManyRequests:  "Too many requests, please try again in %d seconds",
+
+	// Xác thực và phân quyền
+	ErrAuthRequired:        "Please sign in to perform this action",
+	ErrAuthHeaderMissing:   "Authorization header is required",
+	ErrAuthHeaderFormat:    "Authorization header format must be Bearer {token}",
+	ErrInvalidToken:        "Invalid or expired session",
+	ErrTokenExpired:        "Session has expired",
+	ErrAdminRequired:       "Admin privileges required",
+	ErrInvalidCredentials:  "Invalid username or password",
+	ErrOAuthStart:          "Could not start the Google authorization. Please try again.",
+	ErrOAuthStateInvalid:   "The Google authorization session is invalid or has expired. Please try again.",
+	ErrOAuthDenied:         "Google denied the authorization: %s",
+	ErrOAuthCodeMissing:    "No authorization code was received from Google. Please try again.",
+	ErrOAuthExchangeFailed: "Authorization failed: %v",
+	ErrUserExists:          "User %s already exists",
+	ErrUserNotFound:        "User not found: %s",
+	ErrUserProtected:       "Cannot delete the admin account configured by ADMIN_USERNAME: %s",
+	ErrInvalidRole:         "Invalid role: %s (must be user or admin)",
+	ErrPasswordRequired:    "Password must not be empty",
+
+	// Job, bản backup và lần chạy
+	ErrJobNotFound:        "Job not found: %s",
+	ErrJobNotPhysical:     "Job %s is not in physical mode",
+	ErrBackupNotFound:     "Backup not found: %s",
+	ErrNoBackups:          "No backup files found",
+	ErrCompareFailed:      "Could not compare with %s: %v",
+	ErrNoBaseBackup:       "No earlier backup than %s to compare with",
+	ErrBackupListFailed:   "Failed to list backups: %v",
+	ErrBackupFileMissing:  "File %s does not exist",
+	ErrCatalogUnavailable: "Failed to load backup catalog",
+	ErrCatalogUpdate:      "Failed to update backup catalog",
+	ErrRunNotFound:        "Run not found",
+	ErrRunsUnavailable:    "Failed to load runs",
+	ErrAuditUnavailable:   "Failed to load audit log",
+	ErrJobRequired:        "Specify a single job with --job",
+	ErrNoPhysicalJobs:     "No job is in physical mode",
+	ErrJobRunning:         "Job %s is already running, try again after the current run finishes",
+	ErrInvalidTargetTime:  "Invalid restore target time (RFC3339 required, e.g. 2024-05-01T10:30:00+07:00): %v",
+
+	// Cấu hình và khởi động
+	ErrConfigInvalid: "Invalid configuration: %v",
+	ErrStartupFailed: "Startup failed: %v",
+	ErrServerFailed:  "Failed to start the web server: %v",
+
+	// Thao tác
+	ErrDumpFailed:         "Database dump failed: %v",
+	ErrUploadFailed:       "Upload failed: %v",
+	ErrVerifyFailed:       "Verification of %s failed: %v",
+	ErrRestoreFailed:      "Restore failed: %v",
+	ErrDiscoveryDisabled:  "Container discovery is disabled (DISCOVERY_ENABLED=false)",
+	ErrDiscoveryFailed:    "Container discovery failed: %v",
+	ErrChannelNotFound:    "Notification channel not found: %s",
+	ErrNotificationFailed: "Sending the test notification failed: %v",
+
+	// Dump database
+	ErrDumpBackupDir:       "Failed to create backup directory: %v",
+	ErrDumpTempDir:         "Failed to create temporary directory: %v",
+	ErrDumpOutputCreate:    "Failed to create output file: %v",
+	ErrDumpOutputWrite:     "Failed to write output file: %v",
+	ErrDumpOutputMissing:   "File was not created at %s: %v",
+	ErrDumpCommandStart:    "Failed to run command %s: %v",
+	ErrDumpCommandExit:     "Command %s failed with exit code %d",
+	ErrDumpCommandOutput:   "%v: %s",
+	ErrDumpListDatabases:   "failed to list databases: %v: %s",
+	ErrDumpNoDatabases:     "No database matches the include/exclude configuration",
+	ErrDumpAllFailed:       "Dump failed for all %d databases",
+	ErrDumpArchive:         "Failed to archive the cluster backup: %v",
+	ErrDumpBackupLabel:     "Physical backup has no %s: %v",
+	ErrDumpWALStart:        "Could not read the WAL start position from %s",
+	ErrDumpInvalidRowData:  "invalid row count output: %q",
+	ErrDumpManifestInvalid: "invalid manifest: %v",
+	ErrDumpDiskSpace:       "Not enough free disk space to dump job %s: about %s needed (estimated from previous backups), %s free",
+
+	// Google Drive
+	ErrDriveNotAuthorized:      "Google Drive is not authorized: %v",
+	ErrDriveTokenMissing:       "no authorization token found. Please authorize via the UI or CLI",
+	ErrDriveTokenExpired:       "access token has expired and there is no refresh token",
+	ErrDriveTokenRefresh:       "failed to refresh token: %v",
+	ErrDriveTokenSave:          "failed to save token: %v",
+	ErrDriveTokenMigrate:       "failed to move token into the encrypted store: %v",
+	ErrDriveExchange:           "failed to exchange authorization code: %v",
+	ErrDriveReadCode:           "failed to read authorization code: %v",
+	ErrDriveLoopback:           "Failed to open the authorization callback listener: %v",
+	ErrDriveDeviceAuth:         "Failed to start device authorization: %v",
+	ErrDriveServiceAccount:     "Failed to obtain an access token with the service account: %v",
+	ErrDriveServiceAccountMode: "Google Drive uses a service account (GOOGLE_SERVICE_ACCOUNT_KEY), no Google account needs to be linked",
+	ErrDriveService:            "failed to create Drive service: %v",
+	ErrDriveConnect:            "failed to connect to Google Drive: %v",
+	ErrDriveFolderFind:         "failed to find folder: %v",
+	ErrDriveFolderCreate:       "failed to create folder: %v",
+	ErrDriveFolderPath:         "failed to create folder %s: %v",
+	ErrDriveFileCheck:          "failed to check whether file exists: %v",
+	ErrDriveList:               "failed to list files: %v",
+	ErrDriveDelete:             "failed to delete file %s: %v",
+	ErrDriveFileOpen:           "failed to open file: %v",
+	ErrDriveUpload:             "failed to upload file: %v",
+	ErrDriveReadBackupDir:      "failed to read backup directory: %v",
+	ErrDriveUploadsPartial:     "%d/%d file uploads failed",
+	ErrDriveQuota:              "Failed to read the Google Drive storage quota: %v",
+	ErrDriveQuotaExceeded:      "Not enough Google Drive storage: %s needed, %s free",
+
+	// Catalog và thư mục backup
+	ErrCatalogRead:      "failed to read the catalog: %v",
+	ErrCatalogWrite:     "failed to write the catalog: %v",
+	ErrAuditRead:        "failed to read the audit log: %v",
+	ErrBackupDirRead:    "failed to read the backup directory: %v",
+	ErrJobBackupDirRead: "failed to read the backup directory of job %s: %v",
+	ErrBackupJobUnknown: "could not determine the job of backup %s",
+
+	// Chạy job (backup, hooks, anomaly)
+	ErrJobDumpFailed:   "dump of job %s failed: %v",
+	ErrJobUploadFailed: "upload of job %s failed: %v",
+	ErrHooksFailed:     "%d %s hooks failed: %s",
+	ErrHookTimeout:     "timed out after %s",
+
+	// Docker
+	ErrDockerConnect:       "failed to connect to the Docker Engine: %v",
+	ErrContainerNotFound:   "container %s does not exist",
+	ErrContainerNotRunning: "container %s is not running (%s)",
+	ErrContainerCreate:     "failed to create container: %v",
+	ErrContainerStart:      "failed to start container: %v",
+	ErrContainerCopy:       "failed to copy files into the container: %v",
+	ErrContainerAttach:     "failed to attach to the container: %v",
+	ErrContainerWait:       "failed to wait for the container to exit: %v",
+	ErrContainerPort:       "invalid port %q (format hostPort:containerPort)",
+	ErrExecCreate:          "failed to create exec: %v",
+	ErrExecStart:           "failed to start exec: %v",
+	ErrExecInspect:         "failed to read the exit code: %v",
+	ErrExecRunning:         "exec is still running after its stream ended",
+	ErrImagePull:           "failed to pull image %s: %v",
+	ErrDockerStreamRead:    "failed to read the Docker stream: %v",
+	ErrDockerStreamInvalid: "invalid Docker stream: stream type %d",
+
+	// Kiểm tra khôi phục (verify)
+	ErrVerifyExtract:         "failed to extract the cluster backup: %v",
+	ErrVerifyManifestMissing: "cluster backup has no manifest.json: %v",
+	ErrVerifyManifestInvalid: "invalid manifest.json: %v",
+	ErrVerifyNoDatabases:     "cluster backup contains no databases",
+	ErrVerifyArchivePath:     "invalid path in archive: %s",
+	ErrVerifyPhysical:        "physical backups are checked with point-in-time recovery (PITR), not verify",
+	ErrVerifyRowCounts:       "failed to read the row counts recorded at dump time: %v",
+	ErrVerifyRestoreDatabase: "restoring database %s failed: %v",
+	ErrVerifyCountRows:       "failed to count rows in database %s: %v",
+	ErrVerifySourceSchema:    "failed to read the source database schema: %v",
+	ErrVerifyRestoreSchema:   "restoring the schema failed: %v",
+	ErrVerifyNotReady:        "postgres in the temporary container was not ready after %s",
+	ErrVerifyCommandExit:     "%s exited with code %d: %s",
+	ErrVerifyChecksFailed:    "%d/%d checks failed",
+
+	// WAL và khôi phục theo thời điểm
+	ErrWALDir:                 "failed to create the WAL directory: %v",
+	ErrWALFetch:               "failed to fetch WAL %s: %v",
+	ErrWALListArchive:         "failed to read %s in the container: %s",
+	ErrWALSegmentSizeRead:     "failed to read wal_segment_size: %v",
+	ErrWALSegmentSizeInvalid:  "invalid wal_segment_size: %q",
+	ErrWALCat:                 "cat failed: %s",
+	ErrWALSegmentIncomplete:   "segment is %d bytes, %d bytes required (wal_segment_size)",
+	ErrWALUploadsFailed:       "failed to upload %d WAL files",
+	ErrWALSegmentMissing:      "WAL segment %s required by backup %s is missing",
+	ErrWALGap:                 "continuous WAL only reaches %s (segment %s), cannot recover to %s",
+	ErrPITRVersion:            "point-in-time recovery requires PostgreSQL %d or later (source server: %q)",
+	ErrPITRCopy:               "failed to copy data into container %s: %v",
+	ErrPITRFailed:             "%v (see docker logs %s)",
+	ErrPITRContainerStopped:   "container stopped during recovery",
+	ErrPITRTimeout:            "timed out waiting for recovery: %v",
+	ErrNoPhysicalBackup:       "job %s has no physical backups yet",
+	ErrNoPhysicalBackupBefore: "job %s has no physical backup before %s",
+
+	// Xóa bản backup hết hạn (retention)
+	ErrRetentionDelete:      "failed to delete %d expired backups",
+	ErrRetentionWALDelete:   "failed to delete %d WAL files",
+	ErrDriveRetentionDelete: "failed to delete %d expired backups on Drive",
+
+	// Thông báo và heartbeat
+	ErrNotifySend:       "failed to send notifications: %s",
+	ErrNotifyCanceled:   "%v (previous error: %v)",
+	ErrRetriesExhausted: "failed after %d attempts: %v",
+	ErrHeartbeatURL:     "invalid heartbeat URL: %v",
+
+	// Kiểm tra sẵn sàng (health)
+	ErrHealthDatabase:        "database is not initialized",
+	ErrHealthDiskLow:         "%s, below the %d MB threshold",
+	ErrHealthDiskUnsupported: "free space checks are not supported on this operating system",
+
+	// Kết quả thao tác
+	"dump.success":             "Database dump succeeded",
+	"dump.physical_success":    "Physical backup succeeded",
+	"dump.cluster_success":     "Cluster dump succeeded for %d/%d databases",
+	"verify.success":           "Backup %s is restorable: %s",
+	"auth.login_success":       "Login successful",
+	"auth.logout_success":      "Logout successful",
+	"auth_success.message":     "Google Drive authorized successfully! This window will close automatically.",
+	"flash.dump_success":       "Database dump succeeded. Files: %s",
+	"flash.dump_anomalies":     "Anomalous backup warning: %s",
+	"flash.upload_success":     "Uploaded %s to Google Drive",
+	"flash.upload_all_success": "Uploaded all backup files to Google Drive",
+
+	// Tóm tắt lần chạy, kiểm tra và thông báo
+	"run.uploaded":            "Uploaded to Google Drive",
+	"run.verify":              "Restore check: %s",
+	"run.pruned":              "Removed %d expired backups",
+	"run.anomaly":             "Anomaly: %s",
+	"anomaly.size_drop":       "size %s is %.0f%% smaller than the median %s of the previous %d backups",
+	"anomaly.size_growth":     "size %s is %.0f%% larger than the median %s of the previous %d backups",
+	"anomaly.slow_dump":       "dump took %s, %.0f%% slower than the median %s",
+	"verify.restored":         "Restore succeeded, %d checks passed",
+	"verify.no_row_counts":    "no row counts recorded at dump time to compare",
+	"verify.table_missing":    "table missing after restore",
+	"verify.row_mismatch":     "%d rows at dump time, %d rows after restore",
+	"verify.tables_matched":   "%d/%d tables match",
+	"verify.assertion_result": "result %q, expected true",
+	"notify.test_title":       "[backup] Test notification",
+	"notify.test_text":        "Channel %s is configured correctly.",
+	"notify.failure_title":    "[backup] Job %s failed",
+	"notify.failure_error":    "Error: %s",
+	"notify.success_title":    "[backup] Job %s succeeded",
+	"notify.anomaly_title":    "[backup] Anomalous backup of job %s",
+	"notify.digest_title":     "[backup] Summary since %s: %d backups, %d failures, %d anomalies",
+	"notify.digest_line":      "[%s] %s: %d backups (%s), %d failures, %d anomalies",
+	"notify.digest_latest":    ", latest %s",
+	"notify.digest_failed":    "FAILED",
+	"notify.digest_anomaly":   "ANOMALY",
+	"notify.digest_empty":     "NO BACKUPS",
+	"health.no_drive_jobs":    "no job uploads to Drive",
+	"health.token_valid":      "token is valid",
+	"health.disk_free":        "%d MB free",
+
+	// Dòng lệnh
+	"cli.dumping":                     "Dumping database for job %s...",
+	"cli.dump_success":                "Dump succeeded: %s",
+	"cli.anomaly_warning":             "Warning: anomalous backup: %s",
+	"cli.dump_failed":                 "%d/%d job dumps failed",
+	"cli.finding_latest":              "Looking for the latest backup file...",
+	"cli.uploading":                   "Uploading %s to Google Drive...",
+	"cli.upload_failed":               "%d/%d file uploads failed",
+	"cli.uploading_all":               "Uploading all backup files to Google Drive...",
+	"cli.verifying":                   "Verifying restore of %s...",
+	"cli.verify_failed":               "%d/%d job verifications failed",
+	"cli.wal_archived":                "Job %s: archived %d WAL files",
+	"cli.wal_failed":                  "%d/%d WAL archiving jobs failed",
+	"cli.restoring":                   "Restoring job %s...",
+	"cli.restore_success":             "Restored into container %s (image %s) from %s, WAL %s..%s",
+	"cli.notify_test_sent":            "Sent a test notification to channel %s",
+	"cli.starting_web":                "Starting the web application on port %s...",
+	"cli.daemon_running":              "Running in daemon mode, press Ctrl+C to stop...",
+	"cli.usage":                       "Usage",
+	"cli.commands":                    "Commands",
+	"cli.help_hint":                   "Run \"%s <command> -h\" for the flags of a command",
+	"cli.unknown_command":             "Unknown command: %s",
+	"cli.invalid_output":              "Invalid output format: %s (must be text or json)",
+	"cli.upload_success":              "Upload succeeded: %s",
+	"cli.upload_all_success":          "All backup files uploaded",
+	"cli.pruned":                      "Job %s: removed %d expired backups",
+	"cli.prune_dry_run":               "Job %s: %d expired backups would be removed",
+	"cli.prune_failed":                "%d/%d job prunes failed",
+	"cli.list_item":                   "  %s",
+	"cli.channel_required":            "Specify the name of a notification channel",
+	"cli.auth_revoked":                "Google Drive token revoked and removed",
+	"cli.username_required":           "Specify a single username",
+	"cli.password_prompt":             "Enter password: ",
+	"cli.user_created":                "Created user %s (role %s)",
+	"cli.user_password_changed":       "Changed the password of user %s",
+	"cli.user_deleted":                "Deleted user %s",
+	"cli.config_valid":                "Configuration is valid, %d jobs:",
+	"cli.shell_required":              "Specify a shell: bash, zsh or fish",
+	"list.header":                     "ID\tJOB\tFILE\tSIZE\tCREATED\tUPLOADED\tVERIFY",
+	"users.header":                    "USERNAME\tROLE\tLOCALE\tCREATED",
+	"cli.auth_open_url":               "Open the following URL in a browser to link Google Drive:\n\n  %s\n\nWaiting for Google to redirect to %s\nIf the browser is on another machine, paste the URL it was redirected to (or the code) here and press Enter:",
+	"cli.auth_device_code":            "Open %s on any device and enter the code: %s\nThe code expires at %s. Waiting for confirmation...",
+	"cli.auth_linked":                 "Google Drive linked",
+	"cli.auth_status_not_authorized":  "Google Drive is not linked",
+	"cli.auth_status_service_account": "Authenticated with service account: %s",
+	"cli.auth_status_impersonate":     "Domain-wide delegation for: %s",
+	"cli.auth_status_scopes":          "Scopes: %s",
+	"cli.auth_status_expiry":          "Access token expires: %s",
+	"cli.auth_status_refresh_ok":      "Token refresh: OK",
+	"cli.auth_status_refresh_failed":  "Token refresh: failed (%s)",
+	"cli.auth_status_refresh_missing": "Token refresh: no refresh token, link again",
+
+	// Tham số dòng lệnh
+	"flag.upload_all":     "Upload all backup files",
+	"flag.daemon":         "Run scheduled jobs without the web UI",
+	"flag.schedule":       "Also run scheduled jobs alongside the web UI",
+	"flag.port":           "Port for the web application",
+	"flag.job":            "Name of the job to run (default: all jobs)",
+	"flag.target_time":    "Restore target time (RFC3339), default: end of archived WAL",
+	"flag.restore_name":   "Name of the restore container (default: <job>-pitr-<time>)",
+	"flag.restore_port":   "Host port publishing PostgreSQL of the restore container",
+	"flag.output":         "Output format: text or json",
+	"flag.upload":         "Upload the backup to Google Drive after dumping",
+	"flag.prune":          "Remove expired backups according to the retention policy after dumping",
+	"flag.id":             "Backup ID (see the list command)",
+	"flag.dry_run":        "Only list the backups that would be removed",
+	"flag.role":           "User role: user or admin",
+	"flag.password_stdin": "Read the password from stdin (no prompt)",
+	"flag.device":         "Use the device authorization flow (servers without a browser)",
+	"flag.callback_port":  "Port of the callback listener on 127.0.0.1 (0: random)",
+
+	// Lệnh
+	"cmd.dump":            "Dump the databases of jobs",
+	"cmd.upload":          "Upload backups to Google Drive",
+	"cmd.list":            "List local backups",
+	"cmd.verify":          "Verify that backups can be restored",
+	"cmd.restore":         "Restore a physical job to a point in time into a new container",
+	"cmd.prune":           "Remove expired backups according to the retention policy",
+	"cmd.archive_wal":     "Fetch new WAL segments of physical jobs once",
+	"cmd.notify":          "Notification channels",
+	"cmd.notify_test":     "Send a test notification to a channel",
+	"cmd.serve":           "Start the web application or the scheduler daemon",
+	"cmd.auth":            "Google Drive authorization",
+	"cmd.auth_google":     "Link Google Drive from the command line",
+	"cmd.auth_status":     "Show the scopes, expiry and refresh health of the token",
+	"cmd.auth_revoke":     "Revoke and remove the Google Drive token",
+	"cmd.users":           "Manage web UI users",
+	"cmd.users_list":      "List users",
+	"cmd.users_add":       "Create a user",
+	"cmd.users_passwd":    "Change the password of a user",
+	"cmd.users_delete":    "Delete a user",
+	"cmd.config":          "Configuration",
+	"cmd.config_validate": "Validate the configuration",
+	"cmd.completion":      "Print the shell completion script",
+
+	// Giao diện web
+	"auth_success.closing":        "Closing...",
+	"auth_success.heading":        "Google Drive authorized!",
+	"auth_success.title":          "Authorization successful",
+	"backup.after":                "After",
+	"backup.anomaly":              "Anomalous backup:",
+	"backup.anomaly_kept":         "This backup will not be deleted automatically.",
+	"backup.before":               "Before",
+	"backup.change":               "Change",
+	"backup.compare":              "Compare",
+	"backup.compare_heading":      "Row count comparison",
+	"backup.compared_with":        "Compared with",
+	"backup.created_at":           "Created",
+	"backup.dump_duration":        "Dump duration",
+	"backup.extensions":           "Extensions:",
+	"backup.file_size":            "File size",
+	"backup.no_diff":              "No table information to compare",
+	"backup.no_log":               "No log.",
+	"backup.no_others":            "This job has no other backups to compare with.",
+	"backup.no_tables":            "No table information",
+	"backup.not_in_catalog":       "This backup is not in the catalog (it was created before statistics were collected).",
+	"backup.rows":                 "Rows",
+	"backup.run_log":              "Run log",
+	"backup.run_summary":          "Started %s, ran for %s,",
+	"backup.server_version":       "Server version",
+	"backup.size":                 "Size",
+	"backup.table":                "Table",
+	"backup.table_added":          "New table",
+	"backup.table_missing":        "Table missing",
+	"backup.threshold_note":       "Tables that lost %v%% or more of their rows are highlighted in red.",
+	"common.back":                 "Back",
+	"common.failure":              "Failed",
+	"common.success":              "Succeeded",
+	"error.auth_incomplete":       "Could not complete authorization!",
+	"error.back_home":             "Back to home",
+	"error.heading":               "Something went wrong",
+	"error.retry_auth":            "Retry authorization",
+	"error.title":                 "Error",
+	"index.actions":               "Actions",
+	"index.all_jobs":              "All jobs",
+	"index.anomaly":               "Anomaly",
+	"index.auth_before_upload":    "Please authorize a Google account before uploading",
+	"index.auth_google":           "Authorize with Google",
+	"index.drive_quota":           "Google Drive storage",
+	"index.drive_quota_unlimited": "%s used (unlimited)",
+	"index.drive_quota_usage":     "%s / %s (%d%%)",
+	"index.backups_heading":       "Backup files",
+	"index.details":               "Details",
+	"index.discovered_short":      "discovered",
+	"index.download":              "Download",
+	"index.dump_description":      "Create a backup from the Docker container and store it in the local directory.",
+	"index.file_name":             "File name",
+	"index.file_size":             "Size",
+	"index.flash_error":           "Error!",
+	"index.flash_success":         "Success!",
+	"index.heading":               "Database Backup and Upload Tool",
+	"index.job_configured":        "Configuration",
+	"index.job_discovered":        "Discovered (label)",
+	"index.job_origin":            "Defined by",
+	"index.job_schedule":          "Schedule",
+	"index.job_source":            "Source",
+	"index.jobs_heading":          "Jobs",
+	"index.language":              "Language",
+	"index.logout":                "Sign out",
+	"index.need_auth":             "Authorization required!",
+	"index.need_auth_detail":      "To upload to Google Drive, you need to authorize a Google account.",
+	"index.no_backups":            "No backup files yet",
+	"index.no_jobs":               "No jobs yet",
+	"index.not_uploaded":          "Not uploaded",
+	"index.not_verified":          "Not verified",
+	"index.title":                 "Database Backup Manager",
+	"index.upload":                "Upload",
+	"index.upload_all":            "Upload All",
+	"index.upload_all_confirm":    "Are you sure you want to upload all backup files to Google Drive?",
+	"index.upload_description":    "Upload the latest backup file or all backup files to Google Drive.",
+	"index.upload_heading":        "Upload to Google Drive",
+	"index.upload_latest":         "Upload Latest File",
+	"index.uploaded":              "Uploaded",
+	"index.verified":              "Restorable",
+	"index.verify":                "Verify",
+	"index.verify_failed":         "Restore failed",
+	"login.failed":                "Sign-in failed",
+	"login.in_progress":           "Signing in...",
+	"login.password":              "Password",
+	"login.submit":                "Sign in",
+	"login.success":               "Signed in! Redirecting...",
+	"login.title":                 "Sign in",
+	"login.too_many_attempts":     "Too many failed sign-in attempts, please try again in %d seconds",
+	"login.username":              "Username",
+}
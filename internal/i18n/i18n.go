@@ -0,0 +1,203 @@
+// Package i18n chứa bảng thông báo tiếng Việt và tiếng Anh dùng cho CLI, API và giao diện web.
+// Mỗi thông báo có một khóa ổn định; các lỗi trả về qua API mang khóa này trong trường code
+// để máy đọc được bất kể ngôn ngữ hiển thị.
+package i18n
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Các ngôn ngữ được hỗ trợ
+const (
+	VI = "vi"
+	EN = "en"
+)
+
+// catalogs là bảng thông báo theo ngôn ngữ
+var catalogs = map[string]map[string]string{
+	VI: vi,
+	EN: en,
+}
+
+// defaultLocale là ngôn ngữ dùng khi không xác định được ngôn ngữ của người dùng (LOCALE)
+var defaultLocale = VI
+
+// SetDefault đặt ngôn ngữ mặc định; bỏ qua nếu ngôn ngữ không được hỗ trợ
+func SetDefault(lang string) {
+	if lang = Normalize(lang); lang != "" {
+		defaultLocale = lang
+	}
+}
+
+// Default trả về ngôn ngữ mặc định
+func Default() string {
+	return defaultLocale
+}
+
+// Supported trả về danh sách ngôn ngữ được hỗ trợ
+func Supported() []string {
+	langs := make([]string, 0, len(catalogs))
+	for lang := range catalogs {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Normalize chuẩn hóa tên ngôn ngữ ("en-US", "vi_VN.UTF-8" -> "en", "vi"),
+// trả về rỗng nếu ngôn ngữ không được hỗ trợ
+func Normalize(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if i := strings.IndexAny(lang, "-_."); i >= 0 {
+		lang = lang[:i]
+	}
+	if _, ok := catalogs[lang]; ok {
+		return lang
+	}
+	return ""
+}
+
+// T trả về thông báo key theo ngôn ngữ lang, định dạng với args theo kiểu fmt.Sprintf.
+// Thông báo không có trong ngôn ngữ lang được lấy từ ngôn ngữ mặc định, sau cùng là chính key.
+// Các tham số là *Error được dịch cùng ngôn ngữ.
+func T(lang, key string, args ...any) string {
+	lang = Normalize(lang)
+	if lang == "" {
+		lang = defaultLocale
+	}
+
+	format, ok := catalogs[lang][key]
+	if !ok {
+		if format, ok = catalogs[defaultLocale][key]; !ok {
+			format = key
+		}
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, localizeArgs(lang, args)...)
+}
+
+func localizeArgs(lang string, args []any) []any {
+	out := make([]any, len(args))
+	for i, arg := range args {
+		if e, ok := arg.(*Error); ok {
+			out[i] = e.Localize(lang)
+		} else {
+			out[i] = arg
+		}
+	}
+	return out
+}
+
+// Error là lỗi có mã ổn định (khóa thông báo), nội dung được dịch theo ngôn ngữ khi hiển thị.
+// Error() trả về nội dung theo ngôn ngữ mặc định.
+type Error struct {
+	Code string
+	Args []any
+}
+
+// Errorf tạo lỗi với mã code và tham số của thông báo
+func Errorf(code string, args ...any) *Error {
+	return &Error{Code: code, Args: args}
+}
+
+func (e *Error) Error() string {
+	return e.Localize(defaultLocale)
+}
+
+// Localize trả về nội dung lỗi theo ngôn ngữ lang
+func (e *Error) Localize(lang string) string {
+	return T(lang, e.Code, e.Args...)
+}
+
+// Unwrap trả về lỗi gốc (tham số kiểu error đầu tiên) để dùng được errors.Is/As
+func (e *Error) Unwrap() error {
+	for _, arg := range e.Args {
+		if err, ok := arg.(error); ok {
+			return err
+		}
+	}
+	return nil
+}
+
+// Message trả về nội dung lỗi theo ngôn ngữ lang; lỗi không phải *Error giữ nguyên nội dung
+func Message(lang string, err error) string {
+	if e, ok := err.(*Error); ok {
+		return e.Localize(lang)
+	}
+	return err.Error()
+}
+
+// Code trả về mã của *Error đầu tiên trong chuỗi lỗi, hoặc rỗng nếu không có
+func Code(err error) string {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return ""
+}
+
+// ErrorBody trả về nội dung JSON của một lỗi API: {"code": ..., "error": ...} với thông báo
+// theo ngôn ngữ của context
+func ErrorBody(ctx context.Context, code string, args ...any) map[string]any {
+	return map[string]any{
+		"code":  code,
+		"error": T(FromContext(ctx), code, args...),
+	}
+}
+
+// ErrorBodyFor trả về nội dung JSON của lỗi err: dùng mã của err nếu err là *Error,
+// ngược lại dùng mã fallback với err làm tham số của thông báo
+func ErrorBodyFor(ctx context.Context, err error, fallback string) map[string]any {
+	if e, ok := err.(*Error); ok {
+		return ErrorBody(ctx, e.Code, e.Args...)
+	}
+	return ErrorBody(ctx, fallback, err)
+}
+
+// ctxKey là khóa lưu ngôn ngữ trong context
+type ctxKey struct{}
+
+// WithLocale trả về context mang ngôn ngữ lang
+func WithLocale(ctx context.Context, lang string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, lang)
+}
+
+// FromContext trả về ngôn ngữ của context, mặc định là ngôn ngữ mặc định
+func FromContext(ctx context.Context) string {
+	if lang, ok := ctx.Value(ctxKey{}).(string); ok && lang != "" {
+		return lang
+	}
+	return defaultLocale
+}
+
+// Match chọn ngôn ngữ được hỗ trợ phù hợp nhất với header Accept-Language
+// (vd: "en-US,en;q=0.9,vi;q=0.8"), trả về rỗng nếu không có
+func Match(acceptLanguage string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, q := part, 1.0
+		if i := strings.Index(part, ";"); i >= 0 {
+			tag = part[:i]
+			param := strings.TrimSpace(part[i+1:])
+			if strings.HasPrefix(param, "q=") {
+				v, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64)
+				if err != nil {
+					continue
+				}
+				q = v
+			}
+		}
+
+		if lang := Normalize(tag); lang != "" && q > bestQ {
+			best, bestQ = lang, q
+		}
+	}
+	return best
+}
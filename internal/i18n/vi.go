@@ -0,0 +1,421 @@
+package i18n
+
+// vi là bảng thông báo tiếng Việt (ngôn ngữ mặc định)
+var vi = map[string]string{
+	// Lỗi chung
+	ErrInvalidRequest:   "Dữ liệu gửi lên không hợp lệ",
+	ErrInvalidParameter: "Tham số %s không hợp lệ",
+	ErrInternal:         "Lỗi hệ thống, vui lòng thử lại sau",
+	ErrTooManyRequests:  "Quá nhiều yêu cầu, vui lòng thử lại sau %d giây",
+
+	// Xác thực và phân quyền
+	ErrAuthRequired:        "Vui lòng đăng nhập để thực hiện thao tác này",
+	ErrAuthHeaderMissing:   "Thiếu header Authorization",
+	ErrAuthHeaderFormat:    "Header Authorization phải có dạng Bearer {token}",
+	ErrInvalidToken:        "Phiên đăng nhập không hợp lệ hoặc đã hết hạn",
+	ErrTokenExpired:        "Phiên đăng nhập đã hết hạn",
+	ErrAdminRequired:       "Thao tác này chỉ dành cho admin",
+	ErrInvalidCredentials:  "Tên đăng nhập hoặc mật khẩu không đúng",
+	ErrOAuthStart:          "Không thể khởi tạo phiên xác thực Google. Vui lòng thử lại.",
+	ErrOAuthStateInvalid:   "Phiên xác thực Google không hợp lệ hoặc đã hết hạn. Vui lòng thử lại.",
+	ErrOAuthDenied:         "Google từ chối xác thực: %s",
+	ErrOAuthCodeMissing:    "Không nhận được mã xác thực từ Google. Vui lòng thử lại.",
+	ErrOAuthExchangeFailed: "Lỗi xác thực: %v",
+	ErrUserExists:          "Người dùng %s đã tồn tại",
+	ErrUserNotFound:        "Không tìm thấy người dùng: %s",
+	ErrUserProtected:       "Không thể xóa tài khoản admin cấu hình qua ADMIN_USERNAME: %s",
+	ErrInvalidRole:         "Quyền không hợp lệ: %s (chỉ chấp nhận user hoặc admin)",
+	ErrPasswordRequired:    "Mật khẩu không được để trống",
+
+	// Job, bản backup và lần chạy
+	ErrJobNotFound:        "Không tìm thấy job: %s",
+	ErrJobNotPhysical:     "Job %s không ở chế độ physical",
+	ErrBackupNotFound:     "Không tìm thấy file backup có ID: %s",
+	ErrNoBackups:          "Không tìm thấy file backup nào",
+	ErrCompareFailed:      "Không thể so sánh với %s: %v",
+	ErrNoBaseBackup:       "Không có bản backup trước %s để so sánh",
+	ErrBackupListFailed:   "Không thể lấy danh sách backup: %v",
+	ErrBackupFileMissing:  "File %s không tồn tại",
+	ErrCatalogUnavailable: "Không thể đọc catalog backup",
+	ErrCatalogUpdate:      "Không thể cập nhật catalog backup",
+	ErrRunNotFound:        "Không tìm thấy lần chạy",
+	ErrRunsUnavailable:    "Không thể đọc lịch sử chạy",
+	ErrAuditUnavailable:   "Không thể đọc audit log",
+	ErrJobRequired:        "Cần chỉ định một job bằng --job",
+	ErrNoPhysicalJobs:     "Không có job nào ở chế độ physical",
+	ErrJobRunning:         "Job %s đang chạy, hãy thử lại sau khi lần chạy hiện tại kết thúc",
+	ErrInvalidTargetTime:  "Thời điểm khôi phục không hợp lệ (cần RFC3339, ví dụ 2024-05-01T10:30:00+07:00): %v",
+
+	// Cấu hình và khởi động
+	ErrConfigInvalid: "Cấu hình không hợp lệ: %v",
+	ErrStartupFailed: "Không thể khởi động: %v",
+	ErrServerFailed:  "Không thể khởi động server web: %v",
+
+	// Thao tác
+	ErrDumpFailed:         "Lỗi khi dump database: %v",
+	ErrUploadFailed:       "Lỗi khi upload file: %v",
+	ErrVerifyFailed:       "Kiểm tra %s thất bại: %v",
+	ErrRestoreFailed:      "Khôi phục thất bại: %v",
+	ErrDiscoveryDisabled:  "Tính năng tự động phát hiện container đang tắt (DISCOVERY_ENABLED=false)",
+	ErrDiscoveryFailed:    "Phát hiện container thất bại: %v",
+	ErrChannelNotFound:    "Không tìm thấy kênh thông báo: %s",
+	ErrNotificationFailed: "Gửi thông báo thử thất bại: %v",
+
+	// Dump database
+	ErrDumpBackupDir:       "Không thể tạo thư mục backup: %v",
+	ErrDumpTempDir:         "Không thể tạo thư mục tạm: %v",
+	ErrDumpOutputCreate:    "Không thể tạo file output: %v",
+	ErrDumpOutputWrite:     "Không thể ghi file output: %v",
+	ErrDumpOutputMissing:   "File không được tạo tại %s: %v",
+	ErrDumpCommandStart:    "Không thể chạy lệnh %s: %v",
+	ErrDumpCommandExit:     "Lệnh %s thất bại với mã lỗi: %d",
+	ErrDumpCommandOutput:   "%v: %s",
+	ErrDumpListDatabases:   "không thể liệt kê database: %v: %s",
+	ErrDumpNoDatabases:     "Không có database nào khớp với cấu hình include/exclude",
+	ErrDumpAllFailed:       "Dump thất bại với tất cả %d database",
+	ErrDumpArchive:         "Không thể đóng gói backup cluster: %v",
+	ErrDumpBackupLabel:     "Bản sao lưu vật lý không có %s: %v",
+	ErrDumpWALStart:        "Không đọc được vị trí WAL bắt đầu trong %s",
+	ErrDumpInvalidRowData:  "kết quả đếm dòng không hợp lệ: %q",
+	ErrDumpManifestInvalid: "manifest không hợp lệ: %v",
+	ErrDumpDiskSpace:       "Không đủ dung lượng trống để dump job %s: cần khoảng %s (ước lượng từ các bản backup trước), còn trống %s",
+
+	// Google Drive
+	ErrDriveNotAuthorized:      "chưa xác thực Google Drive: %v",
+	ErrDriveTokenMissing:       "không tìm thấy token xác thực. Vui lòng xác thực qua UI hoặc CLI",
+	ErrDriveTokenExpired:       "access token đã hết hạn và không có refresh token",
+	ErrDriveTokenRefresh:       "không thể làm mới token: %v",
+	ErrDriveTokenSave:          "không thể lưu token: %v",
+	ErrDriveTokenMigrate:       "không thể chuyển token vào kho mã hóa: %v",
+	ErrDriveExchange:           "không thể đổi mã xác thực: %v",
+	ErrDriveReadCode:           "không thể đọc mã xác thực: %v",
+	ErrDriveLoopback:           "Không thể mở cổng nhận callback xác thực: %v",
+	ErrDriveDeviceAuth:         "Không thể bắt đầu xác thực thiết bị: %v",
+	ErrDriveServiceAccount:     "Không lấy được access token bằng service account: %v",
+	ErrDriveServiceAccountMode: "Google Drive đang dùng service account (GOOGLE_SERVICE_ACCOUNT_KEY), không cần liên kết tài khoản Google",
+	ErrDriveService:            "không thể tạo Drive service: %v",
+	ErrDriveConnect:            "không thể kết nối Google Drive: %v",
+	ErrDriveFolderFind:         "không thể tìm folder: %v",
+	ErrDriveFolderCreate:       "không thể tạo folder: %v",
+	ErrDriveFolderPath:         "không thể tạo folder %s: %v",
+	ErrDriveFileCheck:          "không thể kiểm tra file tồn tại: %v",
+	ErrDriveList:               "không thể liệt kê file: %v",
+	ErrDriveDelete:             "không thể xóa file %s: %v",
+	ErrDriveFileOpen:           "không thể mở file: %v",
+	ErrDriveUpload:             "không thể upload file: %v",
+	ErrDriveReadBackupDir:      "không thể đọc thư mục backup: %v",
+	ErrDriveUploadsPartial:     "%d/%d file upload thất bại",
+	ErrDriveQuota:              "Không thể đọc hạn mức lưu trữ Google Drive: %v",
+	ErrDriveQuotaExceeded:      "Không đủ dung lượng Google Drive: cần %s, còn trống %s",
+
+	// Catalog và thư mục backup
+	ErrCatalogRead:      "không thể đọc catalog: %v",
+	ErrCatalogWrite:     "không thể ghi catalog: %v",
+	ErrAuditRead:        "không thể đọc audit log: %v",
+	ErrBackupDirRead:    "không thể đọc thư mục backup: %v",
+	ErrJobBackupDirRead: "không thể đọc thư mục backup của job %s: %v",
+	ErrBackupJobUnknown: "không xác định được job của bản backup %s",
+
+	// Chạy job (backup, hooks, anomaly)
+	ErrJobDumpFailed:   "dump job %s thất bại: %v",
+	ErrJobUploadFailed: "upload job %s thất bại: %v",
+	ErrHooksFailed:     "%d hook %s thất bại: %s",
+	ErrHookTimeout:     "timeout sau %s",
+
+	// Docker
+	ErrDockerConnect:       "không thể kết nối Docker Engine: %v",
+	ErrContainerNotFound:   "container %s không tồn tại",
+	ErrContainerNotRunning: "container %s không ở trạng thái running (%s)",
+	ErrContainerCreate:     "không thể tạo container: %v",
+	ErrContainerStart:      "không thể start container: %v",
+	ErrContainerCopy:       "không thể copy file vào container: %v",
+	ErrContainerAttach:     "không thể attach container: %v",
+	ErrContainerWait:       "không thể chờ container kết thúc: %v",
+	ErrContainerPort:       "cổng không hợp lệ %q (dạng hostPort:containerPort)",
+	ErrExecCreate:          "không thể tạo exec: %v",
+	ErrExecStart:           "không thể chạy exec: %v",
+	ErrExecInspect:         "không thể lấy exit code: %v",
+	ErrExecRunning:         "exec vẫn đang chạy sau khi stream kết thúc",
+	ErrImagePull:           "không thể pull image %s: %v",
+	ErrDockerStreamRead:    "lỗi đọc stream từ Docker: %v",
+	ErrDockerStreamInvalid: "stream Docker không hợp lệ: mã luồng %d",
+
+	// Kiểm tra khôi phục (verify)
+	ErrVerifyExtract:         "không thể giải nén bản backup cluster: %v",
+	ErrVerifyManifestMissing: "bản backup cluster thiếu manifest.json: %v",
+	ErrVerifyManifestInvalid: "manifest.json không hợp lệ: %v",
+	ErrVerifyNoDatabases:     "bản backup cluster không chứa database nào",
+	ErrVerifyArchivePath:     "đường dẫn không hợp lệ trong archive: %s",
+	ErrVerifyPhysical:        "bản sao lưu vật lý được kiểm tra bằng khôi phục theo thời điểm (PITR), không qua verify",
+	ErrVerifyRowCounts:       "không thể đọc số dòng lúc dump: %v",
+	ErrVerifyRestoreDatabase: "khôi phục database %s thất bại: %v",
+	ErrVerifyCountRows:       "không thể đếm số dòng trong database %s: %v",
+	ErrVerifySourceSchema:    "không thể lấy cấu trúc database nguồn: %v",
+	ErrVerifyRestoreSchema:   "khôi phục cấu trúc thất bại: %v",
+	ErrVerifyNotReady:        "postgres trong container tạm không sẵn sàng sau %s",
+	ErrVerifyCommandExit:     "%s thoát với mã %d: %s",
+	ErrVerifyChecksFailed:    "%d/%d kiểm tra không đạt",
+
+	// WAL và khôi phục theo thời điểm
+	ErrWALDir:                 "không thể tạo thư mục WAL: %v",
+	ErrWALFetch:               "không thể lấy WAL %s: %v",
+	ErrWALListArchive:         "không thể đọc %s trong container: %s",
+	ErrWALSegmentSizeRead:     "không thể đọc wal_segment_size: %v",
+	ErrWALSegmentSizeInvalid:  "wal_segment_size không hợp lệ: %q",
+	ErrWALCat:                 "cat thất bại: %s",
+	ErrWALSegmentIncomplete:   "segment có kích thước %d byte, cần %d byte (wal_segment_size)",
+	ErrWALUploadsFailed:       "không thể upload %d file WAL",
+	ErrWALSegmentMissing:      "thiếu segment WAL %s cần cho bản sao lưu %s",
+	ErrWALGap:                 "WAL liên tục chỉ có tới %s (segment %s), không thể khôi phục tới %s",
+	ErrPITRVersion:            "khôi phục theo thời điểm cần PostgreSQL %d trở lên (server nguồn: %q)",
+	ErrPITRCopy:               "không thể chép dữ liệu vào container %s: %v",
+	ErrPITRFailed:             "%v (xem docker logs %s)",
+	ErrPITRContainerStopped:   "container dừng trong khi khôi phục",
+	ErrPITRTimeout:            "hết thời gian chờ khôi phục: %v",
+	ErrNoPhysicalBackup:       "job %s chưa có bản sao lưu vật lý nào",
+	ErrNoPhysicalBackupBefore: "job %s không có bản sao lưu vật lý nào trước %s",
+
+	// Xóa bản backup hết hạn (retention)
+	ErrRetentionDelete:      "không thể xóa %d backup hết hạn",
+	ErrRetentionWALDelete:   "không thể xóa %d file WAL",
+	ErrDriveRetentionDelete: "không thể xóa %d backup hết hạn trên Drive",
+
+	// Thông báo và heartbeat
+	ErrNotifySend:       "không thể gửi thông báo: %s",
+	ErrNotifyCanceled:   "%v (lỗi trước đó: %v)",
+	ErrRetriesExhausted: "thất bại sau %d lần thử: %v",
+	ErrHeartbeatURL:     "URL heartbeat không hợp lệ: %v",
+
+	// Kiểm tra sẵn sàng (health)
+	ErrHealthDatabase:        "database chưa được khởi tạo",
+	ErrHealthDiskLow:         "%s, thấp hơn ngưỡng %d MB",
+	ErrHealthDiskUnsupported: "không hỗ trợ kiểm tra dung lượng trống trên hệ điều hành này",
+
+	// Kết quả thao tác
+	"dump.success":             "Dump dữ liệu thành công",
+	"dump.physical_success":    "Sao lưu vật lý thành công",
+	"dump.cluster_success":     "Dump cluster thành công %d/%d database",
+	"verify.success":           "Bản backup %s khôi phục được: %s",
+	"auth.login_success":       "Đăng nhập thành công",
+	"auth.logout_success":      "Đăng xuất thành công",
+	"auth_success.message":     "Xác thực Google Drive thành công! Cửa sổ này sẽ tự động đóng.",
+	"flash.dump_success":       "Đã dump database thành công. File: %s",
+	"flash.dump_anomalies":     "Cảnh báo bản backup bất thường: %s",
+	"flash.upload_success":     "Đã upload file %s lên Google Drive",
+	"flash.upload_all_success": "Đã upload tất cả file backup lên Google Drive",
+
+	// Tóm tắt lần chạy, kiểm tra và thông báo
+	"run.uploaded":            "Đã upload lên Google Drive",
+	"run.verify":              "Kiểm tra khôi phục: %s",
+	"run.pruned":              "Đã xóa %d bản backup hết hạn",
+	"run.anomaly":             "Bất thường: %s",
+	"anomaly.size_drop":       "kích thước %s nhỏ hơn %.0f%% so với trung vị %s của %d bản backup trước",
+	"anomaly.size_growth":     "kích thước %s lớn hơn %.0f%% so với trung vị %s của %d bản backup trước",
+	"anomaly.slow_dump":       "thời gian dump %s chậm hơn %.0f%% so với trung vị %s",
+	"verify.restored":         "Khôi phục thành công, %d kiểm tra đạt",
+	"verify.no_row_counts":    "không có số dòng lúc dump để so sánh",
+	"verify.table_missing":    "không có bảng sau khi khôi phục",
+	"verify.row_mismatch":     "lúc dump %d dòng, sau khi khôi phục %d dòng",
+	"verify.tables_matched":   "%d/%d bảng khớp",
+	"verify.assertion_result": "kết quả %q, cần true",
+	"notify.test_title":       "[backup] Thông báo thử",
+	"notify.test_text":        "Kênh %s đã được cấu hình đúng.",
+	"notify.failure_title":    "[backup] Job %s thất bại",
+	"notify.failure_error":    "Lỗi: %s",
+	"notify.success_title":    "[backup] Job %s thành công",
+	"notify.anomaly_title":    "[backup] Bản backup bất thường của job %s",
+	"notify.digest_title":     "[backup] Tổng hợp từ %s: %d bản backup, %d lỗi, %d bất thường",
+	"notify.digest_line":      "[%s] %s: %d bản backup (%s), %d lỗi, %d bất thường",
+	"notify.digest_latest":    ", gần nhất %s",
+	"notify.digest_failed":    "LỖI",
+	"notify.digest_anomaly":   "BẤT THƯỜNG",
+	"notify.digest_empty":     "KHÔNG CÓ BACKUP",
+	"health.no_drive_jobs":    "không có job upload lên Drive",
+	"health.token_valid":      "token hợp lệ",
+	"health.disk_free":        "%d MB trống",
+
+	// Dòng lệnh
+	"cli.dumping":                     "Đang thực hiện dump database cho job %s...",
+	"cli.dump_success":                "Dump thành công: %s",
+	"cli.anomaly_warning":             "Cảnh báo: bản backup bất thường: %s",
+	"cli.dump_failed":                 "%d/%d job dump thất bại",
+	"cli.finding_latest":              "Đang tìm file backup mới nhất...",
+	"cli.uploading":                   "Đang upload file %s lên Google Drive...",
+	"cli.upload_failed":               "%d/%d file upload thất bại",
+	"cli.uploading_all":               "Đang upload tất cả file backup lên Google Drive...",
+	"cli.verifying":                   "Đang kiểm tra khôi phục %s...",
+	"cli.verify_failed":               "%d/%d job kiểm tra thất bại",
+	"cli.wal_archived":                "Job %s: đã lưu trữ %d file WAL",
+	"cli.wal_failed":                  "%d/%d job lưu trữ WAL thất bại",
+	"cli.restoring":                   "Đang khôi phục job %s...",
+	"cli.restore_success":             "Khôi phục thành công vào container %s (image %s) từ %s, WAL %s..%s",
+	"cli.notify_test_sent":            "Đã gửi thông báo thử tới kênh %s",
+	"cli.starting_web":                "Đang khởi động ứng dụng web trên port %s...",
+	"cli.daemon_running":              "Đang chạy ở chế độ daemon, nhấn Ctrl+C để dừng...",
+	"cli.usage":                       "Cách dùng",
+	"cli.commands":                    "Các lệnh",
+	"cli.help_hint":                   "Xem tham số của từng lệnh: %s <lệnh> -h",
+	"cli.unknown_command":             "Lệnh không hợp lệ: %s",
+	"cli.invalid_output":              "Định dạng output không hợp lệ: %s (chỉ chấp nhận text hoặc json)",
+	"cli.upload_success":              "Upload thành công: %s",
+	"cli.upload_all_success":          "Upload tất cả file backup thành công",
+	"cli.pruned":                      "Job %s: đã xóa %d bản backup hết hạn",
+	"cli.prune_dry_run":               "Job %s: %d bản backup hết hạn sẽ bị xóa",
+	"cli.prune_failed":                "%d/%d job dọn dẹp thất bại",
+	"cli.list_item":                   "  %s",
+	"cli.channel_required":            "Cần chỉ định tên kênh thông báo",
+	"cli.auth_revoked":                "Đã thu hồi và xóa token Google Drive",
+	"cli.username_required":           "Cần chỉ định một tên người dùng",
+	"cli.password_prompt":             "Nhập mật khẩu: ",
+	"cli.user_created":                "Đã tạo người dùng %s (quyền %s)",
+	"cli.user_password_changed":       "Đã đổi mật khẩu của người dùng %s",
+	"cli.user_deleted":                "Đã xóa người dùng %s",
+	"cli.config_valid":                "Cấu hình hợp lệ, %d job:",
+	"cli.shell_required":              "Cần chỉ định shell: bash, zsh hoặc fish",
+	"list.header":                     "ID\tJOB\tTÊN FILE\tKÍCH THƯỚC\tNGÀY TẠO\tUPLOAD\tKIỂM TRA",
+	"users.header":                    "TÊN ĐĂNG NHẬP\tQUYỀN\tNGÔN NGỮ\tNGÀY TẠO",
+	"cli.auth_open_url":               "Mở URL sau trong trình duyệt để liên kết Google Drive:\n\n  %s\n\nĐang chờ Google chuyển hướng về %s\nNếu trình duyệt ở máy khác, hãy dán URL trình duyệt bị chuyển tới (hoặc mã code) vào đây rồi nhấn Enter:",
+	"cli.auth_device_code":            "Mở %s trên một thiết bị bất kỳ và nhập mã: %s\nMã hết hạn lúc %s. Đang chờ xác nhận...",
+	"cli.auth_linked":                 "Đã liên kết Google Drive",
+	"cli.auth_status_not_authorized":  "Chưa liên kết Google Drive",
+	"cli.auth_status_service_account": "Xác thực bằng service account: %s",
+	"cli.auth_status_impersonate":     "Ủy quyền toàn miền cho: %s",
+	"cli.auth_status_scopes":          "Scope: %s",
+	"cli.auth_status_expiry":          "Access token hết hạn: %s",
+	"cli.auth_status_refresh_ok":      "Làm mới token: OK",
+	"cli.auth_status_refresh_failed":  "Làm mới token: thất bại (%s)",
+	"cli.auth_status_refresh_missing": "Làm mới token: không có refresh token, cần liên kết lại",
+
+	// Tham số dòng lệnh
+	"flag.upload_all":     "Upload tất cả các file backup",
+	"flag.daemon":         "Chạy các job theo lịch, không có giao diện web",
+	"flag.schedule":       "Chạy các job theo lịch cùng với giao diện web",
+	"flag.port":           "Port cho ứng dụng web",
+	"flag.job":            "Tên job cần thực hiện (mặc định: tất cả các job)",
+	"flag.target_time":    "Thời điểm khôi phục (RFC3339), mặc định: cuối WAL đã lưu trữ",
+	"flag.restore_name":   "Tên container khôi phục (mặc định: <job>-pitr-<thời gian>)",
+	"flag.restore_port":   "Cổng trên host công bố PostgreSQL của container khôi phục",
+	"flag.output":         "Định dạng output: text hoặc json",
+	"flag.upload":         "Upload bản backup lên Google Drive sau khi dump",
+	"flag.prune":          "Xóa các bản backup hết hạn theo chính sách lưu giữ sau khi dump",
+	"flag.id":             "ID của bản backup (xem lệnh list)",
+	"flag.dry_run":        "Chỉ liệt kê các bản backup sẽ bị xóa",
+	"flag.role":           "Quyền của người dùng: user hoặc admin",
+	"flag.password_stdin": "Đọc mật khẩu từ stdin (không hiện lời nhắc)",
+	"flag.device":         "Dùng luồng xác thực thiết bị (máy chủ không có trình duyệt)",
+	"flag.callback_port":  "Cổng của listener nhận callback trên 127.0.0.1 (0: ngẫu nhiên)",
+
+	// Lệnh
+	"cmd.dump":            "Dump database của các job",
+	"cmd.upload":          "Upload bản backup lên Google Drive",
+	"cmd.list":            "Liệt kê các bản backup local",
+	"cmd.verify":          "Kiểm tra khả năng khôi phục bản backup",
+	"cmd.restore":         "Khôi phục job physical tới một thời điểm vào container mới",
+	"cmd.prune":           "Xóa các bản backup hết hạn theo chính sách lưu giữ",
+	"cmd.archive_wal":     "Lấy các segment WAL mới của các job physical một lượt",
+	"cmd.notify":          "Kênh thông báo",
+	"cmd.notify_test":     "Gửi thông báo thử tới một kênh",
+	"cmd.serve":           "Khởi động ứng dụng web hoặc daemon chạy theo lịch",
+	"cmd.auth":            "Xác thực Google Drive",
+	"cmd.auth_google":     "Liên kết Google Drive từ dòng lệnh",
+	"cmd.auth_status":     "Xem scope, thời hạn và khả năng làm mới của token",
+	"cmd.auth_revoke":     "Thu hồi và xóa token Google Drive",
+	"cmd.users":           "Quản lý người dùng giao diện web",
+	"cmd.users_list":      "Liệt kê người dùng",
+	"cmd.users_add":       "Tạo người dùng",
+	"cmd.users_passwd":    "Đổi mật khẩu người dùng",
+	"cmd.users_delete":    "Xóa người dùng",
+	"cmd.config":          "Cấu hình",
+	"cmd.config_validate": "Kiểm tra cấu hình",
+	"cmd.completion":      "In script completion cho shell",
+
+	// Giao diện web
+	"auth_success.closing":        "Đang đóng...",
+	"auth_success.heading":        "Đã xác thực Google Drive!",
+	"auth_success.title":          "Xác thực thành công",
+	"backup.after":                "Sau",
+	"backup.anomaly":              "Bản backup bất thường:",
+	"backup.anomaly_kept":         "Bản backup này sẽ không bị xóa tự động.",
+	"backup.before":               "Trước",
+	"backup.change":               "Thay đổi",
+	"backup.compare":              "So sánh",
+	"backup.compare_heading":      "So sánh số dòng",
+	"backup.compared_with":        "So với",
+	"backup.created_at":           "Ngày tạo",
+	"backup.dump_duration":        "Thời gian dump",
+	"backup.extensions":           "Extension:",
+	"backup.file_size":            "Kích thước file",
+	"backup.no_diff":              "Không có thông tin bảng để so sánh",
+	"backup.no_log":               "Không có log.",
+	"backup.no_others":            "Job chưa có bản backup khác để so sánh.",
+	"backup.no_tables":            "Không có thông tin bảng",
+	"backup.not_in_catalog":       "Bản backup này chưa có trong catalog (được tạo trước khi có tính năng thống kê).",
+	"backup.rows":                 "Số dòng",
+	"backup.run_log":              "Log lần chạy",
+	"backup.run_summary":          "Bắt đầu %s, thời gian chạy %s,",
+	"backup.server_version":       "Phiên bản server",
+	"backup.size":                 "Dung lượng",
+	"backup.table":                "Bảng",
+	"backup.table_added":          "Bảng mới",
+	"backup.table_missing":        "Mất bảng",
+	"backup.threshold_note":       "Bảng giảm từ %v%% số dòng trở lên được tô đỏ.",
+	"common.back":                 "Quay lại",
+	"common.failure":              "Thất bại",
+	"common.success":              "Thành công",
+	"error.auth_incomplete":       "Không thể hoàn tất xác thực!",
+	"error.back_home":             "Quay lại trang chủ",
+	"error.heading":               "Đã xảy ra lỗi",
+	"error.retry_auth":            "Thử lại xác thực",
+	"error.title":                 "Lỗi",
+	"index.actions":               "Thao tác",
+	"index.all_jobs":              "Tất cả job",
+	"index.anomaly":               "Bất thường",
+	"index.auth_before_upload":    "Vui lòng xác thực tài khoản Google trước khi upload",
+	"index.auth_google":           "Xác thực với Google",
+	"index.drive_quota":           "Dung lượng Google Drive",
+	"index.drive_quota_unlimited": "Đã dùng %s (không giới hạn)",
+	"index.drive_quota_usage":     "%s / %s (%d%%)",
+	"index.backups_heading":       "Danh sách file backup",
+	"index.details":               "Chi tiết",
+	"index.discovered_short":      "tự động",
+	"index.download":              "Tải xuống",
+	"index.dump_description":      "Tạo bản sao lưu dữ liệu từ container Docker và lưu vào thư mục local.",
+	"index.file_name":             "Tên file",
+	"index.file_size":             "Kích thước",
+	"index.flash_error":           "Lỗi!",
+	"index.flash_success":         "Thành công!",
+	"index.heading":               "Công cụ Backup và Upload Database",
+	"index.job_configured":        "Cấu hình",
+	"index.job_discovered":        "Tự động (label)",
+	"index.job_origin":            "Nguồn cấu hình",
+	"index.job_schedule":          "Lịch chạy",
+	"index.job_source":            "Nguồn dữ liệu",
+	"index.jobs_heading":          "Danh sách job",
+	"index.language":              "Ngôn ngữ",
+	"index.logout":                "Đăng xuất",
+	"index.need_auth":             "Cần xác thực!",
+	"index.need_auth_detail":      "Để sử dụng tính năng upload lên Google Drive, bạn cần xác thực tài khoản Google.",
+	"index.no_backups":            "Chưa có file backup nào",
+	"index.no_jobs":               "Chưa có job nào",
+	"index.not_uploaded":          "Chưa upload",
+	"index.not_verified":          "Chưa kiểm tra",
+	"index.title":                 "Quản lý Backup Database",
+	"index.upload":                "Upload",
+	"index.upload_all":            "Upload Tất Cả",
+	"index.upload_all_confirm":    "Bạn có chắc chắn muốn upload tất cả các file backup lên Google Drive?",
+	"index.upload_description":    "Upload file backup mới nhất hoặc tất cả các file backup lên Google Drive.",
+	"index.upload_heading":        "Upload lên Google Drive",
+	"index.upload_latest":         "Upload File Mới Nhất",
+	"index.uploaded":              "Đã upload",
+	"index.verified":              "Khôi phục được",
+	"index.verify":                "Kiểm tra",
+	"index.verify_failed":         "Lỗi khôi phục",
+	"login.failed":                "Đăng nhập thất bại",
+	"login.in_progress":           "Đang đăng nhập...",
+	"login.password":              "Mật khẩu",
+	"login.submit":                "Đăng nhập",
+	"login.success":               "Đăng nhập thành công! Đang chuyển hướng...",
+	"login.title":                 "Đăng nhập",
+	"login.too_many_attempts":     "Đăng nhập sai quá nhiều lần, vui lòng thử lại sau %d giây",
+	"login.username":              "Tên đăng nhập",
+}
@@ -0,0 +1,217 @@
+package logging
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/backup-cronjob/internal/config"
+)
+
+// Tên các trường log dùng chung
+const (
+	KeyJob   = "job"
+	KeyRunID = "run_id"
+	KeyFile  = "file"
+	KeyError = "error"
+)
+
+// Các định dạng output
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// MaxCaptureSize giới hạn kích thước log lưu cùng một lần chạy (byte); phần vượt quá bị bỏ
+const MaxCaptureSize = 1 << 20
+
+// Setup cấu hình logger mặc định ghi ra w theo định dạng (text, json) và mức log (debug, info,
+// warn, error). Các lời gọi log.Printf còn lại cũng được chuyển qua logger này ở mức info.
+func Setup(w io.Writer, format, level string) error {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("invalid LOG_LEVEL %q (supported: debug, info, warn, error)", level)
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+	var base slog.Handler
+	switch strings.ToLower(format) {
+	case "", FormatText:
+		base = slog.NewTextHandler(w, opts)
+	case FormatJSON:
+		base = slog.NewJSONHandler(w, opts)
+	default:
+		return fmt.Errorf("invalid LOG_FORMAT %q (supported: text, json)", format)
+	}
+
+	slog.SetDefault(slog.New(&handler{base: base}))
+	return nil
+}
+
+// ctxKey là khóa lưu logger và bộ thu log trong context
+type ctxKey int
+
+const loggerKey ctxKey = 0
+
+// With trả về context mang logger có thêm các trường args (cặp key/value)
+func With(ctx context.Context, args ...any) context.Context {
+	return context.WithValue(ctx, loggerKey, From(ctx).With(args...))
+}
+
+// From trả về logger của context (kèm các trường đã thêm bằng With), mặc định là slog.Default()
+func From(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// NewID tạo ID ngẫu nhiên dạng UUID (v4) cho một lần chạy job hoặc một request
+func NewID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	h := hex.EncodeToString(b)
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
+
+// Err trả về trường lỗi chuẩn
+func Err(err error) slog.Attr {
+	return slog.Any(KeyError, err)
+}
+
+// Capture thu lại các dòng log của một lần chạy job (mọi mức, kể cả debug) để lưu cùng bản ghi lần chạy
+type Capture struct {
+	mu        sync.Mutex
+	buf       bytes.Buffer
+	handler   slog.Handler
+	truncated bool
+}
+
+// StartCapture trả về context có logger mà mọi dòng log ghi qua nó (From(ctx) và các logger
+// tạo từ đó bằng With) đồng thời được thu vào Capture trả về
+func StartCapture(ctx context.Context) (context.Context, *Capture) {
+	c := &Capture{}
+	// Log được lưu vào database nên cũng phải che secret như output chính
+	c.handler = slog.NewTextHandler(config.NewRedactingWriter(captureWriter{c}), &slog.HandlerOptions{Level: slog.LevelDebug})
+
+	h, ok := From(ctx).Handler().(*handler)
+	if !ok {
+		h = &handler{base: From(ctx).Handler()}
+	}
+	captured := &handler{base: h.base, ops: h.ops, capture: c}
+	return context.WithValue(ctx, loggerKey, slog.New(captured)), c
+}
+
+// String trả về nội dung log đã thu
+func (c *Capture) String() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.truncated {
+		return c.buf.String() + "... (log truncated)\n"
+	}
+	return c.buf.String()
+}
+
+// captureWriter ghi vào bộ đệm của Capture, bỏ phần vượt quá MaxCaptureSize
+type captureWriter struct{ c *Capture }
+
+func (w captureWriter) Write(p []byte) (int, error) {
+	w.c.mu.Lock()
+	defer w.c.mu.Unlock()
+
+	if room := MaxCaptureSize - w.c.buf.Len(); len(p) > room {
+		w.c.truncated = true
+		if room > 0 {
+			w.c.buf.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return w.c.buf.Write(p)
+}
+
+// handler chuyển bản ghi tới handler chính theo mức log cấu hình và tới Capture (nếu có)
+type handler struct {
+	base slog.Handler
+	// ops là các trường/nhóm đã thêm qua WithAttrs/WithGroup, áp dụng lại cho handler của Capture
+	ops     []func(slog.Handler) slog.Handler
+	capture *Capture
+}
+
+func (h *handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.capture != nil || h.base.Enabled(ctx, level)
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	if c := h.capture; c != nil {
+		target := c.handler
+		for _, op := range h.ops {
+			target = op(target)
+		}
+		target.Handle(ctx, r.Clone())
+	}
+	if h.base.Enabled(ctx, r.Level) {
+		return h.base.Handle(ctx, r)
+	}
+	return nil
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(h.base.WithAttrs(attrs), func(next slog.Handler) slog.Handler { return next.WithAttrs(attrs) })
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	return h.with(h.base.WithGroup(name), func(next slog.Handler) slog.Handler { return next.WithGroup(name) })
+}
+
+func (h *handler) with(base slog.Handler, op func(slog.Handler) slog.Handler) *handler {
+	ops := append(append([]func(slog.Handler) slog.Handler(nil), h.ops...), op)
+	return &handler{base: base, ops: ops, capture: h.capture}
+}
+
+// Writer trả về io.Writer ghi mỗi dòng nhận được thành một bản ghi log ở mức level với các trường
+// args (dùng cho stderr của pg_dump, hook...). Gọi Close để ghi nốt dòng cuối chưa kết thúc.
+func Writer(ctx context.Context, level slog.Level, msg string, args ...any) io.WriteCloser {
+	pr, pw := io.Pipe()
+	logger := From(ctx).With(args...)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		scanner := bufio.NewScanner(pr)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			if line := strings.TrimRight(scanner.Text(), "\r"); line != "" {
+				logger.Log(ctx, level, msg, "line", line)
+			}
+		}
+		// Bỏ phần còn lại nếu một dòng quá dài để không chặn bên ghi
+		io.Copy(io.Discard, pr)
+	}()
+
+	return &lineWriter{pw: pw, done: done}
+}
+
+type lineWriter struct {
+	pw   *io.PipeWriter
+	done chan struct{}
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	return w.pw.Write(p)
+}
+
+func (w *lineWriter) Close() error {
+	w.pw.Close()
+	<-w.done
+	return nil
+}
@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/backup-cronjob/internal/config"
+	"github.com/backup-cronjob/internal/database"
+	"github.com/backup-cronjob/internal/logging"
+)
+
+// contentType là content type của định dạng text Prometheus
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Handler trả về http.Handler xuất metric theo định dạng text của Prometheus.
+// token khác rỗng thì yêu cầu header "Authorization: Bearer <token>".
+func Handler(cfg *config.Config, token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !Authorized(r, token) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var buf bytes.Buffer
+		Write(&buf, cfg)
+
+		w.Header().Set("Content-Type", contentType)
+		w.Write(buf.Bytes())
+	})
+}
+
+// Authorized cho biết request có header "Authorization: Bearer <token>" khớp token hay không;
+// luôn đúng khi token rỗng
+func Authorized(r *http.Request, token string) bool {
+	if token == "" {
+		return true
+	}
+	given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1
+}
+
+// Write ghi toàn bộ metric: thời điểm backup thành công gần nhất lấy từ catalog (bao gồm cả các
+// lần chạy từ CLI) và các metric ghi nhận trong tiến trình
+func Write(w io.Writer, cfg *config.Config) {
+	writeCatalog(w, cfg)
+	for _, m := range registry {
+		m.write(w)
+	}
+}
+
+// writeCatalog ghi thời điểm dump/upload thành công gần nhất của từng job. Job chưa có bản backup
+// nào có giá trị 0 để cảnh báo "không có backup trong N giờ" vẫn kích hoạt.
+func writeCatalog(w io.Writer, cfg *config.Config) {
+	created, uploaded, err := database.LastBackupTimes()
+	up := 1
+	if err != nil {
+		slog.Error("Failed to read backup catalog for metrics", logging.Err(err))
+		up = 0
+	}
+
+	writeHeader(w, "backup_catalog_up", "1 nếu đọc được catalog backup", "gauge")
+	fmt.Fprintf(w, "backup_catalog_up %d\n", up)
+	if err != nil {
+		return
+	}
+
+	for _, m := range []struct {
+		name, help string
+		times      map[string]time.Time
+		enabled    func(job *config.Job) bool
+	}{
+		{"backup_last_success_dump_timestamp_seconds", "Thời điểm (unix) dump thành công gần nhất của job", created,
+			func(job *config.Job) bool { return true }},
+		{"backup_last_success_upload_timestamp_seconds", "Thời điểm (unix) upload thành công gần nhất của job", uploaded,
+			func(job *config.Job) bool { return job.HasDestination(config.DestinationDrive) }},
+	} {
+		writeHeader(w, m.name, m.help, "gauge")
+		for _, job := range cfg.Jobs() {
+			if !m.enabled(job) {
+				continue
+			}
+			var value int64
+			if t, ok := m.times[job.Name]; ok {
+				value = t.Unix()
+			}
+			fmt.Fprintf(w, "%s%s %d\n", m.name, formatLabels([]string{"job"}, []string{job.Name}), value)
+		}
+	}
+}
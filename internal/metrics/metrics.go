@@ -0,0 +1,280 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/api/googleapi"
+)
+
+// Bucket của các histogram
+var (
+	// DurationBuckets (giây) cho thời gian dump
+	DurationBuckets = []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800, 3600, 7200}
+	// SizeBuckets (byte) cho kích thước bản backup
+	SizeBuckets = []float64{1 << 20, 10 << 20, 100 << 20, 1 << 30, 10 << 30, 100 << 30}
+)
+
+// Các metric ghi nhận trong tiến trình (reset khi khởi động lại)
+var (
+	dumpDuration = newHistogram("backup_dump_duration_seconds", "Thời gian dump database", []string{"job"}, DurationBuckets)
+	dumpSize     = newHistogram("backup_dump_size_bytes", "Kích thước file backup sau khi dump", []string{"job"}, SizeBuckets)
+	dumpFailures = newCounter("backup_dump_failures_total", "Số lần dump thất bại", []string{"job"})
+
+	uploadBytes    = newCounter("backup_upload_bytes_total", "Số byte đã upload lên đích từ xa", []string{"destination", "job"})
+	uploadFiles    = newCounter("backup_upload_files_total", "Số file đã upload lên đích từ xa", []string{"destination", "job"})
+	uploadFailures = newCounter("backup_upload_failures_total", "Số lần upload thất bại", []string{"destination", "job"})
+
+	driveErrors = newCounter("backup_drive_api_errors_total", "Số lỗi khi gọi Google Drive API theo mã trạng thái HTTP", []string{"status"})
+
+	driveQuotaLimit = newGauge("backup_drive_quota_limit_bytes", "Hạn mức lưu trữ Google Drive (0 = không giới hạn hoặc chưa đọc)", nil)
+	driveQuotaUsage = newGauge("backup_drive_quota_usage_bytes", "Dung lượng Google Drive đã dùng", nil)
+
+	preflightFailures = newCounter("backup_preflight_failures_total", "Số lần từ chối chạy do kiểm tra dung lượng trước (disk, drive_quota)", []string{"check", "job"})
+
+	queueDepth = newGauge("backup_job_queue_depth", "Số job đang chạy hoặc chờ chạy", nil)
+)
+
+// ObserveDump ghi nhận một lần dump thành công
+func ObserveDump(job string, duration time.Duration, size int64) {
+	dumpDuration.observe(duration.Seconds(), job)
+	dumpSize.observe(float64(size), job)
+}
+
+// DumpFailed ghi nhận một lần dump thất bại
+func DumpFailed(job string) {
+	dumpFailures.add(1, job)
+}
+
+// Uploaded ghi nhận một file đã upload lên destination
+func Uploaded(destination, job string, bytes int64) {
+	uploadBytes.add(float64(bytes), destination, job)
+	uploadFiles.add(1, destination, job)
+}
+
+// UploadFailed ghi nhận một lần upload thất bại
+func UploadFailed(destination, job string) {
+	uploadFailures.add(1, destination, job)
+}
+
+// DriveAPIError ghi nhận lỗi khi gọi Drive API theo mã trạng thái ("network" nếu không có phản hồi HTTP)
+// và trả lại err để tiện dùng tại nơi gọi
+func DriveAPIError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	status := "network"
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		status = strconv.Itoa(apiErr.Code)
+	}
+	driveErrors.add(1, status)
+	return err
+}
+
+// DriveQuota ghi nhận hạn mức và dung lượng đã dùng của Google Drive
+func DriveQuota(limit, usage int64) {
+	driveQuotaLimit.set(float64(limit))
+	driveQuotaUsage.set(float64(usage))
+}
+
+// PreflightFailed ghi nhận một lần từ chối dump hoặc upload vì không đủ dung lượng
+func PreflightFailed(check, job string) {
+	preflightFailures.add(1, check, job)
+}
+
+// JobQueued tăng số job trong hàng đợi; trả về hàm giảm lại khi job kết thúc
+func JobQueued() func() {
+	queueDepth.add(1)
+	var once sync.Once
+	return func() {
+		once.Do(func() { queueDepth.add(-1) })
+	}
+}
+
+// metric là một metric có thể ghi ra định dạng text của Prometheus
+type metric interface {
+	write(w io.Writer)
+}
+
+// registry chứa các metric theo thứ tự đăng ký
+var registry []metric
+
+// series là giá trị của một metric theo bộ nhãn
+type series struct {
+	labels []string
+	value  float64
+}
+
+// vec lưu các series của một metric, khóa theo giá trị nhãn
+type vec struct {
+	name, help, kind string
+	labelNames       []string
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+func newVec(name, help, kind string, labelNames []string) *vec {
+	v := &vec{name: name, help: help, kind: kind, labelNames: labelNames, series: make(map[string]*series)}
+	registry = append(registry, v)
+	return v
+}
+
+func newCounter(name, help string, labelNames []string) *vec {
+	return newVec(name, help, "counter", labelNames)
+}
+
+func newGauge(name, help string, labelNames []string) *vec {
+	v := newVec(name, help, "gauge", labelNames)
+	if len(labelNames) == 0 {
+		// Gauge không nhãn luôn được xuất (kể cả khi bằng 0)
+		v.add(0)
+	}
+	return v
+}
+
+func (v *vec) add(delta float64, labels ...string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	key := strings.Join(labels, "\xff")
+	s, ok := v.series[key]
+	if !ok {
+		s = &series{labels: labels}
+		v.series[key] = s
+	}
+	s.value += delta
+}
+
+func (v *vec) set(value float64, labels ...string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	key := strings.Join(labels, "\xff")
+	s, ok := v.series[key]
+	if !ok {
+		s = &series{labels: labels}
+		v.series[key] = s
+	}
+	s.value = value
+}
+
+func (v *vec) write(w io.Writer) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	writeHeader(w, v.name, v.help, v.kind)
+	for _, key := range sortedKeys(v.series) {
+		s := v.series[key]
+		fmt.Fprintf(w, "%s%s %s\n", v.name, formatLabels(v.labelNames, s.labels), formatValue(s.value))
+	}
+}
+
+// histogram là histogram với bucket cố định, theo bộ nhãn
+type histogram struct {
+	name, help string
+	labelNames []string
+	buckets    []float64
+
+	mu     sync.Mutex
+	series map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	labels []string
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func newHistogram(name, help string, labelNames []string, buckets []float64) *histogram {
+	h := &histogram{name: name, help: help, labelNames: labelNames, buckets: buckets, series: make(map[string]*histogramSeries)}
+	registry = append(registry, h)
+	return h
+}
+
+func (h *histogram) observe(value float64, labels ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	key := strings.Join(labels, "\xff")
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{labels: labels, counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	for i, bound := range h.buckets {
+		if value <= bound {
+			s.counts[i]++
+		}
+	}
+	s.count++
+	s.sum += value
+}
+
+func (h *histogram) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	writeHeader(w, h.name, h.help, "histogram")
+	bucketLabels := append(append([]string(nil), h.labelNames...), "le")
+	for _, key := range sortedKeys(h.series) {
+		s := h.series[key]
+		for i, bound := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(bucketLabels, append(append([]string(nil), s.labels...), formatValue(bound))), s.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(bucketLabels, append(append([]string(nil), s.labels...), "+Inf")), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, formatLabels(h.labelNames, s.labels), formatValue(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, formatLabels(h.labelNames, s.labels), s.count)
+	}
+}
+
+// writeHeader ghi dòng HELP và TYPE của metric
+func writeHeader(w io.Writer, name, help, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, kind)
+}
+
+// formatLabels ghép nhãn dạng {a="x",b="y"}, rỗng nếu không có nhãn
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+
+	escape := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf(`%s="%s"`, name, escape.Replace(values[i]))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// formatValue định dạng giá trị theo quy ước của Prometheus
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// sortedKeys trả về các khóa của map theo thứ tự để output ổn định
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,115 @@
+package models
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+// Các loại hành động được ghi vào audit log
+const (
+	AuditActionLogin        = "login"
+	AuditActionLoginFailed  = "login_failed"
+	AuditActionLogout       = "logout"
+	AuditActionDump         = "dump"
+	AuditActionUpload       = "upload"
+	AuditActionDownload     = "download"
+	AuditActionRestore      = "restore"
+	AuditActionVerify       = "verify"
+	AuditActionDelete       = "delete"
+	AuditActionAnomalyClear = "anomaly_clear"
+	AuditActionNotifyTest   = "notify_test"
+	AuditActionGoogleLink   = "google_link"
+	AuditActionGoogleUnlink = "google_unlink"
+	AuditActionUserCreate   = "user_create"
+	AuditActionUserUpdate   = "user_update"
+	AuditActionUserDelete   = "user_delete"
+)
+
+// Kết quả của một hành động
+const (
+	AuditResultSuccess = "success"
+	AuditResultFailure = "failure"
+	AuditResultDenied  = "denied"
+	// AuditResultWarning: thao tác thành công nhưng kết quả bất thường (vd: bản backup nhỏ bất thường)
+	AuditResultWarning = "warning"
+)
+
+// AuditEvent đại diện cho một sự kiện trong audit log
+type AuditEvent struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Actor     string    `json:"actor"`
+	Action    string    `json:"action"`
+	Target    string    `json:"target"`
+	SourceIP  string    `json:"source_ip"`
+	UserAgent string    `json:"user_agent"`
+	Result    string    `json:"result"`
+	Details   string    `json:"details"`
+	PrevHash  string    `json:"prev_hash"`
+	Hash      string    `json:"hash"`
+}
+
+// AuditHashPrefix đánh dấu hash HMAC-SHA256 có khóa; hash không có tiền tố là SHA-256 không khóa
+// của các phiên bản cũ
+const AuditHashPrefix = "hmac:"
+
+// AuditChainStatus là kết quả kiểm tra chuỗi hash của audit log. Count và HeadHash có thể được
+// lưu ở nơi khác (ngoài database) để sau này phát hiện việc viết lại hoặc cắt bớt cả chuỗi.
+type AuditChainStatus struct {
+	Count int `json:"checked"`
+	// BrokenID là ID của bản ghi đầu tiên bị sai lệch, 0 nếu chuỗi còn nguyên vẹn
+	BrokenID int64  `json:"broken_id"`
+	HeadID   int64  `json:"head_id"`
+	HeadHash string `json:"head_hash"`
+}
+
+// ComputeHash tính HMAC-SHA256 (khóa key) của sự kiện, nối với hash của sự kiện liền trước để
+// tạo thành chuỗi hash; sửa hoặc xóa bất kỳ bản ghi nào sẽ làm đứt chuỗi và không tính lại được
+// nếu không có khóa. Mỗi trường được ghi kèm độ dài để ranh giới giữa các trường không bị dịch chuyển.
+func (e *AuditEvent) ComputeHash(key []byte, prevHash string) string {
+	mac := hmac.New(sha256.New, key)
+	for _, field := range e.hashFields(prevHash) {
+		var size [8]byte
+		binary.BigEndian.PutUint64(size[:], uint64(len(field)))
+		mac.Write(size[:])
+		mac.Write([]byte(field))
+	}
+	return AuditHashPrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// ComputeLegacyHash tính hash SHA-256 không khóa của các phiên bản cũ, chỉ dùng để kiểm tra các bản
+// ghi được tạo trước khi chuyển sang ComputeHash
+func (e *AuditEvent) ComputeLegacyHash(prevHash string) string {
+	sum := sha256.Sum256([]byte(strings.Join(e.hashFields(prevHash), "\x1f")))
+	return hex.EncodeToString(sum[:])
+}
+
+// hashFields trả về các trường của sự kiện được đưa vào hash
+func (e *AuditEvent) hashFields(prevHash string) []string {
+	return []string{
+		prevHash,
+		e.CreatedAt.UTC().Format(time.RFC3339Nano),
+		e.Actor,
+		e.Action,
+		e.Target,
+		e.SourceIP,
+		e.UserAgent,
+		e.Result,
+		e.Details,
+	}
+}
+
+// AuditFilter chứa các điều kiện lọc audit log
+type AuditFilter struct {
+	Actor  string
+	Action string
+	Target string
+	Result string
+	From   time.Time
+	To     time.Time
+	Limit  int
+}
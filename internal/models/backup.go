@@ -4,38 +4,60 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
+
+	"github.com/backup-cronjob/internal/i18n"
 )
 
+// backupPatterns là các mẫu tên file được coi là bản backup
+// (.sql: dump một database, .tar.gz: bản backup cluster nhiều database)
+var backupPatterns = []string{"*.sql", "*.tar.gz"}
+
+// dateLayout là định dạng tên thư mục ngày trong thư mục backup
+const dateLayout = "2006-01-02"
+
 // BackupFile đại diện cho một file backup
 type BackupFile struct {
-	ID        string
+	ID        string // Đường dẫn tương đối so với thư mục backup: <job>/<ngày>/<tên> hoặc <ngày>/<tên>
+	Job       string // Rỗng với các bản backup theo cấu trúc cũ (backups/<ngày>/)
 	Name      string
 	Path      string
 	Size      int64
 	CreatedAt time.Time
 	Uploaded  bool
+
+	// Kết quả kiểm tra khôi phục và đánh dấu bất thường lấy từ catalog
+	VerifyStatus  string
+	VerifyMessage string
+	Anomaly       string
 }
 
 // FormatSize trả về kích thước file đã được format
 func (b *BackupFile) FormatSize() string {
+	return FormatBytes(b.Size)
+}
+
+// FormatBytes định dạng dung lượng theo đơn vị B, KB, MB, GB
+func FormatBytes(n int64) string {
 	const (
 		KB = 1024
 		MB = 1024 * KB
 		GB = 1024 * MB
 	)
 
-	size := float64(b.Size)
+	size := float64(n)
 
 	switch {
-	case b.Size >= GB:
+	case n >= GB:
 		return fmt.Sprintf("%.2f GB", size/GB)
-	case b.Size >= MB:
+	case n >= MB:
 		return fmt.Sprintf("%.2f MB", size/MB)
-	case b.Size >= KB:
+	case n >= KB:
 		return fmt.Sprintf("%.2f KB", size/KB)
 	default:
-		return fmt.Sprintf("%d B", b.Size)
+		return fmt.Sprintf("%d B", n)
 	}
 }
 
@@ -44,59 +66,208 @@ func (b *BackupFile) FormatCreatedAt() string {
 	return b.CreatedAt.Format("02/01/2006 15:04:05")
 }
 
+// BackupDirFor trả về thư mục chứa backup của job trong ngày t: <backupDir>/<job>/<YYYY-MM-DD>
+func BackupDirFor(backupDir, job string, t time.Time) string {
+	return filepath.Join(backupDir, job, t.Format(dateLayout))
+}
+
+// IsBackupName cho biết tên file có phải là bản backup (không tính manifest, WAL...)
+func IsBackupName(name string) bool {
+	for _, pattern := range backupPatterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ManifestPath trả về đường dẫn file manifest đặt cạnh bản backup một database
+func ManifestPath(path string) string {
+	return path + ".manifest.json"
+}
+
+// ParseBackupLocation xác định job và thư mục ngày của một file backup từ đường dẫn.
+// Hỗ trợ cả cấu trúc mới (<job>/<ngày>/file) và cấu trúc cũ (<ngày>/file, job rỗng).
+func ParseBackupLocation(backupDir, path string) (job string, date string) {
+	rel, err := filepath.Rel(backupDir, path)
+	if err != nil {
+		return "", ""
+	}
+
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	switch len(parts) {
+	case 2:
+		return "", parts[0]
+	case 3:
+		return parts[0], parts[1]
+	default:
+		return "", ""
+	}
+}
+
+// isDateDir kiểm tra tên thư mục có phải dạng ngày YYYY-MM-DD không
+func isDateDir(name string) bool {
+	_, err := time.Parse(dateLayout, name)
+	return err == nil
+}
+
 // GetAllBackups lấy tất cả các file backup từ thư mục
 func GetAllBackups(backupDir string) ([]*BackupFile, error) {
 	var backups []*BackupFile
 
-	// Duyệt qua tất cả thư mục con (thư mục ngày)
-	dateDirs, err := os.ReadDir(backupDir)
+	entries, err := os.ReadDir(backupDir)
 	if err != nil {
-		return nil, fmt.Errorf("không thể đọc thư mục backup: %v", err)
+		return nil, i18n.Errorf(i18n.ErrBackupDirRead, err)
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		// Cấu trúc cũ: backups/<ngày>/file
+		if isDateDir(entry.Name()) {
+			backups = append(backups, readDateDir(backupDir, filepath.Join(backupDir, entry.Name()), "")...)
+			continue
+		}
+
+		// Cấu trúc mới: backups/<job>/<ngày>/file
+		jobBackups, err := GetJobBackups(backupDir, entry.Name())
+		if err != nil {
+			continue
+		}
+		backups = append(backups, jobBackups...)
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+
+	return backups, nil
+}
+
+// GetJobBackups lấy các file backup của một job, sắp xếp mới nhất trước
+func GetJobBackups(backupDir, job string) ([]*BackupFile, error) {
+	jobDir := filepath.Join(backupDir, job)
+	dateDirs, err := os.ReadDir(jobDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, i18n.Errorf(i18n.ErrJobBackupDirRead, job, err)
 	}
 
+	var backups []*BackupFile
 	for _, dateDir := range dateDirs {
-		if dateDir.IsDir() {
-			dateDirPath := filepath.Join(backupDir, dateDir.Name())
-
-			// Đọc tất cả file SQL trong thư mục ngày
-			files, err := filepath.Glob(filepath.Join(dateDirPath, "*.sql"))
-			if err != nil {
-				continue
-			}
-
-			// Thêm mỗi file vào danh sách
-			for _, file := range files {
-				fileInfo, err := os.Stat(file)
-				if err != nil {
-					continue
-				}
-
-				backup := &BackupFile{
-					ID:        filepath.Base(file),
-					Name:      filepath.Base(file),
-					Path:      file,
-					Size:      fileInfo.Size(),
-					CreatedAt: fileInfo.ModTime(),
-					Uploaded:  false, // Sẽ được cập nhật sau
-				}
-
-				backups = append(backups, backup)
-			}
+		if dateDir.IsDir() && isDateDir(dateDir.Name()) {
+			backups = append(backups, readDateDir(backupDir, filepath.Join(jobDir, dateDir.Name()), job)...)
 		}
 	}
 
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+
 	return backups, nil
 }
 
-// FindLatestBackup tìm file backup mới nhất
-func FindLatestBackup(backupDir string) (*BackupFile, error) {
+// readDateDir đọc tất cả file backup trong một thư mục ngày
+func readDateDir(backupDir, dateDirPath, job string) []*BackupFile {
+	var backups []*BackupFile
+
+	// Đọc tất cả file backup trong thư mục ngày
+	var files []string
+	for _, pattern := range backupPatterns {
+		matches, err := filepath.Glob(filepath.Join(dateDirPath, pattern))
+		if err != nil {
+			continue
+		}
+		files = append(files, matches...)
+	}
+
+	// Thêm mỗi file vào danh sách
+	for _, file := range files {
+		fileInfo, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+
+		rel, err := filepath.Rel(backupDir, file)
+		if err != nil {
+			continue
+		}
+
+		backups = append(backups, &BackupFile{
+			ID:        filepath.ToSlash(rel),
+			Job:       job,
+			Name:      filepath.Base(file),
+			Path:      file,
+			Size:      fileInfo.Size(),
+			CreatedAt: fileInfo.ModTime(),
+			Uploaded:  false, // Sẽ được cập nhật sau
+		})
+	}
+
+	return backups
+}
+
+// FindBackupByID tìm file backup theo ID (đường dẫn tương đối, xem BackupFile.ID)
+func FindBackupByID(backupDir, id string) (*BackupFile, error) {
+	if !validBackupID(id) {
+		return nil, i18n.Errorf(i18n.ErrBackupNotFound, id)
+	}
+
 	backups, err := GetAllBackups(backupDir)
 	if err != nil {
 		return nil, err
 	}
 
+	for _, backup := range backups {
+		if backup.ID == id {
+			return backup, nil
+		}
+	}
+
+	return nil, i18n.Errorf(i18n.ErrBackupNotFound, id)
+}
+
+// validBackupID kiểm tra ID có dạng <job>/<ngày>/<tên> hoặc <ngày>/<tên>, không chứa thành phần
+// như ".." hay đường dẫn tuyệt đối có thể trỏ ra ngoài thư mục backup
+func validBackupID(id string) bool {
+	if strings.ContainsAny(id, "\\\x00") {
+		return false
+	}
+
+	parts := strings.Split(id, "/")
+	if len(parts) != 2 && len(parts) != 3 {
+		return false
+	}
+	for _, part := range parts {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+
+	return isDateDir(parts[len(parts)-2]) && IsBackupName(parts[len(parts)-1])
+}
+
+// FindLatestBackup tìm file backup mới nhất, chỉ trong job nếu job khác rỗng
+func FindLatestBackup(backupDir, job string) (*BackupFile, error) {
+	var (
+		backups []*BackupFile
+		err     error
+	)
+	if job == "" {
+		backups, err = GetAllBackups(backupDir)
+	} else {
+		backups, err = GetJobBackups(backupDir, job)
+	}
+	if err != nil {
+		return nil, err
+	}
+
 	if len(backups) == 0 {
-		return nil, fmt.Errorf("không tìm thấy file backup nào")
+		return nil, i18n.Errorf(i18n.ErrNoBackups)
 	}
 
 	// Tìm file mới nhất
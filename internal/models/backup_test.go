@@ -0,0 +1,38 @@
+package models
+
+import "testing"
+
+func TestValidBackupID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{"shop/2025-01-02/shop_20250102_030000_data.sql", true},
+		{"2025-01-02/db_20250102_030000_data.sql", true},
+		{"shop/2025-01-02/base_20250102_030000.tar.gz", true},
+
+		{"", false},
+		{"shop_20250102_030000_data.sql", false},
+		{"shop/not-a-date/shop_20250102_030000_data.sql", false},
+		{"shop/2025-01-02/notes.txt", false},
+		{"a/shop/2025-01-02/shop_20250102_030000_data.sql", false},
+		{"shop/2025-01-02/", false},
+		{"shop//2025-01-02/x.sql", false},
+
+		// Các ID trỏ ra ngoài thư mục backup
+		{"../2025-01-02/x.sql", false},
+		{"../../etc/2025-01-02/passwd.sql", false},
+		{"./2025-01-02/x.sql", false},
+		{"/2025-01-02/x.sql", false},
+		{"shop/../2025-01-02/x.sql", false},
+		{"shop/2025-01-02/..", false},
+		{"shop\\..\\2025-01-02\\x.sql", false},
+		{"shop/2025-01-02/x.sql\x00", false},
+	}
+
+	for _, tt := range tests {
+		if got := validBackupID(tt.id); got != tt.want {
+			t.Errorf("validBackupID(%q) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
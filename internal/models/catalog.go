@@ -0,0 +1,150 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// Trạng thái kiểm tra khôi phục của một bản backup
+const (
+	VerifyStatusNone     = ""
+	VerifyStatusVerified = "verified"
+	VerifyStatusFailed   = "failed"
+)
+
+// BackupRecord là thông tin của một bản backup trong catalog (SQLite)
+type BackupRecord struct {
+	ID            int64     `json:"id"`
+	Job           string    `json:"job"`
+	Path          string    `json:"path"`
+	Size          int64     `json:"size"`
+	CreatedAt     time.Time `json:"created_at"`
+	ServerVersion string    `json:"server_version,omitempty"`
+	// Kind là loại bản backup (BackupKindLogical, BackupKindBase)
+	Kind string `json:"kind"`
+	// WALStart là segment WAL đầu tiên cần để khôi phục từ bản sao lưu vật lý
+	WALStart     string `json:"wal_start,omitempty"`
+	DatabaseSize int64  `json:"database_size"`
+	// DurationMs là thời gian dump (mili giây)
+	DurationMs int64 `json:"duration_ms"`
+	// Anomaly là lý do bản backup bị đánh dấu bất thường, rỗng nếu bình thường.
+	// Bản backup bị đánh dấu không bao giờ bị xóa tự động theo chính sách lưu giữ.
+	Anomaly       string     `json:"anomaly,omitempty"`
+	VerifyStatus  string     `json:"verify_status,omitempty"`
+	VerifyMessage string     `json:"verify_message,omitempty"`
+	VerifiedAt    *time.Time `json:"verified_at,omitempty"`
+	// UploadedAt là thời điểm bản backup được upload lên đích từ xa, nil nếu chưa upload
+	UploadedAt *time.Time `json:"uploaded_at,omitempty"`
+}
+
+// FormatDuration trả về thời gian dump đã được format
+func (r *BackupRecord) FormatDuration() string {
+	return (time.Duration(r.DurationMs) * time.Millisecond).Round(time.Second).String()
+}
+
+// DatabaseStats là thông tin của một database nguồn tại thời điểm dump
+type DatabaseStats struct {
+	Name       string      `json:"name"`
+	SizeBytes  int64       `json:"size_bytes"`
+	Extensions []string    `json:"extensions,omitempty"`
+	Tables     []TableStat `json:"tables,omitempty"`
+}
+
+// FormatSize trả về dung lượng database đã được format
+func (d *DatabaseStats) FormatSize() string {
+	return FormatBytes(d.SizeBytes)
+}
+
+// TableStat là thông tin của một bảng trong database nguồn tại thời điểm dump
+type TableStat struct {
+	Database string `json:"database,omitempty"`
+	Schema   string `json:"schema"`
+	Table    string `json:"table"`
+	// RowCount là số dòng đếm chính xác (chỉ khi job bật verify), -1 nếu không đếm
+	RowCount    int64 `json:"row_count"`
+	RowEstimate int64 `json:"row_estimate"`
+	TotalBytes  int64 `json:"total_bytes"`
+}
+
+// QualifiedName trả về tên đầy đủ schema.table
+func (t *TableStat) QualifiedName() string {
+	return t.Schema + "." + t.Table
+}
+
+// FormatSize trả về dung lượng bảng (gồm index và TOAST) đã được format
+func (t *TableStat) FormatSize() string {
+	return FormatBytes(t.TotalBytes)
+}
+
+// Rows trả về số dòng chính xác nếu có, nếu không thì số dòng ước lượng
+func (t *TableStat) Rows() int64 {
+	if t.RowCount >= 0 {
+		return t.RowCount
+	}
+	return t.RowEstimate
+}
+
+// TableDiff là chênh lệch của một bảng giữa hai bản backup
+type TableDiff struct {
+	Database string  `json:"database,omitempty"`
+	Table    string  `json:"table"`
+	FromRows int64   `json:"from_rows"`
+	ToRows   int64   `json:"to_rows"`
+	Change   float64 `json:"change_percent"`
+	Missing  bool    `json:"missing,omitempty"`
+	Added    bool    `json:"added,omitempty"`
+	// Flagged đánh dấu bảng bị giảm số dòng vượt ngưỡng hoặc biến mất
+	Flagged bool `json:"flagged"`
+}
+
+// DiffTables so sánh số dòng các bảng giữa bản backup cũ (from) và mới (to).
+// Bảng giảm từ dropThreshold phần trăm trở lên hoặc biến mất được đánh dấu Flagged.
+// Kết quả sắp xếp: bảng bị đánh dấu trước, sau đó theo mức giảm.
+func DiffTables(from, to []TableStat, dropThreshold float64) []TableDiff {
+	key := func(t *TableStat) string { return t.Database + "\x00" + t.QualifiedName() }
+
+	toIndex := make(map[string]*TableStat, len(to))
+	for i := range to {
+		toIndex[key(&to[i])] = &to[i]
+	}
+
+	var diffs []TableDiff
+	seen := make(map[string]bool)
+	for i := range from {
+		f := &from[i]
+		seen[key(f)] = true
+
+		diff := TableDiff{Database: f.Database, Table: f.QualifiedName(), FromRows: f.Rows()}
+		t, ok := toIndex[key(f)]
+		if !ok {
+			diff.Missing = true
+			diff.Change = -100
+			diff.Flagged = true
+			diffs = append(diffs, diff)
+			continue
+		}
+
+		diff.ToRows = t.Rows()
+		if diff.FromRows > 0 {
+			diff.Change = float64(diff.ToRows-diff.FromRows) * 100 / float64(diff.FromRows)
+		}
+		diff.Flagged = diff.Change <= -dropThreshold
+		diffs = append(diffs, diff)
+	}
+
+	for i := range to {
+		t := &to[i]
+		if !seen[key(t)] {
+			diffs = append(diffs, TableDiff{Database: t.Database, Table: t.QualifiedName(), ToRows: t.Rows(), Added: true})
+		}
+	}
+
+	sort.SliceStable(diffs, func(i, j int) bool {
+		if diffs[i].Flagged != diffs[j].Flagged {
+			return diffs[i].Flagged
+		}
+		return diffs[i].Change < diffs[j].Change
+	})
+
+	return diffs
+}
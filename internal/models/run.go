@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Trạng thái của một lần chạy job
+const (
+	RunStatusSuccess = "success"
+	RunStatusFailure = "failure"
+)
+
+// RunRecord là bản ghi một lần chạy job kèm log của lần chạy
+type RunRecord struct {
+	// ID là run ID (cũng được gửi kèm ping heartbeat và ghi vào mọi dòng log của lần chạy)
+	ID         string    `json:"id"`
+	Job        string    `json:"job"`
+	Status     string    `json:"status"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	// BackupPath là file backup tạo ra (rỗng nếu dump thất bại)
+	BackupPath string `json:"backup_path,omitempty"`
+	Error      string `json:"error,omitempty"`
+	// Log là các dòng log của lần chạy, gồm cả stderr của pg_dump; không trả về trong danh sách
+	Log string `json:"log,omitempty"`
+}
+
+// FormatStartedAt định dạng thời điểm bắt đầu để hiển thị
+func (r *RunRecord) FormatStartedAt() string {
+	return r.StartedAt.Format("02/01/2006 15:04:05")
+}
+
+// FormatDuration định dạng thời gian chạy để hiển thị
+func (r *RunRecord) FormatDuration() string {
+	return r.FinishedAt.Sub(r.StartedAt).Round(time.Second).String()
+}
@@ -6,11 +6,20 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// Các vai trò người dùng
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+)
+
 // User đại diện cho một người dùng trong hệ thống
 type User struct {
-	ID        int64     `json:"id"`
-	Username  string    `json:"username"`
-	Password  string    `json:"-"` // Không trả về password trong JSON
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	Password string `json:"-"` // Không trả về password trong JSON
+	Role     string `json:"role"`
+	// Locale là ngôn ngữ người dùng chọn (rỗng = theo trình duyệt hoặc LOCALE)
+	Locale    string    `json:"locale"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -37,4 +46,11 @@ type Auth struct {
 type JWTClaims struct {
 	Username string `json:"username"`
 	UserID   int64  `json:"user_id"`
+	Role     string `json:"role"`
+	Locale   string `json:"locale"`
+}
+
+// IsAdmin kiểm tra người dùng có quyền quản trị không
+func (c *JWTClaims) IsAdmin() bool {
+	return c.Role == RoleAdmin
 }
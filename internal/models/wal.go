@@ -0,0 +1,122 @@
+package models
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Loại bản backup trong catalog
+const (
+	// BackupKindLogical là bản dump logic (pg_dump/pg_dumpall)
+	BackupKindLogical = "logical"
+	// BackupKindBase là bản sao lưu vật lý (pg_basebackup) dùng làm điểm bắt đầu khôi phục theo thời điểm
+	BackupKindBase = "base"
+)
+
+// WALDirName là tên thư mục chứa segment WAL trong thư mục backup của job (và trong folder của job trên Drive)
+const WALDirName = "wal"
+
+// WALSegment là một segment WAL đã được lưu trữ
+type WALSegment struct {
+	Job  string `json:"job"`
+	Name string `json:"name"`
+	// Size là kích thước segment chưa nén
+	Size       int64     `json:"size"`
+	ArchivedAt time.Time `json:"archived_at"`
+	Uploaded   bool      `json:"uploaded"`
+}
+
+// WALRange là khoảng WAL liên tục có sẵn kể từ một bản sao lưu vật lý
+type WALRange struct {
+	Start string `json:"start"`
+	End   string `json:"end,omitempty"`
+	// Segments là số segment liên tục từ Start tới End
+	Segments int `json:"segments"`
+	// RestorableUntil là thời điểm lưu trữ segment cuối cùng, mốc muộn nhất có thể khôi phục
+	RestorableUntil *time.Time `json:"restorable_until,omitempty"`
+}
+
+// WALDir trả về thư mục chứa segment WAL của job: <backupDir>/<job>/wal
+func WALDir(backupDir, job string) string {
+	return filepath.Join(backupDir, job, WALDirName)
+}
+
+// WALPath trả về đường dẫn file nén của segment WAL
+func WALPath(backupDir, job, name string) string {
+	return filepath.Join(WALDir(backupDir, job), name+".gz")
+}
+
+// IsWALSegment kiểm tra tên file có phải segment WAL (24 ký tự hex) không
+func IsWALSegment(name string) bool {
+	if len(name) != 24 {
+		return false
+	}
+	_, _, _, ok := parseWALName(name)
+	return ok
+}
+
+// parseWALName tách tên segment thành timeline, log và số segment
+func parseWALName(name string) (timeline, log, seg uint64, ok bool) {
+	if len(name) != 24 {
+		return 0, 0, 0, false
+	}
+	var err error
+	if timeline, err = strconv.ParseUint(name[0:8], 16, 32); err != nil {
+		return 0, 0, 0, false
+	}
+	if log, err = strconv.ParseUint(name[8:16], 16, 32); err != nil {
+		return 0, 0, 0, false
+	}
+	if seg, err = strconv.ParseUint(name[16:24], 16, 32); err != nil {
+		return 0, 0, 0, false
+	}
+	return timeline, log, seg, true
+}
+
+// nextWALName trả về tên segment kế tiếp trên cùng timeline. segmentSize là kích thước
+// segment (wal_segment_size), quyết định số segment trong mỗi log.
+func nextWALName(name string, segmentSize int64) string {
+	timeline, log, seg, ok := parseWALName(name)
+	if !ok || segmentSize <= 0 {
+		return ""
+	}
+
+	seg++
+	if seg >= uint64(0x100000000/segmentSize) {
+		log++
+		seg = 0
+	}
+	return formatWALName(timeline, log, seg)
+}
+
+// formatWALName ghép tên segment từ timeline, log và số segment
+func formatWALName(timeline, log, seg uint64) string {
+	return fmt.Sprintf("%08X%08X%08X", timeline, log, seg)
+}
+
+// ContiguousWAL tính khoảng WAL liên tục bắt đầu từ segment start trong các segment đã lưu trữ
+// (segments phải được sắp xếp theo tên). Khoảng dừng ở segment đầu tiên bị thiếu.
+func ContiguousWAL(start string, segments []*WALSegment) WALRange {
+	r := WALRange{Start: start}
+
+	byName := make(map[string]*WALSegment, len(segments))
+	for _, segment := range segments {
+		byName[segment.Name] = segment
+	}
+
+	for name := start; name != ""; {
+		segment, ok := byName[name]
+		if !ok {
+			break
+		}
+		r.End = segment.Name
+		r.Segments++
+		archivedAt := segment.ArchivedAt
+		r.RestorableUntil = &archivedAt
+		name = nextWALName(name, segment.Size)
+	}
+
+	return r
+}
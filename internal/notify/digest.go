@@ -0,0 +1,130 @@
+package notify
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/backup-cronjob/internal/config"
+	"github.com/backup-cronjob/internal/cron"
+	"github.com/backup-cronjob/internal/database"
+	"github.com/backup-cronjob/internal/i18n"
+	"github.com/backup-cronjob/internal/logging"
+	"github.com/backup-cronjob/internal/models"
+)
+
+// Digest tạo báo cáo tổng hợp các lần backup của mọi job từ since tới nay:
+// số bản backup, dung lượng, số lần dump/upload thất bại và bản backup bất thường
+func Digest(cfg *config.Config, since time.Time) (*Event, error) {
+	records, err := database.ListBackupRecords()
+	if err != nil {
+		return nil, i18n.Errorf(i18n.ErrCatalogRead, err)
+	}
+
+	failures := make(map[string]int)
+	for _, action := range []string{models.AuditActionDump, models.AuditActionUpload} {
+		events, err := database.ListAuditEvents(models.AuditFilter{Action: action, Result: models.AuditResultFailure, From: since})
+		if err != nil {
+			return nil, i18n.Errorf(i18n.ErrAuditRead, err)
+		}
+		for _, event := range events {
+			failures[strings.SplitN(event.Target, "/", 2)[0]]++
+		}
+	}
+
+	var (
+		lines        []string
+		totalBackups int
+		totalFailed  int
+		totalAnomaly int
+	)
+	for _, job := range cfg.Jobs() {
+		var (
+			count, anomalies int
+			size             int64
+			latest           time.Time
+		)
+		for _, record := range records {
+			if record.Job != job.Name {
+				continue
+			}
+			if record.CreatedAt.After(latest) {
+				latest = record.CreatedAt
+			}
+			if record.CreatedAt.Before(since) {
+				continue
+			}
+			count++
+			size += record.Size
+			if record.Anomaly != "" {
+				anomalies++
+			}
+		}
+
+		status := "OK"
+		switch {
+		case failures[job.Name] > 0:
+			status = i18n.T("", "notify.digest_failed")
+		case anomalies > 0:
+			status = i18n.T("", "notify.digest_anomaly")
+		case count == 0:
+			status = i18n.T("", "notify.digest_empty")
+		}
+
+		line := i18n.T("", "notify.digest_line", status, job.Name, count, models.FormatBytes(size), failures[job.Name], anomalies)
+		if !latest.IsZero() {
+			line += i18n.T("", "notify.digest_latest", latest.Local().Format("2006-01-02 15:04"))
+		}
+		lines = append(lines, line)
+
+		totalBackups += count
+		totalFailed += failures[job.Name]
+		totalAnomaly += anomalies
+	}
+
+	return &Event{
+		Type: config.NotifyDigest,
+		Title: i18n.T("", "notify.digest_title",
+			since.Local().Format("2006-01-02 15:04"), totalBackups, totalFailed, totalAnomaly),
+		Text: strings.Join(lines, "\n"),
+		Time: time.Now(),
+	}, nil
+}
+
+// StartDigest gửi báo cáo tổng hợp theo lịch DigestSchedule tới các kênh có quy tắc digest,
+// cho tới khi ctx bị hủy. Mỗi báo cáo tính từ lần gửi trước (lần đầu: 24 giờ trước).
+func (d *Dispatcher) StartDigest(ctx context.Context, cfg *config.Config) {
+	if len(d.route(config.NotifyDigest, "")) == 0 {
+		return
+	}
+
+	sched, err := cron.Parse(d.Config.DigestSchedule)
+	if err != nil {
+		slog.Error("Invalid digest_schedule, digest notifications disabled", "schedule", d.Config.DigestSchedule, logging.Err(err))
+		return
+	}
+
+	go func() {
+		since := time.Now().Add(-24 * time.Hour)
+		for {
+			next := sched.Next(time.Now())
+			if next.IsZero() {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Until(next)):
+			}
+
+			event, err := Digest(cfg, since)
+			if err != nil {
+				slog.Error("Failed to build notification digest", logging.Err(err))
+				continue
+			}
+			since = next
+			d.Notify(ctx, event)
+		}
+	}()
+}
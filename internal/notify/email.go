@@ -0,0 +1,103 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/backup-cronjob/internal/config"
+)
+
+// emailNotifier gửi thông báo qua SMTP
+type emailNotifier struct {
+	channel *config.NotifyChannel
+}
+
+func (n *emailNotifier) Notify(ctx context.Context, event *Event) error {
+	ch := n.channel
+	addr := net.JoinHostPort(ch.SMTPHost, strconv.Itoa(ch.SMTPPort))
+
+	dialer := &net.Dialer{}
+	var (
+		conn net.Conn
+		err  error
+	)
+	if ch.SMTPTLS == "tls" {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: ch.SMTPHost}}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// net/smtp không nhận context, giới hạn toàn bộ phiên bằng deadline của kết nối
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, ch.SMTPHost)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if ch.SMTPTLS == "starttls" {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return &permanentError{fmt.Errorf("SMTP server %s does not support STARTTLS", ch.SMTPHost)}
+		}
+		if err := client.StartTLS(&tls.Config{ServerName: ch.SMTPHost}); err != nil {
+			return err
+		}
+	}
+
+	if ch.SMTPUsername != "" {
+		if err := client.Auth(smtp.PlainAuth("", ch.SMTPUsername, ch.SMTPPassword, ch.SMTPHost)); err != nil {
+			return &permanentError{err}
+		}
+	}
+
+	if err := client.Mail(ch.From); err != nil {
+		return err
+	}
+	for _, to := range ch.To {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(buildEmail(ch.From, ch.To, event)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// buildEmail tạo nội dung email (text/plain, UTF-8)
+func buildEmail(from string, to []string, event *Event) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + strings.Join(to, ", ") + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", event.Title) + "\r\n")
+	b.WriteString("Date: " + event.Time.Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(event.Text, "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
@@ -0,0 +1,219 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/backup-cronjob/internal/config"
+)
+
+// fakeSMTP là server SMTP giả ghi lại phiên gửi thư
+type fakeSMTP struct {
+	// rejectAuth trả về 535 cho lệnh AUTH
+	rejectAuth bool
+
+	mu       sync.Mutex
+	auth     string
+	from     string
+	rcpts    []string
+	messages []string
+}
+
+// newFakeSMTP khởi động server SMTP giả và trả về kênh email trỏ tới nó
+func newFakeSMTP(t *testing.T) (*fakeSMTP, *config.NotifyChannel) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	fake := &fakeSMTP{}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go fake.serve(conn)
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	return fake, &config.NotifyChannel{
+		Type:     config.ChannelEmail,
+		SMTPHost: "127.0.0.1",
+		SMTPPort: addr.Port,
+		From:     "backup@example.com",
+		To:       []string{"ops@example.com", "dba@example.com"},
+	}
+}
+
+func (f *fakeSMTP) serve(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 fake ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		f.mu.Lock()
+		switch verb {
+		case "EHLO", "HELO":
+			reply("250-fake")
+			reply("250 AUTH PLAIN")
+		case "AUTH":
+			f.auth = line
+			if f.rejectAuth {
+				reply("535 authentication failed")
+			} else {
+				reply("235 ok")
+			}
+		case "MAIL":
+			f.from = line
+			reply("250 ok")
+		case "RCPT":
+			f.rcpts = append(f.rcpts, line)
+			reply("250 ok")
+		case "DATA":
+			reply("354 go ahead")
+			var msg strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					f.mu.Unlock()
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				msg.WriteString(l)
+			}
+			f.messages = append(f.messages, msg.String())
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			f.mu.Unlock()
+			return
+		default:
+			reply("502 not implemented")
+		}
+		f.mu.Unlock()
+	}
+}
+
+func TestEmailNotifier(t *testing.T) {
+	fake, channel := newFakeSMTP(t)
+	notifier, err := NewNotifier(channel)
+	if err != nil {
+		t.Fatalf("NewNotifier: %v", err)
+	}
+
+	event := testEvent()
+	event.Title = "[backup] Job shop thất bại"
+	event.Text = "line 1\nline 2"
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := notifier.Notify(ctx, event); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+
+	if fake.auth != "" {
+		t.Errorf("unexpected AUTH without username: %q", fake.auth)
+	}
+	if !strings.HasPrefix(fake.from, "MAIL FROM:<backup@example.com>") {
+		t.Errorf("MAIL = %q", fake.from)
+	}
+	if len(fake.rcpts) != 2 || !strings.Contains(fake.rcpts[0], "<ops@example.com>") || !strings.Contains(fake.rcpts[1], "<dba@example.com>") {
+		t.Errorf("RCPT = %v", fake.rcpts)
+	}
+	if len(fake.messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(fake.messages))
+	}
+
+	msg := fake.messages[0]
+	for _, want := range []string{
+		"To: ops@example.com, dba@example.com\r\n",
+		"Subject: =?utf-8?q?",
+		"Content-Type: text/plain; charset=utf-8\r\n",
+		"\r\n\r\nline 1\r\nline 2\r\n",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message missing %q:\n%s", want, msg)
+		}
+	}
+}
+
+func TestEmailNotifierAuth(t *testing.T) {
+	fake, channel := newFakeSMTP(t)
+	channel.SMTPUsername = "mailer"
+	channel.SMTPPassword = "pw"
+
+	notifier := &emailNotifier{channel: channel}
+	if err := notifier.Notify(context.Background(), testEvent()); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if !strings.HasPrefix(fake.auth, "AUTH PLAIN ") {
+		t.Errorf("AUTH = %q", fake.auth)
+	}
+}
+
+func TestEmailNotifierAuthRejectedIsPermanent(t *testing.T) {
+	fake, channel := newFakeSMTP(t)
+	fake.rejectAuth = true
+	channel.SMTPUsername = "mailer"
+	channel.SMTPPassword = "wrong"
+
+	err := (&emailNotifier{channel: channel}).Notify(context.Background(), testEvent())
+	if _, ok := err.(*permanentError); !ok {
+		t.Fatalf("err = %v, want permanentError", err)
+	}
+}
+
+func TestEmailNotifierStartTLSUnsupported(t *testing.T) {
+	_, channel := newFakeSMTP(t)
+	channel.SMTPTLS = "starttls"
+
+	err := (&emailNotifier{channel: channel}).Notify(context.Background(), testEvent())
+	if _, ok := err.(*permanentError); !ok {
+		t.Fatalf("err = %v, want permanentError", err)
+	}
+}
+
+func TestEmailNotifierUnreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	channel := &config.NotifyChannel{SMTPHost: "127.0.0.1", SMTPPort: port, From: "a@example.com", To: []string{"b@example.com"}}
+	err = (&emailNotifier{channel: channel}).Notify(context.Background(), testEvent())
+	if err == nil {
+		t.Fatal("expected error for closed port")
+	}
+	if _, ok := err.(*permanentError); ok {
+		t.Errorf("connection errors should be retried, got permanentError: %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/backup-cronjob/internal/config"
+)
+
+// maxResponse giới hạn số byte phản hồi lỗi được giữ lại
+const maxResponse = 512
+
+// Header của webhook chung
+const (
+	HeaderEvent     = "X-Backup-Event"
+	HeaderTimestamp = "X-Backup-Timestamp"
+	// HeaderSignature là chữ ký "sha256=<hex>" của HMAC-SHA256(secret, timestamp + "." + body)
+	HeaderSignature = "X-Backup-Signature"
+)
+
+// webhookNotifier gửi sự kiện dạng JSON tới URL bất kỳ, ký bằng HMAC nếu có secret
+type webhookNotifier struct {
+	channel *config.NotifyChannel
+}
+
+func (n *webhookNotifier) Notify(ctx context.Context, event *Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(event.Time.Unix(), 10)
+	headers := map[string]string{
+		HeaderEvent:     event.Type,
+		HeaderTimestamp: timestamp,
+	}
+	if n.channel.Secret != "" {
+		headers[HeaderSignature] = "sha256=" + Sign(n.channel.Secret, timestamp, body)
+	}
+	return postJSON(ctx, n.channel.URL, body, headers)
+}
+
+// Sign tính chữ ký HMAC-SHA256 (hex) của webhook; bên nhận tính lại để xác thực
+// và nên từ chối timestamp quá cũ để tránh gửi lại
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// chatNotifier gửi tin nhắn tới incoming webhook của Slack ({"text": ...}) hoặc Discord ({"content": ...})
+type chatNotifier struct {
+	channel *config.NotifyChannel
+	field   string
+	limit   int
+}
+
+func (n *chatNotifier) Notify(ctx context.Context, event *Event) error {
+	body, err := json.Marshal(map[string]string{n.field: truncateText(event.Message(), n.limit)})
+	if err != nil {
+		return err
+	}
+	return postJSON(ctx, n.channel.URL, body, nil)
+}
+
+// telegramNotifier gửi tin nhắn qua Telegram Bot API (sendMessage)
+type telegramNotifier struct {
+	channel *config.NotifyChannel
+}
+
+func (n *telegramNotifier) Notify(ctx context.Context, event *Event) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"chat_id":                  n.channel.ChatID,
+		"text":                     truncateText(event.Message(), 4096),
+		"disable_web_page_preview": true,
+	})
+	if err != nil {
+		return err
+	}
+
+	endpoint := strings.TrimRight(n.channel.APIURL, "/") + "/bot" + url.PathEscape(n.channel.BotToken) + "/sendMessage"
+	return postJSON(ctx, endpoint, body, nil)
+}
+
+// postJSON gửi body JSON tới URL. Mã 4xx (trừ 408, 429) được coi là lỗi cấu hình, không gửi lại.
+func postJSON(ctx context.Context, target string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return &permanentError{err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		// Lỗi của client chứa URL (có thể có token), chỉ giữ nguyên nhân
+		if urlErr, ok := err.(*url.Error); ok {
+			return urlErr.Err
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponse))
+		return nil
+	}
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponse))
+	err = fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+		resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+		return &permanentError{err}
+	}
+	return err
+}
+
+// truncateText cắt s về tối đa limit ký tự
+func truncateText(s string, limit int) string {
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:limit-3]) + "..."
+}
@@ -0,0 +1,211 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/backup-cronjob/internal/config"
+)
+
+// capturedRequest là một request mà server giả nhận được
+type capturedRequest struct {
+	Path   string
+	Header http.Header
+	Body   []byte
+}
+
+// recorder là server HTTP giả ghi lại các request và trả về lần lượt các mã trong statuses
+// (hết danh sách thì trả 200)
+type recorder struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []capturedRequest
+}
+
+func newRecorder(t *testing.T, statuses ...int) (*recorder, *httptest.Server) {
+	t.Helper()
+
+	rec := &recorder{statuses: statuses}
+	server := httptest.NewServer(rec)
+	t.Cleanup(server.Close)
+	return rec, server
+}
+
+func (rec *recorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	rec.mu.Lock()
+	rec.requests = append(rec.requests, capturedRequest{Path: r.URL.Path, Header: r.Header.Clone(), Body: body})
+	status := http.StatusOK
+	if len(rec.statuses) > 0 {
+		status, rec.statuses = rec.statuses[0], rec.statuses[1:]
+	}
+	rec.mu.Unlock()
+
+	w.WriteHeader(status)
+	w.Write([]byte(http.StatusText(status)))
+}
+
+func (rec *recorder) count() int {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return len(rec.requests)
+}
+
+func testEvent() *Event {
+	return &Event{
+		Type:  config.NotifyFailure,
+		Job:   "shop",
+		Title: "[backup] Job shop failed",
+		Text:  "pg_dump exited with code 1",
+		Time:  time.Unix(1700000000, 0),
+	}
+}
+
+func TestWebhookSignature(t *testing.T) {
+	rec, server := newRecorder(t)
+	notifier := &webhookNotifier{channel: &config.NotifyChannel{URL: server.URL, Secret: "s3cret"}}
+
+	event := testEvent()
+	if err := notifier.Notify(context.Background(), event); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	req := rec.requests[0]
+	if got := req.Header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	if got := req.Header.Get(HeaderEvent); got != config.NotifyFailure {
+		t.Errorf("%s = %q", HeaderEvent, got)
+	}
+	timestamp := req.Header.Get(HeaderTimestamp)
+	if timestamp != strconv.FormatInt(event.Time.Unix(), 10) {
+		t.Errorf("%s = %q", HeaderTimestamp, timestamp)
+	}
+
+	// Bên nhận tính lại HMAC-SHA256(secret, timestamp + "." + body)
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write([]byte(timestamp + "." + string(req.Body)))
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if got := req.Header.Get(HeaderSignature); got != want {
+		t.Errorf("%s = %q, want %q", HeaderSignature, got, want)
+	}
+
+	var payload Event
+	if err := json.Unmarshal(req.Body, &payload); err != nil {
+		t.Fatalf("body: %v", err)
+	}
+	if payload.Type != event.Type || payload.Job != event.Job || payload.Title != event.Title {
+		t.Errorf("payload = %+v", payload)
+	}
+}
+
+func TestWebhookWithoutSecret(t *testing.T) {
+	rec, server := newRecorder(t)
+	notifier := &webhookNotifier{channel: &config.NotifyChannel{URL: server.URL}}
+
+	if err := notifier.Notify(context.Background(), testEvent()); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if got := rec.requests[0].Header.Get(HeaderSignature); got != "" {
+		t.Errorf("unexpected signature %q", got)
+	}
+}
+
+func TestPostJSONStatusClassification(t *testing.T) {
+	tests := []struct {
+		status    int
+		permanent bool
+	}{
+		{http.StatusBadRequest, true},
+		{http.StatusUnauthorized, true},
+		{http.StatusNotFound, true},
+		{http.StatusRequestTimeout, false},
+		{http.StatusTooManyRequests, false},
+		{http.StatusInternalServerError, false},
+		{http.StatusBadGateway, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.status), func(t *testing.T) {
+			_, server := newRecorder(t, tt.status)
+			err := postJSON(context.Background(), server.URL, []byte("{}"), nil)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			_, permanent := err.(*permanentError)
+			if permanent != tt.permanent {
+				t.Errorf("permanent = %v, want %v (err: %v)", permanent, tt.permanent, err)
+			}
+		})
+	}
+}
+
+func TestTelegram(t *testing.T) {
+	rec, server := newRecorder(t)
+	notifier := &telegramNotifier{channel: &config.NotifyChannel{APIURL: server.URL + "/", BotToken: "123:abc", ChatID: "-100"}}
+
+	event := testEvent()
+	event.Text = strings.Repeat("ư", 5000)
+	if err := notifier.Notify(context.Background(), event); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	req := rec.requests[0]
+	if req.Path != "/bot123:abc/sendMessage" {
+		t.Errorf("path = %q", req.Path)
+	}
+
+	var payload struct {
+		ChatID string `json:"chat_id"`
+		Text   string `json:"text"`
+	}
+	if err := json.Unmarshal(req.Body, &payload); err != nil {
+		t.Fatalf("body: %v", err)
+	}
+	if payload.ChatID != "-100" {
+		t.Errorf("chat_id = %q", payload.ChatID)
+	}
+	if n := utf8.RuneCountInString(payload.Text); n != 4096 || !strings.HasSuffix(payload.Text, "...") {
+		t.Errorf("text has %d runes, want 4096 ending with ...", n)
+	}
+}
+
+func TestChatNotifiers(t *testing.T) {
+	for _, channelType := range []string{config.ChannelSlack, config.ChannelDiscord} {
+		t.Run(channelType, func(t *testing.T) {
+			rec, server := newRecorder(t)
+			notifier, err := NewNotifier(&config.NotifyChannel{Type: channelType, URL: server.URL})
+			if err != nil {
+				t.Fatalf("NewNotifier: %v", err)
+			}
+			if err := notifier.Notify(context.Background(), testEvent()); err != nil {
+				t.Fatalf("Notify: %v", err)
+			}
+
+			var payload map[string]string
+			if err := json.Unmarshal(rec.requests[0].Body, &payload); err != nil {
+				t.Fatalf("body: %v", err)
+			}
+			field := "text"
+			if channelType == config.ChannelDiscord {
+				field = "content"
+			}
+			if payload[field] != testEvent().Message() {
+				t.Errorf("payload = %v", payload)
+			}
+		})
+	}
+}
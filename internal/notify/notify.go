@@ -0,0 +1,258 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/backup-cronjob/internal/config"
+	"github.com/backup-cronjob/internal/i18n"
+	"github.com/backup-cronjob/internal/logging"
+	"github.com/backup-cronjob/internal/models"
+)
+
+// EventTest là loại sự kiện của thông báo thử
+const EventTest = "test"
+
+// Event là nội dung một thông báo
+type Event struct {
+	// Type là loại sự kiện: failure, success, anomaly, digest hoặc test
+	Type     string    `json:"event"`
+	Job      string    `json:"job,omitempty"`
+	Title    string    `json:"title"`
+	Text     string    `json:"text"`
+	FilePath string    `json:"file_path,omitempty"`
+	FileSize int64     `json:"file_size,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	Anomaly  string    `json:"anomaly,omitempty"`
+	Time     time.Time `json:"time"`
+}
+
+// Message trả về nội dung dạng văn bản gồm tiêu đề và chi tiết
+func (e *Event) Message() string {
+	if e.Text == "" {
+		return e.Title
+	}
+	return e.Title + "\n" + e.Text
+}
+
+// Notifier gửi thông báo tới một kênh
+type Notifier interface {
+	Notify(ctx context.Context, event *Event) error
+}
+
+// permanentError là lỗi không nên gửi lại (vd: webhook trả về 4xx do cấu hình sai)
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// NewNotifier tạo Notifier theo loại kênh
+func NewNotifier(channel *config.NotifyChannel) (Notifier, error) {
+	switch channel.Type {
+	case config.ChannelWebhook:
+		return &webhookNotifier{channel: channel}, nil
+	case config.ChannelSlack:
+		return &chatNotifier{channel: channel, field: "text", limit: 40000}, nil
+	case config.ChannelDiscord:
+		return &chatNotifier{channel: channel, field: "content", limit: 2000}, nil
+	case config.ChannelTelegram:
+		return &telegramNotifier{channel: channel}, nil
+	case config.ChannelEmail:
+		return &emailNotifier{channel: channel}, nil
+	}
+	return nil, fmt.Errorf("unknown notification channel type %q", channel.Type)
+}
+
+// Dispatcher gửi sự kiện tới các kênh theo quy tắc trong cấu hình thông báo.
+// Lỗi gửi thông báo không bao giờ làm job backup thất bại.
+type Dispatcher struct {
+	Config *config.NotificationsConfig
+
+	channels  map[string]*config.NotifyChannel
+	notifiers map[string]Notifier
+}
+
+// New tạo Dispatcher từ cấu hình thông báo (nil = không có kênh nào)
+func New(cfg *config.NotificationsConfig) *Dispatcher {
+	if cfg == nil {
+		cfg = &config.NotificationsConfig{}
+	}
+
+	d := &Dispatcher{
+		Config:    cfg,
+		channels:  make(map[string]*config.NotifyChannel),
+		notifiers: make(map[string]Notifier),
+	}
+	for _, channel := range cfg.Channels {
+		notifier, err := NewNotifier(channel)
+		if err != nil {
+			slog.Error("Notification channel disabled", "channel", channel.Name, logging.Err(err))
+			continue
+		}
+		d.channels[channel.Name] = channel
+		d.notifiers[channel.Name] = notifier
+	}
+	return d
+}
+
+// Notify gửi sự kiện tới các kênh có quy tắc khớp (song song, mỗi kênh có retry riêng)
+// và chờ tới khi gửi xong. Lỗi được ghi log và gộp lại để caller có thể ghi nhận.
+func (d *Dispatcher) Notify(ctx context.Context, event *Event) error {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	names := d.route(event.Type, event.Job)
+	if len(names) == 0 {
+		return nil
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed []string
+	)
+	for _, name := range names {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			if err := d.send(ctx, name, event); err != nil {
+				logging.From(ctx).Error("Failed to send notification", "event", event.Type, "channel", name, logging.Err(err))
+				mu.Lock()
+				failed = append(failed, fmt.Sprintf("%s: %v", name, err))
+				mu.Unlock()
+			}
+		}(name)
+	}
+	wg.Wait()
+
+	if len(failed) > 0 {
+		return i18n.Errorf(i18n.ErrNotifySend, strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// Test gửi một thông báo thử tới kênh name (bỏ qua quy tắc)
+func (d *Dispatcher) Test(ctx context.Context, name string) error {
+	if _, ok := d.notifiers[name]; !ok {
+		return i18n.Errorf(i18n.ErrChannelNotFound, name)
+	}
+
+	return d.send(ctx, name, &Event{
+		Type:  EventTest,
+		Title: i18n.T("", "notify.test_title"),
+		Text:  i18n.T("", "notify.test_text", name),
+		Time:  time.Now(),
+	})
+}
+
+// Channels trả về các kênh thông báo đang hoạt động
+func (d *Dispatcher) Channels() []*config.NotifyChannel {
+	var channels []*config.NotifyChannel
+	for _, channel := range d.Config.Channels {
+		if _, ok := d.channels[channel.Name]; ok {
+			channels = append(channels, channel)
+		}
+	}
+	return channels
+}
+
+// route trả về tên các kênh (không trùng lặp) nhận sự kiện eventType của job
+func (d *Dispatcher) route(eventType, job string) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, rule := range d.Config.Rules {
+		if !rule.Matches(eventType, job) {
+			continue
+		}
+		for _, name := range rule.Channels {
+			if _, ok := d.notifiers[name]; ok && !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// send gửi sự kiện tới một kênh, thử lại tối đa Retries lần (cách nhau RetryDelay) khi lỗi tạm thời
+func (d *Dispatcher) send(ctx context.Context, name string, event *Event) error {
+	channel := d.channels[name]
+	notifier := d.notifiers[name]
+
+	var err error
+	for attempt := 0; attempt <= channel.Retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return i18n.Errorf(i18n.ErrNotifyCanceled, ctx.Err(), err)
+			case <-time.After(channel.RetryDelay):
+			}
+		}
+
+		attemptCtx, cancel := context.WithTimeout(ctx, channel.Timeout)
+		err = notifier.Notify(attemptCtx, event)
+		cancel()
+		if err == nil {
+			return nil
+		}
+
+		var permanent *permanentError
+		if errors.As(err, &permanent) {
+			return err
+		}
+	}
+	return i18n.Errorf(i18n.ErrRetriesExhausted, channel.Retries+1, err)
+}
+
+// Failure tạo sự kiện job thất bại
+func Failure(job, filePath string, cause error) *Event {
+	event := &Event{
+		Type:     config.NotifyFailure,
+		Job:      job,
+		Title:    i18n.T("", "notify.failure_title", job),
+		FilePath: filePath,
+		Time:     time.Now(),
+	}
+	if cause != nil {
+		event.Error = cause.Error()
+		event.Text = i18n.T("", "notify.failure_error", event.Error)
+	}
+	if filePath != "" {
+		event.Text += "\nFile: " + filePath
+	}
+	return event
+}
+
+// Success tạo sự kiện job chạy thành công
+func Success(job, filePath string, size int64, details ...string) *Event {
+	lines := append([]string{fmt.Sprintf("File: %s (%s)", filePath, models.FormatBytes(size))}, details...)
+	return &Event{
+		Type:     config.NotifySuccess,
+		Job:      job,
+		Title:    i18n.T("", "notify.success_title", job),
+		Text:     strings.Join(lines, "\n"),
+		FilePath: filePath,
+		FileSize: size,
+		Time:     time.Now(),
+	}
+}
+
+// Anomaly tạo sự kiện bản backup bị đánh dấu bất thường
+func Anomaly(job, filePath string, size int64, reason string) *Event {
+	return &Event{
+		Type:     config.NotifyAnomaly,
+		Job:      job,
+		Title:    i18n.T("", "notify.anomaly_title", job),
+		Text:     fmt.Sprintf("%s\nFile: %s (%s)", reason, filePath, models.FormatBytes(size)),
+		FilePath: filePath,
+		FileSize: size,
+		Anomaly:  reason,
+		Time:     time.Now(),
+	}
+}
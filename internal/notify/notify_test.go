@@ -0,0 +1,94 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/backup-cronjob/internal/config"
+)
+
+// newTestDispatcher tạo Dispatcher với một kênh webhook tới url nhận mọi sự kiện
+func newTestDispatcher(url string, retries int) *Dispatcher {
+	return New(&config.NotificationsConfig{
+		Channels: []*config.NotifyChannel{{
+			Name:       "hook",
+			Type:       config.ChannelWebhook,
+			URL:        url,
+			Timeout:    5 * time.Second,
+			Retries:    retries,
+			RetryDelay: time.Millisecond,
+		}},
+		Rules: []*config.NotifyRule{{
+			Events:   []string{config.NotifyFailure},
+			Channels: []string{"hook"},
+		}},
+	})
+}
+
+func TestDispatcherRetriesTemporaryErrors(t *testing.T) {
+	rec, server := newRecorder(t, http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusInternalServerError)
+	d := newTestDispatcher(server.URL, 3)
+
+	if err := d.Notify(context.Background(), testEvent()); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if got := rec.count(); got != 4 {
+		t.Errorf("got %d requests, want 4", got)
+	}
+}
+
+func TestDispatcherGivesUpAfterRetries(t *testing.T) {
+	rec, server := newRecorder(t, http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway)
+	d := newTestDispatcher(server.URL, 2)
+
+	if err := d.Notify(context.Background(), testEvent()); err == nil {
+		t.Fatal("expected error after exhausting retries")
+	}
+	if got := rec.count(); got != 3 {
+		t.Errorf("got %d requests, want 3", got)
+	}
+}
+
+func TestDispatcherDoesNotRetryClientErrors(t *testing.T) {
+	for _, status := range []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound} {
+		rec, server := newRecorder(t, status)
+		d := newTestDispatcher(server.URL, 3)
+
+		if err := d.Notify(context.Background(), testEvent()); err == nil {
+			t.Fatalf("status %d: expected error", status)
+		}
+		if got := rec.count(); got != 1 {
+			t.Errorf("status %d: got %d requests, want 1", status, got)
+		}
+	}
+}
+
+func TestDispatcherRoutesByEventAndJob(t *testing.T) {
+	rec, server := newRecorder(t)
+	d := New(&config.NotificationsConfig{
+		Channels: []*config.NotifyChannel{{Name: "hook", Type: config.ChannelWebhook, URL: server.URL, Timeout: 5 * time.Second}},
+		Rules: []*config.NotifyRule{
+			{Events: []string{config.NotifyFailure}, Jobs: []string{"shop*"}, Channels: []string{"hook"}},
+			// Cùng kênh ở nhiều quy tắc chỉ nhận một lần
+			{Events: []string{config.NotifyFailure}, Channels: []string{"hook"}},
+		},
+	})
+
+	if err := d.Notify(context.Background(), testEvent()); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if got := rec.count(); got != 1 {
+		t.Errorf("failure: got %d requests, want 1", got)
+	}
+
+	success := testEvent()
+	success.Type = config.NotifySuccess
+	if err := d.Notify(context.Background(), success); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if got := rec.count(); got != 1 {
+		t.Errorf("success should not be routed, got %d requests", got)
+	}
+}
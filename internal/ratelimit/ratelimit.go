@@ -0,0 +1,106 @@
+package ratelimit
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/backup-cronjob/internal/i18n"
+	"github.com/gin-gonic/gin"
+)
+
+// Limiter giới hạn số request theo khóa (IP, username...) trong một cửa sổ thời gian trượt
+type Limiter struct {
+	limit     int
+	window    time.Duration
+	mu        sync.Mutex
+	hits      map[string][]time.Time
+	lastSweep time.Time
+}
+
+// NewLimiter tạo limiter cho phép tối đa limit request trong mỗi window.
+// limit <= 0 nghĩa là không giới hạn.
+func NewLimiter(limit int, window time.Duration) *Limiter {
+	return &Limiter{
+		limit:     limit,
+		window:    window,
+		hits:      make(map[string][]time.Time),
+		lastSweep: time.Now(),
+	}
+}
+
+// Allow kiểm tra và ghi nhận một request cho khóa key.
+// Nếu vượt giới hạn, trả về false cùng thời gian cần chờ trước khi thử lại.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	if l == nil || l.limit <= 0 {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	cutoff := now.Add(-l.window)
+
+	// Dọn dẹp định kỳ các khóa không còn hoạt động để tránh rò rỉ bộ nhớ
+	if now.Sub(l.lastSweep) > l.window {
+		for k, times := range l.hits {
+			if len(times) == 0 || times[len(times)-1].Before(cutoff) {
+				delete(l.hits, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	// Bỏ các request đã nằm ngoài cửa sổ
+	times := l.hits[key]
+	i := 0
+	for i < len(times) && times[i].Before(cutoff) {
+		i++
+	}
+	times = times[i:]
+
+	if len(times) >= l.limit {
+		l.hits[key] = times
+		return false, times[0].Add(l.window).Sub(now)
+	}
+
+	l.hits[key] = append(times, now)
+	return true, 0
+}
+
+// KeyFunc xác định khóa giới hạn từ request
+type KeyFunc func(c *gin.Context) string
+
+// ByIP dùng địa chỉ IP của client làm khóa
+func ByIP(c *gin.Context) string {
+	return c.ClientIP()
+}
+
+// Middleware trả về gin middleware áp dụng limiter cho các route
+func Middleware(l *Limiter, keyFunc KeyFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allowed, retryAfter := l.Allow(keyFunc(c))
+		if !allowed {
+			AbortTooManyRequests(c, retryAfter)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// AbortTooManyRequests trả về lỗi 429 kèm header Retry-After (tính bằng giây)
+func AbortTooManyRequests(c *gin.Context, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	body := i18n.ErrorBody(c.Request.Context(), i18n.ErrTooManyRequests, seconds)
+	body["retry_after"] = seconds
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, body)
+}
@@ -0,0 +1,153 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAllowUnlimited(t *testing.T) {
+	var nilLimiter *Limiter
+	for _, l := range []*Limiter{nilLimiter, NewLimiter(0, time.Minute), NewLimiter(-1, time.Minute)} {
+		for i := 0; i < 100; i++ {
+			if allowed, wait := l.Allow("k"); !allowed || wait != 0 {
+				t.Fatalf("Allow = %v, %v; want unlimited", allowed, wait)
+			}
+		}
+	}
+}
+
+func TestAllowSlidingWindow(t *testing.T) {
+	const window = 300 * time.Millisecond
+	l := NewLimiter(2, window)
+
+	start := time.Now()
+	if allowed, _ := l.Allow("k"); !allowed {
+		t.Fatal("first request denied")
+	}
+	time.Sleep(window / 2)
+	if allowed, _ := l.Allow("k"); !allowed {
+		t.Fatal("second request denied")
+	}
+
+	allowed, wait := l.Allow("k")
+	if allowed {
+		t.Fatal("third request allowed")
+	}
+	// Phải chờ tới khi request đầu tiên ra khỏi cửa sổ
+	if max := window - time.Since(start) + 10*time.Millisecond; wait <= 0 || wait > max {
+		t.Errorf("retry after = %v, want (0, %v]", wait, max)
+	}
+
+	// Mỗi khóa có cửa sổ riêng
+	if allowed, _ := l.Allow("other"); !allowed {
+		t.Error("other key denied")
+	}
+
+	// Request đầu ra khỏi cửa sổ thì có thêm đúng một chỗ, request thứ hai vẫn còn tính
+	time.Sleep(wait + 10*time.Millisecond)
+	if allowed, _ := l.Allow("k"); !allowed {
+		t.Fatal("request denied after the oldest hit left the window")
+	}
+	if allowed, wait := l.Allow("k"); allowed || wait <= 0 {
+		t.Errorf("Allow = %v, %v; want denied while the second hit is in the window", allowed, wait)
+	}
+}
+
+func TestDeniedRequestsAreNotCounted(t *testing.T) {
+	const window = 200 * time.Millisecond
+	l := NewLimiter(1, window)
+
+	l.Allow("k")
+	for i := 0; i < 5; i++ {
+		l.Allow("k")
+	}
+
+	// Request bị từ chối không kéo dài thời gian khóa
+	time.Sleep(window + 10*time.Millisecond)
+	if allowed, _ := l.Allow("k"); !allowed {
+		t.Error("denied requests extended the window")
+	}
+}
+
+func TestAllowSweepsIdleKeys(t *testing.T) {
+	const window = 50 * time.Millisecond
+	l := NewLimiter(1, window)
+
+	l.Allow("idle")
+	time.Sleep(window + 10*time.Millisecond)
+	l.Allow("active")
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.hits["idle"]; ok {
+		t.Error("idle key was not swept")
+	}
+	if _, ok := l.hits["active"]; !ok {
+		t.Error("active key was swept")
+	}
+}
+
+func TestMiddlewareRetryAfter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/", Middleware(NewLimiter(1, 90*time.Second), ByIP), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	get := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := get(); w.Code != http.StatusOK {
+		t.Fatalf("first request: status %d", w.Code)
+	}
+
+	w := get()
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request: status %d, want 429", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "90" {
+		t.Errorf("Retry-After = %q, want %q", got, "90")
+	}
+	var body struct {
+		RetryAfter int `json:"retry_after"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.RetryAfter != 90 {
+		t.Errorf("body = %s, want retry_after 90", w.Body.String())
+	}
+}
+
+func TestAbortTooManyRequestsRoundsUp(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		retryAfter time.Duration
+		want       string
+	}{
+		{0, "1"},
+		{100 * time.Millisecond, "1"},
+		{time.Second, "1"},
+		{1500 * time.Millisecond, "2"},
+		{time.Minute, "60"},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+
+		AbortTooManyRequests(c, tt.retryAfter)
+		if got := w.Header().Get("Retry-After"); got != tt.want {
+			t.Errorf("AbortTooManyRequests(%v): Retry-After = %q, want %q", tt.retryAfter, got, tt.want)
+		}
+	}
+}
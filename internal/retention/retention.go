@@ -0,0 +1,177 @@
+package retention
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/backup-cronjob/internal/config"
+	"github.com/backup-cronjob/internal/database"
+	"github.com/backup-cronjob/internal/i18n"
+	"github.com/backup-cronjob/internal/logging"
+	"github.com/backup-cronjob/internal/models"
+)
+
+// SelectExpired chọn các bản backup cần xóa theo chính sách lưu giữ.
+// backups phải được sắp xếp mới nhất trước. Một bản backup bị xóa khi nó không nằm
+// trong KeepLast bản mới nhất và (nếu có MaxAgeDays) đã cũ hơn MaxAgeDays ngày.
+// Bản backup bị đánh dấu bất thường luôn được giữ lại và không được tính vào KeepLast.
+func SelectExpired(backups []*models.BackupFile, policy config.RetentionPolicy, now time.Time) []*models.BackupFile {
+	if policy.KeepLast == 0 && policy.MaxAgeDays == 0 {
+		return nil
+	}
+
+	var expired []*models.BackupFile
+	kept := 0
+	for _, backup := range backups {
+		if backup.Anomaly != "" {
+			continue
+		}
+
+		if policy.KeepLast > 0 && kept < policy.KeepLast {
+			kept++
+			continue
+		}
+
+		if policy.MaxAgeDays > 0 && now.Sub(backup.CreatedAt) <= time.Duration(policy.MaxAgeDays)*24*time.Hour {
+			continue
+		}
+
+		expired = append(expired, backup)
+	}
+
+	return expired
+}
+
+// ExpiredLocal trả về các bản backup local đã hết hạn của job theo chính sách lưu giữ (không xóa)
+func ExpiredLocal(backupDir string, job *config.Job) ([]*models.BackupFile, error) {
+	backups, err := models.GetJobBackups(backupDir, job.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Không dọn dẹp khi không biết bản backup nào bị đánh dấu bất thường
+	records, err := database.ListBackupRecords()
+	if err != nil {
+		return nil, i18n.Errorf(i18n.ErrCatalogRead, err)
+	}
+	for _, backup := range backups {
+		if record, ok := records[backup.Path]; ok {
+			backup.Anomaly = record.Anomaly
+		}
+	}
+
+	return SelectExpired(backups, job.Retention, time.Now()), nil
+}
+
+// PruneLocal xóa các bản backup local đã hết hạn của job.
+// Trả về danh sách file đã xóa; lỗi của từng file không làm dừng các file còn lại.
+func PruneLocal(backupDir string, job *config.Job) ([]*models.BackupFile, error) {
+	expired, err := ExpiredLocal(backupDir, job)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		removed []*models.BackupFile
+		failed  int
+	)
+	for _, backup := range expired {
+		if err := os.Remove(backup.Path); err != nil {
+			slog.Error("Failed to remove expired backup", logging.KeyJob, job.Name, logging.KeyFile, backup.Path, logging.Err(err))
+			failed++
+			continue
+		}
+		removed = append(removed, backup)
+
+		// Xóa manifest đặt cạnh file dump (nếu có)
+		os.Remove(models.ManifestPath(backup.Path))
+
+		// Xóa thư mục ngày nếu đã trống
+		os.Remove(filepath.Dir(backup.Path))
+	}
+
+	if failed > 0 {
+		return removed, i18n.Errorf(i18n.ErrRetentionDelete, failed)
+	}
+
+	// Job physical: xóa các segment WAL không còn bản sao lưu vật lý nào cần tới
+	if job.Mode == config.ModePhysical {
+		if _, err := PruneWAL(backupDir, job); err != nil {
+			return removed, err
+		}
+	}
+
+	return removed, nil
+}
+
+// PruneWAL xóa các file WAL cũ hơn điểm bắt đầu của bản sao lưu vật lý cũ nhất còn giữ lại,
+// để mọi bản sao lưu vật lý còn lại vẫn khôi phục được tới bất kỳ thời điểm nào sau nó.
+// File .history luôn được giữ. Khi chưa có bản sao lưu vật lý nào thì không xóa gì.
+// Trả về số file WAL đã xóa.
+func PruneWAL(backupDir string, job *config.Job) (int, error) {
+	oldest, err := WALCutoff(job)
+	if err != nil || oldest == "" {
+		return 0, err
+	}
+
+	segments, err := database.ListWALSegments(job.Name)
+	if err != nil {
+		return 0, i18n.Errorf(i18n.ErrCatalogRead, err)
+	}
+
+	removed, failed := 0, 0
+	for _, segment := range segments {
+		if !WALExpired(segment.Name, oldest) {
+			continue
+		}
+
+		if err := os.Remove(models.WALPath(backupDir, job.Name, segment.Name)); err != nil && !os.IsNotExist(err) {
+			slog.Error("Failed to remove WAL segment", logging.KeyJob, job.Name, "segment", segment.Name, logging.Err(err))
+			failed++
+			continue
+		}
+		if err := database.DeleteWALSegment(job.Name, segment.Name); err != nil {
+			slog.Error("Failed to remove WAL segment from catalog", logging.KeyJob, job.Name, "segment", segment.Name, logging.Err(err))
+		}
+		removed++
+	}
+
+	if failed > 0 {
+		return removed, i18n.Errorf(i18n.ErrRetentionWALDelete, failed)
+	}
+	return removed, nil
+}
+
+// WALCutoff trả về điểm bắt đầu WAL của bản sao lưu vật lý cũ nhất còn giữ lại của job (các file WAL
+// trước điểm này không còn cần để khôi phục), rỗng nếu chưa có bản sao lưu vật lý nào
+func WALCutoff(job *config.Job) (string, error) {
+	records, err := database.ListJobBackupRecords(job.Name, models.BackupKindBase)
+	if err != nil {
+		return "", i18n.Errorf(i18n.ErrCatalogRead, err)
+	}
+
+	oldest := ""
+	for _, record := range records {
+		if record.WALStart == "" {
+			continue
+		}
+		if _, err := os.Stat(record.Path); err != nil {
+			continue
+		}
+		if oldest == "" || record.WALStart < oldest {
+			oldest = record.WALStart
+		}
+	}
+	return oldest, nil
+}
+
+// WALExpired kiểm tra file WAL name (segment, file .backup, có thể kèm đuôi nén) có nằm trước cutoff
+// không. File .history luôn được giữ.
+func WALExpired(name, cutoff string) bool {
+	name = strings.TrimSuffix(name, ".gz")
+	// Tên segment và file .backup bắt đầu bằng 24 ký tự tên segment, so sánh được theo thứ tự WAL
+	return cutoff != "" && !strings.HasSuffix(name, ".history") && len(name) >= 24 && name[:24] < cutoff
+}
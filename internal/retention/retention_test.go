@@ -0,0 +1,187 @@
+package retention
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/backup-cronjob/internal/config"
+	"github.com/backup-cronjob/internal/database"
+	"github.com/backup-cronjob/internal/models"
+)
+
+func TestSelectExpired(t *testing.T) {
+	now := time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	// backup tạo bản backup có tuổi age, name bắt đầu bằng "!" nghĩa là bị đánh dấu bất thường
+	backup := func(name string, age time.Duration) *models.BackupFile {
+		b := &models.BackupFile{Name: name, CreatedAt: now.Add(-age)}
+		if name[0] == '!' {
+			b.Anomaly = "size drop"
+		}
+		return b
+	}
+
+	tests := []struct {
+		name    string
+		policy  config.RetentionPolicy
+		backups []*models.BackupFile
+		want    []string
+	}{
+		{"no policy", config.RetentionPolicy{},
+			[]*models.BackupFile{backup("a", day), backup("b", 100*day)}, nil},
+		{"keep last", config.RetentionPolicy{KeepLast: 2},
+			[]*models.BackupFile{backup("a", day), backup("b", 2*day), backup("c", 3*day), backup("d", 4*day)}, []string{"c", "d"}},
+		// Bản bất thường không bị xóa và không chiếm chỗ trong KeepLast
+		{"anomaly exempt from keep last", config.RetentionPolicy{KeepLast: 2},
+			[]*models.BackupFile{backup("!a", day), backup("b", 2*day), backup("c", 3*day), backup("d", 4*day), backup("!e", 5*day)}, []string{"d"}},
+		// Đúng MaxAgeDays ngày vẫn được giữ, cũ hơn một giây thì hết hạn
+		{"max age boundary", config.RetentionPolicy{MaxAgeDays: 7},
+			[]*models.BackupFile{backup("a", day), backup("b", 7*day), backup("c", 7*day+time.Second), backup("d", 30*day)}, []string{"c", "d"}},
+		{"anomaly exempt from max age", config.RetentionPolicy{MaxAgeDays: 7},
+			[]*models.BackupFile{backup("!a", 30*day)}, nil},
+		// KeepLast giữ bản mới nhất dù đã quá hạn; các bản khác xét theo tuổi
+		{"keep last and max age", config.RetentionPolicy{KeepLast: 1, MaxAgeDays: 7},
+			[]*models.BackupFile{backup("a", 10*day), backup("b", 20*day)}, []string{"b"}},
+		{"keep last and max age, recent kept", config.RetentionPolicy{KeepLast: 1, MaxAgeDays: 7},
+			[]*models.BackupFile{backup("a", day), backup("b", 2*day), backup("c", 10*day)}, []string{"c"}},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, b := range SelectExpired(tt.backups, tt.policy, now) {
+			got = append(got, b.Name)
+		}
+		if !equalNames(got, tt.want) {
+			t.Errorf("%s: SelectExpired = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func equalNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestWALExpired(t *testing.T) {
+	const cutoff = "000000010000000000000005"
+
+	tests := []struct {
+		name, cutoff string
+		want         bool
+	}{
+		{"000000010000000000000004", cutoff, true},
+		{"000000010000000000000004.gz", cutoff, true},
+		{"000000010000000000000004.00000028.backup", cutoff, true},
+		{"000000010000000000000005", cutoff, false},
+		{"000000010000000000000005.00000028.backup", cutoff, false},
+		{"000000010000000000000006", cutoff, false},
+		{"0000000100000001000000A0", cutoff, false},
+		{"00000001.history", cutoff, false},
+		{"00000001.history.gz", cutoff, false},
+		{"short", cutoff, false},
+		// Chưa có bản sao lưu vật lý nào: không xóa gì
+		{"000000010000000000000004", "", false},
+	}
+
+	for _, tt := range tests {
+		if got := WALExpired(tt.name, tt.cutoff); got != tt.want {
+			t.Errorf("WALExpired(%q, %q) = %v, want %v", tt.name, tt.cutoff, got, tt.want)
+		}
+	}
+}
+
+// openTestDB mở database SQLite tạm cho catalog
+func openTestDB(t *testing.T) {
+	t.Helper()
+
+	prev := database.DB
+	database.DB = nil
+	if err := database.InitDB(&config.Config{SQLiteDBPath: filepath.Join(t.TempDir(), "test.db")}); err != nil {
+		t.Fatalf("InitDB: %v", err)
+	}
+	t.Cleanup(func() {
+		database.DB.Close()
+		database.DB = prev
+	})
+}
+
+func TestPruneWAL(t *testing.T) {
+	openTestDB(t)
+	backupDir := t.TempDir()
+	job := &config.Job{Name: "pg", Mode: config.ModePhysical}
+
+	// Bản sao lưu vật lý còn giữ bắt đầu ở segment 05; bản bắt đầu ở 02 đã bị xóa khỏi đĩa
+	// nên không còn giữ các segment trước 05 lại
+	kept := filepath.Join(backupDir, "pg", "2025-03-01", "base_20250301_000000.tar.gz")
+	if err := os.MkdirAll(filepath.Dir(kept), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(kept, []byte("base"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	for path, start := range map[string]string{
+		kept: "000000010000000000000005",
+		filepath.Join(backupDir, "pg", "2025-02-01", "base_20250201_000000.tar.gz"): "000000010000000000000002",
+	} {
+		if err := database.SaveBackupRecord(&models.BackupRecord{Job: job.Name, Path: path, Kind: models.BackupKindBase, WALStart: start}, nil); err != nil {
+			t.Fatalf("SaveBackupRecord: %v", err)
+		}
+	}
+
+	segments := []string{
+		"000000010000000000000003",
+		"000000010000000000000004",
+		"000000010000000000000004.00000028.backup",
+		"000000010000000000000005",
+		"000000010000000000000006",
+		"00000002.history",
+	}
+	if err := os.MkdirAll(models.WALDir(backupDir, job.Name), 0700); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range segments {
+		if err := os.WriteFile(models.WALPath(backupDir, job.Name, name), nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := database.SaveWALSegment(&models.WALSegment{Job: job.Name, Name: name, ArchivedAt: time.Now()}); err != nil {
+			t.Fatalf("SaveWALSegment: %v", err)
+		}
+	}
+
+	removed, err := PruneWAL(backupDir, job)
+	if err != nil {
+		t.Fatalf("PruneWAL: %v", err)
+	}
+	if removed != 3 {
+		t.Errorf("removed = %d, want 3", removed)
+	}
+
+	remaining, err := database.ListWALSegments(job.Name)
+	if err != nil {
+		t.Fatalf("ListWALSegments: %v", err)
+	}
+	var names []string
+	for _, segment := range remaining {
+		names = append(names, segment.Name)
+		if _, err := os.Stat(models.WALPath(backupDir, job.Name, segment.Name)); err != nil {
+			t.Errorf("kept segment %s missing on disk: %v", segment.Name, err)
+		}
+	}
+	want := []string{"000000010000000000000005", "000000010000000000000006", "00000002.history"}
+	if !equalNames(names, want) {
+		t.Errorf("remaining = %v, want %v", names, want)
+	}
+	if _, err := os.Stat(models.WALPath(backupDir, job.Name, "000000010000000000000003")); !os.IsNotExist(err) {
+		t.Errorf("expired segment still on disk: %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/backup-cronjob/internal/config"
+	"github.com/backup-cronjob/internal/cron"
+	"github.com/backup-cronjob/internal/logging"
+)
+
+// RunFunc là hàm được gọi khi đến lịch chạy của một job
+type RunFunc func(ctx context.Context, job *config.Job)
+
+// Scheduler chạy các job theo biểu thức cron trong cấu hình của từng job
+type Scheduler struct {
+	Config *config.Config
+	run    RunFunc
+
+	mu        sync.Mutex
+	running   map[string]bool
+	schedules map[string]*cron.Schedule
+	wg        sync.WaitGroup
+}
+
+// New tạo scheduler mới
+func New(cfg *config.Config, run RunFunc) *Scheduler {
+	return &Scheduler{
+		Config:    cfg,
+		run:       run,
+		running:   make(map[string]bool),
+		schedules: make(map[string]*cron.Schedule),
+	}
+}
+
+// Start chạy vòng lặp lập lịch cho tới khi ctx bị hủy. Danh sách job được đọc lại
+// mỗi phút nên các job thêm mới trong lúc chạy cũng được lập lịch.
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, job := range s.Config.Jobs() {
+		if job.Schedule == "" {
+			continue
+		}
+		if sched, err := s.schedule(job.Schedule); err != nil {
+			slog.Error("Invalid schedule, job will not run automatically", logging.KeyJob, job.Name, logging.Err(err))
+		} else {
+			slog.Info("Job scheduled", logging.KeyJob, job.Name, "schedule", job.Schedule, "next_run", sched.Next(time.Now()))
+		}
+	}
+
+	go func() {
+		for {
+			// Chờ tới đầu phút tiếp theo
+			now := time.Now()
+			next := now.Truncate(time.Minute).Add(time.Minute)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(next.Sub(now)):
+			}
+
+			s.tick(ctx, next)
+		}
+	}()
+}
+
+// Wait chờ các job đang chạy hoàn tất
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+// tick khởi chạy các job có lịch khớp với phút t
+func (s *Scheduler) tick(ctx context.Context, t time.Time) {
+	for _, job := range s.Config.Jobs() {
+		if job.Schedule == "" {
+			continue
+		}
+
+		sched, err := s.schedule(job.Schedule)
+		if err != nil || !sched.Next(t.Add(-time.Minute)).Equal(t) {
+			continue
+		}
+
+		s.mu.Lock()
+		if s.running[job.Name] {
+			s.mu.Unlock()
+			slog.Warn("Job is still running, scheduled run skipped", logging.KeyJob, job.Name, "scheduled_at", t)
+			continue
+		}
+		s.running[job.Name] = true
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go func(job *config.Job) {
+			defer s.wg.Done()
+			defer func() {
+				s.mu.Lock()
+				delete(s.running, job.Name)
+				s.mu.Unlock()
+			}()
+
+			s.run(ctx, job)
+		}(job)
+	}
+}
+
+// schedule parse (và lưu cache) biểu thức cron
+func (s *Scheduler) schedule(expr string) (*cron.Schedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if sched, ok := s.schedules[expr]; ok {
+		return sched, nil
+	}
+
+	sched, err := cron.Parse(expr)
+	if err != nil {
+		return nil, err
+	}
+	s.schedules[expr] = sched
+	return sched, nil
+}
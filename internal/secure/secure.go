@@ -0,0 +1,158 @@
+package secure
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/backup-cronjob/internal/config"
+)
+
+// keySize là độ dài master key (AES-256)
+const keySize = 32
+
+var masterKey []byte
+
+// Init nạp master key dùng để mã hóa dữ liệu nhạy cảm lưu trong SQLite.
+// Ưu tiên biến MASTER_KEY (base64 hoặc hex, 32 byte); nếu không có, đọc từ file
+// MasterKeyFile và tự tạo key ngẫu nhiên (quyền 0600) nếu file chưa tồn tại.
+func Init(cfg *config.Config) error {
+	if cfg.MasterKey != "" {
+		key, err := decodeKey(cfg.MasterKey)
+		if err != nil {
+			return fmt.Errorf("invalid MASTER_KEY: %w", err)
+		}
+		masterKey = key
+		return nil
+	}
+
+	key, err := loadOrCreateKeyFile(cfg.MasterKeyFile)
+	if err != nil {
+		return fmt.Errorf("error loading master key file: %w", err)
+	}
+	masterKey = key
+	return nil
+}
+
+// Encrypt mã hóa dữ liệu bằng AES-256-GCM, nonce được đặt ở đầu ciphertext
+func Encrypt(plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM()
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// Decrypt giải mã dữ liệu đã được mã hóa bởi Encrypt
+func Decrypt(ciphertext []byte) ([]byte, error) {
+	gcm, err := newGCM()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+
+	nonce, data := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("decryption failed (wrong master key?): %w", err)
+	}
+
+	return plaintext, nil
+}
+
+// DeriveKey tạo khóa con HMAC-SHA256(master key, purpose) để mỗi mục đích (vd: hash audit log)
+// dùng một khóa riêng thay vì dùng trực tiếp master key
+func DeriveKey(purpose string) ([]byte, error) {
+	if masterKey == nil {
+		return nil, errors.New("master key is not initialized")
+	}
+
+	mac := hmac.New(sha256.New, masterKey)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil), nil
+}
+
+// WriteFilePrivate ghi file với quyền 0600, đảm bảo quyền đúng cả khi file đã tồn tại
+func WriteFilePrivate(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return err
+	}
+
+	return os.Chmod(path, 0600)
+}
+
+// newGCM tạo AES-GCM cipher từ master key
+func newGCM() (cipher.AEAD, error) {
+	if masterKey == nil {
+		return nil, errors.New("master key is not initialized")
+	}
+
+	block, err := aes.NewCipher(masterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// loadOrCreateKeyFile đọc master key từ file hoặc tạo mới nếu chưa có
+func loadOrCreateKeyFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		// Siết lại quyền nếu file key đang mở rộng hơn 0600
+		if info, statErr := os.Stat(path); statErr == nil && info.Mode().Perm()&0077 != 0 {
+			os.Chmod(path, 0600)
+		}
+		return decodeKey(strings.TrimSpace(string(data)))
+	}
+
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	if err := WriteFilePrivate(path, []byte(base64.StdEncoding.EncodeToString(key)+"\n")); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// decodeKey giải mã master key dạng base64 hoặc hex
+func decodeKey(value string) ([]byte, error) {
+	if key, err := base64.StdEncoding.DecodeString(value); err == nil && len(key) == keySize {
+		return key, nil
+	}
+
+	if key, err := hex.DecodeString(value); err == nil && len(key) == keySize {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("master key must be %d bytes encoded as base64 or hex", keySize)
+}
@@ -0,0 +1,171 @@
+package verify
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/backup-cronjob/internal/config"
+	"github.com/backup-cronjob/internal/dbdump"
+	"github.com/backup-cronjob/internal/i18n"
+	"github.com/backup-cronjob/internal/models"
+)
+
+// prepareTargets xác định các database cần khôi phục từ file backup. Bản backup cluster (.tar.gz)
+// được giải nén vào workDir; trả về kèm file globals.sql và phiên bản server trong manifest.
+func prepareTargets(job *config.Job, path, workDir string) ([]restoreTarget, string, string, error) {
+	if !strings.HasSuffix(path, ".tar.gz") {
+		target := restoreTarget{Database: job.DBName, File: path}
+		// Bản backup cũ có thể không có manifest đặt cạnh
+		if manifest, err := dbdump.ReadManifest(path); err == nil && len(manifest.Databases) > 0 {
+			target.Schema = manifest.Databases[0].Schema
+		}
+		return []restoreTarget{target}, "", "", nil
+	}
+
+	if err := extractArchive(path, workDir); err != nil {
+		return nil, "", "", i18n.Errorf(i18n.ErrVerifyExtract, err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(workDir, dbdump.ManifestFile))
+	if err != nil {
+		return nil, "", "", i18n.Errorf(i18n.ErrVerifyManifestMissing, err)
+	}
+	var manifest dbdump.Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, "", "", i18n.Errorf(i18n.ErrVerifyManifestInvalid, err)
+	}
+
+	var targets []restoreTarget
+	for _, entry := range manifest.Databases {
+		if entry.Success {
+			targets = append(targets, restoreTarget{Database: entry.Name, File: filepath.Join(workDir, filepath.FromSlash(entry.File)), Schema: entry.Schema})
+		}
+	}
+	if len(targets) == 0 {
+		return nil, "", "", i18n.Errorf(i18n.ErrVerifyNoDatabases)
+	}
+
+	globalsFile := ""
+	if manifest.Globals != nil && manifest.Globals.Success {
+		globalsFile = filepath.Join(workDir, filepath.FromSlash(manifest.Globals.File))
+	}
+
+	return targets, globalsFile, manifest.ServerVersion, nil
+}
+
+// extractArchive giải nén file tar.gz vào dir, từ chối các đường dẫn thoát ra ngoài dir
+func extractArchive(path, dir string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		target := filepath.Join(dir, filepath.FromSlash(header.Name))
+		if !strings.HasPrefix(target, filepath.Clean(dir)+string(os.PathSeparator)) {
+			return i18n.Errorf(i18n.ErrVerifyArchivePath, header.Name)
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+			return err
+		}
+
+		out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(out, tr)
+		out.Close()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// sourceTables lọc số dòng đếm lúc dump của database dbName. Với bản backup một database,
+// tên database được bỏ đi (bản ghi cũ không lưu tên database).
+func sourceTables(tables []models.TableStat, dbName string, cluster bool) []models.TableStat {
+	var result []models.TableStat
+	for _, t := range tables {
+		if t.RowCount < 0 {
+			continue
+		}
+		if cluster && t.Database == dbName {
+			result = append(result, t)
+		} else if !cluster && (t.Database == "" || t.Database == dbName) {
+			t.Database = ""
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+// compareRowCounts so sánh số dòng lúc dump với sau khi khôi phục. Trả về một kiểm tra cho
+// mỗi bảng không khớp và một kiểm tra tổng hợp.
+func compareRowCounts(source, restored []models.TableStat, tolerance float64) []Check {
+	if len(source) == 0 {
+		return []Check{{Name: "row counts", Passed: true, Detail: i18n.T("", "verify.no_row_counts")}}
+	}
+
+	dbName := source[0].Database
+	counts := make(map[string]int64, len(restored))
+	for _, t := range restored {
+		counts[t.QualifiedName()] = t.RowCount
+	}
+
+	var checks []Check
+	for _, t := range source {
+		name := t.QualifiedName()
+		if dbName != "" {
+			name = dbName + ":" + name
+		}
+
+		count, ok := counts[t.QualifiedName()]
+		if !ok {
+			checks = append(checks, Check{Name: "rows " + name, Detail: i18n.T("", "verify.table_missing")})
+			continue
+		}
+
+		diff := count - t.RowCount
+		if diff < 0 {
+			diff = -diff
+		}
+		if float64(diff) > float64(t.RowCount)*tolerance/100 {
+			checks = append(checks, Check{
+				Name:   "rows " + name,
+				Detail: i18n.T("", "verify.row_mismatch", t.RowCount, count),
+			})
+		}
+	}
+
+	summary := Check{Name: "row counts", Passed: len(checks) == 0}
+	if dbName != "" {
+		summary.Name += " " + dbName
+	}
+	summary.Detail = i18n.T("", "verify.tables_matched", len(source)-len(checks), len(source))
+
+	return append(checks, summary)
+}
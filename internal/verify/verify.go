@@ -0,0 +1,353 @@
+package verify
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/backup-cronjob/internal/config"
+	"github.com/backup-cronjob/internal/database"
+	"github.com/backup-cronjob/internal/dbdump"
+	"github.com/backup-cronjob/internal/docker"
+	"github.com/backup-cronjob/internal/i18n"
+	"github.com/backup-cronjob/internal/logging"
+	"github.com/backup-cronjob/internal/models"
+)
+
+// readyTimeout là thời gian tối đa chờ postgres trong container tạm sẵn sàng
+const readyTimeout = 2 * time.Minute
+
+// restoreDir là thư mục chứa file khôi phục bên trong container tạm
+const restoreDir = "/tmp"
+
+// Check là kết quả của một bước kiểm tra
+type Check struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Result là kết quả kiểm tra khôi phục một bản backup
+type Result struct {
+	Path    string  `json:"path"`
+	Status  string  `json:"status"`
+	Message string  `json:"message"`
+	Image   string  `json:"image"`
+	Checks  []Check `json:"checks"`
+}
+
+// Verifier khôi phục bản backup vào container postgres tạm và kiểm tra dữ liệu
+type Verifier struct {
+	Config *config.Config
+	Docker *docker.Client
+	Dumper *dbdump.DatabaseDumper
+}
+
+// New tạo Verifier mới, dùng chung Docker client với dumper
+func New(cfg *config.Config, dumper *dbdump.DatabaseDumper) *Verifier {
+	return &Verifier{
+		Config: cfg,
+		Docker: dumper.Docker,
+		Dumper: dumper,
+	}
+}
+
+// restoreTarget là một database cần khôi phục từ file dump
+type restoreTarget struct {
+	Database string
+	File     string
+	// Schema là cấu trúc database lưu trong manifest lúc dump (bản dump chỉ có dữ liệu)
+	Schema string
+}
+
+// Verify khôi phục bản backup vào một container postgres tạm (cùng major version với server nguồn),
+// so sánh số dòng từng bảng với lúc dump và chạy các assertion của job. Kết quả được ghi vào
+// catalog; container luôn bị xóa sau khi kiểm tra. Lỗi khác nil khi bản backup không đạt.
+func (v *Verifier) Verify(ctx context.Context, job *config.Job, backup *models.BackupFile) (*Result, error) {
+	result := &Result{Path: backup.Path, Status: models.VerifyStatusFailed}
+
+	err := v.verify(ctx, job, backup, result)
+	if err != nil {
+		result.Message = err.Error()
+	} else {
+		result.Status = models.VerifyStatusVerified
+		result.Message = i18n.T("", "verify.restored", len(result.Checks))
+	}
+
+	if dbErr := database.UpdateVerifyStatus(backup.Path, result.Status, result.Message); dbErr != nil {
+		logging.From(ctx).Error("Failed to record verification result", logging.KeyFile, backup.Path, logging.Err(dbErr))
+	}
+
+	return result, err
+}
+
+// verify thực hiện các bước kiểm tra, ghi từng bước vào result
+func (v *Verifier) verify(ctx context.Context, job *config.Job, backup *models.BackupFile, result *Result) error {
+	// Bản backup chưa có trong catalog (vd: tạo bởi phiên bản cũ) thì thêm mới, không có số dòng để so sánh
+	record, err := database.GetBackupRecord(backup.Path)
+	if err != nil {
+		return i18n.Errorf(i18n.ErrCatalogRead, err)
+	}
+	if record == nil {
+		record = &models.BackupRecord{Job: job.Name, Path: backup.Path, Size: backup.Size, CreatedAt: backup.CreatedAt}
+		if err := database.SaveBackupRecord(record, nil); err != nil {
+			return i18n.Errorf(i18n.ErrCatalogWrite, err)
+		}
+	}
+
+	if record.Kind == models.BackupKindBase || job.Mode == config.ModePhysical {
+		return i18n.Errorf(i18n.ErrVerifyPhysical)
+	}
+
+	tables, err := database.GetBackupTables(record.ID)
+	if err != nil {
+		return i18n.Errorf(i18n.ErrVerifyRowCounts, err)
+	}
+
+	// Chuẩn bị các file cần khôi phục
+	workDir, err := os.MkdirTemp("", "verify-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(workDir)
+
+	targets, globalsFile, serverVersion, err := prepareTargets(job, backup.Path, workDir)
+	if err != nil {
+		return err
+	}
+	if serverVersion == "" {
+		serverVersion = record.ServerVersion
+	}
+
+	result.Image = job.Verify.Image
+	if result.Image == "" {
+		result.Image = ImageFor(serverVersion)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, job.Verify.Timeout)
+	defer cancel()
+
+	// Khởi động container postgres tạm
+	id, err := v.Docker.CreateContainer(ctx, docker.RunOptions{
+		Image: result.Image,
+		Env:   []string{"POSTGRES_HOST_AUTH_METHOD=trust"},
+	})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := v.Docker.RemoveContainer(id); err != nil {
+			logging.From(ctx).Warn("Failed to remove verification container", "container", id, logging.Err(err))
+		}
+	}()
+
+	if err := v.Docker.StartContainer(ctx, id); err != nil {
+		return err
+	}
+	if err := v.waitReady(ctx, id); err != nil {
+		return err
+	}
+
+	// Roles: từ globals.sql của bản backup cluster, hoặc user của job
+	if globalsFile != "" {
+		if err := v.copyFile(ctx, id, globalsFile, "globals.sql"); err != nil {
+			return err
+		}
+		// Một số role (vd: postgres) đã tồn tại nên bỏ qua lỗi
+		v.psql(ctx, id, "postgres", "-q", "-f", restoreDir+"/globals.sql")
+	} else {
+		v.exec(ctx, id, "createuser", "-h", "127.0.0.1", "-U", "postgres", job.DBUser)
+	}
+
+	for _, target := range targets {
+		if err := v.restore(ctx, id, job, target); err != nil {
+			result.Checks = append(result.Checks, Check{Name: "restore " + target.Database, Detail: err.Error()})
+			return i18n.Errorf(i18n.ErrVerifyRestoreDatabase, target.Database, err)
+		}
+		result.Checks = append(result.Checks, Check{Name: "restore " + target.Database, Passed: true})
+	}
+
+	// So sánh số dòng từng bảng với lúc dump
+	failed := 0
+	for _, target := range targets {
+		output, err := v.psql(ctx, id, target.Database, "-At", "-F", "\t", "-c", dbdump.RowCountQuery)
+		if err != nil {
+			return i18n.Errorf(i18n.ErrVerifyCountRows, target.Database, err)
+		}
+		restored, err := dbdump.ParseRowCounts(output)
+		if err != nil {
+			return err
+		}
+
+		for _, check := range compareRowCounts(sourceTables(tables, target.Database, globalsFile != ""), restored, *job.Verify.RowCountTolerance) {
+			if !check.Passed {
+				failed++
+			}
+			result.Checks = append(result.Checks, check)
+		}
+	}
+
+	// Các assertion của người dùng
+	for _, assertion := range job.Verify.Assertions {
+		dbName := assertion.Database
+		if dbName == "" {
+			dbName = targets[0].Database
+		}
+
+		check := Check{Name: assertion.Name}
+		output, err := v.psql(ctx, id, dbName, "-Atc", assertion.SQL)
+		switch value := strings.TrimSpace(output); {
+		case err != nil:
+			check.Detail = err.Error()
+		case value == "t" || value == "true":
+			check.Passed = true
+		default:
+			check.Detail = i18n.T("", "verify.assertion_result", value)
+		}
+
+		if !check.Passed {
+			failed++
+		}
+		result.Checks = append(result.Checks, check)
+	}
+
+	if failed > 0 {
+		return i18n.Errorf(i18n.ErrVerifyChecksFailed, failed, len(result.Checks))
+	}
+	return nil
+}
+
+// restore tạo database và khôi phục file dump của target vào container
+func (v *Verifier) restore(ctx context.Context, id string, job *config.Job, target restoreTarget) error {
+	if target.Database != "postgres" {
+		if _, err := v.exec(ctx, id, "createdb", "-h", "127.0.0.1", "-U", "postgres", target.Database); err != nil {
+			return err
+		}
+	}
+
+	// Bản dump chỉ có dữ liệu cần cấu trúc bảng: dùng cấu trúc lưu trong manifest lúc dump.
+	// Bản backup cũ không có cấu trúc đi kèm thì lấy từ database nguồn hiện tại.
+	schema := bytes.NewBufferString(target.Schema)
+	if target.Schema == "" && dbdump.IsDataOnly(job.DumpOptions) {
+		logging.From(ctx).Warn("Backup has no captured schema, using current schema of source database", "database", target.Database)
+		if err := v.Dumper.DumpSchema(ctx, job, target.Database, schema); err != nil {
+			return i18n.Errorf(i18n.ErrVerifySourceSchema, err)
+		}
+	}
+	if schema.Len() > 0 {
+		if err := v.Docker.CopyFile(ctx, id, restoreDir, "schema.sql", schema, int64(schema.Len())); err != nil {
+			return err
+		}
+		if _, err := v.psql(ctx, id, target.Database, "-v", "ON_ERROR_STOP=1", "-q", "-f", restoreDir+"/schema.sql"); err != nil {
+			return i18n.Errorf(i18n.ErrVerifyRestoreSchema, err)
+		}
+	}
+
+	if err := v.copyFile(ctx, id, target.File, "restore.dump"); err != nil {
+		return err
+	}
+
+	custom, err := isCustomFormat(target.File)
+	if err != nil {
+		return err
+	}
+	if custom {
+		_, err = v.exec(ctx, id, "pg_restore", "-h", "127.0.0.1", "-U", "postgres", "-d", target.Database,
+			"--no-owner", "--no-privileges", "--exit-on-error", restoreDir+"/restore.dump")
+	} else {
+		_, err = v.psql(ctx, id, target.Database, "-v", "ON_ERROR_STOP=1", "-q", "-f", restoreDir+"/restore.dump")
+	}
+	return err
+}
+
+// waitReady chờ postgres trong container nhận kết nối TCP. Trong lúc khởi tạo, image postgres
+// chạy server tạm chỉ nghe trên unix socket nên kiểm tra qua 127.0.0.1 tránh nhận nhầm.
+func (v *Verifier) waitReady(ctx context.Context, id string) error {
+	deadline := time.Now().Add(readyTimeout)
+	for {
+		if _, err := v.exec(ctx, id, "pg_isready", "-h", "127.0.0.1", "-U", "postgres"); err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return i18n.Errorf(i18n.ErrVerifyNotReady, readyTimeout)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// copyFile copy file local vào thư mục restoreDir của container với tên name
+func (v *Verifier) copyFile(ctx context.Context, id, path, name string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	return v.Docker.CopyFile(ctx, id, restoreDir, name, file, info.Size())
+}
+
+// psql chạy psql với superuser trong container tạm
+func (v *Verifier) psql(ctx context.Context, id, dbName string, args ...string) (string, error) {
+	return v.exec(ctx, id, append([]string{"psql", "-h", "127.0.0.1", "-U", "postgres", "-d", dbName}, args...)...)
+}
+
+// exec chạy lệnh trong container tạm, trả về stdout; exit code khác 0 được coi là lỗi kèm stderr
+func (v *Verifier) exec(ctx context.Context, id string, command ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	code, err := v.Docker.Exec(ctx, id, docker.ExecOptions{Cmd: command}, &stdout, &stderr)
+	if err != nil {
+		return "", err
+	}
+	if code != 0 {
+		msg := strings.TrimSpace(stderr.String())
+		if len(msg) > 500 {
+			msg = "..." + msg[len(msg)-500:]
+		}
+		return stdout.String(), i18n.Errorf(i18n.ErrVerifyCommandExit, command[0], code, msg)
+	}
+	return stdout.String(), nil
+}
+
+// isCustomFormat kiểm tra file dump có phải định dạng custom của pg_dump (-Fc) không
+func isCustomFormat(path string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(file, header); err != nil {
+		return false, nil
+	}
+	return string(header) == "PGDMP", nil
+}
+
+// ImageFor trả về image postgres cùng major version với server nguồn
+func ImageFor(serverVersion string) string {
+	parts := strings.Split(serverVersion, ".")
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return config.DefaultClientImage
+	}
+
+	// Từ PostgreSQL 10, major version chỉ gồm một số; trước đó gồm hai số (vd: 9.6)
+	tag := parts[0]
+	if major < 10 && len(parts) > 1 {
+		tag += "." + parts[1]
+	}
+	return "postgres:" + tag + "-alpine"
+}
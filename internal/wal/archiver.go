@@ -0,0 +1,293 @@
+package wal
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/backup-cronjob/internal/config"
+	"github.com/backup-cronjob/internal/database"
+	"github.com/backup-cronjob/internal/docker"
+	"github.com/backup-cronjob/internal/drive"
+	"github.com/backup-cronjob/internal/i18n"
+	"github.com/backup-cronjob/internal/logging"
+	"github.com/backup-cronjob/internal/models"
+)
+
+// tickInterval là chu kỳ kiểm tra các job tới hạn lấy WAL; mỗi job còn có poll_interval riêng
+const tickInterval = 15 * time.Second
+
+// Archiver định kỳ lấy các segment WAL mà server nguồn đã lưu trữ (archive_command) trong
+// container về thư mục backup, ghi vào catalog và upload lên các đích từ xa của job
+type Archiver struct {
+	Config   *config.Config
+	Docker   *docker.Client
+	Uploader *drive.DriveUploader
+
+	mu      sync.Mutex
+	lastRun map[string]time.Time
+}
+
+// New tạo Archiver mới
+func New(cfg *config.Config, client *docker.Client, uploader *drive.DriveUploader) *Archiver {
+	return &Archiver{
+		Config:   cfg,
+		Docker:   client,
+		Uploader: uploader,
+		lastRun:  make(map[string]time.Time),
+	}
+}
+
+// Start chạy vòng lặp lưu trữ WAL trong nền cho tới khi ctx bị hủy
+func (a *Archiver) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(tickInterval)
+		defer ticker.Stop()
+
+		for {
+			a.archiveDue(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// archiveDue lưu trữ WAL của các job physical đã tới hạn theo poll_interval
+func (a *Archiver) archiveDue(ctx context.Context) {
+	for _, job := range a.Config.Jobs() {
+		if job.Mode != config.ModePhysical {
+			continue
+		}
+
+		a.mu.Lock()
+		due := time.Since(a.lastRun[job.Name]) >= job.WAL.PollInterval
+		if due {
+			a.lastRun[job.Name] = time.Now()
+		}
+		a.mu.Unlock()
+		if !due {
+			continue
+		}
+
+		jobCtx := logging.With(ctx, logging.KeyJob, job.Name)
+		if n, err := a.ArchiveJob(jobCtx, job); err != nil {
+			logging.From(jobCtx).Error("WAL archiving failed", logging.Err(err))
+		} else if n > 0 {
+			logging.From(jobCtx).Info("Archived WAL files", "count", n)
+		}
+	}
+}
+
+// ArchiveJob lấy các file WAL mới trong archive_dir của container về thư mục backup,
+// xóa khỏi container sau khi đã lưu, rồi upload các file chưa upload lên Drive (nếu job có đích Drive).
+// Trả về số file đã lấy về.
+func (a *Archiver) ArchiveJob(ctx context.Context, job *config.Job) (int, error) {
+	if job.Mode != config.ModePhysical {
+		return 0, i18n.Errorf(i18n.ErrJobNotPhysical, job.Name)
+	}
+	if err := a.Docker.EnsureRunning(ctx, job.ContainerName); err != nil {
+		return 0, err
+	}
+
+	names, err := a.listArchived(ctx, job)
+	if err != nil {
+		return 0, err
+	}
+
+	walDir := models.WALDir(a.Config.BackupDir, job.Name)
+	if err := os.MkdirAll(walDir, 0700); err != nil {
+		return 0, i18n.Errorf(i18n.ErrWALDir, err)
+	}
+
+	// Kích thước segment của server, dùng để phát hiện segment chưa được chép xong
+	var segmentSize int64
+	for _, name := range names {
+		if models.IsWALSegment(name) {
+			if segmentSize, err = a.segmentSize(ctx, job); err != nil {
+				return 0, err
+			}
+			break
+		}
+	}
+
+	archived := 0
+	for _, name := range names {
+		if err := a.fetch(ctx, job, name, segmentSize); err != nil {
+			return archived, i18n.Errorf(i18n.ErrWALFetch, name, err)
+		}
+		archived++
+	}
+
+	if job.HasDestination(config.DestinationDrive) {
+		if err := a.uploadPending(ctx, job); err != nil {
+			return archived, err
+		}
+	}
+
+	return archived, nil
+}
+
+// listArchived liệt kê các file WAL (segment, .history, .backup) trong archive_dir của container,
+// sắp xếp theo thứ tự WAL. File .partial và file tạm (archive_command chép vào rồi mv) bị bỏ qua.
+func (a *Archiver) listArchived(ctx context.Context, job *config.Job) ([]string, error) {
+	var stdout, stderr bytes.Buffer
+	exitCode, err := a.Docker.Exec(ctx, job.ContainerName, docker.ExecOptions{Cmd: []string{"ls", "-1", job.WAL.ArchiveDir}}, &stdout, &stderr)
+	if err != nil {
+		return nil, err
+	}
+	if exitCode != 0 {
+		return nil, i18n.Errorf(i18n.ErrWALListArchive, job.WAL.ArchiveDir, strings.TrimSpace(stderr.String()))
+	}
+
+	var names []string
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		name := strings.TrimSpace(line)
+		if isArchiveFile(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// isArchiveFile kiểm tra tên file có phải file WAL cần lưu trữ không
+func isArchiveFile(name string) bool {
+	switch {
+	case strings.HasPrefix(name, "."), strings.HasSuffix(name, ".tmp"):
+		return false
+	case models.IsWALSegment(name):
+		return true
+	case strings.HasSuffix(name, ".history"), strings.HasSuffix(name, ".backup"):
+		return len(name) >= 8 && !strings.ContainsAny(name, "/ ")
+	default:
+		return false
+	}
+}
+
+// segmentSize đọc wal_segment_size (byte) của server nguồn trong container
+func (a *Archiver) segmentSize(ctx context.Context, job *config.Job) (int64, error) {
+	var stdout, stderr bytes.Buffer
+	exitCode, err := a.Docker.Exec(ctx, job.ContainerName, docker.ExecOptions{
+		Cmd: []string{"psql", "-U", job.DBUser, "-d", job.DBName, "-Atc", "SELECT pg_size_bytes(current_setting('wal_segment_size'))"},
+		Env: []string{"PGPASSWORD=" + job.DBPassword},
+	}, &stdout, &stderr)
+	if err == nil && exitCode != 0 {
+		err = fmt.Errorf("%s", strings.TrimSpace(stderr.String()))
+	}
+	if err != nil {
+		return 0, i18n.Errorf(i18n.ErrWALSegmentSizeRead, err)
+	}
+
+	size, err := strconv.ParseInt(strings.TrimSpace(stdout.String()), 10, 64)
+	if err != nil || size <= 0 {
+		return 0, i18n.Errorf(i18n.ErrWALSegmentSizeInvalid, strings.TrimSpace(stdout.String()))
+	}
+	return size, nil
+}
+
+// fetch chép một file WAL từ container về thư mục backup (nén gzip), ghi vào catalog
+// rồi xóa file khỏi archive_dir của container. Segment có kích thước khác segmentSize (vd: đang
+// được archive_command chép dở) bị từ chối và giữ nguyên trong container để lấy lại lần sau.
+func (a *Archiver) fetch(ctx context.Context, job *config.Job, name string, segmentSize int64) error {
+	target := models.WALPath(a.Config.BackupDir, job.Name, name)
+	tmp := target + ".tmp"
+
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(out)
+	counter := &countingWriter{w: gz}
+	var stderr bytes.Buffer
+	exitCode, err := a.Docker.Exec(ctx, job.ContainerName, docker.ExecOptions{Cmd: []string{"cat", path.Join(job.WAL.ArchiveDir, name)}}, counter, &stderr)
+	if err == nil && exitCode != 0 {
+		err = i18n.Errorf(i18n.ErrWALCat, strings.TrimSpace(stderr.String()))
+	}
+	if err == nil && models.IsWALSegment(name) && counter.n != segmentSize {
+		err = i18n.Errorf(i18n.ErrWALSegmentIncomplete, counter.n, segmentSize)
+	}
+	if err == nil {
+		err = gz.Close()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, target)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	segment := &models.WALSegment{Job: job.Name, Name: name, Size: counter.n, ArchivedAt: time.Now()}
+	if err := database.SaveWALSegment(segment); err != nil {
+		return i18n.Errorf(i18n.ErrCatalogWrite, err)
+	}
+
+	// File đã an toàn trong thư mục backup, xóa khỏi container để archive_dir không đầy
+	stderr.Reset()
+	exitCode, err = a.Docker.Exec(ctx, job.ContainerName, docker.ExecOptions{Cmd: []string{"rm", "-f", path.Join(job.WAL.ArchiveDir, name)}}, io.Discard, &stderr)
+	if err == nil && exitCode != 0 {
+		err = fmt.Errorf("%s", strings.TrimSpace(stderr.String()))
+	}
+	if err != nil {
+		logging.From(ctx).Warn("Failed to remove archived WAL from container", "segment", name, "container", job.ContainerName, logging.Err(err))
+	}
+	return nil
+}
+
+// uploadPending upload các file WAL chưa được upload của job lên Drive
+func (a *Archiver) uploadPending(ctx context.Context, job *config.Job) error {
+	segments, err := database.ListWALSegments(job.Name)
+	if err != nil {
+		return i18n.Errorf(i18n.ErrCatalogRead, err)
+	}
+
+	failed := 0
+	for _, segment := range segments {
+		if segment.Uploaded {
+			continue
+		}
+
+		if err := a.Uploader.UploadFile(ctx, models.WALPath(a.Config.BackupDir, job.Name, segment.Name)); err != nil {
+			logging.From(ctx).Error("Failed to upload WAL", "segment", segment.Name, logging.Err(err))
+			failed++
+			continue
+		}
+		if err := database.MarkWALUploaded(job.Name, segment.Name); err != nil {
+			logging.From(ctx).Error("Failed to mark WAL as uploaded", "segment", segment.Name, logging.Err(err))
+		}
+	}
+
+	if failed > 0 {
+		return i18n.Errorf(i18n.ErrWALUploadsFailed, failed)
+	}
+	return nil
+}
+
+// countingWriter đếm số byte đã ghi (kích thước file WAL chưa nén)
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
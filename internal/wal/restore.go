@@ -0,0 +1,330 @@
+package wal
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/backup-cronjob/internal/config"
+	"github.com/backup-cronjob/internal/database"
+	"github.com/backup-cronjob/internal/docker"
+	"github.com/backup-cronjob/internal/i18n"
+	"github.com/backup-cronjob/internal/models"
+	"github.com/backup-cronjob/internal/verify"
+)
+
+// Đường dẫn trong container khôi phục
+const (
+	// restoreRoot là thư mục cha (luôn có trong image postgres) chứa dữ liệu và WAL khôi phục
+	restoreRoot    = "/var/lib/postgresql"
+	restoreDataDir = "data"
+	restoreWALDir  = "wal-restore"
+)
+
+// DefaultRestoreTimeout là thời gian tối đa chờ khôi phục theo thời điểm hoàn tất
+const DefaultRestoreTimeout = time.Hour
+
+// minRestoreMajor là major version tối thiểu (cấu hình khôi phục qua recovery.signal)
+const minRestoreMajor = 12
+
+// RestoreOptions là tham số khôi phục theo thời điểm
+type RestoreOptions struct {
+	// Target là thời điểm cần khôi phục tới; zero = tới cuối WAL đã lưu trữ
+	Target time.Time
+	// Name là tên container mới; mặc định <job>-pitr-<thời gian>
+	Name string
+	// Image là image postgres; mặc định theo major version của server nguồn
+	Image string
+	// Port là cổng trên host công bố cổng 5432 của container; rỗng = không công bố
+	Port    string
+	Timeout time.Duration
+}
+
+// RestoreResult là kết quả khôi phục theo thời điểm
+type RestoreResult struct {
+	Container  string          `json:"container"`
+	Image      string          `json:"image"`
+	BaseBackup string          `json:"base_backup"`
+	Target     *time.Time      `json:"target,omitempty"`
+	WAL        models.WALRange `json:"wal"`
+}
+
+// Restore khôi phục job ở chế độ physical tới thời điểm opts.Target vào một container postgres mới:
+// chọn bản sao lưu vật lý gần nhất trước thời điểm đó, chép dữ liệu cùng các segment WAL đã lưu
+// trữ vào container, cấu hình recovery_target_time rồi chờ server khôi phục xong và promote.
+// Container được giữ lại (kể cả khi thất bại, để xem log).
+func Restore(ctx context.Context, client *docker.Client, backupDir string, job *config.Job, opts RestoreOptions) (*RestoreResult, error) {
+	if job.Mode != config.ModePhysical {
+		return nil, i18n.Errorf(i18n.ErrJobNotPhysical, job.Name)
+	}
+
+	base, err := selectBaseBackup(job, opts.Target)
+	if err != nil {
+		return nil, err
+	}
+
+	segments, err := database.ListWALSegments(job.Name)
+	if err != nil {
+		return nil, i18n.Errorf(i18n.ErrCatalogRead, err)
+	}
+	walRange := models.ContiguousWAL(base.WALStart, segments)
+	if walRange.Segments == 0 {
+		return nil, i18n.Errorf(i18n.ErrWALSegmentMissing, base.WALStart, path.Base(base.Path))
+	}
+	if !opts.Target.IsZero() && walRange.RestorableUntil != nil && opts.Target.After(*walRange.RestorableUntil) {
+		return nil, i18n.Errorf(i18n.ErrWALGap,
+			walRange.RestorableUntil.Format(time.RFC3339), walRange.End, opts.Target.Format(time.RFC3339))
+	}
+
+	result := &RestoreResult{
+		Container:  opts.Name,
+		Image:      opts.Image,
+		BaseBackup: base.Path,
+		WAL:        walRange,
+	}
+	if !opts.Target.IsZero() {
+		target := opts.Target
+		result.Target = &target
+	}
+	if result.Container == "" {
+		result.Container = fmt.Sprintf("%s-pitr-%s", job.Name, time.Now().Format("20060102150405"))
+	}
+	if result.Image == "" {
+		major, _ := strconv.Atoi(strings.Split(base.ServerVersion, ".")[0])
+		if major < minRestoreMajor {
+			return nil, i18n.Errorf(i18n.ErrPITRVersion, minRestoreMajor, base.ServerVersion)
+		}
+		result.Image = verify.ImageFor(base.ServerVersion)
+	}
+
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultRestoreTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	runOpts := docker.RunOptions{
+		Name:  result.Container,
+		Image: result.Image,
+		Env:   []string{"PGDATA=" + path.Join(restoreRoot, restoreDataDir)},
+	}
+	if opts.Port != "" {
+		runOpts.Ports = []string{opts.Port + ":5432"}
+	}
+	id, err := client.CreateContainer(ctx, runOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Chép dữ liệu và WAL vào container trước khi start; entrypoint của image postgres
+	// tự đổi owner thư mục dữ liệu và bỏ qua initdb vì đã có PG_VERSION
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(writeRestoreArchive(pw, backupDir, job.Name, base, segments, opts.Target))
+	}()
+	err = client.CopyArchive(ctx, id, restoreRoot, pr)
+	// Đóng đầu đọc để goroutine ghi không bị treo khi Docker từ chối giữa chừng
+	pr.Close()
+	if err != nil {
+		return result, i18n.Errorf(i18n.ErrPITRCopy, result.Container, err)
+	}
+
+	if err := client.StartContainer(ctx, id); err != nil {
+		return result, err
+	}
+
+	if err := waitPromoted(ctx, client, id, job); err != nil {
+		return result, i18n.Errorf(i18n.ErrPITRFailed, err, result.Container)
+	}
+
+	return result, nil
+}
+
+// selectBaseBackup chọn bản sao lưu vật lý mới nhất (còn file) hoàn tất trước thời điểm target
+func selectBaseBackup(job *config.Job, target time.Time) (*models.BackupRecord, error) {
+	records, err := database.ListJobBackupRecords(job.Name, models.BackupKindBase)
+	if err != nil {
+		return nil, i18n.Errorf(i18n.ErrCatalogRead, err)
+	}
+
+	for _, record := range records {
+		if record.WALStart == "" || (!target.IsZero() && record.CreatedAt.After(target)) {
+			continue
+		}
+		if _, err := os.Stat(record.Path); err != nil {
+			continue
+		}
+		return record, nil
+	}
+
+	if target.IsZero() {
+		return nil, i18n.Errorf(i18n.ErrNoPhysicalBackup, job.Name)
+	}
+	return nil, i18n.Errorf(i18n.ErrNoPhysicalBackupBefore, job.Name, target.Format(time.RFC3339))
+}
+
+// writeRestoreArchive ghi luồng tar gồm thư mục dữ liệu từ bản sao lưu vật lý (kèm cấu hình
+// khôi phục và recovery.signal) và các file WAL từ segment bắt đầu trở đi
+func writeRestoreArchive(w io.Writer, backupDir, jobName string, base *models.BackupRecord, segments []*models.WALSegment, target time.Time) error {
+	tw := tar.NewWriter(w)
+
+	if err := copyBaseBackup(tw, base.Path, recoverySettings(target)); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if err := tw.WriteHeader(&tar.Header{Name: restoreWALDir + "/", Typeflag: tar.TypeDir, Mode: 0755, ModTime: now}); err != nil {
+		return err
+	}
+	for _, segment := range segments {
+		// Chỉ cần các segment từ điểm bắt đầu của bản sao lưu và các file .history
+		if segment.Name < base.WALStart && !strings.HasSuffix(segment.Name, ".history") {
+			continue
+		}
+		if err := addFile(tw, models.WALPath(backupDir, jobName, segment.Name), restoreWALDir+"/"+segment.Name+".gz"); err != nil {
+			return err
+		}
+	}
+
+	return tw.Close()
+}
+
+// copyBaseBackup chép nội dung bản sao lưu vật lý (tar.gz) vào tw dưới thư mục dữ liệu,
+// bổ sung settings vào postgresql.auto.conf và thêm recovery.signal
+func copyBaseBackup(tw *tar.Writer, basePath, settings string) error {
+	file, err := os.Open(basePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	if err := tw.WriteHeader(&tar.Header{Name: restoreDataDir + "/", Typeflag: tar.TypeDir, Mode: 0700, ModTime: time.Now()}); err != nil {
+		return err
+	}
+
+	autoConf := []byte(nil)
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		name := strings.TrimPrefix(header.Name, "./")
+		if name == "" || strings.HasPrefix(name, "/") || strings.Contains(name, "..") {
+			continue
+		}
+		if name == "postgresql.auto.conf" {
+			if autoConf, err = io.ReadAll(tr); err != nil {
+				return err
+			}
+			continue
+		}
+
+		header.Name = restoreDataDir + "/" + name
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := io.Copy(tw, tr); err != nil {
+			return err
+		}
+	}
+
+	autoConf = append(autoConf, []byte(settings)...)
+	for _, entry := range []struct {
+		name string
+		data []byte
+	}{
+		{"postgresql.auto.conf", autoConf},
+		{"recovery.signal", nil},
+	} {
+		if err := writeEntry(tw, restoreDataDir+"/"+entry.name, bytes.NewReader(entry.data), int64(len(entry.data)), 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recoverySettings trả về các tham số khôi phục thêm vào postgresql.auto.conf.
+// archive_mode bị tắt để server khôi phục không chép WAL vào archive_dir của server nguồn.
+func recoverySettings(target time.Time) string {
+	var b strings.Builder
+	b.WriteString("\n# Khôi phục theo thời điểm\n")
+	fmt.Fprintf(&b, "restore_command = 'gunzip -c %s/%%f.gz > %%p'\n", path.Join(restoreRoot, restoreWALDir))
+	b.WriteString("archive_mode = 'off'\n")
+	if !target.IsZero() {
+		fmt.Fprintf(&b, "recovery_target_time = '%s'\n", target.Format("2006-01-02 15:04:05.000000-07:00"))
+		b.WriteString("recovery_target_action = 'promote'\n")
+	}
+	return b.String()
+}
+
+// addFile thêm file trên đĩa vào tar với tên name
+func addFile(tw *tar.Writer, filePath, name string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	return writeEntry(tw, name, file, info.Size(), 0644)
+}
+
+// writeEntry ghi một file thường vào tar
+func writeEntry(tw *tar.Writer, name string, r io.Reader, size int64, mode int64) error {
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: mode, Size: size, ModTime: time.Now()}); err != nil {
+		return err
+	}
+	_, err := io.Copy(tw, r)
+	return err
+}
+
+// waitPromoted chờ server trong container khôi phục xong và chấp nhận ghi (không còn ở chế độ recovery)
+func waitPromoted(ctx context.Context, client *docker.Client, id string, job *config.Job) error {
+	for {
+		info, err := client.ContainerInspect(ctx, id)
+		if err != nil {
+			return err
+		}
+		if info.State == nil || !info.State.Running {
+			return i18n.Errorf(i18n.ErrPITRContainerStopped)
+		}
+
+		var stdout bytes.Buffer
+		exitCode, err := client.Exec(ctx, id, docker.ExecOptions{
+			Cmd: []string{"psql", "-U", job.DBUser, "-d", job.DBName, "-Atc", "SELECT pg_is_in_recovery()"},
+			Env: []string{"PGPASSWORD=" + job.DBPassword},
+		}, &stdout, io.Discard)
+		if err == nil && exitCode == 0 && strings.TrimSpace(stdout.String()) == "f" {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return i18n.Errorf(i18n.ErrPITRTimeout, ctx.Err())
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
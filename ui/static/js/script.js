@@ -17,14 +17,14 @@ document.addEventListener('DOMContentLoaded', function() {
     const uploadAllForm = document.querySelector('form[action="/upload-all"]');
     if (uploadAllForm) {
         uploadAllForm.addEventListener('submit', function(e) {
-            if (!confirm('Bạn có chắc chắn muốn upload tất cả các file backup lên Google Drive?')) {
+            if (!confirm(uploadAllForm.dataset.confirm)) {
                 e.preventDefault();
             }
         });
     }
 
     // Format file size ở UI
-    const fileSizeCells = document.querySelectorAll('td:nth-child(3)');
+    const fileSizeCells = document.querySelectorAll('td.file-size');
     fileSizeCells.forEach(function(cell) {
         const sizeInBytes = parseInt(cell.textContent);
         if (!isNaN(sizeInBytes)) {